@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tomochain/tomochain/cmd/utils"
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:     "db",
+		Usage:    "Manage the chain databases",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The db commands maintain the databases holding the chain and its state.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "convert",
+				Usage:  "Convert the chain databases to another storage engine",
+				Action: utils.MigrateFlags(convertDB),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.DBEngineFlag,
+				},
+				Description: `
+    tomo db convert --db.engine <engine>
+
+copies the content of the chain databases into new ones persisted with the
+given storage engine, and swaps them in place of the original ones, which are
+kept next to them until removed by hand. The ancient chain data freezers kept
+inside the databases are moved along. The node must not be running.`,
+			},
+		},
+	}
+)
+
+// convertDB copies the chain databases into databases of the requested
+// storage engine.
+func convertDB(ctx *cli.Context) error {
+	if !ctx.GlobalIsSet(utils.DBEngineFlag.Name) {
+		utils.Fatalf("The storage engine to convert to is required (--%s)", utils.DBEngineFlag.Name)
+	}
+	stack, cfg := makeConfigNode(ctx)
+	engine := cfg.Node.DBEngine
+
+	for _, name := range []string{"chaindata", "lightchaindata"} {
+		logger := log.New("database", name)
+
+		dbdir := stack.ResolvePath(name)
+		current := ethdb.DetectEngine(dbdir)
+		switch current {
+		case "":
+			logger.Info("Database doesn't exist, skipping", "path", dbdir)
+			continue
+		case engine:
+			logger.Info("Database already uses the engine, skipping", "path", dbdir, "engine", engine)
+			continue
+		}
+		backup, err := convertDatabase(dbdir, current, engine, ctx.GlobalInt(utils.CacheFlag.Name), utils.MakeDatabaseHandles())
+		if err != nil {
+			utils.Fatalf("Failed to convert database %s: %v", dbdir, err)
+		}
+		fmt.Printf("Converted %s from %s to %s, the original database is kept in %s\n", dbdir, current, engine, backup)
+	}
+	return nil
+}
+
+// convertDatabase copies a database into a new one of the given storage engine
+// and swaps it in place of the original one, returning where the original one
+// was moved to.
+func convertDatabase(dbdir, from, to string, cache, handles int) (string, error) {
+	var (
+		tmpdir = dbdir + ".converting"
+		backup = dbdir + "." + from
+	)
+	if common.FileExist(backup) {
+		return "", fmt.Errorf("a previous original database is in the way: %s", backup)
+	}
+	// Start from scratch if a former conversion was interrupted
+	if err := os.RemoveAll(tmpdir); err != nil {
+		return "", err
+	}
+	src, err := ethdb.OpenDatabase(from, dbdir, cache/2, handles/2)
+	if err != nil {
+		return "", err
+	}
+	dst, err := ethdb.OpenDatabase(to, tmpdir, cache/2, handles/2)
+	if err != nil {
+		src.Close()
+		return "", err
+	}
+	err = copyDatabase(src, dst)
+	src.Close()
+	dst.Close()
+	if err != nil {
+		os.RemoveAll(tmpdir)
+		return "", err
+	}
+	// Move the freezers and any other directory kept inside the database along,
+	// the storage engines keeping only files
+	entries, err := ioutil.ReadDir(dbdir)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if err := os.Rename(filepath.Join(dbdir, entry.Name()), filepath.Join(tmpdir, entry.Name())); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := os.Rename(dbdir, backup); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpdir, dbdir); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+// copyDatabase writes the whole content of a database into another one.
+func copyDatabase(src, dst ethdb.Database) error {
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		count  int
+		size   common.StorageSize
+		batch  = dst.NewBatch()
+	)
+	it := src.NewIterator()
+	defer it.Release()
+
+	for it.Next() {
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			return err
+		}
+		count++
+		size += common.StorageSize(len(it.Key()) + len(it.Value()))
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Converting database", "entries", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Converted database", "entries", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
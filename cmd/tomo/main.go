@@ -58,6 +58,8 @@ var (
 		utils.BootnodesV4Flag,
 		utils.BootnodesV5Flag,
 		utils.DataDirFlag,
+		utils.AncientFlag,
+		utils.DBEngineFlag,
 		utils.KeyStoreDirFlag,
 		//utils.NoUSBFlag,
 		//utils.DashboardEnabledFlag,
@@ -85,11 +87,16 @@ var (
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
+		utils.TxPoolSpecialSlotsFlag,
 		utils.TxPoolLifetimeFlag,
 		utils.FastSyncFlag,
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
+		utils.SnapshotFlag,
+		utils.ParallelTxsFlag,
+		utils.InternalTxIndexFlag,
+		utils.LogIndexFlag,
 		//utils.LightServFlag,
 		//utils.LightPeersFlag,
 		//utils.LightKDFFlag,
@@ -138,6 +145,7 @@ var (
 		utils.RPCListenAddrFlag,
 		utils.RPCPortFlag,
 		utils.RPCApiFlag,
+		utils.GraphQLEnabledFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
@@ -166,6 +174,9 @@ func init() {
 		exportCommand,
 		removedbCommand,
 		dumpCommand,
+		// See snapshotcmd.go:
+		snapshotCommand,
+		dbCommand,
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
@@ -0,0 +1,103 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tomochain/tomochain/cmd/utils"
+	"github.com/tomochain/tomochain/consensus/posv"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "Manage the snapshots stored in the chain database",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The snapshot commands maintain the consensus snapshots stored next to the chain.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "prune",
+				Usage:  "Prune stale PoSV voting snapshots",
+				Action: utils.MigrateFlags(pruneSnapshots),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+				},
+				Description: `
+    tomo snapshot prune
+
+deletes the PoSV voting snapshots which are older than the recently retained
+checkpoints, including the ones of abandoned side chains, and rewrites the
+remaining snapshots stored in the legacy JSON format in the compact encoding.
+The node must not be running.`,
+			},
+			{
+				Name:   "prune-state",
+				Usage:  "Prune stale state trie nodes",
+				Action: utils.MigrateFlags(pruneState),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+				},
+				Description: `
+    tomo snapshot prune-state
+
+deletes the state trie nodes which are unreachable from the most recent
+persisted state, including the ones of abandoned side chains. An interrupted
+pruning is resumed by the next invocation or node startup. The node must not be
+running; use admin.pruneState() to prune a running node, which also prunes the
+TomoX state.`,
+			},
+		},
+	}
+)
+
+func pruneSnapshots(ctx *cli.Context) error {
+	stack, _ := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	config := chain.Config().Posv
+	if config == nil {
+		utils.Fatalf("The chain doesn't use the PoSV consensus engine")
+	}
+	start := time.Now()
+	pruned, migrated, err := posv.PruneSnapshots(chainDb, config, chain.CurrentHeader().Number.Uint64())
+	if err != nil {
+		utils.Fatalf("Snapshot pruning failed: %v", err)
+	}
+	fmt.Printf("Pruned %d and migrated %d voting snapshots in %v\n", pruned, migrated, time.Since(start))
+	return nil
+}
+
+func pruneState(ctx *cli.Context) error {
+	stack, _ := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	start := time.Now()
+	if err := chain.PruneState(); err != nil {
+		utils.Fatalf("State pruning failed: %v", err)
+	}
+	fmt.Printf("Pruned stale state in %v\n", time.Since(start))
+	return nil
+}
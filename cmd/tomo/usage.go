@@ -69,6 +69,8 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			configFileFlag,
 			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.DBEngineFlag,
 			utils.KeyStoreDirFlag,
 			//utils.NoUSBFlag,
 			utils.NetworkIdFlag,
@@ -76,6 +78,10 @@ var AppHelpFlagGroups = []flagGroup{
 			//utils.RinkebyFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.SnapshotFlag,
+			utils.ParallelTxsFlag,
+			utils.InternalTxIndexFlag,
+			utils.LogIndexFlag,
 			utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			//utils.LightServFlag,
@@ -122,6 +128,7 @@ var AppHelpFlagGroups = []flagGroup{
 	//		utils.TxPoolGlobalSlotsFlag,
 	//		utils.TxPoolAccountQueueFlag,
 	//		utils.TxPoolGlobalQueueFlag,
+	//		utils.TxPoolSpecialSlotsFlag,
 	//		utils.TxPoolLifetimeFlag,
 	//	},
 	//},
@@ -148,6 +155,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCListenAddrFlag,
 			utils.RPCPortFlag,
 			utils.RPCApiFlag,
+			utils.GraphQLEnabledFlag,
 			utils.WSEnabledFlag,
 			utils.WSListenAddrFlag,
 			utils.WSPortFlag,
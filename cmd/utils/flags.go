@@ -131,6 +131,14 @@ var (
 		Usage: "Data directory for the databases and keystore",
 		Value: DirectoryString{node.DefaultDataDir()},
 	}
+	AncientFlag = DirectoryFlag{
+		Name:  "datadir.ancient",
+		Usage: "Directory for the ancient chain data freezer, relative to the chain database if not absolute (disabled if empty)",
+	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: "Storage engine of the chain databases (" + strings.Join(ethdb.Engines, ", ") + "), existing ones keeping their own if unset",
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -192,6 +200,23 @@ var (
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "full",
 	}
+	SnapshotFlag = cli.BoolFlag{
+		Name:  "state.snapshot",
+		Usage: "Maintain a flat snapshot of the recent states for faster account and storage reads",
+	}
+	ParallelTxsFlag = cli.IntFlag{
+		Name:  "parallel.txs",
+		Usage: "Number of workers speculatively executing the transactions of the imported blocks in parallel (0 = in order)",
+		Value: 0,
+	}
+	InternalTxIndexFlag = cli.BoolFlag{
+		Name:  "internaltx.index",
+		Usage: "Trace the imported blocks to index their internal transactions for trace_filter (past blocks need --gcmode=archive)",
+	}
+	LogIndexFlag = cli.BoolFlag{
+		Name:  "logindex",
+		Usage: "Index the logs of the chain by address and first topic for faster log filtering",
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -306,6 +331,11 @@ var (
 		Usage: "Maximum number of non-executable transaction slots for all accounts",
 		Value: eth.DefaultConfig.TxPool.GlobalQueue,
 	}
+	TxPoolSpecialSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.specialslots",
+		Usage: "Maximum number of executable signing and randomize transaction slots for all signers",
+		Value: eth.DefaultConfig.TxPool.SpecialSlots,
+	}
 	TxPoolLifetimeFlag = cli.DurationFlag{
 		Name:  "txpool.lifetime",
 		Usage: "Maximum amount of time non-executable transaction are queued",
@@ -424,6 +454,10 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable the GraphQL query endpoint on the HTTP-RPC server (requires --rpc)",
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -937,6 +971,13 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.DataDir = filepath.Join(node.DefaultDataDir(), "rinkeby")
 	}
 
+	if ctx.GlobalIsSet(DBEngineFlag.Name) {
+		engine := ctx.GlobalString(DBEngineFlag.Name)
+		if !isDBEngine(engine) {
+			Fatalf("--%s: unknown database engine %q, want one of %s", DBEngineFlag.Name, engine, strings.Join(ethdb.Engines, ", "))
+		}
+		cfg.DBEngine = engine
+	}
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
@@ -988,6 +1029,9 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolGlobalQueueFlag.Name) {
 		cfg.GlobalQueue = ctx.GlobalUint64(TxPoolGlobalQueueFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolSpecialSlotsFlag.Name) {
+		cfg.SpecialSlots = ctx.GlobalUint64(TxPoolSpecialSlotsFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
@@ -1126,11 +1170,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		cfg.DatabaseCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 	}
 	cfg.DatabaseHandles = MakeDatabaseHandles()
+	if ctx.GlobalIsSet(AncientFlag.Name) && !ctx.GlobalBool(LightModeFlag.Name) {
+		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
+	}
 
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
+	cfg.Snapshot = ctx.GlobalBool(SnapshotFlag.Name)
+	cfg.ParallelTxs = ctx.GlobalInt(ParallelTxsFlag.Name)
+	cfg.InternalTxIndex = ctx.GlobalBool(InternalTxIndexFlag.Name)
+	cfg.LogIndex = ctx.GlobalBool(LogIndexFlag.Name)
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
@@ -1212,6 +1263,16 @@ func SetupNetwork(ctx *cli.Context) {
 	params.TargetGasLimit = ctx.GlobalUint64(TargetGasLimitFlag.Name)
 }
 
+// isDBEngine returns whether the given name is a supported database engine.
+func isDBEngine(engine string) bool {
+	for _, name := range ethdb.Engines {
+		if engine == name {
+			return true
+		}
+	}
+	return false
+}
+
 // MakeChainDatabase open an LevelDB using the flags passed to the client and will hard crash if it fails.
 func MakeChainDatabase(ctx *cli.Context, stack *node.Node) ethdb.Database {
 	var (
@@ -1222,7 +1283,15 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node) ethdb.Database {
 	if ctx.GlobalBool(LightModeFlag.Name) {
 		name = "lightchaindata"
 	}
-	chainDb, err := stack.OpenDatabase(name, cache, handles)
+	var (
+		chainDb ethdb.Database
+		err     error
+	)
+	if freezer := ctx.GlobalString(AncientFlag.Name); freezer != "" && !ctx.GlobalBool(LightModeFlag.Name) {
+		chainDb, err = stack.OpenDatabaseWithFreezer(name, cache, handles, freezer)
+	} else {
+		chainDb, err = stack.OpenDatabase(name, cache, handles)
+	}
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
@@ -1275,6 +1344,8 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		Disabled:      ctx.GlobalString(GCModeFlag.Name) == "archive",
 		TrieNodeLimit: eth.DefaultConfig.TrieCache,
 		TrieTimeLimit: eth.DefaultConfig.TrieTimeout,
+		Snapshot:      ctx.GlobalBool(SnapshotFlag.Name),
+		ParallelTxs:   ctx.GlobalInt(ParallelTxsFlag.Name),
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cache.TrieNodeLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
@@ -1,10 +1,13 @@
 package utils
 
 import (
+	"errors"
+
 	"github.com/tomochain/tomochain/dashboard"
 	"github.com/tomochain/tomochain/eth"
 	"github.com/tomochain/tomochain/eth/downloader"
 	"github.com/tomochain/tomochain/ethstats"
+	"github.com/tomochain/tomochain/graphql"
 	"github.com/tomochain/tomochain/les"
 	"github.com/tomochain/tomochain/node"
 	"github.com/tomochain/tomochain/tomox"
@@ -69,6 +72,25 @@ func RegisterEthStatsService(stack *node.Node, url string) {
 	}
 }
 
+// RegisterGraphQLService adds a GraphQL endpoint, served by the HTTP RPC server,
+// to the given node.
+func RegisterGraphQLService(stack *node.Node) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		// Serve the queries from either the eth or the les service
+		var ethServ *eth.Ethereum
+		if err := ctx.Service(&ethServ); err == nil {
+			return graphql.New(ethServ.ApiBackend)
+		}
+		var lesServ *les.LightEthereum
+		if err := ctx.Service(&lesServ); err == nil {
+			return graphql.New(lesServ.ApiBackend)
+		}
+		return nil, errors.New("no Ethereum service to serve the queries from")
+	}); err != nil {
+		Fatalf("Failed to register the GraphQL service: %v", err)
+	}
+}
+
 func RegisterTomoXService(stack *node.Node, cfg *tomox.Config) {
 	if err := stack.Register(func(n *node.ServiceContext) (node.Service, error) {
 		return tomox.New(cfg), nil
@@ -22,6 +22,7 @@ const (
 	MergeSignRange             = 15
 	RangeReturnSigner          = 150
 	MinimunMinerBlockPerEpoch  = 1
+	MaxAttestationDelay        = 15
 )
 
 var TIP2019Block = big.NewInt(1050000)
@@ -105,3 +106,9 @@ var Blacklist = map[Address]bool{
 	HexToAddress("0xe187cf86c2274b1f16e8225a7da9a75aba4f1f5f"): true,
 }
 var TIPTRC21Fee = big.NewInt(13523400)
+var TIPAttestation = big.NewInt(99999999999)
+var TIPSlashing = big.NewInt(99999999999)
+var TIPCommitReveal = big.NewInt(99999999999)
+var TIPCreate2 = big.NewInt(99999999999)
+var TIPIstanbul = big.NewInt(99999999999)
+var TIPTomoXPrecompiles = big.NewInt(99999999999)
@@ -0,0 +1,309 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package posv
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/tomochain/tomochain/accounts"
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/consensus"
+	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/log"
+)
+
+// maxAttestations is the maximum number of attestations a single header may
+// carry. A header can only include attestations for blocks within the last
+// MaxAttestationDelay blocks, which holds at most two attested blocks.
+const maxAttestations = 2 * common.MaxMasternodes
+
+var (
+	// errAttestationsBeforeFork is returned if a header carries attestations
+	// before TIPAttestation is active.
+	errAttestationsBeforeFork = errors.New("attestations before fork")
+
+	// errTooManyAttestations is returned if a header carries more attestations
+	// than allowed.
+	errTooManyAttestations = errors.New("too many attestations")
+
+	// errInvalidAttestationTarget is returned if an attestation is for a block
+	// which is not attestable or not a recent ancestor of the including header.
+	errInvalidAttestationTarget = errors.New("invalid attestation target")
+
+	// errUnauthorizedAttestation is returned if an attestation is signed by an
+	// address which is not a masternode of the attested block.
+	errUnauthorizedAttestation = errors.New("unauthorized attestation")
+
+	// errDuplicateAttestation is returned if an attestation was already
+	// included in the same header or in one of its recent ancestors.
+	errDuplicateAttestation = errors.New("duplicate attestation")
+
+	// ErrKnownAttestation is returned if an attestation is already pending.
+	ErrKnownAttestation = errors.New("known attestation")
+)
+
+// attestationKey identifies the attestation of a masternode for a block.
+type attestationKey struct {
+	hash   common.Hash
+	signer common.Address
+}
+
+// attestationPool keeps the attestations which are waiting to be included in
+// a block, grouped by the block they attest.
+type attestationPool struct {
+	blocks  map[common.Hash]map[common.Address]*types.Attestation
+	numbers map[common.Hash]uint64
+	lock    sync.RWMutex
+}
+
+func newAttestationPool() *attestationPool {
+	return &attestationPool{
+		blocks:  make(map[common.Hash]map[common.Address]*types.Attestation),
+		numbers: make(map[common.Hash]uint64),
+	}
+}
+
+// add inserts an attestation signed by signer, returning false if the signer
+// already attested the block.
+func (p *attestationPool) add(attestation *types.Attestation, signer common.Address) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	signers, ok := p.blocks[attestation.BlockHash]
+	if !ok {
+		signers = make(map[common.Address]*types.Attestation)
+		p.blocks[attestation.BlockHash] = signers
+		p.numbers[attestation.BlockHash] = attestation.Number
+	}
+	if _, known := signers[signer]; known {
+		return false
+	}
+	signers[signer] = attestation
+	return true
+}
+
+// get returns the pending attestations of a block, sorted by signer.
+func (p *attestationPool) get(hash common.Hash) []*types.Attestation {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	signers := make([]common.Address, 0, len(p.blocks[hash]))
+	for signer := range p.blocks[hash] {
+		signers = append(signers, signer)
+	}
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i][:], signers[j][:]) < 0
+	})
+	attestations := make([]*types.Attestation, 0, len(signers))
+	for _, signer := range signers {
+		attestations = append(attestations, p.blocks[hash][signer])
+	}
+	return attestations
+}
+
+// prune drops the attestations of all blocks below the given number.
+func (p *attestationPool) prune(number uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for hash, n := range p.numbers {
+		if n < number {
+			delete(p.blocks, hash)
+			delete(p.numbers, hash)
+		}
+	}
+}
+
+// isAttestable returns whether masternodes are expected to attest the block.
+func isAttestable(chain consensus.ChainReader, header *types.Header) bool {
+	return chain.Config().IsTIPAttestation(header.Number) && header.Number.Uint64()%common.MergeSignRange == 0
+}
+
+// verifyAttestation checks that the attestation is for an attestable block and
+// is signed by one of its masternodes, returning the signer.
+func (c *Posv) verifyAttestation(chain consensus.ChainReader, target *types.Header, attestation *types.Attestation) (common.Address, error) {
+	if target.Number.Uint64() != attestation.Number || target.Hash() != attestation.BlockHash || !isAttestable(chain, target) {
+		return common.Address{}, errInvalidAttestationTarget
+	}
+	signer, err := attestation.Signer()
+	if err != nil {
+		return common.Address{}, err
+	}
+	for _, masternode := range c.GetMasternodes(chain, target) {
+		if masternode == signer {
+			return signer, nil
+		}
+	}
+	return common.Address{}, errUnauthorizedAttestation
+}
+
+// IsAttester reports whether the local signing credentials belong to one of the
+// masternodes of the given block, which are the ones to attest it.
+func (c *Posv) IsAttester(chain consensus.ChainReader, header *types.Header) bool {
+	signer, signFn := c.signerAt(header.Number.Uint64())
+	if signFn == nil {
+		return false
+	}
+	for _, masternode := range c.GetMasternodes(chain, header) {
+		if masternode == signer {
+			return true
+		}
+	}
+	return false
+}
+
+// Attest signs an attestation for the given block with the local signing
+// credentials and adds it to the pending attestations.
+func (c *Posv) Attest(chain consensus.ChainReader, header *types.Header) (*types.Attestation, error) {
+	signer, signFn := c.signerAt(header.Number.Uint64())
+
+	if signFn == nil {
+		return nil, errUnauthorized
+	}
+	attestation := types.NewAttestation(header.Number.Uint64(), header.Hash())
+	sig, err := signFn(accounts.Account{Address: signer}, attestation.SigHash().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	attestation = attestation.WithSignature(sig)
+	if _, err := c.verifyAttestation(chain, header, attestation); err != nil {
+		return nil, err
+	}
+	c.attestations.add(attestation, signer)
+	return attestation, nil
+}
+
+// AddAttestation verifies an attestation received from the network and adds it
+// to the pending attestations.
+func (c *Posv) AddAttestation(chain consensus.ChainReader, attestation *types.Attestation) error {
+	target := chain.GetHeader(attestation.BlockHash, attestation.Number)
+	if target == nil {
+		return errUnknownBlock
+	}
+	signer, err := c.verifyAttestation(chain, target, attestation)
+	if err != nil {
+		return err
+	}
+	if !c.attestations.add(attestation, signer) {
+		return ErrKnownAttestation
+	}
+	return nil
+}
+
+// recentAncestors returns the ancestors of a header which may still be
+// attested in it, together with the attestations they already include.
+func recentAncestors(chain consensus.ChainReader, header *types.Header, parents []*types.Header) (map[common.Hash]*types.Header, map[attestationKey]bool, error) {
+	var (
+		ancestors = make(map[common.Hash]*types.Header)
+		included  = make(map[attestationKey]bool)
+		number    = header.Number.Uint64() - 1
+		hash      = header.ParentHash
+	)
+	for i := 0; i < common.MaxAttestationDelay && number > 0; i++ {
+		var ancestor *types.Header
+		if len(parents) > 0 && parents[len(parents)-1].Hash() == hash {
+			ancestor = parents[len(parents)-1]
+			parents = parents[:len(parents)-1]
+		} else {
+			ancestor = chain.GetHeader(hash, number)
+		}
+		if ancestor == nil {
+			return nil, nil, consensus.ErrUnknownAncestor
+		}
+		ancestors[hash] = ancestor
+		for _, attestation := range ancestor.Attestations {
+			if signer, err := attestation.Signer(); err == nil {
+				included[attestationKey{attestation.BlockHash, signer}] = true
+			}
+		}
+		number, hash = number-1, ancestor.ParentHash
+	}
+	return ancestors, included, nil
+}
+
+// collectAttestations gathers the pending attestations of the recent ancestors
+// of a header which aren't included in the chain yet.
+func (c *Posv) collectAttestations(chain consensus.ChainReader, header *types.Header) ([]*types.Attestation, error) {
+	ancestors, included, err := recentAncestors(chain, header, nil)
+	if err != nil {
+		return nil, err
+	}
+	var attestable []*types.Header
+	for _, ancestor := range ancestors {
+		if isAttestable(chain, ancestor) {
+			attestable = append(attestable, ancestor)
+		}
+	}
+	sort.Slice(attestable, func(i, j int) bool {
+		return attestable[i].Number.Cmp(attestable[j].Number) < 0
+	})
+	var attestations []*types.Attestation
+	for _, ancestor := range attestable {
+		for _, attestation := range c.attestations.get(ancestor.Hash()) {
+			signer, err := attestation.Signer()
+			if err != nil || included[attestationKey{attestation.BlockHash, signer}] {
+				continue
+			}
+			if len(attestations) == maxAttestations {
+				return attestations, nil
+			}
+			attestations = append(attestations, attestation)
+		}
+	}
+	if number := header.Number.Uint64(); number > 2*common.MaxAttestationDelay {
+		c.attestations.prune(number - 2*common.MaxAttestationDelay)
+	}
+	return attestations, nil
+}
+
+// verifyAttestations checks that every attestation of a header is for a recent
+// attestable ancestor, is signed by one of its masternodes and is not included
+// twice.
+func (c *Posv) verifyAttestations(chain consensus.ChainReader, header *types.Header, parents []*types.Header) error {
+	if len(header.Attestations) == 0 {
+		return nil
+	}
+	if !chain.Config().IsTIPAttestation(header.Number) {
+		return errAttestationsBeforeFork
+	}
+	if len(header.Attestations) > maxAttestations {
+		return errTooManyAttestations
+	}
+	ancestors, included, err := recentAncestors(chain, header, parents)
+	if err != nil {
+		return err
+	}
+	for _, attestation := range header.Attestations {
+		target, ok := ancestors[attestation.BlockHash]
+		if !ok {
+			return errInvalidAttestationTarget
+		}
+		signer, err := c.verifyAttestation(chain, target, attestation)
+		if err != nil {
+			log.Debug("Invalid attestation in header", "number", header.Number, "target", attestation.Number, "err", err)
+			return err
+		}
+		key := attestationKey{attestation.BlockHash, signer}
+		if included[key] {
+			return errDuplicateAttestation
+		}
+		included[key] = true
+	}
+	return nil
+}
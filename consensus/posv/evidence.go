@@ -0,0 +1,432 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package posv
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/consensus"
+	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/log"
+)
+
+const (
+	// maxEvidences is the maximum number of evidences a single header may carry.
+	maxEvidences = 16
+
+	// inmemorySeals is the number of recently sealed headers kept to detect
+	// equivocations.
+	inmemorySeals = 4096
+)
+
+var (
+	// errEvidencesBeforeFork is returned if a header carries evidences before
+	// TIPSlashing is active.
+	errEvidencesBeforeFork = errors.New("evidences before fork")
+
+	// errTooManyEvidences is returned if a header carries more evidences than
+	// allowed.
+	errTooManyEvidences = errors.New("too many evidences")
+
+	// errInvalidEvidence is returned if an evidence doesn't consist of two
+	// different headers sealed or validated by the same masternode on the
+	// same parent.
+	errInvalidEvidence = errors.New("invalid evidence")
+
+	// errStaleEvidence is returned if an evidence is for a block which is not
+	// within the last epoch of the including header.
+	errStaleEvidence = errors.New("stale evidence")
+
+	// errDuplicateEvidence is returned if an evidence was already included in
+	// the same header or in one of its recent ancestors.
+	errDuplicateEvidence = errors.New("duplicate evidence")
+
+	// errDoubleSeal is returned if the local signer is asked to seal a header
+	// conflicting with one it already sealed.
+	errDoubleSeal = errors.New("refusing to seal conflicting header")
+
+	// errDoubleValidation is returned if the local signer is asked to validate
+	// a header conflicting with one it already validated.
+	errDoubleValidation = errors.New("refusing to validate conflicting header")
+)
+
+// sealKey identifies the header sealed or validated by a masternode at a given
+// height.
+type sealKey struct {
+	number uint64
+	signer common.Address
+	kind   types.EvidenceKind
+}
+
+// evidencePool keeps the evidences which are waiting to be included in a
+// block, one per offence.
+type evidencePool struct {
+	evidences map[sealKey]*types.Evidence
+	lock      sync.RWMutex
+}
+
+func newEvidencePool() *evidencePool {
+	return &evidencePool{
+		evidences: make(map[sealKey]*types.Evidence),
+	}
+}
+
+// add inserts an evidence against offender, returning false if the offence is
+// already known.
+func (p *evidencePool) add(evidence *types.Evidence, offender common.Address) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	key := sealKey{evidence.Number(), offender, evidence.Kind}
+	if _, known := p.evidences[key]; known {
+		return false
+	}
+	p.evidences[key] = evidence
+	return true
+}
+
+// get returns the pending evidences, sorted by number and offender.
+func (p *evidencePool) get() ([]sealKey, []*types.Evidence) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	keys := make([]sealKey, 0, len(p.evidences))
+	for key := range p.evidences {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].number != keys[j].number {
+			return keys[i].number < keys[j].number
+		}
+		if keys[i].signer != keys[j].signer {
+			return bytes.Compare(keys[i].signer[:], keys[j].signer[:]) < 0
+		}
+		return keys[i].kind < keys[j].kind
+	})
+	evidences := make([]*types.Evidence, 0, len(keys))
+	for _, key := range keys {
+		evidences = append(evidences, p.evidences[key])
+	}
+	return keys, evidences
+}
+
+// prune drops the evidences of all blocks up to the given number.
+func (p *evidencePool) prune(number uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for key := range p.evidences {
+		if key.number <= number {
+			delete(p.evidences, key)
+		}
+	}
+}
+
+// len returns the number of pending evidences.
+func (p *evidencePool) len() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return len(p.evidences)
+}
+
+// recordSeal remembers the header sealed by creator and, if the creator already
+// sealed a different header on the same parent, adds an evidence of the
+// equivocation to the pending evidences.
+func (c *Posv) recordSeal(chain consensus.ChainReader, header *types.Header, creator common.Address) {
+	c.recordSignature(chain, header, creator, types.SealEvidence)
+}
+
+// recordValidation remembers the header validated by an M2 validator and, if the
+// validator already validated a different header of the same creator on the
+// same parent, adds an evidence of the double validation to the pending
+// evidences.
+func (c *Posv) recordValidation(chain consensus.ChainReader, header *types.Header, validator common.Address) {
+	c.recordSignature(chain, header, validator, types.ValidationEvidence)
+}
+
+// recordSignature remembers the header signed by a masternode as the given kind
+// of signer, adding an evidence if it conflicts with one signed before.
+func (c *Posv) recordSignature(chain consensus.ChainReader, header *types.Header, signer common.Address, kind types.EvidenceKind) {
+	if !chain.Config().IsTIPSlashing(header.Number) {
+		return
+	}
+	key := sealKey{header.Number.Uint64(), signer, kind}
+	seen, ok := c.seals.Get(key)
+	if !ok {
+		c.seals.Add(key, types.CopyHeader(header))
+		return
+	}
+	previous := seen.(*types.Header)
+	if !c.conflicting(previous, header, kind) {
+		return
+	}
+	evidence := types.NewEvidence(previous, header)
+	if kind == types.ValidationEvidence {
+		evidence = types.NewValidationEvidence(previous, header)
+	}
+	if c.evidences.add(evidence, signer) {
+		log.Warn("Masternode equivocation detected", "number", header.Number, "signer", signer, "kind", kind, "first", previous.Hash(), "second", header.Hash())
+	}
+}
+
+// conflicting reports whether two headers signed by the same masternode prove
+// an equivocation: they are different headers on the same parent and, if
+// validated, were sealed by the same creator.
+func (c *Posv) conflicting(first, second *types.Header, kind types.EvidenceKind) bool {
+	if first.ParentHash != second.ParentHash || sigHash(first) == sigHash(second) {
+		return false
+	}
+	if kind == types.ValidationEvidence {
+		creator, err := ecrecover(first, c.signatures)
+		if err != nil {
+			return false
+		}
+		if other, err := ecrecover(second, c.signatures); err != nil || other != creator {
+			return false
+		}
+	}
+	return true
+}
+
+// checkSeal makes sure the local signer never seals two different headers on
+// top of the same parent, which would be provable as an equivocation.
+func (c *Posv) checkSeal(chain consensus.ChainReader, header *types.Header, signer common.Address) error {
+	if c.signed(chain, header, signer, types.SealEvidence) {
+		return errDoubleSeal
+	}
+	return nil
+}
+
+// CheckValidation makes sure the local signer never validates two different
+// headers of the same creator on top of the same parent, which would be
+// provable as a double validation.
+func (c *Posv) CheckValidation(chain consensus.ChainReader, header *types.Header, validator common.Address) error {
+	if c.signed(chain, header, validator, types.ValidationEvidence) {
+		return errDoubleValidation
+	}
+	return nil
+}
+
+// signed reports whether signer already signed, as the given kind of signer, a
+// header conflicting with the given one.
+func (c *Posv) signed(chain consensus.ChainReader, header *types.Header, signer common.Address, kind types.EvidenceKind) bool {
+	if !chain.Config().IsTIPSlashing(header.Number) {
+		return false
+	}
+	seen, ok := c.seals.Get(sealKey{header.Number.Uint64(), signer, kind})
+	if !ok {
+		return false
+	}
+	return c.conflicting(seen.(*types.Header), header, kind)
+}
+
+// verifyEvidence checks that the evidence consists of two different headers
+// of the same creator on the same parent, sealed by it or validated by the
+// same M2 validator, returning the offending masternode.
+func (c *Posv) verifyEvidence(chain consensus.ChainReader, evidence *types.Evidence) (common.Address, error) {
+	first, second := evidence.First, evidence.Second
+	if first == nil || second == nil || first.Number == nil || second.Number == nil {
+		return common.Address{}, errInvalidEvidence
+	}
+	if first.Number.Cmp(second.Number) != 0 || first.Number.Sign() <= 0 || first.ParentHash != second.ParentHash {
+		return common.Address{}, errInvalidEvidence
+	}
+	if len(first.Extra) < extraSeal || len(second.Extra) < extraSeal || sigHash(first) == sigHash(second) {
+		return common.Address{}, errInvalidEvidence
+	}
+	creator, err := ecrecover(first, c.signatures)
+	if err != nil {
+		return common.Address{}, errInvalidEvidence
+	}
+	if other, err := ecrecover(second, c.signatures); err != nil || other != creator {
+		return common.Address{}, errInvalidEvidence
+	}
+	offender := creator
+	switch evidence.Kind {
+	case types.SealEvidence:
+	case types.ValidationEvidence:
+		validator, err := c.RecoverValidator(first)
+		if err != nil {
+			return common.Address{}, errInvalidEvidence
+		}
+		if other, err := c.RecoverValidator(second); err != nil || other != validator {
+			return common.Address{}, errInvalidEvidence
+		}
+		offender = validator
+	default:
+		return common.Address{}, errInvalidEvidence
+	}
+	for _, masternode := range c.GetMasternodes(chain, first) {
+		if masternode == offender {
+			return offender, nil
+		}
+	}
+	return common.Address{}, errUnauthorized
+}
+
+// evidenceOffender returns the masternode which signed both headers of an
+// already verified evidence.
+func (c *Posv) evidenceOffender(evidence *types.Evidence) (common.Address, error) {
+	if evidence.Kind == types.ValidationEvidence {
+		return c.RecoverValidator(evidence.First)
+	}
+	return ecrecover(evidence.First, c.signatures)
+}
+
+// includedEvidences walks the ancestors of a header with a number of at least
+// from, returning the offences they include and the offenders in the order
+// they were included.
+func (c *Posv) includedEvidences(chain consensus.ChainReader, header *types.Header, parents []*types.Header, from uint64) (map[sealKey]bool, []common.Address, error) {
+	var (
+		included  = make(map[sealKey]bool)
+		offenders []common.Address
+		number    = header.Number.Uint64() - 1
+		hash      = header.ParentHash
+	)
+	for ; number >= from && number > 0; number-- {
+		var ancestor *types.Header
+		if len(parents) > 0 && parents[len(parents)-1].Hash() == hash {
+			ancestor = parents[len(parents)-1]
+			parents = parents[:len(parents)-1]
+		} else {
+			ancestor = chain.GetHeader(hash, number)
+		}
+		if ancestor == nil {
+			return nil, nil, consensus.ErrUnknownAncestor
+		}
+		for i := len(ancestor.Evidences) - 1; i >= 0; i-- {
+			evidence := ancestor.Evidences[i]
+			offender, err := c.evidenceOffender(evidence)
+			if err != nil {
+				continue
+			}
+			included[sealKey{evidence.Number(), offender, evidence.Kind}] = true
+			offenders = append(offenders, offender)
+		}
+		hash = ancestor.ParentHash
+	}
+	// Offenders were gathered from the newest block, reverse them
+	for i, j := 0, len(offenders)-1; i < j; i, j = i+1, j-1 {
+		offenders[i], offenders[j] = offenders[j], offenders[i]
+	}
+	return included, offenders, nil
+}
+
+// evidenceWindow returns the lowest block number an evidence included in the
+// given header may be for.
+func (c *Posv) evidenceWindow(header *types.Header) uint64 {
+	if number := header.Number.Uint64(); number > c.config.Epoch {
+		return number - c.config.Epoch + 1
+	}
+	return 1
+}
+
+// collectEvidences gathers the pending evidences which aren't included in the
+// chain yet.
+func (c *Posv) collectEvidences(chain consensus.ChainReader, header *types.Header) ([]*types.Evidence, error) {
+	if c.evidences.len() == 0 {
+		return nil, nil
+	}
+	from := c.evidenceWindow(header)
+	if from > 1 {
+		c.evidences.prune(from - 1)
+	}
+	included, _, err := c.includedEvidences(chain, header, nil, from)
+	if err != nil {
+		return nil, err
+	}
+	keys, pending := c.evidences.get()
+
+	var evidences []*types.Evidence
+	for i, evidence := range pending {
+		if included[keys[i]] || keys[i].number >= header.Number.Uint64() {
+			continue
+		}
+		if len(evidences) == maxEvidences {
+			break
+		}
+		evidences = append(evidences, evidence)
+	}
+	return evidences, nil
+}
+
+// verifyEvidences checks that every evidence of a header proves an equivocation
+// or a double validation of a masternode within the last epoch which wasn't
+// included before.
+func (c *Posv) verifyEvidences(chain consensus.ChainReader, header *types.Header, parents []*types.Header) error {
+	if len(header.Evidences) == 0 {
+		return nil
+	}
+	if !chain.Config().IsTIPSlashing(header.Number) {
+		return errEvidencesBeforeFork
+	}
+	if len(header.Evidences) > maxEvidences {
+		return errTooManyEvidences
+	}
+	from := c.evidenceWindow(header)
+	included, _, err := c.includedEvidences(chain, header, parents, from)
+	if err != nil {
+		return err
+	}
+	for _, evidence := range header.Evidences {
+		offender, err := c.verifyEvidence(chain, evidence)
+		if err != nil {
+			log.Debug("Invalid evidence in header", "number", header.Number, "err", err)
+			return err
+		}
+		number := evidence.Number()
+		if number < from || number >= header.Number.Uint64() {
+			return errStaleEvidence
+		}
+		key := sealKey{number, offender, evidence.Kind}
+		if included[key] {
+			return errDuplicateEvidence
+		}
+		included[key] = true
+	}
+	return nil
+}
+
+// appendSlashedSigners adds the masternodes whose equivocations and double
+// validations were included since the previous checkpoint to the penalties of
+// a checkpoint header.
+func (c *Posv) appendSlashedSigners(chain consensus.ChainReader, header *types.Header, parents []*types.Header, penalties []common.Address) ([]common.Address, error) {
+	number := header.Number.Uint64()
+	if number < c.config.Epoch {
+		return penalties, nil
+	}
+	_, offenders, err := c.includedEvidences(chain, header, parents, number-c.config.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	penalized := make(map[common.Address]bool)
+	for _, address := range penalties {
+		penalized[address] = true
+	}
+	for _, offender := range offenders {
+		if !penalized[offender] {
+			log.Info("Penalizing equivocating masternode", "address", offender, "number", number)
+			penalties = append(penalties, offender)
+			penalized[offender] = true
+		}
+	}
+	return penalties, nil
+}
@@ -174,7 +174,7 @@ var (
 func sigHash(header *types.Header) (hash common.Hash) {
 	hasher := sha3.NewKeccak256()
 
-	rlp.Encode(hasher, []interface{}{
+	fields := []interface{}{
 		header.ParentHash,
 		header.UncleHash,
 		header.Coinbase,
@@ -190,7 +190,16 @@ func sigHash(header *types.Header) (hash common.Hash) {
 		header.Extra[:len(header.Extra)-65], // Yes, this will panic if extra is too short
 		header.MixDigest,
 		header.Nonce,
-	})
+	}
+	// Attestations and evidences are sealed as well, they are absent before
+	// TIPAttestation and TIPSlashing
+	if len(header.Attestations) > 0 || len(header.Evidences) > 0 {
+		fields = append(fields, header.Attestations)
+	}
+	if len(header.Evidences) > 0 {
+		fields = append(fields, header.Evidences)
+	}
+	rlp.Encode(hasher, fields)
 	hasher.Sum(hash[:0])
 	return hash
 }
@@ -235,10 +244,14 @@ type Posv struct {
 	validatorSignatures *lru.ARCCache // Signatures of recent blocks to speed up mining
 	verifiedHeaders     *lru.ARCCache
 	proposals           map[common.Address]bool // Current list of proposals we are pushing
+	attestations        *attestationPool        // Attestations waiting to be included in a block
+	seals               *lru.ARCCache           // Recently sealed headers by number and creator to detect equivocations
+	evidences           *evidencePool           // Equivocation evidences waiting to be included in a block
 
-	signer common.Address  // Ethereum address of the signing key
-	signFn clique.SignerFn // Signer function to authorize hashes with
-	lock   sync.RWMutex    // Protects the signer fields
+	signer   common.Address  // Ethereum address of the signing key
+	signFn   clique.SignerFn // Signer function to authorize hashes with
+	rotation *signerRotation // Signer scheduled to take over at a future block
+	lock     sync.RWMutex    // Protects the signer fields
 
 	BlockSigners               *lru.Cache
 	HookReward                 func(chain consensus.ChainReader, state *state.StateDB, header *types.Header) (error, map[string]interface{})
@@ -264,6 +277,7 @@ func New(config *params.PosvConfig, db ethdb.Database) *Posv {
 	signatures, _ := lru.NewARC(inmemorySnapshots)
 	validatorSignatures, _ := lru.NewARC(inmemorySnapshots)
 	verifiedHeaders, _ := lru.NewARC(inmemorySnapshots)
+	seals, _ := lru.NewARC(inmemorySeals)
 	return &Posv{
 		config:              &conf,
 		db:                  db,
@@ -273,6 +287,9 @@ func New(config *params.PosvConfig, db ethdb.Database) *Posv {
 		verifiedHeaders:     verifiedHeaders,
 		validatorSignatures: validatorSignatures,
 		proposals:           make(map[common.Address]bool),
+		attestations:        newAttestationPool(),
+		seals:               seals,
+		evidences:           newEvidencePool(),
 	}
 }
 
@@ -282,8 +299,13 @@ func (c *Posv) Author(header *types.Header) (common.Address, error) {
 	return ecrecover(header, c.signatures)
 }
 
-// Get signer coinbase
-func (c *Posv) Signer() common.Address { return c.signer }
+// Signer returns the current signer, which keeps sealing until a scheduled
+// rotation is reached. Use SignerAt for the signer of a given block.
+func (c *Posv) Signer() common.Address {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.signer
+}
 
 // VerifyHeader checks whether a header conforms to the consensus rules.
 func (c *Posv) VerifyHeader(chain consensus.ChainReader, header *types.Header, fullVerify bool) error {
@@ -411,6 +433,12 @@ func (c *Posv) verifyCascadingFields(chain consensus.ChainReader, header *types.
 	if parent.Time.Uint64()+c.config.Period > header.Time.Uint64() {
 		return ErrInvalidTimestamp
 	}
+	if err := c.verifyAttestations(chain, header, parents); err != nil {
+		return err
+	}
+	if err := c.verifyEvidences(chain, header, parents); err != nil {
+		return err
+	}
 
 	if number%c.config.Epoch != 0 {
 		return c.verifySeal(chain, header, parents, fullVerify)
@@ -427,7 +455,7 @@ func (c *Posv) verifyCascadingFields(chain consensus.ChainReader, header *types.
 	}
 
 	signers := snap.GetSigners()
-	err = c.checkSignersOnCheckpoint(chain, header, signers)
+	err = c.checkSignersOnCheckpoint(chain, header, parents, signers)
 	if err == nil {
 		return c.verifySeal(chain, header, parents, fullVerify)
 	}
@@ -444,7 +472,7 @@ func (c *Posv) verifyCascadingFields(chain consensus.ChainReader, header *types.
 		return err
 	}
 
-	err = c.checkSignersOnCheckpoint(chain, header, signers)
+	err = c.checkSignersOnCheckpoint(chain, header, parents, signers)
 	if err == nil {
 		return c.verifySeal(chain, header, parents, fullVerify)
 	}
@@ -452,18 +480,27 @@ func (c *Posv) verifyCascadingFields(chain consensus.ChainReader, header *types.
 	return err
 }
 
-func (c *Posv) checkSignersOnCheckpoint(chain consensus.ChainReader, header *types.Header, signers []common.Address) error {
+func (c *Posv) checkSignersOnCheckpoint(chain consensus.ChainReader, header *types.Header, parents []*types.Header, signers []common.Address) error {
 	number := header.Number.Uint64()
 	penPenalties := []common.Address{}
-	if c.HookPenalty != nil || c.HookPenaltyTIPSigning != nil {
+	slashing := chain.Config().IsTIPSlashing(header.Number)
+	if c.HookPenalty != nil || c.HookPenaltyTIPSigning != nil || slashing {
 		var err error
-		if chain.Config().IsTIPSigning(header.Number) {
-			penPenalties, err = c.HookPenaltyTIPSigning(chain, header, signers)
-		} else {
-			penPenalties, err = c.HookPenalty(chain, number)
+		if c.HookPenalty != nil || c.HookPenaltyTIPSigning != nil {
+			if chain.Config().IsTIPSigning(header.Number) {
+				penPenalties, err = c.HookPenaltyTIPSigning(chain, header, signers)
+			} else {
+				penPenalties, err = c.HookPenalty(chain, number)
+			}
+			if err != nil {
+				return err
+			}
 		}
-		if err != nil {
-			return err
+		if slashing {
+			penPenalties, err = c.appendSlashedSigners(chain, header, parents, penPenalties)
+			if err != nil {
+				return err
+			}
 		}
 		for _, address := range penPenalties {
 			log.Debug("Penalty Info", "address", address, "number", number)
@@ -592,7 +629,7 @@ func (c *Posv) YourTurn(chain consensus.ChainReader, parent *types.Header, signe
 		preIndex = position(masternodes, pre)
 	}
 	curIndex := position(masternodes, signer)
-	if signer == c.signer {
+	if signer == c.SignerAt(parent.Number.Uint64()+1) {
 		log.Debug("Masternodes cycle info", "number of masternodes", len(masternodes), "previous", pre, "position", preIndex, "current", signer, "position", curIndex)
 	}
 	for i, s := range masternodes {
@@ -678,6 +715,14 @@ func (c *Posv) snapshot(chain consensus.ChainReader, number uint64, hash common.
 			return nil, err
 		}
 		log.Trace("Stored voting snapshot to disk", "number", snap.Number, "hash", snap.Hash)
+		// Drop the canonical checkpoint snapshot falling out of the retention
+		if retention := snapshotRetention * c.config.Epoch; snap.Number > retention {
+			if header := chain.GetHeaderByNumber(snap.Number - retention); header != nil {
+				if err := c.db.Delete(snapshotKey(header.Hash())); err != nil {
+					log.Warn("Failed to prune voting snapshot", "number", header.Number, "err", err)
+				}
+			}
+		}
 	}
 	return snap, err
 }
@@ -756,6 +801,8 @@ func (c *Posv) verifySeal(chain consensus.ChainReader, header *types.Header, par
 			return errUnauthorized
 		}
 	}
+	c.recordSeal(chain, header, creator)
+
 	if len(masternodes) > 1 {
 		for seen, recent := range snap.Recents {
 			if recent == creator {
@@ -788,6 +835,7 @@ func (c *Posv) verifySeal(chain consensus.ChainReader, header *types.Header, par
 			log.Debug("Bad block detected. Header contains wrong pair of creator-validator", "creator", creator, "assigned validator", assignedValidator, "wrong validator", validator)
 			return errFailedDoubleValidation
 		}
+		c.recordValidation(chain, header, validator)
 	}
 	return nil
 }
@@ -855,8 +903,25 @@ func (c *Posv) Prepare(chain consensus.ChainReader, header *types.Header) error
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
+	// Include the pending attestations of recent blocks
+	if chain.Config().IsTIPAttestation(header.Number) {
+		attestations, err := c.collectAttestations(chain, header)
+		if err != nil {
+			return err
+		}
+		header.Attestations = attestations
+	}
+	// Include the pending evidences of masternode equivocations
+	if chain.Config().IsTIPSlashing(header.Number) {
+		evidences, err := c.collectEvidences(chain, header)
+		if err != nil {
+			return err
+		}
+		header.Evidences = evidences
+	}
 	// Set the correct difficulty
-	header.Difficulty = c.calcDifficulty(chain, parent, c.signer)
+	signer, _ := c.rotateSigner(number)
+	header.Difficulty = c.calcDifficulty(chain, parent, signer)
 	log.Debug("CalcDifficulty ", "number", header.Number, "difficulty", header.Difficulty)
 	// Ensure the extra data has all it's components
 	if len(header.Extra) < extraVanity {
@@ -865,16 +930,26 @@ func (c *Posv) Prepare(chain consensus.ChainReader, header *types.Header) error
 	header.Extra = header.Extra[:extraVanity]
 	masternodes := snap.GetSigners()
 	if number >= c.config.Epoch && number%c.config.Epoch == 0 {
-		if c.HookPenalty != nil || c.HookPenaltyTIPSigning != nil {
+		slashing := chain.Config().IsTIPSlashing(header.Number)
+		if c.HookPenalty != nil || c.HookPenaltyTIPSigning != nil || slashing {
 			var penMasternodes []common.Address = nil
 			var err error = nil
-			if chain.Config().IsTIPSigning(header.Number) {
-				penMasternodes, err = c.HookPenaltyTIPSigning(chain, header, masternodes)
-			} else {
-				penMasternodes, err = c.HookPenalty(chain, number)
+			if c.HookPenalty != nil || c.HookPenaltyTIPSigning != nil {
+				if chain.Config().IsTIPSigning(header.Number) {
+					penMasternodes, err = c.HookPenaltyTIPSigning(chain, header, masternodes)
+				} else {
+					penMasternodes, err = c.HookPenalty(chain, number)
+				}
+				if err != nil {
+					return err
+				}
 			}
-			if err != nil {
-				return err
+			// Masternodes proven to have equivocated are penalized as well
+			if slashing {
+				penMasternodes, err = c.appendSlashedSigners(chain, header, nil, penMasternodes)
+				if err != nil {
+					return err
+				}
 			}
 			if len(penMasternodes) > 0 {
 				// penalize bad masternode(s)
@@ -981,6 +1056,67 @@ func (c *Posv) Authorize(signer common.Address, signFn clique.SignerFn) {
 	c.signFn = signFn
 }
 
+// signerRotation is a signer scheduled to replace the current one.
+type signerRotation struct {
+	number uint64          // First block sealed by the new signer
+	signer common.Address  // Ethereum address of the new signing key
+	signFn clique.SignerFn // Signer function of the new signing key
+}
+
+// ScheduleAuthorize schedules a new signing key to take over from the given
+// block on. Blocks below it are only ever sealed by the current key and blocks
+// from it on only by the new one, so both keys never sign concurrently.
+func (c *Posv) ScheduleAuthorize(number uint64, signer common.Address, signFn clique.SignerFn) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.rotation = &signerRotation{number: number, signer: signer, signFn: signFn}
+}
+
+// PendingSigner returns the signer scheduled to take over and the block it
+// starts sealing at, if any.
+func (c *Posv) PendingSigner() (common.Address, uint64, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.rotation == nil {
+		return common.Address{}, 0, false
+	}
+	return c.rotation.signer, c.rotation.number, true
+}
+
+// SignerAt returns the signer sealing the block with the given number.
+func (c *Posv) SignerAt(number uint64) common.Address {
+	signer, _ := c.signerAt(number)
+	return signer
+}
+
+// signerAt returns the signing credentials for the block with the given number,
+// without switching over to the scheduled signer.
+func (c *Posv) signerAt(number uint64) (common.Address, clique.SignerFn) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.rotation != nil && number >= c.rotation.number {
+		return c.rotation.signer, c.rotation.signFn
+	}
+	return c.signer, c.signFn
+}
+
+// rotateSigner returns the signing credentials for the block with the given
+// number, switching over to the scheduled signer once its first block is reached.
+func (c *Posv) rotateSigner(number uint64) (common.Address, clique.SignerFn) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.rotation != nil && number >= c.rotation.number {
+		log.Info("Switching to rotated signer", "number", number, "old", c.signer, "new", c.rotation.signer)
+		c.signer, c.signFn = c.rotation.signer, c.rotation.signFn
+		c.rotation = nil
+	}
+	return c.signer, c.signFn
+}
+
 // Seal implements consensus.Engine, attempting to create a sealed block using
 // the local signing credentials.
 func (c *Posv) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
@@ -997,9 +1133,7 @@ func (c *Posv) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan
 		return nil, errWaitTransactions
 	}
 	// Don't hold the signer fields for the entire sealing procedure
-	c.lock.RLock()
-	signer, signFn := c.signer, c.signFn
-	c.lock.RUnlock()
+	signer, signFn := c.rotateSigner(number)
 
 	// Bail out if we're unauthorized to sign a block
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
@@ -1042,12 +1176,17 @@ func (c *Posv) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan
 		return nil, nil
 	default:
 	}
+	// Never sign a header conflicting with one sealed before
+	if err := c.checkSeal(chain, header, signer); err != nil {
+		return nil, err
+	}
 	// Sign all the things!
 	sighash, err := signFn(accounts.Account{Address: signer}, sigHash(header).Bytes())
 	if err != nil {
 		return nil, err
 	}
 	copy(header.Extra[len(header.Extra)-extraSeal:], sighash)
+	c.recordSeal(chain, header, signer)
 	m2, err := c.GetValidator(signer, chain, header)
 	if err != nil {
 		return nil, fmt.Errorf("can't get block validator: %v", err)
@@ -1062,7 +1201,7 @@ func (c *Posv) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan
 // that a new block should have based on the previous blocks in the chain and the
 // current signer.
 func (c *Posv) CalcDifficulty(chain consensus.ChainReader, time uint64, parent *types.Header) *big.Int {
-	return c.calcDifficulty(chain, parent, c.signer)
+	return c.calcDifficulty(chain, parent, c.SignerAt(parent.Number.Uint64()+1))
 }
 
 func (c *Posv) calcDifficulty(chain consensus.ChainReader, parent *types.Header, signer common.Address) *big.Int {
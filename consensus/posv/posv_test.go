@@ -1,12 +1,14 @@
 package posv
 
 import (
+	"crypto/ecdsa"
 	"fmt"
 	"math/big"
 	"testing"
 
 	"github.com/tomochain/tomochain/common"
 	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/crypto"
 	"github.com/tomochain/tomochain/params"
 )
 
@@ -82,3 +84,156 @@ func TestCompareSignersLists(t *testing.T) {
 		t.Error("Failed with list has only one signer")
 	}
 }
+
+func TestAttestationPool(t *testing.T) {
+	pool := newAttestationPool()
+	signers := []common.Address{
+		common.StringToAddress("cccccccccccccccccccccccccccccccccccccccc"),
+		common.StringToAddress("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+	}
+	hash := common.HexToHash("0x01")
+	for _, signer := range signers {
+		if !pool.add(types.NewAttestation(15, hash), signer) {
+			t.Error("failed to add attestation", "signer", signer)
+		}
+	}
+	if pool.add(types.NewAttestation(15, hash), signers[0]) {
+		t.Error("duplicate attestation was added")
+	}
+	pool.add(types.NewAttestation(30, common.HexToHash("0x02")), signers[0])
+	if have := len(pool.get(hash)); have != 2 {
+		t.Error("wrong number of attestations", "want", 2, "have", have)
+	}
+	pool.prune(16)
+	if have := len(pool.get(hash)); have != 0 {
+		t.Error("attestations not pruned", "have", have)
+	}
+	if have := len(pool.get(common.HexToHash("0x02"))); have != 1 {
+		t.Error("recent attestation pruned", "have", have)
+	}
+}
+
+func TestEvidencePool(t *testing.T) {
+	pool := newEvidencePool()
+	offender := common.StringToAddress("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	header := &types.Header{Number: big.NewInt(15), Difficulty: big.NewInt(1), Time: big.NewInt(1)}
+	if !pool.add(types.NewEvidence(header, header), offender) {
+		t.Error("failed to add evidence")
+	}
+	if pool.add(types.NewEvidence(header, header), offender) {
+		t.Error("duplicate evidence was added")
+	}
+	later := types.CopyHeader(header)
+	later.Number = big.NewInt(30)
+	pool.add(types.NewEvidence(later, later), offender)
+	if keys, evidences := pool.get(); len(evidences) != 2 || keys[0].number != 15 {
+		t.Error("wrong pending evidences", "have", len(evidences))
+	}
+	pool.prune(15)
+	if keys, _ := pool.get(); len(keys) != 1 || keys[0].number != 30 {
+		t.Error("evidences not pruned", "have", len(keys))
+	}
+}
+
+func TestVerifyEvidence(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	offender := crypto.PubkeyToAddress(key.PublicKey)
+	validatorKey, _ := crypto.GenerateKey()
+	validator := crypto.PubkeyToAddress(validatorKey.PublicKey)
+
+	engine := New(&params.PosvConfig{Epoch: 900}, nil)
+	seal := func(key *ecdsa.PrivateKey, time int64) *types.Header {
+		header := &types.Header{
+			ParentHash: common.HexToHash("0x01"),
+			Number:     big.NewInt(900),
+			Difficulty: big.NewInt(1),
+			Time:       big.NewInt(time),
+			Extra:      make([]byte, extraVanity+2*common.AddressLength+extraSeal),
+		}
+		copy(header.Extra[extraVanity:], offender[:])
+		copy(header.Extra[extraVanity+common.AddressLength:], validator[:])
+		sig, err := crypto.Sign(sigHash(header).Bytes(), key)
+		if err != nil {
+			t.Fatal("sign error: ", err)
+		}
+		copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+		if header.Validator, err = crypto.Sign(sigHash(header).Bytes(), validatorKey); err != nil {
+			t.Fatal("sign error: ", err)
+		}
+		return header
+	}
+	first, second := seal(key, 1), seal(key, 2)
+
+	have, err := engine.verifyEvidence(nil, types.NewEvidence(first, second))
+	if err != nil {
+		t.Fatal("valid evidence rejected: ", err)
+	}
+	if have != offender {
+		t.Errorf("offender mismatch: have %x, want %x", have, offender)
+	}
+	// The validator of both headers double validated
+	have, err = engine.verifyEvidence(nil, types.NewValidationEvidence(first, second))
+	if err != nil {
+		t.Fatal("valid validation evidence rejected: ", err)
+	}
+	if have != validator {
+		t.Errorf("double validator mismatch: have %x, want %x", have, validator)
+	}
+	// Resealing the same header with a different validator signature is fine
+	resealed := types.CopyHeader(first)
+	resealed.Validator = []byte{0x01}
+	if _, err := engine.verifyEvidence(nil, types.NewEvidence(first, resealed)); err != errInvalidEvidence {
+		t.Errorf("identical seal accepted: have %v, want %v", err, errInvalidEvidence)
+	}
+	// Headers on different parents are not an equivocation
+	other := seal(key, 3)
+	other.ParentHash = common.HexToHash("0x02")
+	if _, err := engine.verifyEvidence(nil, types.NewEvidence(first, other)); err != errInvalidEvidence {
+		t.Errorf("headers on different parents accepted: have %v, want %v", err, errInvalidEvidence)
+	}
+	// Validating competing headers of different creators is not a double validation
+	otherKey, _ := crypto.GenerateKey()
+	competing := seal(otherKey, 4)
+	if _, err := engine.verifyEvidence(nil, types.NewValidationEvidence(first, competing)); err != errInvalidEvidence {
+		t.Errorf("headers of different creators accepted: have %v, want %v", err, errInvalidEvidence)
+	}
+}
+
+func TestSignerRotation(t *testing.T) {
+	var (
+		engine  = New(&params.PosvConfig{Epoch: 900}, nil)
+		current = common.StringToAddress("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+		next    = common.StringToAddress("bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+	)
+	engine.Authorize(current, nil)
+	engine.ScheduleAuthorize(10, next, nil)
+	if signer, number, ok := engine.PendingSigner(); !ok || signer != next || number != 10 {
+		t.Errorf("pending signer mismatch: have %x at %d", signer, number)
+	}
+	if signer := engine.SignerAt(9); signer != current {
+		t.Errorf("signer before rotation mismatch: have %x, want %x", signer, current)
+	}
+	if signer := engine.SignerAt(10); signer != next {
+		t.Errorf("signer at rotation mismatch: have %x, want %x", signer, next)
+	}
+	// Looking up the signer doesn't rotate it
+	if signer := engine.Signer(); signer != current {
+		t.Errorf("signer rotated by a lookup: have %x, want %x", signer, current)
+	}
+	if _, _, ok := engine.PendingSigner(); !ok {
+		t.Error("rotation applied by a lookup")
+	}
+	// Once rotated the old key is never used again
+	if signer, _ := engine.rotateSigner(10); signer != next {
+		t.Errorf("rotated signer mismatch: have %x, want %x", signer, next)
+	}
+	if signer := engine.SignerAt(9); signer != next {
+		t.Errorf("old signer used after rotation: have %x, want %x", signer, next)
+	}
+	if signer := engine.Signer(); signer != next {
+		t.Errorf("signer mismatch after rotation: have %x, want %x", signer, next)
+	}
+	if _, _, ok := engine.PendingSigner(); ok {
+		t.Error("rotation still pending")
+	}
+}
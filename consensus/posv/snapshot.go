@@ -18,12 +18,15 @@ package posv
 import (
 	"bytes"
 	"encoding/json"
+	"sort"
 
 	"github.com/tomochain/tomochain/common"
 	"github.com/tomochain/tomochain/consensus/clique"
 	"github.com/tomochain/tomochain/core/types"
 	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/log"
 	"github.com/tomochain/tomochain/params"
+	"github.com/tomochain/tomochain/rlp"
 	lru "github.com/hashicorp/golang-lru"
 )
 
@@ -75,29 +78,177 @@ func newSnapshot(config *params.PosvConfig, sigcache *lru.ARCCache, number uint6
 	return snap
 }
 
-// loadSnapshot loads an existing snapshot from the database.
+// snapshotRetention is the number of recent checkpoint snapshots kept on disk,
+// older ones are deleted when a new checkpoint snapshot is stored.
+const snapshotRetention = 64
+
+// snapshotPrefix is the database key prefix of the stored snapshots.
+var snapshotPrefix = []byte("posv-")
+
+// snapshotKey = snapshotPrefix + hash
+func snapshotKey(hash common.Hash) []byte {
+	return append(append([]byte{}, snapshotPrefix...), hash[:]...)
+}
+
+// recentRLP is a recent signer in the compact snapshot encoding.
+type recentRLP struct {
+	Block  uint64
+	Signer common.Address
+}
+
+// tallyRLP is a vote tally in the compact snapshot encoding.
+type tallyRLP struct {
+	Address   common.Address
+	Authorize bool
+	Votes     uint64
+}
+
+// snapshotRLP is the compact encoding of a snapshot stored on disk. Maps are
+// flattened into lists sorted by key so that the encoding is deterministic.
+type snapshotRLP struct {
+	Number  uint64
+	Hash    common.Hash
+	Signers []common.Address
+	Recents []recentRLP
+	Votes   []*clique.Vote
+	Tally   []tallyRLP
+}
+
+// encodeSnapshot returns the compact encoding of a snapshot.
+func encodeSnapshot(s *Snapshot) ([]byte, error) {
+	enc := snapshotRLP{
+		Number:  s.Number,
+		Hash:    s.Hash,
+		Signers: make([]common.Address, 0, len(s.Signers)),
+		Recents: make([]recentRLP, 0, len(s.Recents)),
+		Votes:   s.Votes,
+		Tally:   make([]tallyRLP, 0, len(s.Tally)),
+	}
+	for signer := range s.Signers {
+		enc.Signers = append(enc.Signers, signer)
+	}
+	sort.Slice(enc.Signers, func(i, j int) bool {
+		return bytes.Compare(enc.Signers[i][:], enc.Signers[j][:]) < 0
+	})
+	for block, signer := range s.Recents {
+		enc.Recents = append(enc.Recents, recentRLP{block, signer})
+	}
+	sort.Slice(enc.Recents, func(i, j int) bool {
+		return enc.Recents[i].Block < enc.Recents[j].Block
+	})
+	for address, tally := range s.Tally {
+		enc.Tally = append(enc.Tally, tallyRLP{address, tally.Authorize, uint64(tally.Votes)})
+	}
+	sort.Slice(enc.Tally, func(i, j int) bool {
+		return bytes.Compare(enc.Tally[i].Address[:], enc.Tally[j].Address[:]) < 0
+	})
+	return rlp.EncodeToBytes(&enc)
+}
+
+// decodeSnapshot parses a stored snapshot. Snapshots written before the compact
+// encoding are JSON objects, they are still accepted and reported as legacy so
+// that they can be migrated.
+func decodeSnapshot(blob []byte) (snap *Snapshot, legacy bool, err error) {
+	snap = new(Snapshot)
+	if len(blob) > 0 && blob[0] == '{' {
+		if err := json.Unmarshal(blob, snap); err != nil {
+			return nil, false, err
+		}
+		return snap, true, nil
+	}
+	var dec snapshotRLP
+	if err := rlp.DecodeBytes(blob, &dec); err != nil {
+		return nil, false, err
+	}
+	snap.Number = dec.Number
+	snap.Hash = dec.Hash
+	snap.Signers = make(map[common.Address]struct{}, len(dec.Signers))
+	for _, signer := range dec.Signers {
+		snap.Signers[signer] = struct{}{}
+	}
+	snap.Recents = make(map[uint64]common.Address, len(dec.Recents))
+	for _, recent := range dec.Recents {
+		snap.Recents[recent.Block] = recent.Signer
+	}
+	snap.Votes = dec.Votes
+	snap.Tally = make(map[common.Address]clique.Tally, len(dec.Tally))
+	for _, tally := range dec.Tally {
+		snap.Tally[tally.Address] = clique.Tally{Authorize: tally.Authorize, Votes: int(tally.Votes)}
+	}
+	return snap, false, nil
+}
+
+// loadSnapshot loads an existing snapshot from the database. Legacy JSON
+// snapshots are rewritten in the compact encoding.
 func loadSnapshot(config *params.PosvConfig, sigcache *lru.ARCCache, db ethdb.Database, hash common.Hash) (*Snapshot, error) {
-	blob, err := db.Get(append([]byte("posv-"), hash[:]...))
+	blob, err := db.Get(snapshotKey(hash))
 	if err != nil {
 		return nil, err
 	}
-	snap := new(Snapshot)
-	if err := json.Unmarshal(blob, snap); err != nil {
+	snap, legacy, err := decodeSnapshot(blob)
+	if err != nil {
 		return nil, err
 	}
 	snap.config = config
 	snap.sigcache = sigcache
 
+	if legacy {
+		if err := snap.store(db); err != nil {
+			log.Warn("Failed to migrate voting snapshot", "number", snap.Number, "hash", snap.Hash, "err", err)
+		}
+	}
 	return snap, nil
 }
 
 // store inserts the snapshot into the database.
 func (s *Snapshot) store(db ethdb.Database) error {
-	blob, err := json.Marshal(s)
+	blob, err := encodeSnapshot(s)
 	if err != nil {
 		return err
 	}
-	return db.Put(append([]byte("posv-"), s.Hash[:]...), blob)
+	return db.Put(snapshotKey(s.Hash), blob)
+}
+
+// PruneSnapshots deletes all snapshots stored on disk which are older than the
+// retained checkpoints below head, including the ones of side chains, and
+// migrates the remaining legacy snapshots to the compact encoding. It returns
+// the number of pruned and migrated snapshots.
+func PruneSnapshots(db ethdb.Database, config *params.PosvConfig, head uint64) (pruned int, migrated int, err error) {
+	epoch := config.Epoch
+	if epoch == 0 {
+		epoch = epochLength
+	}
+	it := db.NewIteratorWithPrefix(snapshotPrefix)
+	defer it.Release()
+
+	for it.Next() {
+		if len(it.Key()) != len(snapshotPrefix)+common.HashLength {
+			continue
+		}
+		snap, legacy, err := decodeSnapshot(it.Value())
+		if err != nil {
+			log.Warn("Skipping undecodable voting snapshot", "key", common.ToHex(it.Key()), "err", err)
+			continue
+		}
+		key := common.CopyBytes(it.Key())
+		switch {
+		case snap.Number+snapshotRetention*epoch < head:
+			if err := db.Delete(key); err != nil {
+				return pruned, migrated, err
+			}
+			pruned++
+		case legacy:
+			blob, err := encodeSnapshot(snap)
+			if err != nil {
+				return pruned, migrated, err
+			}
+			if err := db.Put(key, blob); err != nil {
+				return pruned, migrated, err
+			}
+			migrated++
+		}
+	}
+	return pruned, migrated, it.Error()
 }
 
 // copy creates a deep copy of the snapshot, though not the individual votes.
@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package posv
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/consensus/clique"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/params"
+)
+
+func testSnapshot(number uint64) *Snapshot {
+	signers := []common.Address{
+		common.StringToAddress("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+		common.StringToAddress("bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"),
+	}
+	hash := common.BigToHash(new(big.Int).SetUint64(number))
+	snap := newSnapshot(&params.PosvConfig{Epoch: 900}, nil, number, hash, signers)
+	snap.Recents[number] = signers[0]
+	snap.Votes = []*clique.Vote{{Signer: signers[0], Block: number, Address: signers[1], Authorize: true}}
+	snap.Tally[signers[1]] = clique.Tally{Authorize: true, Votes: 1}
+	return snap
+}
+
+func TestSnapshotEncoding(t *testing.T) {
+	snap := testSnapshot(895)
+	blob, err := encodeSnapshot(snap)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	decoded, legacy, err := decodeSnapshot(blob)
+	if err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if legacy {
+		t.Error("compact snapshot reported as legacy")
+	}
+	decoded.config = snap.config
+	if !reflect.DeepEqual(decoded, snap) {
+		t.Errorf("snapshot mismatch:\nhave %+v\nwant %+v", decoded, snap)
+	}
+}
+
+func TestSnapshotMigrationAndPruning(t *testing.T) {
+	dir, err := ioutil.TempDir("", "posv-snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		config = &params.PosvConfig{Epoch: 900}
+		stale  = testSnapshot(895)
+		recent = testSnapshot(895 + snapshotRetention*900)
+	)
+	for _, snap := range []*Snapshot{stale, recent} {
+		blob, _ := json.Marshal(snap)
+		if err := db.Put(snapshotKey(snap.Hash), blob); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Loading a legacy snapshot migrates it
+	if _, err := loadSnapshot(config, nil, db, stale.Hash); err != nil {
+		t.Fatal("failed to load legacy snapshot: ", err)
+	}
+	blob, _ := db.Get(snapshotKey(stale.Hash))
+	if _, legacy, _ := decodeSnapshot(blob); legacy {
+		t.Error("legacy snapshot not migrated on load")
+	}
+	pruned, migrated, err := PruneSnapshots(db, config, recent.Number+1)
+	if err != nil {
+		t.Fatal("prune error: ", err)
+	}
+	if pruned != 1 || migrated != 1 {
+		t.Errorf("prune result mismatch: have %d pruned, %d migrated, want 1, 1", pruned, migrated)
+	}
+	if has, _ := db.Has(snapshotKey(stale.Hash)); has {
+		t.Error("stale snapshot not pruned")
+	}
+	if _, err := loadSnapshot(config, nil, db, recent.Hash); err != nil {
+		t.Error("recent snapshot lost: ", err)
+	}
+}
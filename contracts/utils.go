@@ -26,6 +26,7 @@ import (
 	"io"
 	"math/big"
 	"math/rand"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -42,6 +43,7 @@ import (
 	"github.com/tomochain/tomochain/core/state"
 	stateDatabase "github.com/tomochain/tomochain/core/state"
 	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/crypto"
 	"github.com/tomochain/tomochain/ethdb"
 	"github.com/tomochain/tomochain/log"
 	"github.com/tomochain/tomochain/params"
@@ -59,34 +61,37 @@ type rewardLog struct {
 
 var TxSignMu sync.RWMutex
 
-// Send tx sign for block number to smart contract blockSigner.
-func CreateTransactionSign(chainConfig *params.ChainConfig, pool *core.TxPool, manager *accounts.Manager, block *types.Block, chainDb ethdb.Database) error {
+// Send tx sign for block number to smart contract blockSigner, from the signer
+// of the block.
+func CreateTransactionSign(chainConfig *params.ChainConfig, pool *core.TxPool, manager *accounts.Manager, block *types.Block, chainDb ethdb.Database, signer common.Address) error {
 	TxSignMu.Lock()
 	defer TxSignMu.Unlock()
 	if chainConfig.Posv != nil {
-		// Find active account.
-		account := accounts.Account{}
-		var wallet accounts.Wallet
-		if wallets := manager.Wallets(); len(wallets) > 0 {
-			wallet = wallets[0]
-			if accts := wallets[0].Accounts(); len(accts) > 0 {
-				account = accts[0]
-			}
+		// Find the wallet of the signer.
+		account := accounts.Account{Address: signer}
+		wallet, err := manager.Find(account)
+		if err != nil {
+			log.Error("Can't find signer account wallet", "signer", signer, "error", err)
+			return err
 		}
 
 		// Create and send tx to smart contract for sign validate block.
+		// Since TIPAttestation blocks are attested on the eth protocol instead.
 		nonce := pool.State().GetNonce(account.Address)
-		tx := CreateTxSign(block.Number(), block.Hash(), nonce, common.HexToAddress(common.BlockSigners))
-		txSigned, err := wallet.SignTx(account, tx, chainConfig.ChainId)
-		if err != nil {
-			log.Error("Fail to create tx sign", "error", err)
-			return err
-		}
-		// Add tx signed to local tx pool.
-		err = pool.AddLocal(txSigned)
-		if err != nil {
-			log.Error("Fail to add tx sign to local pool.", "error", err, "number", block.NumberU64(), "hash", block.Hash().Hex(), "from", account.Address, "nonce", nonce)
-			return err
+		if !chainConfig.IsTIPAttestation(block.Number()) {
+			tx := CreateTxSign(block.Number(), block.Hash(), nonce, common.HexToAddress(common.BlockSigners))
+			txSigned, err := wallet.SignTx(account, tx, chainConfig.ChainId)
+			if err != nil {
+				log.Error("Fail to create tx sign", "error", err)
+				return err
+			}
+			// Add tx signed to local tx pool.
+			err = pool.AddLocal(txSigned)
+			if err != nil {
+				log.Error("Fail to add tx sign to local pool.", "error", err, "number", block.NumberU64(), "hash", block.Hash().Hex(), "from", account.Address, "nonce", nonce)
+				return err
+			}
+			nonce++
 		}
 
 		// Create secret tx.
@@ -101,7 +106,18 @@ func CreateTransactionSign(chainConfig *params.ChainConfig, pool *core.TxPool, m
 			// Only process when private key empty in state db.
 			// Save randomize key into state db.
 			randomizeKeyValue := RandStringByte(32)
-			tx, err := BuildTxSecretRandomize(nonce+1, common.HexToAddress(common.RandomizeSMC), chainConfig.Posv.Epoch, randomizeKeyValue)
+			// Since TIPCommitReveal the secret is a commitment to the opening of the next checkpoint.
+			var (
+				tx  *types.Transaction
+				err error
+			)
+			checkpoint := blockNumber - checkNumber + chainConfig.Posv.Epoch
+			if chainConfig.IsTIPCommitReveal(new(big.Int).SetUint64(checkpoint)) {
+				commitment := RandomizeCommitment(randomizeKeyValue, checkpoint, account.Address)
+				tx = BuildTxCommitRandomize(nonce, common.HexToAddress(common.RandomizeSMC), commitment)
+			} else {
+				tx, err = BuildTxSecretRandomize(nonce, common.HexToAddress(common.RandomizeSMC), chainConfig.Posv.Epoch, randomizeKeyValue)
+			}
 			if err != nil {
 				log.Error("Fail to get tx opening for randomize", "error", err)
 				return err
@@ -130,7 +146,7 @@ func CreateTransactionSign(chainConfig *params.ChainConfig, pool *core.TxPool, m
 				return err
 			}
 
-			tx, err := BuildTxOpeningRandomize(nonce+1, common.HexToAddress(common.RandomizeSMC), randomizeKeyValue)
+			tx, err := BuildTxOpeningRandomize(nonce, common.HexToAddress(common.RandomizeSMC), randomizeKeyValue)
 			if err != nil {
 				log.Error("Fail to get tx opening for randomize", "error", err)
 				return err
@@ -189,6 +205,23 @@ func BuildTxSecretRandomize(nonce uint64, randomizeAddr common.Address, epocNumb
 	return tx, nil
 }
 
+// Send commitment of the opening into randomize smartcontract.
+func BuildTxCommitRandomize(nonce uint64, randomizeAddr common.Address, commitment common.Hash) *types.Transaction {
+	data := common.Hex2Bytes(common.HexSetSecret)
+	// Array of a single bytes32 element: offset, length and the commitment.
+	inputData := append(data, common.LeftPadBytes(big.NewInt(32).Bytes(), 32)...)
+	inputData = append(inputData, common.LeftPadBytes(big.NewInt(1).Bytes(), 32)...)
+	inputData = append(inputData, commitment.Bytes()...)
+
+	return types.NewTransaction(nonce, randomizeAddr, big.NewInt(0), 200000, big.NewInt(0), inputData)
+}
+
+// RandomizeCommitment returns the commitment to an opening of a masternode. It
+// is bound to the checkpoint so that stale openings can't be replayed.
+func RandomizeCommitment(opening []byte, checkpoint uint64, masternode common.Address) common.Hash {
+	return crypto.Keccak256Hash(common.LeftPadBytes(opening, 32), common.LeftPadBytes(new(big.Int).SetUint64(checkpoint).Bytes(), 32), masternode.Bytes())
+}
+
 // Send opening to randomize SMC.
 func BuildTxOpeningRandomize(nonce uint64, randomizeAddr common.Address, randomizeKey []byte) (*types.Transaction, error) {
 	data := common.Hex2Bytes(common.HexSetOpening)
@@ -307,6 +340,52 @@ func DecryptRandomizeFromSecretsAndOpening(secrets [][32]byte, opening [32]byte)
 	return random, nil
 }
 
+// Get the openings of the masternodes which revealed the value they committed
+// to for the checkpoint, and the masternodes which didn't.
+func GetRandomizeReveals(statedb *state.StateDB, checkpoint uint64, masternodes []common.Address) (map[common.Address]common.Hash, []common.Address) {
+	reveals := make(map[common.Address]common.Hash)
+	missing := []common.Address{}
+	for _, masternode := range masternodes {
+		secrets := state.GetSecret(statedb, masternode)
+		opening := state.GetOpening(statedb, masternode)
+		if len(secrets) != 1 || common.Hash(secrets[0]) != RandomizeCommitment(opening[:], checkpoint, masternode) {
+			missing = append(missing, masternode)
+			continue
+		}
+		reveals[masternode] = opening
+	}
+	return reveals, missing
+}
+
+// Combine the revealed openings into the randomize seed of the checkpoint.
+func RandomizeSeed(checkpoint uint64, reveals map[common.Address]common.Hash) common.Hash {
+	masternodes := make([]common.Address, 0, len(reveals))
+	for masternode := range reveals {
+		masternodes = append(masternodes, masternode)
+	}
+	sort.Slice(masternodes, func(i, j int) bool {
+		return bytes.Compare(masternodes[i][:], masternodes[j][:]) < 0
+	})
+	data := [][]byte{common.LeftPadBytes(new(big.Int).SetUint64(checkpoint).Bytes(), 32)}
+	for _, masternode := range masternodes {
+		opening := reveals[masternode]
+		data = append(data, masternode.Bytes(), opening.Bytes())
+	}
+	return crypto.Keccak256Hash(data...)
+}
+
+// Generate m2 listing from a randomize seed. The listing is a single cycle
+// permutation so that no masternode validates its own blocks.
+func GenM2FromSeed(seed common.Hash, lenSigners int64) []int64 {
+	m2 := NewSlice(int64(0), lenSigners, 1)
+	for i := lenSigners - 1; i > 0; i-- {
+		seed = crypto.Keccak256Hash(seed.Bytes())
+		j := new(big.Int).Mod(seed.Big(), big.NewInt(i)).Int64()
+		m2[i], m2[j] = m2[j], m2[i]
+	}
+	return m2
+}
+
 // Calculate reward for reward checkpoint.
 func GetRewardForCheckpoint(c *posv.Posv, chain consensus.ChainReader, header *types.Header, rCheckpoint uint64, totalSigner *uint64) (map[common.Address]*rewardLog, error) {
 	// Not reward for singer of genesis block and only calculate reward at checkpoint block.
@@ -318,9 +397,17 @@ func GetRewardForCheckpoint(c *posv.Posv, chain consensus.ChainReader, header *t
 	mapBlkHash := map[uint64]common.Hash{}
 
 	data := make(map[common.Hash][]common.Address)
+	attestations := make(map[common.Hash][]common.Address)
 	for i := prevCheckpoint + (rCheckpoint * 2) - 1; i >= startBlockNumber; i-- {
 		header = chain.GetHeader(header.ParentHash, i)
 		mapBlkHash[i] = header.Hash()
+		for _, attestation := range header.Attestations {
+			signer, err := attestation.Signer()
+			if err != nil {
+				return nil, err
+			}
+			attestations[attestation.BlockHash] = append(attestations[attestation.BlockHash], signer)
+		}
 		signData, ok := c.BlockSigners.Get(header.Hash())
 		if !ok {
 			log.Debug("Failed get from cached", "hash", header.Hash().String(), "number", i)
@@ -346,6 +433,9 @@ func GetRewardForCheckpoint(c *posv.Posv, chain consensus.ChainReader, header *t
 	for i := startBlockNumber; i <= endBlockNumber; i++ {
 		if i%common.MergeSignRange == 0 || !chain.Config().IsTIP2019(big.NewInt(int64(i))) {
 			addrs := data[mapBlkHash[i]]
+			if chain.Config().IsTIPAttestation(new(big.Int).SetUint64(i)) {
+				addrs = attestations[mapBlkHash[i]]
+			}
 			// Filter duplicate address.
 			if len(addrs) > 0 {
 				addrSigners := make(map[common.Address]bool)
@@ -25,8 +25,10 @@ import (
 	"github.com/tomochain/tomochain/consensus/posv"
 	"github.com/tomochain/tomochain/contracts/blocksigner"
 	"github.com/tomochain/tomochain/core"
+	"github.com/tomochain/tomochain/core/state"
 	"github.com/tomochain/tomochain/core/types"
 	"github.com/tomochain/tomochain/crypto"
+	"github.com/tomochain/tomochain/ethdb"
 	"math/big"
 	"math/rand"
 	"testing"
@@ -186,6 +188,49 @@ func TestGenM2FromRandomize(t *testing.T) {
 	}
 }
 
+func TestGenM2FromSeed(t *testing.T) {
+	seed := common.HexToHash("0x1234")
+	m2 := GenM2FromSeed(seed, common.MaxMasternodes)
+	if !isArrayEqual([][]int64{m2}, [][]int64{GenM2FromSeed(seed, common.MaxMasternodes)}) {
+		t.Error("M2 listing is not deterministic")
+	}
+	seen := make(map[int64]bool)
+	for i, v := range m2 {
+		if int64(i) == v {
+			t.Errorf("Error check Permutation Without Fixed-point %v - %v", i, v)
+		}
+		seen[v] = true
+	}
+	if len(seen) != common.MaxMasternodes {
+		t.Errorf("M2 listing is not a permutation: %v", m2)
+	}
+}
+
+func TestGetRandomizeReveals(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	randomizeAddr := common.HexToAddress(common.RandomizeSMC)
+	reveal := func(masternode common.Address, commitment common.Hash, opening common.Hash) {
+		locSecret := common.BigToHash(state.GetLocMappingAtKey(masternode.Hash(), 0))
+		statedb.SetState(randomizeAddr, locSecret, common.BigToHash(common.Big1))
+		statedb.SetState(randomizeAddr, state.GetLocDynamicArrAtElement(locSecret, 0, 1), commitment)
+		statedb.SetState(randomizeAddr, common.BigToHash(state.GetLocMappingAtKey(masternode.Hash(), 1)), opening)
+	}
+	checkpoint := uint64(1800)
+	opening := common.HexToHash("0x42")
+	// acc1 revealed, acc2 revealed an opening of a previous checkpoint, acc3 didn't commit
+	reveal(acc1Addr, RandomizeCommitment(opening[:], checkpoint, acc1Addr), opening)
+	reveal(acc2Addr, RandomizeCommitment(opening[:], checkpoint-900, acc2Addr), opening)
+
+	reveals, missing := GetRandomizeReveals(statedb, checkpoint, []common.Address{acc1Addr, acc2Addr, acc3Addr})
+	if len(reveals) != 1 || reveals[acc1Addr] != opening {
+		t.Errorf("Fail to get reveals %v", reveals)
+	}
+	if len(missing) != 2 || missing[0] != acc2Addr || missing[1] != acc3Addr {
+		t.Errorf("Fail to get missing reveals %v", missing)
+	}
+}
+
 // Unit test for validator m2.
 func TestBuildValidatorFromM2(t *testing.T) {
 	a := []int64{84, 58, 27, 96, 127, 60, 136, 20, 121, 31, 87, 85, 40, 120, 149, 109, 141, 145, 11, 110, 147, 35, 76, 46, 34, 108, 72, 103, 102, 12, 23, 47, 70, 86, 125, 112, 128, 13, 130, 98, 126, 62, 132, 111, 134, 6, 106, 67, 24, 91, 101, 50, 94, 43, 77, 73, 129, 71, 51, 10, 92, 29, 80, 95, 33, 100, 124, 75, 38, 133, 79, 83, 61, 36, 122, 99, 16, 28, 18, 116, 140, 97, 119, 82, 148, 48, 56, 32, 93, 107, 69, 68, 123, 81, 22, 137, 25, 115, 44, 8, 42, 131, 143, 17, 55, 89, 9, 15, 19, 59, 146, 54, 5, 30, 41, 144, 117, 1, 104, 49, 105, 45, 88, 78, 74, 135, 0, 21, 57, 3, 66, 52, 63, 138, 4, 114, 37, 118, 14, 2, 26, 7, 65, 139, 39, 64, 90, 142, 53, 113}
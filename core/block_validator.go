@@ -108,6 +108,25 @@ func (v *BlockValidator) ValidateState(block, parent *types.Block, statedb *stat
 func (v *BlockValidator) ValidateMatchingOrder(statedb *state.StateDB, tomoxStatedb *tomox_state.TomoXStateDB, txMatchBatch tomox_state.TxMatchBatch, coinbase common.Address) error {
 	log.Debug("verify matching transaction found a TxMatches Batch", "numTxMatches", len(txMatchBatch.Data))
 
+	return ApplyTxMatchBatch(statedb, txMatchBatch, func(order *tomox_state.OrderItem, orderBook common.Hash) error {
+		// process Matching Engine
+		posvEngine, _ := v.bc.Engine().(*posv.Posv)
+		if posvEngine != nil {
+			if tomoXService := posvEngine.GetTomoXService(); tomoXService != nil {
+				if _, _, err := tomoXService.ApplyOrder(coinbase, v.bc, statedb, tomoxStatedb, orderBook, order); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// ApplyTxMatchBatch decodes and verifies the orders of a matching transaction
+// one after the other, handing each one with its order book to apply and
+// stopping at the first failure. The block validation and the order tracing
+// share it so that the traced matching can't drift from the validated one.
+func ApplyTxMatchBatch(statedb *state.StateDB, txMatchBatch tomox_state.TxMatchBatch, apply func(order *tomox_state.OrderItem, orderBook common.Hash) error) error {
 	for _, txMatch := range txMatchBatch.Data {
 		// verify orderItem
 		order, err := txMatch.DecodeOrder()
@@ -119,17 +138,10 @@ func (v *BlockValidator) ValidateMatchingOrder(statedb *state.StateDB, tomoxStat
 		if err := order.VerifyOrder(statedb); err != nil {
 			return fmt.Errorf("invalid order . Error: %v", err)
 		}
-		// process Matching Engine
-		posvEngine, _  := v.bc.Engine().(*posv.Posv)
-		if posvEngine != nil {
-			if tomoXService := posvEngine.GetTomoXService(); tomoXService != nil {
-				if _, _,  err := tomoXService.ApplyOrder(coinbase, v.bc, statedb, tomoxStatedb, tomox_state.GetOrderBookHash(order.BaseToken,order.QuoteToken), order); err != nil {
-					return err
-				}
-			}
+		if err := apply(order, tomox_state.GetOrderBookHash(order.BaseToken, order.QuoteToken)); err != nil {
+			return err
 		}
 	}
-
 	return nil
 }
 
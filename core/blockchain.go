@@ -37,6 +37,7 @@ import (
 	"github.com/tomochain/tomochain/consensus/posv"
 	contractValidator "github.com/tomochain/tomochain/contracts/validator/contract"
 	"github.com/tomochain/tomochain/core/state"
+	"github.com/tomochain/tomochain/core/state/snapshot"
 	"github.com/tomochain/tomochain/core/types"
 	"github.com/tomochain/tomochain/core/vm"
 	"github.com/tomochain/tomochain/crypto"
@@ -79,6 +80,8 @@ type CacheConfig struct {
 	Disabled      bool          // Whether to disable trie write caching (archive node)
 	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
 	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+	Snapshot      bool          // Whether to maintain a flat snapshot of the recent states
+	ParallelTxs   int           // Number of workers executing the transactions of a block in parallel, 0 executes them in order
 }
 type ResultProcessBlock struct {
 	logs       []*types.Log
@@ -130,6 +133,7 @@ type BlockChain struct {
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
 
 	stateCache state.Database // State database to reuse between imports (contains state cache)
+	snaps      *snapshot.Tree // Flat snapshot of the recent states, nil if disabled
 
 	bodyCache        *lru.Cache    // Cache for the most recent block bodies
 	bodyRLPCache     *lru.Cache    // Cache for the most recent block bodies in RLP encoded format
@@ -142,6 +146,7 @@ type BlockChain struct {
 	running          int32         // running must be called atomically
 	// procInterrupt must be atomically called
 	procInterrupt int32          // interrupt signaler for block processing
+	pruning       int32          // state pruning in progress, must be called atomically
 	wg            sync.WaitGroup // chain processing wait group for shutting down
 
 	engine    consensus.Engine
@@ -197,7 +202,9 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		blocksHashCache:  blocksHashCache,
 	}
 	bc.SetValidator(NewBlockValidator(chainConfig, bc, engine))
-	bc.SetProcessor(NewStateProcessor(chainConfig, bc, engine))
+	processor := NewStateProcessor(chainConfig, bc, engine)
+	processor.parallel = cacheConfig.ParallelTxs
+	bc.SetProcessor(processor)
 
 	var err error
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.getProcInterrupt)
@@ -211,6 +218,11 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
 	}
+	if cacheConfig.Snapshot {
+		if bc.snaps, err = snapshot.New(db, bc.stateCache.TrieDB(), bc.CurrentBlock().Root()); err != nil {
+			log.Warn("Failed to load state snapshot, disabling", "err", err)
+		}
+	}
 	// Check the current state of the block hashes and make sure that we do not have any of the bad blocks in our chain
 	for hash := range BadHashes {
 		if header := bc.GetHeaderByHash(hash); header != nil {
@@ -226,6 +238,13 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	}
 	// Take ownership of this particular state
 	go bc.update()
+
+	// Move the immutable blocks out of the key-value database if there's an
+	// ancient store attached
+	if freezer := freezerOf(bc.db); freezer != nil {
+		bc.wg.Add(1)
+		go bc.freeze(freezer)
+	}
 	return bc, nil
 }
 
@@ -365,6 +384,10 @@ func (bc *BlockChain) SetHead(head uint64) error {
 			// Rewound state missing, rolled back to before pivot, reset to genesis
 			bc.currentBlock.Store(bc.genesisBlock)
 		}
+		// The snapshot layers above the new head are gone
+		if bc.snaps != nil {
+			bc.snaps.Rebuild(bc.CurrentBlock().Root())
+		}
 	}
 	// Rewind the fast block in a simpleton way to the target head
 	if currentFastBlock := bc.CurrentFastBlock(); currentFastBlock != nil && currentHeader.Number.Uint64() < currentFastBlock.NumberU64() {
@@ -404,6 +427,9 @@ func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
 	bc.currentBlock.Store(block)
 	bc.mu.Unlock()
 
+	if bc.snaps != nil {
+		bc.snaps.Rebuild(block.Root())
+	}
 	log.Info("Committed new head block", "number", block.Number(), "hash", hash)
 	return nil
 }
@@ -460,7 +486,7 @@ func (bc *BlockChain) State() (*state.StateDB, error) {
 
 // StateAt returns a new mutable state based on a particular point in time.
 func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
-	return state.New(root, bc.stateCache)
+	return state.NewWithSnapshot(root, bc.stateCache, bc.snaps)
 }
 
 // OrderStateAt returns a new mutable state based on a particular point in time.
@@ -482,6 +508,27 @@ func (bc *BlockChain) OrderStateAt(block *types.Block) (*tomox_state.TomoXStateD
 
 }
 
+// PrecompileTomoXState returns the order books the TomoX precompiled contracts
+// read while executing the transactions of header, which are the ones committed
+// by its parent. It returns nil before TIPTomoXPrecompiles or without the TomoX
+// service, the precompiled contracts then reading every order book as empty,
+// and an error if the order books of the parent can't be opened, as executing
+// the transactions without them would yield a different state than the other
+// nodes.
+func (bc *BlockChain) PrecompileTomoXState(header *types.Header) (*tomox_state.TomoXStateDB, error) {
+	if !bc.Config().IsTIPTomoXPrecompiles(header.Number) || header.Number.Sign() == 0 {
+		return nil, nil
+	}
+	if engine, ok := bc.Engine().(*posv.Posv); !ok || engine.GetTomoXService == nil || engine.GetTomoXService() == nil {
+		return nil, nil
+	}
+	parent := bc.GetBlock(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	return bc.OrderStateAt(parent)
+}
+
 // Reset purges the entire blockchain, restoring it to its genesis state.
 func (bc *BlockChain) Reset() error {
 	return bc.ResetWithGenesisBlock(bc.genesisBlock)
@@ -659,7 +706,7 @@ func (bc *BlockChain) HasBlock(hash common.Hash, number uint64) bool {
 		return true
 	}
 	ok, _ := bc.db.Has(blockBodyKey(hash, number))
-	return ok
+	return ok || hasAncient(bc.db, hash, number)
 }
 
 // HasState checks if state trie is fully present in the database or not.
@@ -767,10 +814,25 @@ func (bc *BlockChain) GetUnclesInChain(block *types.Block, length int) []*types.
 	return uncles
 }
 
+// StateCache returns the caching database of the chain state.
+func (bc *BlockChain) StateCache() state.Database {
+	return bc.stateCache
+}
+
 // TrieNode retrieves a blob of data associated with a trie node (or code hash)
 // either from ephemeral in-memory cache, or from persistent storage.
 func (bc *BlockChain) TrieNode(hash common.Hash) ([]byte, error) {
-	return bc.stateCache.TrieDB().Node(hash)
+	node, err := bc.stateCache.TrieDB().Node(hash)
+	if err == nil {
+		return node, nil
+	}
+	// Fall back to the TomoX state, fast synced along with the chain state
+	if cache := bc.TomoXStateCache(); cache != nil {
+		if node, err := cache.TrieDB().Node(hash); err == nil {
+			return node, nil
+		}
+	}
+	return nil, err
 }
 
 // Stop stops the blockchain service. If any imports are currently in progress
@@ -786,6 +848,13 @@ func (bc *BlockChain) Stop() {
 
 	bc.wg.Wait()
 
+	// Flatten the snapshot of the head state to disk to resume it on restart
+	if bc.snaps != nil {
+		if err := bc.snaps.Cap(bc.CurrentBlock().Root(), 0); err != nil {
+			log.Error("Failed to persist state snapshot", "err", err)
+		}
+		bc.snaps.Release()
+	}
 	// Ensure the state of a recent block is also stored to disk before exiting.
 	// We're writing three different states to catch different restart scenarios:
 	//  - HEAD:     So we don't need to reprocess any blocks in the general case
@@ -1204,6 +1273,14 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	// Set new head.
 	if status == CanonStatTy {
 		bc.insert(block)
+
+		// Keep the snapshot layers of the states retained in memory only
+		if bc.snaps != nil {
+			if err := bc.snaps.Cap(block.Root(), triesInMemory-1); err != nil {
+				log.Warn("Failed to cap state snapshot, rebuilding", "root", block.Root(), "err", err)
+				bc.snaps.Rebuild(block.Root())
+			}
+		}
 	}
 	// save cache BlockSigners
 	if bc.chainConfig.Posv != nil && bc.chainConfig.IsTIPSigning(block.Number()) {
@@ -1363,7 +1440,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 		} else {
 			parent = chain[i-1]
 		}
-		statedb, err := state.New(parent.Root(), bc.stateCache)
+		statedb, err := state.NewWithSnapshot(parent.Root(), bc.stateCache, bc.snaps)
 		if err != nil {
 			return i, events, coalescedLogs, err
 		}
@@ -1594,7 +1671,7 @@ func (bc *BlockChain) getResultBlock(block *types.Block, verifiedM2 bool) (*Resu
 	// Create a new statedb using the parent block and report an
 	// error if it fails.
 	var parent = bc.GetBlock(block.ParentHash(), block.NumberU64()-1)
-	statedb, err := state.New(parent.Root(), bc.stateCache)
+	statedb, err := state.NewWithSnapshot(parent.Root(), bc.stateCache, bc.snaps)
 	if err != nil {
 		return nil, err
 	}
@@ -18,8 +18,10 @@ package core
 
 import (
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"math/rand"
+	"os"
 	"sync"
 	"testing"
 	"time"
@@ -1411,3 +1413,81 @@ func TestAreTwoBlocksSamePath(t *testing.T) {
 	})
 
 }
+
+// Tests that importing blocks reading the state through the snapshot yields
+// the same states, and that the snapshot follows the head across restarts.
+func TestSnapshotImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tomo-snapshot")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewLDBDatabase(dir, 16, 16)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		contract = crypto.CreateAddress(address, 0)
+		gspec    = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainId)
+		engine  = ethash.NewFaker()
+		// Stores the block number in the first slot on every call
+		code = common.FromHex("6443600055006000526005601bf3")
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, engine, db, 10, func(i int, block *BlockGen) {
+		var tx *types.Transaction
+		if i == 0 {
+			tx = types.NewContractCreation(block.TxNonce(address), new(big.Int), 100000, nil, code)
+		} else {
+			tx = types.NewTransaction(block.TxNonce(address), contract, big.NewInt(int64(i)), 100000, nil, nil)
+		}
+		tx, err := types.SignTx(tx, signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	cacheConfig := &CacheConfig{TrieNodeLimit: 256 * 1024 * 1024, TrieTimeLimit: 5 * time.Minute, Snapshot: true}
+	chain, err := NewBlockChain(db, cacheConfig, gspec.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	head := chain.CurrentBlock()
+	if chain.snaps.Snapshot(head.Root()) == nil {
+		t.Fatalf("snapshot of the head state missing")
+	}
+	statedb, _ := chain.State()
+	if value := statedb.GetState(contract, common.Hash{}); value != common.BigToHash(head.Number()) {
+		t.Errorf("contract storage mismatch: have %x, want %x", value, head.Number())
+	}
+	if balance := statedb.GetBalance(contract); balance.Cmp(big.NewInt(45)) != 0 {
+		t.Errorf("contract balance mismatch: have %v, want %v", balance, 45)
+	}
+	chain.Stop()
+
+	// The snapshot flattened on shutdown must be resumed as is
+	if persisted, _ := db.Get([]byte("SnapshotRoot")); common.BytesToHash(persisted) != head.Root() {
+		t.Errorf("persisted snapshot root mismatch: have %x, want %x", persisted, head.Root())
+	}
+	chain, err = NewBlockChain(db, cacheConfig, gspec.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if chain.snaps.Snapshot(head.Root()) == nil {
+		t.Fatalf("snapshot of the head state missing after restart")
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/log"
+	"github.com/tomochain/tomochain/rlp"
+)
+
+const (
+	// ancientThreshold is the number of recent blocks kept in the key-value
+	// database, older canonical blocks are deemed immutable and frozen.
+	ancientThreshold = 90000
+
+	// freezeBatchLimit is the maximum number of blocks frozen in one round.
+	freezeBatchLimit = 30000
+
+	// freezeRecheckInterval is the time between two rounds of freezing.
+	freezeRecheckInterval = time.Minute
+)
+
+// freeze periodically moves the immutable blocks into the ancient store until
+// the chain is stopped.
+func (bc *BlockChain) freeze(freezer *ethdb.Freezer) {
+	defer bc.wg.Done()
+
+	timer := time.NewTimer(freezeRecheckInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-bc.quit:
+			return
+		}
+		frozen, err := bc.freezeBlocks(freezer, ancientThreshold)
+		if err != nil {
+			log.Error("Failed to freeze ancient blocks", "err", err)
+		}
+		// Keep going right away while catching up with a long chain
+		if frozen == freezeBatchLimit {
+			timer.Reset(0)
+		} else {
+			timer.Reset(freezeRecheckInterval)
+		}
+	}
+}
+
+// freezeBlocks moves the canonical blocks older than threshold blocks from the
+// head into the ancient store, deleting them and their side chain siblings
+// from the key-value database. It returns the number of blocks frozen.
+func (bc *BlockChain) freezeBlocks(freezer *ethdb.Freezer, threshold uint64) (int, error) {
+	head := bc.CurrentBlock().NumberU64()
+	if head < threshold {
+		return 0, nil
+	}
+	first, limit := freezer.Ancients(), head-threshold
+	if first > limit {
+		return 0, nil
+	}
+	if limit-first >= freezeBatchLimit {
+		limit = first + freezeBatchLimit - 1
+	}
+	start := time.Now()
+
+	// Append the blocks to the freezer, reading them from the key-value database
+	var (
+		hashes       []common.Hash
+		emptyList, _ = rlp.EncodeToBytes([]interface{}{})
+	)
+freeze:
+	for number := first; number <= limit; number++ {
+		select {
+		case <-bc.quit:
+			break freeze
+		default:
+		}
+		hash, err := bc.db.Get(append(append(headerPrefix, encodeBlockNumber(number)...), numSuffix...))
+		if err != nil {
+			return len(hashes), fmt.Errorf("canonical hash #%d missing: %v", number, err)
+		}
+		var (
+			canon       = common.BytesToHash(hash)
+			header, _   = bc.db.Get(headerKey(canon, number))
+			body, _     = bc.db.Get(blockBodyKey(canon, number))
+			receipts, _ = bc.db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), canon[:]...))
+			td, _       = bc.db.Get(append(append(append(headerPrefix, encodeBlockNumber(number)...), canon[:]...), tdSuffix...))
+		)
+		if len(header) == 0 || len(body) == 0 || len(td) == 0 {
+			return len(hashes), fmt.Errorf("block #%d [%x…] missing", number, canon[:4])
+		}
+		if len(receipts) == 0 {
+			receipts = emptyList
+		}
+		if err := freezer.AppendAncient(number, hash, header, body, receipts, td); err != nil {
+			return len(hashes), err
+		}
+		hashes = append(hashes, canon)
+	}
+	if err := freezer.Sync(); err != nil {
+		return 0, err
+	}
+	// Delete the frozen blocks from the key-value database, keeping only the
+	// hash to number mappings of the canonical ones
+	for i, hash := range hashes {
+		number := first + uint64(i)
+
+		DeleteCanonicalHash(bc.db, number)
+		bc.db.Delete(headerKey(hash, number))
+		DeleteBody(bc.db, hash, number)
+		DeleteBlockReceipts(bc.db, hash, number)
+		DeleteTd(bc.db, hash, number)
+
+		for _, side := range bc.sideHashes(number, hash) {
+			DeleteBlock(bc.db, side, number)
+		}
+	}
+	if len(hashes) > 0 {
+		log.Info("Frozen ancient blocks", "from", first, "to", first+uint64(len(hashes))-1, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return len(hashes), nil
+}
+
+// sideHashes returns the hashes of the non-canonical headers stored with the
+// given number.
+func (bc *BlockChain) sideHashes(number uint64, canon common.Hash) []common.Hash {
+	prefix := append(headerPrefix, encodeBlockNumber(number)...)
+	it := bc.db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+
+	var hashes []common.Hash
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+common.HashLength {
+			continue
+		}
+		if hash := key[len(prefix):]; !bytes.Equal(hash, canon[:]) {
+			hashes = append(hashes, common.BytesToHash(hash))
+		}
+	}
+	return hashes
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/consensus/ethash"
+	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/core/vm"
+	"github.com/tomochain/tomochain/crypto"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/params"
+)
+
+// Tests that freezing the old blocks moves them out of the key-value database
+// while the accessors keep serving them, and that rewinds truncate them.
+func TestFreezeBlocks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tomo-freezer")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewLDBDatabase(dir, 16, 16)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+	if err := db.OpenFreezer(filepath.Join(dir, "ancient")); err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainId)
+		engine  = ethash.NewFaker()
+	)
+	blocks, receipts := GenerateChain(gspec.Config, genesis, engine, db, 20, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x01}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	side, _ := GenerateChain(gspec.Config, blocks[2], engine, db, 1, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{0x02})
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if _, err := chain.InsertChain(side); err != nil {
+		t.Fatalf("failed to insert side chain: %v", err)
+	}
+	if !chain.HasHeader(side[0].Hash(), side[0].NumberU64()) {
+		t.Fatalf("side chain block not stored")
+	}
+	freezer := db.Freezer()
+	if frozen, err := chain.freezeBlocks(freezer, 10); err != nil || frozen != 11 {
+		t.Fatalf("freezing mismatch: have %d, %v, want %d, nil", frozen, err, 11)
+	}
+	if ancients := freezer.Ancients(); ancients != 11 {
+		t.Fatalf("ancients mismatch: have %d, want %d", ancients, 11)
+	}
+	chain.blockCache.Purge()
+	chain.bodyCache.Purge()
+	chain.hc.headerCache.Purge()
+	chain.hc.tdCache.Purge()
+	chain.hc.numberCache.Purge()
+
+	for i, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+		if has, _ := db.Has(blockBodyKey(hash, number)); has == (number <= 10) {
+			t.Errorf("block %d: body in key-value database: %v", number, has)
+		}
+		if canon := GetCanonicalHash(db, number); canon != hash {
+			t.Errorf("block %d: canonical hash mismatch: have %x, want %x", number, canon, hash)
+		}
+		if got := chain.GetBlockByNumber(number); got == nil || got.Hash() != hash {
+			t.Errorf("block %d: block not retrievable", number)
+		}
+		if !chain.HasBlock(hash, number) || !chain.HasHeader(hash, number) {
+			t.Errorf("block %d: block not found", number)
+		}
+		if got := GetBlockReceipts(db, hash, number); len(got) != len(receipts[i]) {
+			t.Errorf("block %d: receipt count mismatch: have %d, want %d", number, len(got), len(receipts[i]))
+		}
+		if td := chain.GetTd(hash, number); td == nil || td.Cmp(new(big.Int).Add(chain.GetTd(block.ParentHash(), number-1), block.Difficulty())) != 0 {
+			t.Errorf("block %d: total difficulty mismatch", number)
+		}
+	}
+	if chain.HasHeader(side[0].Hash(), side[0].NumberU64()) {
+		t.Errorf("frozen side chain block not deleted")
+	}
+	// Rewind into the frozen blocks
+	if err := chain.SetHead(5); err != nil {
+		t.Fatalf("failed to rewind: %v", err)
+	}
+	if ancients := freezer.Ancients(); ancients != 6 {
+		t.Fatalf("ancients mismatch after rewind: have %d, want %d", ancients, 6)
+	}
+	if canon := GetCanonicalHash(db, 8); canon != (common.Hash{}) {
+		t.Errorf("rewound block still canonical: %x", canon)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != blocks[4].Hash() {
+		t.Errorf("head mismatch after rewind: have #%d, want #%d", head.NumberU64(), 5)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/tomochain/tomochain/common"
 	"github.com/tomochain/tomochain/core/types"
 	"github.com/tomochain/tomochain/ethdb"
@@ -49,6 +50,8 @@ var (
 	headFastKey   = []byte("LastFast")
 	trieSyncKey   = []byte("TrieSync")
 
+	internalTxIndexStartKey = []byte("InternalTxIndexStart")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`).
 	headerPrefix        = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	tdSuffix            = []byte("t") // headerPrefix + num (uint64 big endian) + hash + tdSuffix -> td
@@ -58,12 +61,17 @@ var (
 	blockReceiptsPrefix = []byte("r") // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
 	lookupPrefix        = []byte("l") // lookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix     = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
+	internalTxsPrefix   = []byte("c") // internalTxsPrefix + num (uint64 big endian) -> block hash and internal transactions
+	internalTxPrefix    = []byte("C") // internalTxPrefix + address + num (uint64 big endian) + index (uint32 big endian) -> nothing
+	logIndexPrefix      = []byte("g") // logIndexPrefix + address + topic + num (uint64 big endian) -> nothing
 
 	preimagePrefix = "secure-key-"              // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
-	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	BloomBitsIndexPrefix  = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	InternalTxIndexPrefix = []byte("iC") // InternalTxIndexPrefix is the data table of the internal transaction indexer to track its progress
+	LogIndexPrefix        = []byte("iG") // LogIndexPrefix is the data table of the log indexer to track its progress
 
 	// used by old db, now only used for conversion
 	oldReceiptsPrefix = []byte("receipts-")
@@ -90,9 +98,47 @@ func encodeBlockNumber(number uint64) []byte {
 	return enc
 }
 
+// freezerOf returns the ancient store of a database, nil if it has none.
+func freezerOf(db DatabaseReader) *ethdb.Freezer {
+	if adb, ok := db.(ethdb.AncientDatabase); ok {
+		return adb.Freezer()
+	}
+	return nil
+}
+
+// getAncient retrieves the data of a block from the given freezer table, nil
+// if the block is not frozen.
+func getAncient(db DatabaseReader, kind string, hash common.Hash, number uint64) []byte {
+	freezer := freezerOf(db)
+	if freezer == nil || !freezer.HasAncient(kind, number) {
+		return nil
+	}
+	// Only canonical blocks are frozen, make sure it's the one requested
+	if frozen, _ := freezer.Ancient(ethdb.FreezerHashTable, number); common.BytesToHash(frozen) != hash {
+		return nil
+	}
+	data, _ := freezer.Ancient(kind, number)
+	return data
+}
+
+// hasAncient reports whether the given block is frozen in the ancient store.
+func hasAncient(db DatabaseReader, hash common.Hash, number uint64) bool {
+	freezer := freezerOf(db)
+	if freezer == nil || !freezer.HasAncient(ethdb.FreezerHashTable, number) {
+		return false
+	}
+	frozen, _ := freezer.Ancient(ethdb.FreezerHashTable, number)
+	return common.BytesToHash(frozen) == hash
+}
+
 // GetCanonicalHash retrieves a hash assigned to a canonical block number.
 func GetCanonicalHash(db DatabaseReader, number uint64) common.Hash {
 	data, _ := db.Get(append(append(headerPrefix, encodeBlockNumber(number)...), numSuffix...))
+	if len(data) == 0 {
+		if freezer := freezerOf(db); freezer != nil {
+			data, _ = freezer.Ancient(ethdb.FreezerHashTable, number)
+		}
+	}
 	if len(data) == 0 {
 		return common.Hash{}
 	}
@@ -161,6 +207,9 @@ func GetTrieSyncProgress(db DatabaseReader) uint64 {
 // if the header's not found.
 func GetHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(headerKey(hash, number))
+	if len(data) == 0 {
+		data = getAncient(db, ethdb.FreezerHeaderTable, hash, number)
+	}
 	return data
 }
 
@@ -182,6 +231,9 @@ func GetHeader(db DatabaseReader, hash common.Hash, number uint64) *types.Header
 // GetBodyRLP retrieves the block body (transactions and uncles) in RLP encoding.
 func GetBodyRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(blockBodyKey(hash, number))
+	if len(data) == 0 {
+		data = getAncient(db, ethdb.FreezerBodiesTable, hash, number)
+	}
 	return data
 }
 
@@ -212,6 +264,9 @@ func GetBody(db DatabaseReader, hash common.Hash, number uint64) *types.Body {
 // none found.
 func GetTd(db DatabaseReader, hash common.Hash, number uint64) *big.Int {
 	data, _ := db.Get(append(append(append(headerPrefix, encodeBlockNumber(number)...), hash[:]...), tdSuffix...))
+	if len(data) == 0 {
+		data = getAncient(db, ethdb.FreezerDifficultyTable, hash, number)
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -247,6 +302,9 @@ func GetBlock(db DatabaseReader, hash common.Hash, number uint64) *types.Block {
 // in a block given by its hash.
 func GetBlockReceipts(db DatabaseReader, hash common.Hash, number uint64) types.Receipts {
 	data, _ := db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash[:]...))
+	if len(data) == 0 {
+		data = getAncient(db, ethdb.FreezerReceiptTable, hash, number)
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -552,6 +610,213 @@ func DeleteTxLookupEntry(db DatabaseDeleter, hash common.Hash) {
 	db.Delete(append(lookupPrefix, hash.Bytes()...))
 }
 
+// GetInternalTxIndexStart retrieves the number of the first block the internal
+// transaction indexer traced, the ones before it were never indexed.
+func GetInternalTxIndexStart(db DatabaseReader) uint64 {
+	data, _ := db.Get(internalTxIndexStartKey)
+	if len(data) == 0 {
+		return 0
+	}
+	return new(big.Int).SetBytes(data).Uint64()
+}
+
+// WriteInternalTxIndexStart stores the number of the first block the internal
+// transaction indexer traces.
+func WriteInternalTxIndexStart(db ethdb.Putter, number uint64) error {
+	if err := db.Put(internalTxIndexStartKey, new(big.Int).SetUint64(number).Bytes()); err != nil {
+		log.Crit("Failed to store internal transaction index start", "err", err)
+	}
+	return nil
+}
+
+// storedInternalTxs is the database form of the internal transactions of a
+// block, along with the hash of the block they were gathered from.
+type storedInternalTxs struct {
+	Hash common.Hash
+	Txs  []*types.InternalTx
+}
+
+// GetInternalTxs retrieves the internal transactions gathered from the block of
+// the given number, and the hash of that block, which may not be canonical.
+func GetInternalTxs(db DatabaseReader, number uint64) (common.Hash, []*types.InternalTx) {
+	data, _ := db.Get(append(internalTxsPrefix, encodeBlockNumber(number)...))
+	if len(data) == 0 {
+		return common.Hash{}, nil
+	}
+	var stored storedInternalTxs
+	if err := rlp.DecodeBytes(data, &stored); err != nil {
+		log.Error("Invalid internal transaction array RLP", "number", number, "err", err)
+		return common.Hash{}, nil
+	}
+	return stored.Hash, stored.Txs
+}
+
+// WriteInternalTxs stores the internal transactions gathered from a block, along
+// with a lookup entry for the sender and the recipient of each.
+func WriteInternalTxs(db ethdb.Putter, hash common.Hash, number uint64, txs []*types.InternalTx) error {
+	bytes, err := rlp.EncodeToBytes(&storedInternalTxs{Hash: hash, Txs: txs})
+	if err != nil {
+		return err
+	}
+	if err := db.Put(append(internalTxsPrefix, encodeBlockNumber(number)...), bytes); err != nil {
+		log.Crit("Failed to store internal transactions", "err", err)
+	}
+	for i, tx := range txs {
+		for _, addr := range []common.Address{tx.From, tx.To} {
+			if err := db.Put(internalTxLookupKey(addr, number, uint32(i)), nil); err != nil {
+				log.Crit("Failed to store internal transaction lookup entry", "err", err)
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteInternalTxs removes the internal transactions gathered from the block of
+// the given number, along with their lookup entries.
+func DeleteInternalTxs(db DatabaseReader, batch DatabaseDeleter, number uint64) {
+	_, txs := GetInternalTxs(db, number)
+	for i, tx := range txs {
+		batch.Delete(internalTxLookupKey(tx.From, number, uint32(i)))
+		batch.Delete(internalTxLookupKey(tx.To, number, uint32(i)))
+	}
+	batch.Delete(append(internalTxsPrefix, encodeBlockNumber(number)...))
+}
+
+// internalTxLookupKey = internalTxPrefix + address + num (uint64 big endian) + index (uint32 big endian)
+func internalTxLookupKey(addr common.Address, number uint64, index uint32) []byte {
+	key := make([]byte, len(internalTxPrefix)+common.AddressLength+12)
+	copy(key, internalTxPrefix)
+	copy(key[len(internalTxPrefix):], addr.Bytes())
+	binary.BigEndian.PutUint64(key[len(internalTxPrefix)+common.AddressLength:], number)
+	binary.BigEndian.PutUint32(key[len(internalTxPrefix)+common.AddressLength+8:], index)
+	return key
+}
+
+// InternalTxIterator walks the lookup entries of the internal transactions sent
+// or received by an address within a range of blocks, in chain order.
+type InternalTxIterator struct {
+	it      iterator.Iterator
+	to      uint64
+	pending bool // Whether the initial seek landed on an entry not yet returned
+
+	Number uint64 // Number of the block holding the current internal transaction
+	Index  uint32 // Index of the current internal transaction in the block
+}
+
+// NewInternalTxIterator creates an iterator over the internal transactions sent
+// or received by the address from block from up to block to, both inclusive.
+func NewInternalTxIterator(db ethdb.Iteratee, addr common.Address, from, to uint64) *InternalTxIterator {
+	prefix := append(append([]byte{}, internalTxPrefix...), addr.Bytes()...)
+
+	it := db.NewIteratorWithPrefix(prefix)
+	pending := it.Seek(internalTxLookupKey(addr, from, 0))
+	return &InternalTxIterator{it: it, to: to, pending: pending}
+}
+
+// Next moves the iterator to the next internal transaction, returning whether
+// there is one.
+func (it *InternalTxIterator) Next() bool {
+	if it.pending {
+		it.pending = false
+	} else if !it.it.Next() {
+		return false
+	}
+	key := it.it.Key()
+	if len(key) != len(internalTxPrefix)+common.AddressLength+12 {
+		return false
+	}
+	number := binary.BigEndian.Uint64(key[len(internalTxPrefix)+common.AddressLength:])
+	if number > it.to {
+		return false
+	}
+	it.Number, it.Index = number, binary.BigEndian.Uint32(key[len(internalTxPrefix)+common.AddressLength+8:])
+	return true
+}
+
+// Release releases the resources of the iterator.
+func (it *InternalTxIterator) Release() {
+	it.it.Release()
+}
+
+// WriteLogIndexEntries stores a lookup entry for the address and the first topic
+// of the logs of a block, the empty hash for logs without topics.
+func WriteLogIndexEntries(db ethdb.Putter, number uint64, receipts types.Receipts) error {
+	written := make(map[string]struct{})
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			var topic common.Hash
+			if len(log.Topics) > 0 {
+				topic = log.Topics[0]
+			}
+			key := logIndexKey(log.Address, topic, number)
+			if _, ok := written[string(key)]; ok {
+				continue
+			}
+			if err := db.Put(key, nil); err != nil {
+				return err
+			}
+			written[string(key)] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// logIndexKey = logIndexPrefix + address + topic + num (uint64 big endian)
+func logIndexKey(addr common.Address, topic common.Hash, number uint64) []byte {
+	key := make([]byte, len(logIndexPrefix)+common.AddressLength+common.HashLength+8)
+	copy(key, logIndexPrefix)
+	copy(key[len(logIndexPrefix):], addr.Bytes())
+	copy(key[len(logIndexPrefix)+common.AddressLength:], topic.Bytes())
+	binary.BigEndian.PutUint64(key[len(logIndexPrefix)+common.AddressLength+common.HashLength:], number)
+	return key
+}
+
+// LogIndexIterator walks the numbers of the blocks holding logs of an address
+// with a first topic within a range of blocks, in chain order. The entries of
+// blocks reorged away are not removed, the logs of the blocks returned need to
+// be checked.
+type LogIndexIterator struct {
+	it      iterator.Iterator
+	to      uint64
+	pending bool // Whether the initial seek landed on an entry not yet returned
+
+	Number uint64 // Number of the current block
+}
+
+// NewLogIndexIterator creates an iterator over the blocks holding logs of the
+// address with the first topic from block from up to block to, both inclusive.
+func NewLogIndexIterator(db ethdb.Iteratee, addr common.Address, topic common.Hash, from, to uint64) *LogIndexIterator {
+	prefix := append(append(append([]byte{}, logIndexPrefix...), addr.Bytes()...), topic.Bytes()...)
+
+	it := db.NewIteratorWithPrefix(prefix)
+	pending := it.Seek(logIndexKey(addr, topic, from))
+	return &LogIndexIterator{it: it, to: to, pending: pending}
+}
+
+// Next moves the iterator to the next block, returning whether there is one.
+func (it *LogIndexIterator) Next() bool {
+	if it.pending {
+		it.pending = false
+	} else if !it.it.Next() {
+		return false
+	}
+	key := it.it.Key()
+	if len(key) != len(logIndexPrefix)+common.AddressLength+common.HashLength+8 {
+		return false
+	}
+	number := binary.BigEndian.Uint64(key[len(logIndexPrefix)+common.AddressLength+common.HashLength:])
+	if number > it.to {
+		return false
+	}
+	it.Number = number
+	return true
+}
+
+// Release releases the resources of the iterator.
+func (it *LogIndexIterator) Release() {
+	it.it.Release()
+}
+
 // PreimageTable returns a Database instance with the key prefix for preimage entries.
 func PreimageTable(db ethdb.Database) ethdb.Database {
 	return ethdb.NewTable(db, preimagePrefix)
@@ -38,6 +38,10 @@ type PendingStateEvent struct{}
 // NewMinedBlockEvent is posted when a block has been imported.
 type NewMinedBlockEvent struct{ Block *types.Block }
 
+// NewAttestationEvent is posted when a block has been attested by the local
+// masternode or a valid attestation has been received from the network.
+type NewAttestationEvent struct{ Attestation *types.Attestation }
+
 // RemovedTransactionEvent is posted when a reorg happens
 type RemovedTransactionEvent struct{ Txs types.Transactions }
 
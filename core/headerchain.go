@@ -369,7 +369,7 @@ func (hc *HeaderChain) HasHeader(hash common.Hash, number uint64) bool {
 		return true
 	}
 	ok, _ := hc.chainDb.Has(headerKey(hash, number))
-	return ok
+	return ok || hasAncient(hc.chainDb, hash, number)
 }
 
 // GetHeaderByNumber retrieves a block header from the database by number,
@@ -424,6 +424,12 @@ func (hc *HeaderChain) SetHead(head uint64, delFn DeleteCallback) {
 	for i := height; i > head; i-- {
 		DeleteCanonicalHash(hc.chainDb, i)
 	}
+	// Drop the frozen blocks past the new head
+	if freezer := freezerOf(hc.chainDb); freezer != nil && freezer.Ancients() > head+1 {
+		if err := freezer.TruncateAncients(head + 1); err != nil {
+			log.Crit("Failed to truncate ancient blocks", "err", err)
+		}
+	}
 	// Clear out any stale content from the caches
 	hc.headerCache.Purge()
 	hc.tdCache.Purge()
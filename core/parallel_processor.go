@@ -0,0 +1,184 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/core/state"
+	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/core/vm"
+	"github.com/tomochain/tomochain/log"
+	"github.com/tomochain/tomochain/metrics"
+	"github.com/tomochain/tomochain/tomox/tomox_state"
+)
+
+var (
+	parallelTxMeter       = metrics.NewRegisteredMeter("chain/parallel/txs", nil)
+	parallelConflictMeter = metrics.NewRegisteredMeter("chain/parallel/conflicts", nil)
+)
+
+// speculativeTx is the outcome of executing a transaction on a copy of the
+// state the block starts from.
+type speculativeTx struct {
+	state    *state.StateDB
+	accesses *state.AccessSet
+
+	receipt      *types.Receipt
+	gas          uint64 // Gas used by the transaction
+	poolGas      uint64 // Gas taken out of the block gas pool
+	tokenFeeUsed bool
+	err          error
+}
+
+// parallelizable reports whether the transactions of the block may be executed
+// in parallel. Receipts holding the intermediate roots of the state and traced
+// executions need the transactions to be executed one after the other.
+func (p *StateProcessor) parallelizable(block *types.Block, cfg vm.Config) bool {
+	return p.parallel > 0 && len(block.Transactions()) > 1 && !cfg.Debug && p.config.IsByzantium(block.Number())
+}
+
+// applyTransactionsParallel applies the transactions of the block to the state
+// the way the sequential processing does, but executes them all at once on
+// copies of the state first. The results of the transactions which read none
+// of the state written by the ones before them in the block are then carried
+// over to the state in order, while the others are executed again on it.
+//
+// The receipts and the state are the same as the sequential processing ones.
+func (p *StateProcessor) applyTransactionsParallel(block *types.Block, statedb *state.StateDB, tomoxState *tomox_state.TomoXStateDB, cfg vm.Config, balanceFee, balanceUpdated map[common.Address]*big.Int, totalFeeUsed *big.Int, stop func() bool) (types.Receipts, []*types.Log, uint64, error) {
+	var (
+		header = block.Header()
+		txs    = block.Transactions()
+		specs  = make([]*speculativeTx, len(txs))
+		next   = int32(-1)
+		wg     sync.WaitGroup
+	)
+	workers := p.parallel
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := int(atomic.AddInt32(&next, 1)); j < len(txs); j = int(atomic.AddInt32(&next, 1)) {
+				if stop != nil && stop() {
+					return
+				}
+				specs[j] = p.speculate(block, header, statedb, tomoxState, cfg, balanceFee, j)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Carry the results over to the state in order, executing the conflicting
+	// transactions again
+	var (
+		receipts  = make(types.Receipts, len(txs))
+		allLogs   []*types.Log
+		usedGas   = new(uint64)
+		gp        = new(GasPool).AddGas(block.GasLimit())
+		written   = state.NewAccessSet()
+		feeTokens = make(map[common.Address]bool)
+		conflicts int
+	)
+	for i, tx := range txs {
+		if stop != nil && stop() {
+			return nil, nil, 0, ErrStopPreparingBlock
+		}
+		// check black-list txs after hf
+		if (block.Number().Uint64() >= common.BlackListHFNumber) && !common.IsTestnet {
+			// check if sender is in black list
+			if tx.From() != nil && common.Blacklist[*tx.From()] {
+				return nil, nil, 0, fmt.Errorf("Block contains transaction with sender in black-list: %v", tx.From().Hex())
+			}
+			// check if receiver is in black list
+			if tx.To() != nil && common.Blacklist[*tx.To()] {
+				return nil, nil, 0, fmt.Errorf("Block contains transaction with receiver in black-list: %v", tx.To().Hex())
+			}
+		}
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+
+		spec := specs[i]
+		if spec == nil || spec.err != nil || spec.accesses.Conflicts(written) || (tx.To() != nil && feeTokens[*tx.To()]) || (spec.poolGas > 0 && gp.Gas() < tx.Gas()) {
+			// The transaction saw a stale state, execute it again
+			accesses := state.NewAccessSet()
+			statedb.TrackAccesses(accesses)
+			receipt, gas, err, tokenFeeUsed := ApplyTransaction(p.config, balanceFee, p.bc, nil, gp, statedb, tomoxState, header, tx, usedGas, cfg)
+			statedb.TrackAccesses(nil)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			spec = &speculativeTx{accesses: accesses, receipt: receipt, gas: gas, tokenFeeUsed: tokenFeeUsed}
+			conflicts++
+		} else {
+			gp.SubGas(spec.poolGas)
+			*usedGas += spec.gas
+
+			statedb.ApplyWrites(spec.state, spec.accesses)
+			for _, l := range spec.receipt.Logs {
+				statedb.AddLog(l)
+			}
+			statedb.Finalise(true)
+
+			spec.receipt.CumulativeGasUsed = *usedGas
+			spec.receipt.Logs = statedb.GetLogs(tx.Hash())
+		}
+		written.Include(spec.accesses)
+
+		receipts[i] = spec.receipt
+		allLogs = append(allLogs, spec.receipt.Logs...)
+		if spec.tokenFeeUsed {
+			fee := new(big.Int).SetUint64(spec.gas)
+			if header.Number.Cmp(common.TIPTRC21Fee) > 0 {
+				fee = fee.Mul(fee, common.TRC21GasPrice)
+			}
+			balanceFee[*tx.To()] = new(big.Int).Sub(balanceFee[*tx.To()], fee)
+			balanceUpdated[*tx.To()] = balanceFee[*tx.To()]
+			totalFeeUsed.Add(totalFeeUsed, fee)
+
+			// The transactions paying their fees with the token saw its former balance
+			feeTokens[*tx.To()] = true
+		}
+	}
+	parallelTxMeter.Mark(int64(len(txs)))
+	parallelConflictMeter.Mark(int64(conflicts))
+	log.Debug("Executed transactions in parallel", "number", block.Number(), "txs", len(txs), "conflicts", conflicts)
+
+	return receipts, allLogs, *usedGas, nil
+}
+
+// speculate executes the transaction of the block at the given index on a copy
+// of the state, recording the accesses to it.
+func (p *StateProcessor) speculate(block *types.Block, header *types.Header, statedb *state.StateDB, tomoxState *tomox_state.TomoXStateDB, cfg vm.Config, balanceFee map[common.Address]*big.Int, index int) *speculativeTx {
+	var (
+		tx      = block.Transactions()[index]
+		spec    = &speculativeTx{state: statedb.Copy(), accesses: state.NewAccessSet()}
+		gp      = new(GasPool).AddGas(block.GasLimit())
+		usedGas = new(uint64)
+	)
+	spec.state.TrackAccesses(spec.accesses)
+	spec.state.Prepare(tx.Hash(), block.Hash(), index)
+	spec.receipt, spec.gas, spec.err, spec.tokenFeeUsed = ApplyTransaction(p.config, balanceFee, p.bc, nil, gp, spec.state, tomoxState, header, tx, usedGas, cfg)
+	spec.state.TrackAccesses(nil)
+
+	spec.poolGas = block.GasLimit() - gp.Gas()
+	return spec
+}
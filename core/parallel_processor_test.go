@@ -0,0 +1,155 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/consensus/ethash"
+	"github.com/tomochain/tomochain/core/state"
+	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/core/vm"
+	"github.com/tomochain/tomochain/crypto"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/params"
+	"github.com/tomochain/tomochain/rlp"
+)
+
+var (
+	// counterCode increments the first storage slot and emits a log.
+	counterCode = common.FromHex("60016000540160005560006000a000")
+	// callerCode increments the storage slot of the caller.
+	callerCode = common.FromHex("3354600101335500")
+	// suicideCode self destructs, sending the balance to the caller.
+	suicideCode = common.FromHex("33ff")
+	// initCode deploys a contract without code, holding a storage slot.
+	initCode = common.FromHex("600160005500")
+)
+
+// Tests that executing the transactions of blocks in parallel yields the same
+// receipts, logs and state as executing them in order, with transactions
+// depending on each other in all the ways they can.
+func TestParallelProcessing(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		keys    = make([]*ecdsa.PrivateKey, 8)
+		addrs   = make([]common.Address, len(keys))
+		counter = common.Address{0x01, 0x01}
+		caller  = common.Address{0x01, 0x02}
+		suicide = common.Address{0x01, 0x03}
+		miner   = common.Address{0x02}
+		alloc   = GenesisAlloc{
+			miner:   {Balance: big.NewInt(1)}, // fees paid to an existing account don't conflict
+			counter: {Code: counterCode, Balance: big.NewInt(0)},
+			caller:  {Code: callerCode, Balance: big.NewInt(0)},
+			suicide: {Code: suicideCode, Balance: big.NewInt(1000)},
+		}
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		alloc[addrs[i]] = GenesisAccount{Balance: big.NewInt(1000000000000)}
+	}
+	gspec := &Genesis{Config: params.TestChainConfig, Alloc: alloc}
+	genesis := gspec.MustCommit(db)
+
+	signer := types.HomesteadSigner{}
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, block *BlockGen) {
+		block.SetCoinbase(miner)
+		send := func(key int, to *common.Address, value int64, gas uint64, data []byte) {
+			var tx *types.Transaction
+			if to == nil {
+				tx = types.NewContractCreation(block.TxNonce(addrs[key]), big.NewInt(value), gas, big.NewInt(1), data)
+			} else {
+				tx = types.NewTransaction(block.TxNonce(addrs[key]), *to, big.NewInt(value), gas, big.NewInt(1), data)
+			}
+			tx, _ = types.SignTx(tx, signer, keys[key])
+			block.AddTx(tx)
+		}
+		fresh := common.BigToAddress(big.NewInt(int64(1000 + i)))
+
+		send(0, &fresh, 1000, 21000, nil)    // independent transfers
+		send(1, &addrs[6], 1000, 21000, nil) // balance read by a later transfer of the receiver
+		send(2, &counter, 0, 100000, nil)    // conflicting storage writes and logs
+		send(3, &caller, 0, 100000, nil)     // independent storage writes
+		send(4, &addrs[5], 0, 21000, nil)
+		send(4, &addrs[5], 0, 21000, nil) // consecutive nonces
+		send(5, &counter, 0, 100000, nil)
+		send(6, &fresh, 1000, 21000, nil)
+		send(7, nil, 0, 100000, initCode) // contract creation
+		send(0, &caller, 0, 100000, nil)
+		if i == 1 {
+			send(7, &suicide, 0, 100000, nil)
+			send(3, &suicide, 100, 100000, nil) // call to the destructed contract
+		}
+	})
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+
+	parent := genesis
+	for _, block := range blocks {
+		var (
+			sequential = NewStateProcessor(gspec.Config, blockchain, blockchain.Engine())
+			parallel   = NewStateProcessor(gspec.Config, blockchain, blockchain.Engine())
+		)
+		parallel.parallel = 4
+
+		seqState, _ := state.New(parent.Root(), state.NewDatabase(db))
+		seqReceipts, seqLogs, seqGas, err := sequential.Process(block, seqState, vm.Config{}, state.GetTRC21FeeCapacityFromState(seqState))
+		if err != nil {
+			t.Fatalf("block %d: sequential processing failed: %v", block.NumberU64(), err)
+		}
+		parState, _ := state.New(parent.Root(), state.NewDatabase(db))
+		parReceipts, parLogs, parGas, err := parallel.Process(block, parState, vm.Config{}, state.GetTRC21FeeCapacityFromState(parState))
+		if err != nil {
+			t.Fatalf("block %d: parallel processing failed: %v", block.NumberU64(), err)
+		}
+		if seqGas != parGas {
+			t.Errorf("block %d: used gas mismatch: sequential %d, parallel %d", block.NumberU64(), seqGas, parGas)
+		}
+		if len(seqReceipts) != len(parReceipts) {
+			t.Fatalf("block %d: receipt count mismatch: sequential %d, parallel %d", block.NumberU64(), len(seqReceipts), len(parReceipts))
+		}
+		for i := range seqReceipts {
+			seqBlob, _ := rlp.EncodeToBytes((*types.ReceiptForStorage)(seqReceipts[i]))
+			parBlob, _ := rlp.EncodeToBytes((*types.ReceiptForStorage)(parReceipts[i]))
+			if !bytes.Equal(seqBlob, parBlob) {
+				t.Errorf("block %d: receipt %d mismatch:\nsequential %+v\nparallel   %+v", block.NumberU64(), i, seqReceipts[i], parReceipts[i])
+			}
+		}
+		seqLogBlob, _ := rlp.EncodeToBytes(seqLogs)
+		parLogBlob, _ := rlp.EncodeToBytes(parLogs)
+		if len(seqLogs) == 0 || !bytes.Equal(seqLogBlob, parLogBlob) {
+			t.Errorf("block %d: logs mismatch: sequential %v, parallel %v", block.NumberU64(), seqLogs, parLogs)
+		}
+		for i := range seqLogs {
+			if seqLogs[i].Index != parLogs[i].Index || seqLogs[i].TxIndex != parLogs[i].TxIndex {
+				t.Errorf("block %d: log %d position mismatch: sequential %d/%d, parallel %d/%d", block.NumberU64(), i, seqLogs[i].TxIndex, seqLogs[i].Index, parLogs[i].TxIndex, parLogs[i].Index)
+			}
+		}
+		if root := parState.IntermediateRoot(true); root != seqState.IntermediateRoot(true) || root != block.Root() {
+			t.Errorf("block %d: state root mismatch: sequential %x, parallel %x, block %x", block.NumberU64(), seqState.IntermediateRoot(true), root, block.Root())
+		}
+		if hash := types.DeriveSha(parReceipts); hash != block.ReceiptHash() {
+			t.Errorf("block %d: receipt hash mismatch: have %x, want %x", block.NumberU64(), hash, block.ReceiptHash())
+		}
+		parent = block
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+
+	"github.com/tomochain/tomochain/common"
+)
+
+// Parts of the state tracked by an AccessSet.
+const (
+	accountAccess byte = iota // nonce, code and existence of an account
+	balanceAccess             // balance of an account
+	slotAccess                // storage slot of an account
+)
+
+type accessKey struct {
+	kind byte
+	addr common.Address
+	slot common.Hash
+}
+
+// AccessSet records the parts of the state read and written while executing
+// transactions, to find out whether a transaction executed on a copy of the
+// state read anything another one wrote in the meantime.
+//
+// Balances changed without being read, like the payment of the fees to the
+// block signer, are written as differences which add up whatever the order of
+// the transactions, so that they don't conflict with each other.
+type AccessSet struct {
+	reads  map[accessKey]struct{}
+	writes map[accessKey]struct{}
+
+	origins map[common.Address]*big.Int // Balances before the first access
+	created map[*stateObject]struct{}   // Accounts created explicitly
+}
+
+// NewAccessSet creates an empty access set.
+func NewAccessSet() *AccessSet {
+	return &AccessSet{
+		reads:   make(map[accessKey]struct{}),
+		writes:  make(map[accessKey]struct{}),
+		origins: make(map[common.Address]*big.Int),
+		created: make(map[*stateObject]struct{}),
+	}
+}
+
+// Conflicts reports whether anything read in the set was written in the given
+// one.
+func (s *AccessSet) Conflicts(written *AccessSet) bool {
+	for key := range s.reads {
+		if _, ok := written.writes[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Include adds the writes of the given set to the set.
+func (s *AccessSet) Include(other *AccessSet) {
+	for key := range other.writes {
+		s.writes[key] = struct{}{}
+	}
+}
+
+func (s *AccessSet) wrote(kind byte, addr common.Address) bool {
+	_, ok := s.writes[accessKey{kind: kind, addr: addr}]
+	return ok
+}
+
+// TrackAccesses starts recording the accesses to the state in the given set,
+// or stops recording them if it is nil.
+func (self *StateDB) TrackAccesses(set *AccessSet) {
+	self.access = set
+}
+
+func (self *StateDB) trackRead(kind byte, addr common.Address, slot common.Hash) {
+	if self.access != nil {
+		self.access.reads[accessKey{kind, addr, slot}] = struct{}{}
+	}
+}
+
+func (self *StateDB) trackWrite(kind byte, addr common.Address, slot common.Hash) {
+	if self.access != nil {
+		self.access.writes[accessKey{kind, addr, slot}] = struct{}{}
+	}
+}
+
+// trackBalance records a change to the balance of an account, which also
+// changes whether the account exists if it is missing or empty. As the change
+// is applied as a difference, the balance itself isn't read.
+func (self *StateDB) trackBalance(addr common.Address) {
+	if self.access == nil {
+		return
+	}
+	stateObject := self.getStateObject(addr)
+	if _, ok := self.access.origins[addr]; !ok {
+		origin := new(big.Int)
+		if stateObject != nil {
+			origin.Set(stateObject.Balance())
+		}
+		self.access.origins[addr] = origin
+	}
+	self.trackWrite(balanceAccess, addr, common.Hash{})
+	if stateObject == nil || stateObject.empty() {
+		self.trackRead(accountAccess, addr, common.Hash{})
+		self.trackWrite(accountAccess, addr, common.Hash{})
+	}
+}
+
+// ApplyWrites carries the writes recorded in the access set over from src, a
+// copy of the state a transaction was executed on, to the state. Balances are
+// moved by the difference the transaction made to them.
+//
+// The state must hold the same values as the copy for everything the set read.
+func (self *StateDB) ApplyWrites(src *StateDB, set *AccessSet) {
+	slots := make(map[common.Address][]common.Hash)
+	for key := range set.writes {
+		if key.kind == slotAccess {
+			slots[key.addr] = append(slots[key.addr], key.slot)
+		} else if _, ok := slots[key.addr]; !ok {
+			slots[key.addr] = nil
+		}
+	}
+	for addr, keys := range slots {
+		// Accounts created in reverted calls are gone from the copy
+		stateObject := src.stateObjects[addr]
+		if stateObject == nil {
+			continue
+		}
+		if _, ok := set.created[stateObject]; ok {
+			self.CreateAccount(addr)
+		}
+		if set.wrote(accountAccess, addr) {
+			self.SetNonce(addr, stateObject.Nonce())
+			if self.GetCodeHash(addr) != common.BytesToHash(stateObject.CodeHash()) {
+				self.SetCode(addr, stateObject.Code(src.db))
+			}
+		}
+		for _, key := range keys {
+			self.SetState(addr, key, stateObject.GetState(src.db, key))
+		}
+		if stateObject.suicided {
+			self.Suicide(addr)
+			continue
+		}
+		if set.wrote(balanceAccess, addr) {
+			diff := new(big.Int).Sub(stateObject.Balance(), set.origins[addr])
+			if diff.Sign() < 0 {
+				self.SubBalance(addr, diff.Neg(diff))
+			} else {
+				self.AddBalance(addr, diff)
+			}
+		}
+	}
+	for hash, preimage := range src.preimages {
+		if _, ok := self.preimages[hash]; !ok {
+			self.AddPreimage(hash, preimage)
+		}
+	}
+}
@@ -34,7 +34,9 @@ type (
 		account *common.Address
 	}
 	resetObjectChange struct {
-		prev *stateObject
+		prev         *stateObject
+		prevDestruct bool                   // whether the account was already destructed in the snapshot
+		prevStorage  map[common.Hash][]byte // the storage changes of the account for the snapshot
 	}
 	suicideChange struct {
 		account     *common.Address
@@ -84,6 +86,14 @@ func (ch createObjectChange) undo(s *StateDB) {
 
 func (ch resetObjectChange) undo(s *StateDB) {
 	s.setStateObject(ch.prev)
+	if s.snap != nil {
+		if !ch.prevDestruct {
+			delete(s.snapDestructs, ch.prev.addrHash)
+		}
+		if ch.prevStorage != nil {
+			s.snapStorage[ch.prev.addrHash] = ch.prevStorage
+		}
+	}
 }
 
 func (ch suicideChange) undo(s *StateDB) {
@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"sync"
+
+	"github.com/tomochain/tomochain/common"
+)
+
+// diffLayer holds the accounts and storage slots changed by a block on top of
+// the state of its parent. Destructed accounts had their storage wiped before
+// the accounts and slots of the layer were written.
+type diffLayer struct {
+	parent snapshot
+	root   common.Hash
+
+	destructs map[common.Hash]struct{}               // Accounts deleted or reset
+	accounts  map[common.Hash][]byte                 // RLP encoded accounts written
+	storage   map[common.Hash]map[common.Hash][]byte // RLP encoded slots written, nil if deleted
+
+	stale bool // Whether the layer was flattened or dropped
+
+	lock sync.RWMutex
+}
+
+// newDiffLayer creates the layer of a state on top of its parent.
+func newDiffLayer(parent snapshot, root common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) *diffLayer {
+	if destructs == nil {
+		destructs = make(map[common.Hash]struct{})
+	}
+	if accounts == nil {
+		accounts = make(map[common.Hash][]byte)
+	}
+	if storage == nil {
+		storage = make(map[common.Hash]map[common.Hash][]byte)
+	}
+	return &diffLayer{
+		parent:    parent,
+		root:      root,
+		destructs: destructs,
+		accounts:  accounts,
+		storage:   storage,
+	}
+}
+
+// Root returns the state root of the layer.
+func (dl *diffLayer) Root() common.Hash {
+	return dl.root
+}
+
+// Stale reports whether the layer was flattened or dropped.
+func (dl *diffLayer) Stale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.stale
+}
+
+// markStale flags the layer as flattened or dropped.
+func (dl *diffLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+}
+
+// parentLayer returns the layer the diff is built on.
+func (dl *diffLayer) parentLayer() snapshot {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.parent
+}
+
+// setParent rewires the layer onto the disk layer its parent was flattened in.
+func (dl *diffLayer) setParent(parent snapshot) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.parent = parent
+}
+
+// builtOn reports whether the layer descends from the given disk layer through
+// live layers only.
+func (dl *diffLayer) builtOn(disk *diskLayer) bool {
+	for snap := snapshot(dl); ; {
+		if snap.Stale() {
+			return false
+		}
+		diff, ok := snap.(*diffLayer)
+		if !ok {
+			return snap == snapshot(disk)
+		}
+		snap = diff.parentLayer()
+	}
+}
+
+// Account returns the RLP encoded account, nil if it doesn't exist.
+func (dl *diffLayer) Account(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	if dl.stale {
+		dl.lock.RUnlock()
+		return nil, ErrSnapshotStale
+	}
+	if blob, ok := dl.accounts[hash]; ok {
+		dl.lock.RUnlock()
+		return blob, nil
+	}
+	if _, ok := dl.destructs[hash]; ok {
+		dl.lock.RUnlock()
+		return nil, nil
+	}
+	parent := dl.parent
+	dl.lock.RUnlock()
+
+	return parent.Account(hash)
+}
+
+// Storage returns the RLP encoded storage slot, nil if it doesn't exist.
+func (dl *diffLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	if dl.stale {
+		dl.lock.RUnlock()
+		return nil, ErrSnapshotStale
+	}
+	if slots, ok := dl.storage[accountHash]; ok {
+		if blob, ok := slots[storageHash]; ok {
+			dl.lock.RUnlock()
+			return blob, nil
+		}
+	}
+	if _, ok := dl.destructs[accountHash]; ok {
+		dl.lock.RUnlock()
+		return nil, nil
+	}
+	parent := dl.parent
+	dl.lock.RUnlock()
+
+	return parent.Storage(accountHash, storageHash)
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/rlp"
+)
+
+// diskLayer is the snapshot persisted in the chain database.
+type diskLayer struct {
+	diskdb ethdb.Database
+	root   common.Hash
+
+	// genMarker is the hash of the last account generated, nil once the
+	// whole state is generated. Only the accounts up to the marker and their
+	// storage are covered by the layer.
+	genMarker []byte
+
+	stale bool // Whether the layer was flattened into or dropped
+
+	lock sync.RWMutex
+}
+
+// Root returns the state root of the layer.
+func (dl *diskLayer) Root() common.Hash {
+	return dl.root
+}
+
+// Stale reports whether the layer was replaced.
+func (dl *diskLayer) Stale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.stale
+}
+
+// markStale flags the layer as replaced.
+func (dl *diskLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+}
+
+// covered reports whether the data of the account was generated.
+func (dl *diskLayer) covered(hash common.Hash) bool {
+	return dl.genMarker == nil || bytes.Compare(hash[:], dl.genMarker) <= 0
+}
+
+// Account returns the RLP encoded account, nil if it doesn't exist.
+func (dl *diskLayer) Account(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(hash) {
+		return nil, ErrNotCoveredYet
+	}
+	blob, _ := dl.diskdb.Get(accountKey(hash))
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	return blob, nil
+}
+
+// Storage returns the RLP encoded storage slot, nil if it doesn't exist.
+func (dl *diskLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(accountHash) {
+		return nil, ErrNotCoveredYet
+	}
+	blob, _ := dl.diskdb.Get(storageKey(accountHash, storageHash))
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	return blob, nil
+}
+
+// flatten writes a diff layer built on the disk layer into the database,
+// returning the new disk layer and marking this one stale. While generating,
+// only the accounts already generated are written, the others being built
+// from the more recent state later.
+func (dl *diskLayer) flatten(diff *diffLayer) (*diskLayer, error) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	// Invalidate the persisted layer while it's updated
+	if err := dl.diskdb.Delete(rootKey); err != nil {
+		return nil, err
+	}
+	batch := dl.diskdb.NewBatch()
+	flush := func() error {
+		if batch.ValueSize() < ethdb.IdealBatchSize {
+			return nil
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+	for hash := range diff.destructs {
+		if !dl.covered(hash) {
+			continue
+		}
+		if err := dl.diskdb.Delete(accountKey(hash)); err != nil {
+			return nil, err
+		}
+		if err := wipeStorage(dl.diskdb, hash); err != nil {
+			return nil, err
+		}
+	}
+	for hash, blob := range diff.accounts {
+		if !dl.covered(hash) {
+			continue
+		}
+		if err := batch.Put(accountKey(hash), blob); err != nil {
+			return nil, err
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+	for accountHash, slots := range diff.storage {
+		if !dl.covered(accountHash) {
+			continue
+		}
+		for storageHash, blob := range slots {
+			key := storageKey(accountHash, storageHash)
+			if len(blob) == 0 {
+				if err := dl.diskdb.Delete(key); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err := batch.Put(key, blob); err != nil {
+				return nil, err
+			}
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if dl.genMarker != nil {
+		enc, _ := rlp.EncodeToBytes(dl.genMarker)
+		if err := batch.Put(generatorKey, enc); err != nil {
+			return nil, err
+		}
+	}
+	if err := batch.Put(rootKey, diff.root[:]); err != nil {
+		return nil, err
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	dl.stale = true
+	diff.markStale()
+
+	return &diskLayer{
+		diskdb:    dl.diskdb,
+		root:      diff.root,
+		genMarker: dl.genMarker,
+	}, nil
+}
+
+// wipeStorage deletes the storage slots of an account from the database.
+func wipeStorage(db ethdb.Database, accountHash common.Hash) error {
+	it := db.NewIteratorWithPrefix(append(append([]byte{}, storagePrefix...), accountHash[:]...))
+	defer it.Release()
+
+	for it.Next() {
+		if len(it.Key()) != len(storagePrefix)+2*common.HashLength {
+			continue
+		}
+		if err := db.Delete(common.CopyBytes(it.Key())); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
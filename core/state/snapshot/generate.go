@@ -0,0 +1,210 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"time"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/log"
+	"github.com/tomochain/tomochain/rlp"
+	"github.com/tomochain/tomochain/trie"
+)
+
+const (
+	// generateChunkSize is the number of accounts generated while holding the
+	// disk layer, bounding how long capping the tree may be delayed.
+	generateChunkSize = 512
+
+	// generateRetryInterval is the time to wait before retrying a chunk which
+	// failed, usually as the state was dereferenced meanwhile.
+	generateRetryInterval = time.Second
+)
+
+// emptyRoot is the root of an empty storage trie.
+var emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+// account mirrors the state account encoding to reach the storage trie.
+type account struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// generate builds the disk layer from the state trie, chunk by chunk on the
+// current disk layer as it moves up with the chain. The data of an unfinished
+// generation is wiped first.
+func (t *Tree) generate(stop chan chan struct{}) {
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		done   chan struct{}
+	)
+	if disk := t.diskLayer(); len(disk.genMarker) == 0 {
+		if err := wipeSnapshot(t.diskdb); err != nil {
+			log.Error("Failed to wipe state snapshot", "err", err)
+		}
+	}
+	for done == nil {
+		select {
+		case done = <-stop:
+			continue
+		default:
+		}
+		disk := t.diskLayer()
+		finished, err := disk.generateChunk(t.triedb)
+		if err != nil {
+			log.Debug("State snapshot generation stalled", "root", disk.root, "err", err)
+			select {
+			case done = <-stop:
+			case <-time.After(generateRetryInterval):
+			}
+			continue
+		}
+		if finished {
+			log.Info("Generated state snapshot", "root", disk.root, "elapsed", common.PrettyDuration(time.Since(start)))
+			done = <-stop
+			break
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Generating state snapshot", "root", disk.root, "at", common.BytesToHash(disk.marker()), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	close(done)
+}
+
+// marker returns the generation progress of the layer.
+func (dl *diskLayer) marker() []byte {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.genMarker
+}
+
+// generateChunk writes the next accounts and their storage to the database,
+// reporting whether the whole state is generated.
+func (dl *diskLayer) generateChunk(triedb *trie.Database) (bool, error) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	// A stale layer was flattened into a newer one, keep going on that one
+	if dl.stale {
+		return false, nil
+	}
+	if dl.genMarker == nil {
+		return true, nil
+	}
+	accTrie, err := trie.New(dl.root, triedb)
+	if err != nil {
+		return false, err
+	}
+	var (
+		batch  = dl.diskdb.NewBatch()
+		marker = dl.genMarker
+		count  int
+	)
+	it := trie.NewIterator(accTrie.NodeIterator(marker))
+	for count < generateChunkSize && it.Next() {
+		if len(marker) > 0 && bytes.Compare(it.Key, marker) <= 0 {
+			continue
+		}
+		hash := common.BytesToHash(it.Key)
+		if err := batch.Put(accountKey(hash), common.CopyBytes(it.Value)); err != nil {
+			return false, err
+		}
+		var acc account
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			return false, err
+		}
+		if acc.Root != emptyRoot && acc.Root != (common.Hash{}) {
+			storeTrie, err := trie.New(acc.Root, triedb)
+			if err != nil {
+				return false, err
+			}
+			storeIt := trie.NewIterator(storeTrie.NodeIterator(nil))
+			for storeIt.Next() {
+				if err := batch.Put(storageKey(hash, common.BytesToHash(storeIt.Key)), common.CopyBytes(storeIt.Value)); err != nil {
+					return false, err
+				}
+				if batch.ValueSize() >= ethdb.IdealBatchSize {
+					if err := batch.Write(); err != nil {
+						return false, err
+					}
+					batch.Reset()
+				}
+			}
+			if storeIt.Err != nil {
+				return false, storeIt.Err
+			}
+		}
+		marker = hash[:]
+		count++
+	}
+	if it.Err != nil {
+		return false, it.Err
+	}
+	finished := count < generateChunkSize
+	if finished {
+		if err := batch.Write(); err != nil {
+			return false, err
+		}
+		if err := dl.diskdb.Delete(generatorKey); err != nil {
+			return false, err
+		}
+		dl.genMarker = nil
+		return true, nil
+	}
+	enc, _ := rlp.EncodeToBytes(marker)
+	if err := batch.Put(generatorKey, enc); err != nil {
+		return false, err
+	}
+	if err := batch.Write(); err != nil {
+		return false, err
+	}
+	dl.genMarker = marker
+	return false, nil
+}
+
+// wipeSnapshot deletes every account and storage slot of the snapshot.
+func wipeSnapshot(db ethdb.Database) error {
+	for _, prefix := range [][]byte{accountPrefix, storagePrefix} {
+		keyLen := len(prefix) + common.HashLength
+		if bytes.Equal(prefix, storagePrefix) {
+			keyLen += common.HashLength
+		}
+		it := db.NewIteratorWithPrefix(prefix)
+		for it.Next() {
+			if len(it.Key()) != keyLen {
+				continue
+			}
+			if err := db.Delete(common.CopyBytes(it.Key())); err != nil {
+				it.Release()
+				return err
+			}
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package snapshot implements a flat key-value view of the accounts and the
+// storage of the recent states, letting reads bypass the state tries.
+//
+// The snapshot of the oldest retained state lives in the chain database, the
+// disk layer, while each more recent block adds an in-memory diff layer on
+// top of its parent. Capping the tree flattens the bottom diff layers into the
+// disk layer and drops the layers of the abandoned forks.
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/log"
+	"github.com/tomochain/tomochain/rlp"
+	"github.com/tomochain/tomochain/trie"
+)
+
+var (
+	// accountPrefix + account hash -> account RLP
+	accountPrefix = []byte("a")
+
+	// storagePrefix + account hash + storage hash -> storage slot RLP
+	storagePrefix = []byte("o")
+
+	// rootKey tracks the state root of the disk layer.
+	rootKey = []byte("SnapshotRoot")
+
+	// generatorKey tracks the last account generated while the disk layer is
+	// built from the state trie.
+	generatorKey = []byte("SnapshotGenerator")
+)
+
+var (
+	// ErrSnapshotStale is returned from data accessors if the layer was
+	// flattened or dropped, and the caller should fall back to the trie.
+	ErrSnapshotStale = errors.New("snapshot stale")
+
+	// ErrNotCoveredYet is returned from data accessors if the requested item
+	// is not generated yet, and the caller should fall back to the trie.
+	ErrNotCoveredYet = errors.New("not covered yet")
+)
+
+// Snapshot is the flat view of a state.
+type Snapshot interface {
+	// Root returns the state root the snapshot represents.
+	Root() common.Hash
+
+	// Account returns the RLP encoded account, nil if it doesn't exist.
+	Account(hash common.Hash) ([]byte, error)
+
+	// Storage returns the RLP encoded storage slot of an account, nil if it
+	// doesn't exist.
+	Storage(accountHash, storageHash common.Hash) ([]byte, error)
+}
+
+// snapshot is a layer of the snapshot tree.
+type snapshot interface {
+	Snapshot
+
+	// Stale reports whether the layer was flattened or dropped.
+	Stale() bool
+}
+
+// Tree is the collection of snapshot layers of the recent states, built on
+// the single disk layer.
+type Tree struct {
+	diskdb ethdb.Database
+	triedb *trie.Database
+
+	disk   *diskLayer               // Current bottom layer
+	layers map[common.Hash]snapshot // Layers by state root, the disk one included
+
+	genStop chan chan struct{} // Channel stopping the generator, nil if not running
+
+	lock sync.RWMutex
+}
+
+// New opens the snapshot of the state with the given root. If the persisted
+// disk layer doesn't match, it's wiped and regenerated in the background,
+// with the reads falling back to the trie meanwhile.
+func New(diskdb ethdb.Database, triedb *trie.Database, root common.Hash) (*Tree, error) {
+	t := &Tree{
+		diskdb: diskdb,
+		triedb: triedb,
+	}
+	persisted, _ := diskdb.Get(rootKey)
+	if common.BytesToHash(persisted) != root || len(persisted) == 0 {
+		log.Info("Rebuilding state snapshot", "root", root)
+		t.Rebuild(root)
+		return t, nil
+	}
+	disk := &diskLayer{diskdb: diskdb, root: root}
+	if enc, err := diskdb.Get(generatorKey); err == nil {
+		if err := rlp.DecodeBytes(enc, &disk.genMarker); err != nil {
+			log.Warn("Discarding corrupt snapshot generator progress", "err", err)
+			t.Rebuild(root)
+			return t, nil
+		}
+		if disk.genMarker == nil {
+			disk.genMarker = []byte{}
+		}
+	}
+	t.disk, t.layers = disk, map[common.Hash]snapshot{root: disk}
+	if disk.genMarker != nil {
+		log.Info("Resuming state snapshot generation", "root", root, "at", common.BytesToHash(disk.genMarker))
+		t.startGenerator()
+	}
+	return t, nil
+}
+
+// Snapshot returns the snapshot of the given state, nil if it's not retained.
+func (t *Tree) Snapshot(root common.Hash) Snapshot {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if snap, ok := t.layers[root]; ok {
+		return snap
+	}
+	return nil
+}
+
+// Update adds the diff layer of a new state on top of its parent. The maps are
+// owned by the layer from then on.
+func (t *Tree) Update(root, parent common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) error {
+	if root == parent {
+		return fmt.Errorf("snapshot [%#x] cycle", root)
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.layers[root]; ok {
+		return nil
+	}
+	base, ok := t.layers[parent]
+	if !ok {
+		return fmt.Errorf("parent snapshot [%#x] missing", parent)
+	}
+	t.layers[root] = newDiffLayer(base, root, destructs, accounts, storage)
+	return nil
+}
+
+// Cap flattens the diff layers below the given number of layers under the
+// given state into the disk layer, and drops the layers which are no more
+// built on the disk layer. Capping to zero layers flattens the whole state.
+func (t *Tree) Cap(root common.Hash, layers int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	snap, ok := t.layers[root]
+	if !ok {
+		return fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	var chain []*diffLayer
+	for snap != snapshot(t.disk) {
+		diff, ok := snap.(*diffLayer)
+		if !ok {
+			return fmt.Errorf("snapshot [%#x] not built on the disk layer", root)
+		}
+		chain = append(chain, diff)
+		snap = diff.parentLayer()
+	}
+	if len(chain) <= layers {
+		return nil
+	}
+	// Flatten the layers from the bottom up and rewire the lowest kept one
+	for i := len(chain) - 1; i >= layers; i-- {
+		disk, err := t.disk.flatten(chain[i])
+		if err != nil {
+			return err
+		}
+		t.disk = disk
+	}
+	if layers > 0 {
+		chain[layers-1].setParent(t.disk)
+	}
+	// Drop every layer not built on the new disk layer
+	kept := map[common.Hash]snapshot{t.disk.root: t.disk}
+	for hash, snap := range t.layers {
+		diff, ok := snap.(*diffLayer)
+		if !ok {
+			continue
+		}
+		if diff.builtOn(t.disk) {
+			kept[hash] = diff
+		} else {
+			diff.markStale()
+		}
+	}
+	t.layers = kept
+	return nil
+}
+
+// Rebuild discards every layer and regenerates the disk layer of the given
+// state in the background.
+func (t *Tree) Rebuild(root common.Hash) {
+	t.stopGenerator()
+
+	t.lock.Lock()
+	for _, snap := range t.layers {
+		switch layer := snap.(type) {
+		case *diskLayer:
+			layer.markStale()
+		case *diffLayer:
+			layer.markStale()
+		}
+	}
+	// Invalidate the persisted disk layer until it's wiped and regenerated
+	t.diskdb.Delete(rootKey)
+	enc, _ := rlp.EncodeToBytes([]byte{})
+	t.diskdb.Put(generatorKey, enc)
+	t.diskdb.Put(rootKey, root[:])
+
+	t.disk = &diskLayer{diskdb: t.diskdb, root: root, genMarker: []byte{}}
+	t.layers = map[common.Hash]snapshot{root: t.disk}
+	t.lock.Unlock()
+
+	t.startGenerator()
+}
+
+// Release stops the background generation of the disk layer.
+func (t *Tree) Release() {
+	t.stopGenerator()
+}
+
+// startGenerator runs the generation of the disk layer in the background.
+func (t *Tree) startGenerator() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.genStop = make(chan chan struct{})
+	go t.generate(t.genStop)
+}
+
+// stopGenerator aborts the generation of the disk layer if running.
+func (t *Tree) stopGenerator() {
+	t.lock.Lock()
+	stop := t.genStop
+	t.genStop = nil
+	t.lock.Unlock()
+
+	if stop != nil {
+		done := make(chan struct{})
+		stop <- done
+		<-done
+	}
+}
+
+// diskLayer returns the current bottom layer.
+func (t *Tree) diskLayer() *diskLayer {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.disk
+}
+
+// accountKey returns the database key of an account.
+func accountKey(hash common.Hash) []byte {
+	return append(append([]byte{}, accountPrefix...), hash[:]...)
+}
+
+// storageKey returns the database key of a storage slot.
+func storageKey(accountHash, storageHash common.Hash) []byte {
+	return append(append(append([]byte{}, storagePrefix...), accountHash[:]...), storageHash[:]...)
+}
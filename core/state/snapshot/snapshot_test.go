@@ -0,0 +1,204 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/crypto"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/rlp"
+	"github.com/tomochain/tomochain/trie"
+)
+
+// newTestState creates a state of a few accounts, the first one having the
+// given storage slots, returning the state root.
+func newTestState(t *testing.T, triedb *trie.Database, slots map[common.Hash]common.Hash) common.Hash {
+	storage, _ := trie.NewSecure(common.Hash{}, triedb, 0)
+	for key, value := range slots {
+		enc, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
+		storage.Update(key[:], enc)
+	}
+	storageRoot, err := storage.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit storage trie: %v", err)
+	}
+	accounts, _ := trie.NewSecure(common.Hash{}, triedb, 0)
+	for i := byte(1); i <= 3; i++ {
+		acc := account{Nonce: uint64(i), Balance: big.NewInt(int64(i) * 1000), Root: emptyRoot, CodeHash: crypto.Keccak256(nil)}
+		if i == 1 {
+			acc.Root = storageRoot
+		}
+		enc, _ := rlp.EncodeToBytes(&acc)
+		accounts.Update(common.Address{i}.Bytes(), enc)
+	}
+	root, err := accounts.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit account trie: %v", err)
+	}
+	return root
+}
+
+// waitGeneration blocks until the disk layer of the tree is fully generated.
+func waitGeneration(t *testing.T, tree *Tree) {
+	for deadline := time.Now().Add(10 * time.Second); tree.diskLayer().marker() != nil; {
+		if time.Now().After(deadline) {
+			t.Fatalf("snapshot generation timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func addressHash(i byte) common.Hash {
+	return crypto.Keccak256Hash(common.Address{i}.Bytes())
+}
+
+// Tests that the disk layer is generated from the state trie and that it
+// resumes on restart.
+func TestGenerate(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	var (
+		triedb = trie.NewDatabase(db)
+		slot   = common.Hash{0x01}
+		value  = common.Hash{0x02}
+		root   = newTestState(t, triedb, map[common.Hash]common.Hash{slot: value})
+	)
+	tree, err := New(db, triedb, root)
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	waitGeneration(t, tree)
+	tree.Release()
+
+	snap := tree.Snapshot(root)
+	if snap == nil {
+		t.Fatalf("snapshot of the state missing")
+	}
+	for i := byte(1); i <= 3; i++ {
+		blob, err := snap.Account(addressHash(i))
+		if err != nil {
+			t.Fatalf("account %d: failed to retrieve: %v", i, err)
+		}
+		var acc account
+		if err := rlp.DecodeBytes(blob, &acc); err != nil || acc.Nonce != uint64(i) {
+			t.Errorf("account %d: mismatch: have %+v, %v", i, acc, err)
+		}
+	}
+	if blob, err := snap.Account(addressHash(4)); blob != nil || err != nil {
+		t.Errorf("missing account retrieved: %x, %v", blob, err)
+	}
+	enc, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
+	if blob, err := snap.Storage(addressHash(1), crypto.Keccak256Hash(slot[:])); !bytes.Equal(blob, enc) || err != nil {
+		t.Errorf("storage mismatch: have %x, %v, want %x", blob, err, enc)
+	}
+	// Reopening the generated snapshot must keep it
+	tree, err = New(db, triedb, root)
+	if err != nil {
+		t.Fatalf("failed to reopen snapshot: %v", err)
+	}
+	defer tree.Release()
+
+	if marker := tree.diskLayer().marker(); marker != nil {
+		t.Errorf("generated snapshot regenerating from %x", marker)
+	}
+}
+
+// Tests that diff layers shadow their parents, and that capping the tree
+// flattens them into the disk layer while the dropped layers turn stale.
+func TestDiffLayers(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	var (
+		triedb = trie.NewDatabase(db)
+		slot   = common.Hash{0x01}
+		root   = newTestState(t, triedb, map[common.Hash]common.Hash{slot: {0x02}})
+	)
+	tree, err := New(db, triedb, root)
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	defer tree.Release()
+	waitGeneration(t, tree)
+
+	// Update the second account, destruct the first one and fork off the base
+	var (
+		rootA = common.Hash{0xaa}
+		rootB = common.Hash{0xbb}
+		fork  = common.Hash{0xff}
+	)
+	err = tree.Update(rootA, root, nil, map[common.Hash][]byte{addressHash(2): {0x01}}, nil)
+	if err != nil {
+		t.Fatalf("failed to add layer: %v", err)
+	}
+	err = tree.Update(rootB, rootA, map[common.Hash]struct{}{addressHash(1): {}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to add layer: %v", err)
+	}
+	if err := tree.Update(fork, root, nil, nil, nil); err != nil {
+		t.Fatalf("failed to add fork layer: %v", err)
+	}
+	if err := tree.Update(common.Hash{0x01}, common.Hash{0x02}, nil, nil, nil); err == nil {
+		t.Errorf("layer without parent added")
+	}
+	snap := tree.Snapshot(rootB)
+	if blob, _ := snap.Account(addressHash(2)); !bytes.Equal(blob, []byte{0x01}) {
+		t.Errorf("updated account mismatch: have %x", blob)
+	}
+	if blob, _ := snap.Account(addressHash(1)); blob != nil {
+		t.Errorf("destructed account retrieved: %x", blob)
+	}
+	if blob, _ := snap.Storage(addressHash(1), crypto.Keccak256Hash(slot[:])); blob != nil {
+		t.Errorf("destructed storage retrieved: %x", blob)
+	}
+	if blob, _ := tree.Snapshot(rootA).Account(addressHash(1)); blob == nil {
+		t.Errorf("parent layer lost the account")
+	}
+	// Flatten the first layer, dropping the fork
+	forked := tree.Snapshot(fork)
+	if err := tree.Cap(rootB, 1); err != nil {
+		t.Fatalf("failed to cap snapshot: %v", err)
+	}
+	if tree.Snapshot(fork) != nil || tree.Snapshot(root) != nil {
+		t.Errorf("dropped layers still retained")
+	}
+	if _, err := forked.Account(addressHash(2)); err != ErrSnapshotStale {
+		t.Errorf("dropped layer error mismatch: have %v, want %v", err, ErrSnapshotStale)
+	}
+	if blob, _ := db.Get(accountKey(addressHash(2))); !bytes.Equal(blob, []byte{0x01}) {
+		t.Errorf("flattened account mismatch: have %x", blob)
+	}
+	if blob, _ := tree.Snapshot(rootB).Account(addressHash(1)); blob != nil {
+		t.Errorf("destructed account retrieved after cap: %x", blob)
+	}
+	// Flatten everything, the disk layer following the head
+	if err := tree.Cap(rootB, 0); err != nil {
+		t.Fatalf("failed to cap snapshot: %v", err)
+	}
+	if persisted, _ := db.Get(rootKey); common.BytesToHash(persisted) != rootB {
+		t.Errorf("persisted root mismatch: have %x, want %x", persisted, rootB)
+	}
+	if has, _ := db.Has(accountKey(addressHash(1))); has {
+		t.Errorf("destructed account persisted")
+	}
+	if has, _ := db.Has(storageKey(addressHash(1), crypto.Keccak256Hash(slot[:]))); has {
+		t.Errorf("destructed storage persisted")
+	}
+}
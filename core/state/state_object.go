@@ -174,8 +174,17 @@ func (self *stateObject) GetState(db Database, key common.Hash) common.Hash {
 	if exists {
 		return value
 	}
-	// Load from DB in case it is missing.
-	enc, err := self.getTrie(db).TryGet(key[:])
+	// Load from the snapshot if it's retained, or the trie in case it is missing.
+	var (
+		enc []byte
+		err error
+	)
+	if self.db.snap != nil {
+		enc, err = self.db.snapStorageSlot(self.addrHash, crypto.Keccak256Hash(key[:]))
+	}
+	if self.db.snap == nil || err != nil {
+		enc, err = self.getTrie(db).TryGet(key[:])
+	}
 	if err != nil {
 		self.setError(err)
 		return common.Hash{}
@@ -218,13 +227,22 @@ func (self *stateObject) updateTrie(db Database) Trie {
 	tr := self.getTrie(db)
 	for key, value := range self.dirtyStorage {
 		delete(self.dirtyStorage, key)
+		var v []byte
 		if (value == common.Hash{}) {
 			self.setError(tr.TryDelete(key[:]))
-			continue
+		} else {
+			// Encoding []byte cannot fail, ok to ignore the error.
+			v, _ = rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
+			self.setError(tr.TryUpdate(key[:], v))
+		}
+		if self.db.snap != nil {
+			slots := self.db.snapStorage[self.addrHash]
+			if slots == nil {
+				slots = make(map[common.Hash][]byte)
+				self.db.snapStorage[self.addrHash] = slots
+			}
+			slots[crypto.Keccak256Hash(key[:])] = v
 		}
-		// Encoding []byte cannot fail, ok to ignore the error.
-		v, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
-		self.setError(tr.TryUpdate(key[:], v))
 	}
 	return tr
 }
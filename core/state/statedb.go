@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/core/state/snapshot"
 	"github.com/tomochain/tomochain/core/types"
 	"github.com/tomochain/tomochain/crypto"
 	"github.com/tomochain/tomochain/log"
@@ -53,6 +54,14 @@ type StateDB struct {
 	db   Database
 	trie Trie
 
+	// The flat snapshot of the original state, read before the trie, along
+	// with the changes to add on top of it on commit.
+	snaps         *snapshot.Tree
+	snap          snapshot.Snapshot
+	snapDestructs map[common.Hash]struct{}
+	snapAccounts  map[common.Hash][]byte
+	snapStorage   map[common.Hash]map[common.Hash][]byte
+
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects      map[common.Address]*stateObject
 	stateObjectsDirty map[common.Address]struct{}
@@ -80,6 +89,9 @@ type StateDB struct {
 	validRevisions []revision
 	nextRevisionId int
 
+	// The accesses to the state recorded for the parallel block processing.
+	access *AccessSet
+
 	lock sync.Mutex
 }
 
@@ -99,6 +111,34 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 	}, nil
 }
 
+// NewWithSnapshot creates a new state from a given trie, reading the accounts
+// and storage from the snapshot tree when it retains the state.
+func NewWithSnapshot(root common.Hash, db Database, snaps *snapshot.Tree) (*StateDB, error) {
+	statedb, err := New(root, db)
+	if err != nil {
+		return nil, err
+	}
+	if snaps != nil {
+		statedb.snaps = snaps
+		statedb.resetSnapshot(root)
+	}
+	return statedb, nil
+}
+
+// resetSnapshot starts reading the given state from the snapshot tree, if it
+// retains the state.
+func (self *StateDB) resetSnapshot(root common.Hash) {
+	self.snap, self.snapDestructs, self.snapAccounts, self.snapStorage = nil, nil, nil, nil
+	if self.snaps == nil {
+		return
+	}
+	if self.snap = self.snaps.Snapshot(root); self.snap != nil {
+		self.snapDestructs = make(map[common.Hash]struct{})
+		self.snapAccounts = make(map[common.Hash][]byte)
+		self.snapStorage = make(map[common.Hash]map[common.Hash][]byte)
+	}
+}
+
 // setError remembers the first non-nil error it is called with.
 func (self *StateDB) setError(err error) {
 	if self.dbErr == nil {
@@ -126,6 +166,7 @@ func (self *StateDB) Reset(root common.Hash) error {
 	self.logs = make(map[common.Hash][]*types.Log)
 	self.logSize = 0
 	self.preimages = make(map[common.Hash][]byte)
+	self.resetSnapshot(root)
 	self.clearJournalAndRefund()
 	return nil
 }
@@ -176,18 +217,22 @@ func (self *StateDB) AddRefund(gas uint64) {
 // Exist reports whether the given account address exists in the state.
 // Notably this also returns true for suicided accounts.
 func (self *StateDB) Exist(addr common.Address) bool {
+	self.trackRead(accountAccess, addr, common.Hash{})
 	return self.getStateObject(addr) != nil
 }
 
 // Empty returns whether the state object is either non-existent
 // or empty according to the EIP161 specification (balance = nonce = code = 0)
 func (self *StateDB) Empty(addr common.Address) bool {
+	self.trackRead(accountAccess, addr, common.Hash{})
+	self.trackRead(balanceAccess, addr, common.Hash{})
 	so := self.getStateObject(addr)
 	return so == nil || so.empty()
 }
 
 // Retrieve the balance from the given address or 0 if object not found
 func (self *StateDB) GetBalance(addr common.Address) *big.Int {
+	self.trackRead(balanceAccess, addr, common.Hash{})
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Balance()
@@ -196,6 +241,7 @@ func (self *StateDB) GetBalance(addr common.Address) *big.Int {
 }
 
 func (self *StateDB) GetNonce(addr common.Address) uint64 {
+	self.trackRead(accountAccess, addr, common.Hash{})
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Nonce()
@@ -205,6 +251,7 @@ func (self *StateDB) GetNonce(addr common.Address) uint64 {
 }
 
 func (self *StateDB) GetCode(addr common.Address) []byte {
+	self.trackRead(accountAccess, addr, common.Hash{})
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Code(self.db)
@@ -213,6 +260,7 @@ func (self *StateDB) GetCode(addr common.Address) []byte {
 }
 
 func (self *StateDB) GetCodeSize(addr common.Address) int {
+	self.trackRead(accountAccess, addr, common.Hash{})
 	stateObject := self.getStateObject(addr)
 	if stateObject == nil {
 		return 0
@@ -228,6 +276,7 @@ func (self *StateDB) GetCodeSize(addr common.Address) int {
 }
 
 func (self *StateDB) GetCodeHash(addr common.Address) common.Hash {
+	self.trackRead(accountAccess, addr, common.Hash{})
 	stateObject := self.getStateObject(addr)
 	if stateObject == nil {
 		return common.Hash{}
@@ -236,6 +285,8 @@ func (self *StateDB) GetCodeHash(addr common.Address) common.Hash {
 }
 
 func (self *StateDB) GetState(addr common.Address, bhash common.Hash) common.Hash {
+	self.trackRead(accountAccess, addr, common.Hash{})
+	self.trackRead(slotAccess, addr, bhash)
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.GetState(self.db, bhash)
@@ -259,7 +310,16 @@ func (self *StateDB) StorageTrie(addr common.Address) Trie {
 	return cpy.updateTrie(self.db)
 }
 
+// GetProof returns the merkle proof of the account at the given address in the
+// account trie, proving its absence if it doesn't exist.
+func (self *StateDB) GetProof(addr common.Address) ([][]byte, error) {
+	var proof trie.ProofList
+	err := self.trie.Prove(crypto.Keccak256(addr.Bytes()), 0, &proof)
+	return [][]byte(proof), err
+}
+
 func (self *StateDB) HasSuicided(addr common.Address) bool {
+	self.trackRead(accountAccess, addr, common.Hash{})
 	stateObject := self.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.suicided
@@ -273,6 +333,7 @@ func (self *StateDB) HasSuicided(addr common.Address) bool {
 
 // AddBalance adds amount to the account associated with addr.
 func (self *StateDB) AddBalance(addr common.Address, amount *big.Int) {
+	self.trackBalance(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.AddBalance(amount)
@@ -281,13 +342,20 @@ func (self *StateDB) AddBalance(addr common.Address, amount *big.Int) {
 
 // SubBalance subtracts amount from the account associated with addr.
 func (self *StateDB) SubBalance(addr common.Address, amount *big.Int) {
+	self.trackBalance(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SubBalance(amount)
+		if stateObject.empty() {
+			self.trackRead(accountAccess, addr, common.Hash{})
+			self.trackWrite(accountAccess, addr, common.Hash{})
+		}
 	}
 }
 
 func (self *StateDB) SetBalance(addr common.Address, amount *big.Int) {
+	self.trackRead(balanceAccess, addr, common.Hash{})
+	self.trackBalance(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetBalance(amount)
@@ -295,6 +363,8 @@ func (self *StateDB) SetBalance(addr common.Address, amount *big.Int) {
 }
 
 func (self *StateDB) SetNonce(addr common.Address, nonce uint64) {
+	self.trackRead(accountAccess, addr, common.Hash{})
+	self.trackWrite(accountAccess, addr, common.Hash{})
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetNonce(nonce)
@@ -302,6 +372,8 @@ func (self *StateDB) SetNonce(addr common.Address, nonce uint64) {
 }
 
 func (self *StateDB) SetCode(addr common.Address, code []byte) {
+	self.trackRead(accountAccess, addr, common.Hash{})
+	self.trackWrite(accountAccess, addr, common.Hash{})
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetCode(crypto.Keccak256Hash(code), code)
@@ -309,6 +381,9 @@ func (self *StateDB) SetCode(addr common.Address, code []byte) {
 }
 
 func (self *StateDB) SetState(addr common.Address, key, value common.Hash) {
+	self.trackRead(accountAccess, addr, common.Hash{})
+	self.trackRead(slotAccess, addr, key)
+	self.trackWrite(slotAccess, addr, key)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetState(self.db, key, value)
@@ -325,6 +400,10 @@ func (self *StateDB) Suicide(addr common.Address) bool {
 	if stateObject == nil {
 		return false
 	}
+	self.trackRead(balanceAccess, addr, common.Hash{})
+	self.trackBalance(addr)
+	self.trackWrite(accountAccess, addr, common.Hash{})
+
 	self.journal = append(self.journal, suicideChange{
 		account:     &addr,
 		prev:        stateObject.suicided,
@@ -348,6 +427,10 @@ func (self *StateDB) updateStateObject(stateObject *stateObject) {
 		panic(fmt.Errorf("can't encode object at %x: %v", addr[:], err))
 	}
 	self.setError(self.trie.TryUpdate(addr[:], data))
+
+	if self.snap != nil {
+		self.snapAccounts[stateObject.addrHash] = data
+	}
 }
 
 // deleteStateObject removes the given object from the state trie.
@@ -355,6 +438,12 @@ func (self *StateDB) deleteStateObject(stateObject *stateObject) {
 	stateObject.deleted = true
 	addr := stateObject.Address()
 	self.setError(self.trie.TryDelete(addr[:]))
+
+	if self.snap != nil {
+		self.snapDestructs[stateObject.addrHash] = struct{}{}
+		delete(self.snapAccounts, stateObject.addrHash)
+		delete(self.snapStorage, stateObject.addrHash)
+	}
 }
 
 // DeleteAddress removes the address from the state trie.
@@ -375,8 +464,17 @@ func (self *StateDB) getStateObject(addr common.Address) (stateObject *stateObje
 		return obj
 	}
 
-	// Load the object from the database.
-	enc, err := self.trie.TryGet(addr[:])
+	// Load the object from the snapshot if it's retained, or the trie
+	var (
+		enc []byte
+		err error
+	)
+	if self.snap != nil {
+		enc, err = self.snapAccount(crypto.Keccak256Hash(addr[:]))
+	}
+	if self.snap == nil || err != nil {
+		enc, err = self.trie.TryGet(addr[:])
+	}
 	if len(enc) == 0 {
 		self.setError(err)
 		return nil
@@ -392,6 +490,30 @@ func (self *StateDB) getStateObject(addr common.Address) (stateObject *stateObje
 	return obj
 }
 
+// snapAccount retrieves an account from the changes flushed into the trie, or
+// from the snapshot of the original state.
+func (self *StateDB) snapAccount(hash common.Hash) ([]byte, error) {
+	if enc, ok := self.snapAccounts[hash]; ok {
+		return enc, nil
+	}
+	if _, ok := self.snapDestructs[hash]; ok {
+		return nil, nil
+	}
+	return self.snap.Account(hash)
+}
+
+// snapStorageSlot retrieves a storage slot from the changes flushed into the
+// trie, or from the snapshot of the original state.
+func (self *StateDB) snapStorageSlot(accountHash, storageHash common.Hash) ([]byte, error) {
+	if enc, ok := self.snapStorage[accountHash][storageHash]; ok {
+		return enc, nil
+	}
+	if _, ok := self.snapDestructs[accountHash]; ok {
+		return nil, nil
+	}
+	return self.snap.Storage(accountHash, storageHash)
+}
+
 func (self *StateDB) setStateObject(object *stateObject) {
 	self.stateObjects[object.Address()] = object
 }
@@ -420,7 +542,16 @@ func (self *StateDB) createObject(addr common.Address) (newobj, prev *stateObjec
 	if prev == nil {
 		self.journal = append(self.journal, createObjectChange{account: &addr})
 	} else {
-		self.journal = append(self.journal, resetObjectChange{prev: prev})
+		// The storage of the previous account is gone from the snapshot
+		change := resetObjectChange{prev: prev}
+		if self.snap != nil {
+			_, change.prevDestruct = self.snapDestructs[prev.addrHash]
+			change.prevStorage = self.snapStorage[prev.addrHash]
+
+			self.snapDestructs[prev.addrHash] = struct{}{}
+			delete(self.snapStorage, prev.addrHash)
+		}
+		self.journal = append(self.journal, change)
 	}
 	self.setStateObject(newobj)
 	return newobj, prev
@@ -437,10 +568,18 @@ func (self *StateDB) createObject(addr common.Address) (newobj, prev *stateObjec
 //
 // Carrying over the balance ensures that Ether doesn't disappear.
 func (self *StateDB) CreateAccount(addr common.Address) {
+	self.trackRead(accountAccess, addr, common.Hash{})
+	self.trackRead(balanceAccess, addr, common.Hash{})
+	self.trackBalance(addr)
+	self.trackWrite(accountAccess, addr, common.Hash{})
+
 	new, prev := self.createObject(addr)
 	if prev != nil {
 		new.setBalance(prev.data.Balance)
 	}
+	if self.access != nil {
+		self.access.created[new] = struct{}{}
+	}
 }
 
 func (db *StateDB) ForEachStorage(addr common.Address, cb func(key, value common.Hash) bool) {
@@ -474,6 +613,8 @@ func (self *StateDB) Copy() *StateDB {
 	state := &StateDB{
 		db:                self.db,
 		trie:              self.db.CopyTrie(self.trie),
+		snaps:             self.snaps,
+		snap:              self.snap,
 		stateObjects:      make(map[common.Address]*stateObject, len(self.stateObjectsDirty)),
 		stateObjectsDirty: make(map[common.Address]struct{}, len(self.stateObjectsDirty)),
 		refund:            self.refund,
@@ -493,6 +634,24 @@ func (self *StateDB) Copy() *StateDB {
 	for hash, preimage := range self.preimages {
 		state.preimages[hash] = preimage
 	}
+	if self.snap != nil {
+		state.snapDestructs = make(map[common.Hash]struct{}, len(self.snapDestructs))
+		for hash := range self.snapDestructs {
+			state.snapDestructs[hash] = struct{}{}
+		}
+		state.snapAccounts = make(map[common.Hash][]byte, len(self.snapAccounts))
+		for hash, enc := range self.snapAccounts {
+			state.snapAccounts[hash] = enc
+		}
+		state.snapStorage = make(map[common.Hash]map[common.Hash][]byte, len(self.snapStorage))
+		for hash, slots := range self.snapStorage {
+			cpy := make(map[common.Hash][]byte, len(slots))
+			for key, enc := range slots {
+				cpy[key] = enc
+			}
+			state.snapStorage[hash] = cpy
+		}
+	}
 	return state
 }
 
@@ -632,6 +791,16 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error)
 		return nil
 	})
 	log.Debug("Trie cache stats after commit", "misses", trie.CacheMisses(), "unloads", trie.CacheUnloads())
+
+	// Add the changes on top of the snapshot of the original state
+	if err == nil && s.snap != nil {
+		if parent := s.snap.Root(); parent != root {
+			if err := s.snaps.Update(root, parent, s.snapDestructs, s.snapAccounts, s.snapStorage); err != nil {
+				log.Warn("Failed to update state snapshot", "root", root, "parent", parent, "err", err)
+			}
+		}
+		s.snap, s.snapDestructs, s.snapAccounts, s.snapStorage = nil, nil, nil, nil
+	}
 	return root, err
 }
 
@@ -32,7 +32,10 @@ import (
 
 	"github.com/tomochain/tomochain/common"
 	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/crypto"
 	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/rlp"
+	"github.com/tomochain/tomochain/trie"
 )
 
 // Tests that updating a state trie does not leak any database writes prior to
@@ -421,3 +424,59 @@ func (s *StateSuite) TestTouchDelete(c *check.C) {
 		c.Fatal("expected no dirty state object")
 	}
 }
+
+// Tests that the account and storage proofs of the state verify against the
+// state root and the storage root of the account.
+func TestGetProof(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+
+	addr := common.BytesToAddress([]byte{0x01})
+	slot, value := common.BytesToHash([]byte{0x02}), common.BytesToHash([]byte{0x03})
+	state.SetBalance(addr, big.NewInt(42))
+	state.SetState(addr, slot, value)
+	root, _ := state.Commit(false)
+	state, _ = New(root, state.Database())
+
+	verify := func(root common.Hash, key []byte, proof [][]byte) []byte {
+		proofDb, _ := ethdb.NewMemDatabase()
+		for _, node := range proof {
+			proofDb.Put(crypto.Keccak256(node), node)
+		}
+		val, err, _ := trie.VerifyProof(root, crypto.Keccak256(key), proofDb)
+		if err != nil {
+			t.Fatalf("failed to verify proof of %x: %v", key, err)
+		}
+		return val
+	}
+	proof, err := state.GetProof(addr)
+	if err != nil {
+		t.Fatalf("failed to get account proof: %v", err)
+	}
+	var account Account
+	if err := rlp.DecodeBytes(verify(root, addr.Bytes(), proof), &account); err != nil {
+		t.Fatalf("failed to decode proven account: %v", err)
+	}
+	if account.Balance.Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("proven balance mismatch: have %v, want 42", account.Balance)
+	}
+	var storageProof trie.ProofList
+	if err := state.StorageTrie(addr).Prove(crypto.Keccak256(slot.Bytes()), 0, &storageProof); err != nil {
+		t.Fatalf("failed to get storage proof: %v", err)
+	}
+	proof = storageProof
+	var enc []byte
+	if err := rlp.DecodeBytes(verify(account.Root, slot.Bytes(), proof), &enc); err != nil {
+		t.Fatalf("failed to decode proven slot: %v", err)
+	}
+	if common.BytesToHash(enc) != value {
+		t.Errorf("proven slot mismatch: have %x, want %x", enc, value)
+	}
+	// An absent account is proven by the path up to where it would be
+	if proof, err = state.GetProof(common.BytesToAddress([]byte{0x04})); err != nil || len(proof) == 0 {
+		t.Fatalf("failed to get absence proof: %v", err)
+	}
+	if val := verify(root, common.BytesToAddress([]byte{0x04}).Bytes(), proof); val != nil {
+		t.Errorf("absent account proven with value %x", val)
+	}
+}
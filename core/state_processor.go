@@ -30,6 +30,7 @@ import (
 	"github.com/tomochain/tomochain/core/vm"
 	"github.com/tomochain/tomochain/crypto"
 	"github.com/tomochain/tomochain/params"
+	"github.com/tomochain/tomochain/tomox/tomox_state"
 )
 
 // StateProcessor is a basic Processor, which takes care of transitioning
@@ -37,9 +38,10 @@ import (
 //
 // StateProcessor implements Processor.
 type StateProcessor struct {
-	config *params.ChainConfig // Chain configuration options
-	bc     *BlockChain         // Canonical block chain
-	engine consensus.Engine    // Consensus engine used for block rewards
+	config   *params.ChainConfig // Chain configuration options
+	bc       *BlockChain         // Canonical block chain
+	engine   consensus.Engine    // Consensus engine used for block rewards
+	parallel int                 // Number of workers executing the transactions in parallel, 0 executes them in order
 }
 type CalculatedBlock struct {
 	block *types.Block
@@ -78,8 +80,21 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		statedb.DeleteAddress(common.HexToAddress(common.BlockSigners))
 	}
 	InitSignerInTransactions(p.config, header, block.Transactions())
+	tomoxState, err := p.bc.PrecompileTomoXState(header)
+	if err != nil {
+		return nil, nil, 0, err
+	}
 	balanceUpdated := map[common.Address]*big.Int{}
 	totalFeeUsed := big.NewInt(0)
+	if p.parallelizable(block, cfg) {
+		receipts, allLogs, used, err := p.applyTransactionsParallel(block, statedb, tomoxState, cfg, balanceFee, balanceUpdated, totalFeeUsed, nil)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		state.UpdateTRC21Fee(statedb, balanceUpdated, totalFeeUsed)
+		p.engine.Finalize(p.bc, header, statedb, block.Transactions(), block.Uncles(), receipts)
+		return receipts, allLogs, used, nil
+	}
 	for i, tx := range block.Transactions() {
 		// check black-list txs after hf
 		if (block.Number().Uint64() >= common.BlackListHFNumber) && !common.IsTestnet {
@@ -93,7 +108,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 			}
 		}
 		statedb.Prepare(tx.Hash(), block.Hash(), i)
-		receipt, gas, err, tokenFeeUsed := ApplyTransaction(p.config, balanceFee, p.bc, nil, gp, statedb, header, tx, usedGas, cfg)
+		receipt, gas, err, tokenFeeUsed := ApplyTransaction(p.config, balanceFee, p.bc, nil, gp, statedb, tomoxState, header, tx, usedGas, cfg)
 		if err != nil {
 			return nil, nil, 0, err
 		}
@@ -135,12 +150,25 @@ func (p *StateProcessor) ProcessBlockNoValidator(cBlock *CalculatedBlock, stated
 		return nil, nil, 0, ErrStopPreparingBlock
 	}
 	InitSignerInTransactions(p.config, header, block.Transactions())
+	tomoxState, err := p.bc.PrecompileTomoXState(header)
+	if err != nil {
+		return nil, nil, 0, err
+	}
 	balanceUpdated := map[common.Address]*big.Int{}
 	totalFeeUsed := big.NewInt(0)
 
 	if cBlock.stop {
 		return nil, nil, 0, ErrStopPreparingBlock
 	}
+	if p.parallelizable(block, cfg) {
+		receipts, allLogs, used, err := p.applyTransactionsParallel(block, statedb, tomoxState, cfg, balanceFee, balanceUpdated, totalFeeUsed, func() bool { return cBlock.stop })
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		state.UpdateTRC21Fee(statedb, balanceUpdated, totalFeeUsed)
+		p.engine.Finalize(p.bc, header, statedb, block.Transactions(), block.Uncles(), receipts)
+		return receipts, allLogs, used, nil
+	}
 	// Iterate over and process the individual transactions
 	receipts = make([]*types.Receipt, block.Transactions().Len())
 	for i, tx := range block.Transactions() {
@@ -156,7 +184,7 @@ func (p *StateProcessor) ProcessBlockNoValidator(cBlock *CalculatedBlock, stated
 			}
 		}
 		statedb.Prepare(tx.Hash(), block.Hash(), i)
-		receipt, gas, err, tokenFeeUsed := ApplyTransaction(p.config, balanceFee, p.bc, nil, gp, statedb, header, tx, usedGas, cfg)
+		receipt, gas, err, tokenFeeUsed := ApplyTransaction(p.config, balanceFee, p.bc, nil, gp, statedb, tomoxState, header, tx, usedGas, cfg)
 		if err != nil {
 			return nil, nil, 0, err
 		}
@@ -184,8 +212,9 @@ func (p *StateProcessor) ProcessBlockNoValidator(cBlock *CalculatedBlock, stated
 // ApplyTransaction attempts to apply a transaction to the given state database
 // and uses the input parameters for its environment. It returns the receipt
 // for the transaction, gas used and an error if the transaction failed,
-// indicating the block was invalid.
-func ApplyTransaction(config *params.ChainConfig, tokensFee map[common.Address]*big.Int, bc *BlockChain, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, cfg vm.Config) (*types.Receipt, uint64, error, bool) {
+// indicating the block was invalid. The TomoX precompiled contracts read the
+// order books of tomoxState, as returned by BlockChain.PrecompileTomoXState.
+func ApplyTransaction(config *params.ChainConfig, tokensFee map[common.Address]*big.Int, bc *BlockChain, author *common.Address, gp *GasPool, statedb *state.StateDB, tomoxState *tomox_state.TomoXStateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, cfg vm.Config) (*types.Receipt, uint64, error, bool) {
 	if tx.To() != nil && tx.To().String() == common.BlockSigners && config.IsTIPSigning(header.Number) {
 		return ApplySignTransaction(config, statedb, header, tx, usedGas)
 	}
@@ -207,6 +236,7 @@ func ApplyTransaction(config *params.ChainConfig, tokensFee map[common.Address]*
 	}
 	// Create a new context to be used in the EVM environment
 	context := NewEVMContext(msg, header, bc, author)
+	context.TomoXState = tomoxState
 	// Create a new environment which holds all relevant information
 	// about the transaction and calling mechanisms.
 	vmenv := vm.NewEVM(context, statedb, config, cfg)
@@ -0,0 +1,408 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/consensus/posv"
+	"github.com/tomochain/tomochain/core/state"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/log"
+	"github.com/tomochain/tomochain/rlp"
+	"github.com/tomochain/tomochain/tomox/tomox_state"
+	"github.com/tomochain/tomochain/trie"
+)
+
+const (
+	// stateBloomBits is the size of the bloom filter marking the live trie
+	// nodes, 2 GBit (256 MB) keeping false positives below 0.1% for 100M nodes.
+	stateBloomBits = 1 << 31
+
+	// pruneProgressInterval is the number of swept keys after which the sweep
+	// position is persisted.
+	pruneProgressInterval = 100000
+)
+
+var (
+	// statePruningKey tracks the progress of an interrupted state pruning.
+	statePruningKey = []byte("StatePruning")
+
+	// ErrStatePruningRunning is returned if a state pruning is requested while
+	// another one is in progress.
+	ErrStatePruningRunning = errors.New("state pruning already running")
+
+	// errStatePruningAborted is returned if the chain is stopped while pruning.
+	errStatePruningAborted = errors.New("state pruning aborted")
+)
+
+// Stages of the state pruning sweep.
+const (
+	pruneStageState = iota // Sweeping the chain database
+	pruneStageTomoX        // Sweeping the TomoX database
+)
+
+// pruneProgress is the persisted position of an interrupted sweep.
+type pruneProgress struct {
+	Stage uint64
+	Next  []byte
+}
+
+// stateBloom is a concurrent bloom filter of the trie nodes to keep. Trie node
+// hashes are uniformly distributed, so the bit indexes are taken from the hash
+// directly.
+type stateBloom struct {
+	bits []uint64
+}
+
+func newStateBloom(bits uint64) *stateBloom {
+	return &stateBloom{bits: make([]uint64, (bits+63)/64)}
+}
+
+// add marks the given hash as live.
+func (b *stateBloom) add(hash common.Hash) {
+	size := uint64(len(b.bits)) * 64
+	for i := 0; i < common.HashLength; i += 8 {
+		bit := binary.BigEndian.Uint64(hash[i:]) % size
+		word, mask := &b.bits[bit/64], uint64(1)<<(bit%64)
+		for {
+			old := atomic.LoadUint64(word)
+			if old&mask != 0 || atomic.CompareAndSwapUint64(word, old, old|mask) {
+				break
+			}
+		}
+	}
+}
+
+// contains reports whether the given hash may have been marked live.
+func (b *stateBloom) contains(hash []byte) bool {
+	size := uint64(len(b.bits)) * 64
+	for i := 0; i < common.HashLength; i += 8 {
+		bit := binary.BigEndian.Uint64(hash[i:]) % size
+		if atomic.LoadUint64(&b.bits[bit/64])&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// statePruner deletes the trie nodes which are unreachable from the retained
+// states, while the chain keeps running.
+type statePruner struct {
+	bc    *BlockChain
+	bloom *stateBloom
+
+	// lock makes marking a node about to be flushed and sweeping it mutually
+	// exclusive, so a node resurrected while sweeping is never deleted.
+	lock sync.Mutex
+}
+
+// mark flags a node as live. It's installed as the flush hook of the trie
+// databases so nodes persisted while pruning are kept.
+func (p *statePruner) mark(hash common.Hash) {
+	p.lock.Lock()
+	p.bloom.add(hash)
+	p.lock.Unlock()
+}
+
+// StartStatePruning prunes the stale state in the background.
+func (bc *BlockChain) StartStatePruning() error {
+	if !atomic.CompareAndSwapInt32(&bc.pruning, 0, 1) {
+		return ErrStatePruningRunning
+	}
+	bc.wg.Add(1)
+	go func() {
+		defer bc.wg.Done()
+		defer atomic.StoreInt32(&bc.pruning, 0)
+
+		if err := bc.pruneState(); err != nil && err != errStatePruningAborted {
+			log.Error("State pruning failed", "err", err)
+		}
+	}()
+	return nil
+}
+
+// ResumeStatePruning restarts a state pruning interrupted by a shutdown or a
+// crash in the background.
+func (bc *BlockChain) ResumeStatePruning() {
+	if has, _ := bc.db.Has(statePruningKey); !has {
+		return
+	}
+	log.Info("Resuming interrupted state pruning")
+	if err := bc.StartStatePruning(); err != nil {
+		log.Warn("Failed to resume state pruning", "err", err)
+	}
+}
+
+// PruneState deletes the trie nodes of the chain and TomoX databases which are
+// unreachable from the most recent persisted state, the state tries retained
+// in memory and the ones flushed while pruning. It resumes an interrupted
+// pruning if there is one.
+func (bc *BlockChain) PruneState() error {
+	if !atomic.CompareAndSwapInt32(&bc.pruning, 0, 1) {
+		return ErrStatePruningRunning
+	}
+	defer atomic.StoreInt32(&bc.pruning, 0)
+
+	return bc.pruneState()
+}
+
+// pruneState runs the marking and sweeping phases of a state pruning.
+func (bc *BlockChain) pruneState() error {
+	var progress pruneProgress
+	if enc, _ := bc.db.Get(statePruningKey); len(enc) > 0 {
+		if err := rlp.DecodeBytes(enc, &progress); err != nil {
+			log.Warn("Discarding corrupt state pruning progress", "err", err)
+			progress = pruneProgress{}
+		}
+	}
+	if err := bc.savePruneProgress(progress); err != nil {
+		return err
+	}
+	var (
+		pruner      = &statePruner{bc: bc, bloom: newStateBloom(stateBloomBits)}
+		triedb      = bc.stateCache.TrieDB()
+		tomoxCache  = bc.TomoXStateCache()
+		tomoxTriedb *trie.Database
+	)
+	// Keep every node persisted from now on, then mark the retained states
+	triedb.SetFlushHook(pruner.mark)
+	defer triedb.SetFlushHook(nil)
+	if tomoxCache != nil {
+		tomoxTriedb = tomoxCache.TrieDB()
+		tomoxTriedb.SetFlushHook(pruner.mark)
+		defer tomoxTriedb.SetFlushHook(nil)
+	}
+	start := time.Now()
+	roots, tomoxRoots := bc.retainedStateRoots()
+	for _, root := range roots {
+		if err := bc.markState(pruner, root); err != nil {
+			return err
+		}
+	}
+	for _, root := range tomoxRoots {
+		if err := tomox_state.IterateNodes(tomoxCache, root, pruner.bloom.add); err != nil {
+			return err
+		}
+	}
+	log.Info("Marked live state", "roots", len(roots), "tomoxroots", len(tomoxRoots), "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Sweep the unmarked nodes, recording the progress along the way
+	if progress.Stage == pruneStageState {
+		if err := bc.sweepState(pruner, bc.db, &progress); err != nil {
+			return err
+		}
+		progress = pruneProgress{Stage: pruneStageTomoX}
+		if err := bc.savePruneProgress(progress); err != nil {
+			return err
+		}
+	}
+	if tomoxTriedb != nil {
+		if tomoxDb, ok := tomoxTriedb.DiskDB().(ethdb.Database); ok {
+			if err := bc.sweepState(pruner, tomoxDb, &progress); err != nil {
+				return err
+			}
+		}
+	}
+	if err := bc.db.Delete(statePruningKey); err != nil {
+		return err
+	}
+	log.Info("Pruned stale state", "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// TomoXService returns the TomoX service, nil if it doesn't run.
+func (bc *BlockChain) TomoXService() posv.TomoXService {
+	if engine, ok := bc.Engine().(*posv.Posv); ok && engine.GetTomoXService != nil {
+		return engine.GetTomoXService()
+	}
+	return nil
+}
+
+// TomoXStateCache returns the TomoX state database if the TomoX service runs.
+func (bc *BlockChain) TomoXStateCache() tomox_state.Database {
+	if tomoXService := bc.TomoXService(); tomoXService != nil {
+		return tomoXService.GetStateCache()
+	}
+	return nil
+}
+
+// retainedStateRoots returns the state and TomoX roots to keep: the ones of the
+// genesis, the recent blocks and the tries held in memory which were persisted,
+// along with the most recent persisted root the chain restarts from. Tries only held in
+// memory are built on top of these, and their nodes are marked on flush.
+func (bc *BlockChain) retainedStateRoots() ([]common.Hash, []common.Hash) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	var (
+		diskdb     = bc.stateCache.TrieDB().DiskDB()
+		tomoxCache = bc.TomoXStateCache()
+		engine, _  = bc.Engine().(*posv.Posv)
+
+		roots      []common.Hash
+		tomoxRoots []common.Hash
+		seen       = make(map[common.Hash]bool)
+	)
+	retain := func(root common.Hash) bool {
+		if seen[root] {
+			return true
+		}
+		if has, _ := diskdb.Has(root[:]); !has {
+			return false
+		}
+		seen[root] = true
+		roots = append(roots, root)
+		return true
+	}
+	retainTomoX := func(root common.Hash) {
+		if tomoxCache == nil || common.EmptyHash(root) || root == tomox_state.EmptyRoot || seen[root] {
+			return
+		}
+		if has, _ := tomoxCache.TrieDB().DiskDB().Has(root[:]); has {
+			seen[root] = true
+			tomoxRoots = append(tomoxRoots, root)
+		}
+	}
+	// Retain the genesis state, which rewinds fall back to
+	retain(bc.genesisBlock.Root())
+
+	// Retain the persisted tries referenced from memory
+	var items []interface{}
+	var priorities []float32
+	for !bc.triegc.Empty() {
+		root, priority := bc.triegc.Pop()
+		retain(root.(common.Hash))
+		items, priorities = append(items, root), append(priorities, priority)
+	}
+	for i := range items {
+		bc.triegc.Push(items[i], priorities[i])
+	}
+	if engine != nil && engine.GetTomoXService != nil {
+		if tomoXService := engine.GetTomoXService(); tomoXService != nil && tomoXService.GetTriegc() != nil {
+			triegc := tomoXService.GetTriegc()
+			items, priorities = items[:0], priorities[:0]
+			for !triegc.Empty() {
+				root, priority := triegc.Pop()
+				retainTomoX(root.(common.Hash))
+				items, priorities = append(items, root), append(priorities, priority)
+			}
+			for i := range items {
+				triegc.Push(items[i], priorities[i])
+			}
+		}
+	}
+	// Retain the recent blocks, down to the most recent persisted state
+	persisted := false
+	for block := bc.CurrentBlock(); block != nil; block = bc.GetBlock(block.ParentHash(), block.NumberU64()-1) {
+		recent := bc.CurrentBlock().NumberU64()-block.NumberU64() < triesInMemory
+		if !recent && persisted {
+			break
+		}
+		if retain(block.Root()) {
+			persisted = true
+		}
+		if engine != nil && engine.GetTomoXService != nil && bc.Config().IsTIPTomoX(block.Number()) {
+			if tomoXService := engine.GetTomoXService(); tomoXService != nil {
+				tomoxRoot, _ := tomoXService.GetTomoxStateRoot(block)
+				retainTomoX(tomoxRoot)
+			}
+		}
+		if block.NumberU64() == 0 {
+			break
+		}
+	}
+	return roots, tomoxRoots
+}
+
+// markState flags every node and contract code of a state as live.
+func (bc *BlockChain) markState(pruner *statePruner, root common.Hash) error {
+	statedb, err := state.New(root, bc.stateCache)
+	if err != nil {
+		return err
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+		if it.Hash != (common.Hash{}) {
+			pruner.bloom.add(it.Hash)
+		}
+	}
+	return it.Error
+}
+
+// sweepState deletes the unmarked trie nodes of a database, starting from the
+// recorded progress.
+func (bc *BlockChain) sweepState(pruner *statePruner, db ethdb.Database, progress *pruneProgress) error {
+	it := db.NewIterator()
+	defer it.Release()
+
+	var (
+		start   = time.Now()
+		logged  = time.Now()
+		swept   int
+		deleted int
+	)
+	if len(progress.Next) > 0 && !it.Seek(progress.Next) {
+		return it.Error()
+	}
+	for ok := len(progress.Next) > 0 || it.Next(); ok; ok = it.Next() {
+		key := it.Key()
+		// Only trie nodes and contract codes are keyed by a bare hash
+		if len(key) == common.HashLength {
+			pruner.lock.Lock()
+			if !pruner.bloom.contains(key) {
+				if err := db.Delete(key); err != nil {
+					pruner.lock.Unlock()
+					return err
+				}
+				deleted++
+			}
+			pruner.lock.Unlock()
+		}
+		if swept++; swept%pruneProgressInterval == 0 {
+			progress.Next = common.CopyBytes(key)
+			if err := bc.savePruneProgress(*progress); err != nil {
+				return err
+			}
+			select {
+			case <-bc.quit:
+				log.Info("State pruning interrupted", "stage", progress.Stage, "deleted", deleted)
+				return errStatePruningAborted
+			default:
+			}
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Sweeping stale state", "stage", progress.Stage, "swept", swept, "deleted", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+		}
+	}
+	log.Info("Swept stale state", "stage", progress.Stage, "swept", swept, "deleted", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+	return it.Error()
+}
+
+// savePruneProgress persists the position of the state pruning sweep.
+func (bc *BlockChain) savePruneProgress(progress pruneProgress) error {
+	enc, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		return err
+	}
+	return bc.db.Put(statePruningKey, enc)
+}
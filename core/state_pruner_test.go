@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/consensus/ethash"
+	"github.com/tomochain/tomochain/core/state"
+	"github.com/tomochain/tomochain/core/vm"
+	"github.com/tomochain/tomochain/crypto"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/params"
+)
+
+// Tests that pruning the state of an archive chain deletes the stale tries and
+// junk nodes, while keeping the recent and genesis states intact.
+func TestPruneState(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	engine := ethash.NewFaker()
+	genesis := new(Genesis).MustCommit(db)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, engine, db, triesInMemory+8, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{byte(i)})
+	})
+	chain, err := NewBlockChain(db, &CacheConfig{Disabled: true}, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	junk := crypto.Keccak256([]byte("junk"))
+	if err := db.Put(junk, []byte{0xc0}); err != nil {
+		t.Fatalf("failed to insert junk node: %v", err)
+	}
+	if err := chain.PruneState(); err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	if has, _ := db.Has(junk); has {
+		t.Errorf("junk node not pruned")
+	}
+	if has, _ := db.Has(blocks[0].Root().Bytes()); has {
+		t.Errorf("stale state root not pruned")
+	}
+	if has, _ := db.Has(statePruningKey); has {
+		t.Errorf("pruning progress not cleared")
+	}
+	for _, root := range []common.Hash{genesis.Root(), blocks[8].Root(), chain.CurrentBlock().Root()} {
+		statedb, err := state.New(root, state.NewDatabase(db))
+		if err != nil {
+			t.Fatalf("state %x missing: %v", root, err)
+		}
+		it := state.NewNodeIterator(statedb)
+		for it.Next() {
+		}
+		if it.Error != nil {
+			t.Errorf("state %x incomplete: %v", root, it.Error)
+		}
+	}
+}
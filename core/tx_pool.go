@@ -106,6 +106,11 @@ var (
 	queuedRateLimitCounter = metrics.NewRegisteredCounter("txpool/queued/ratelimit", nil) // Dropped due to rate limiting
 	queuedNofundsCounter   = metrics.NewRegisteredCounter("txpool/queued/nofunds", nil)   // Dropped due to out-of-funds
 
+	// Metrics for the special lane
+	specialAcceptCounter  = metrics.NewRegisteredCounter("txpool/special/accept", nil)
+	specialDiscardCounter = metrics.NewRegisteredCounter("txpool/special/discard", nil) // Dropped due to a duplicate
+	specialGauge          = metrics.NewRegisteredGauge("txpool/special/slots", nil)
+
 	// General tx metrics
 	invalidTxCounter     = metrics.NewRegisteredCounter("txpool/invalid", nil)
 	underpricedTxCounter = metrics.NewRegisteredCounter("txpool/underpriced", nil)
@@ -144,6 +149,7 @@ type TxPoolConfig struct {
 	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
+	SpecialSlots uint64 // Maximum number of executable special transaction slots for all signers
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
 }
@@ -161,6 +167,7 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalSlots:  4096,
 	AccountQueue: 64,
 	GlobalQueue:  1024,
+	SpecialSlots: 1024,
 
 	Lifetime: 3 * time.Hour,
 }
@@ -181,6 +188,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if conf.SpecialSlots < 1 {
+		log.Warn("Sanitizing invalid txpool special slots", "provided", conf.SpecialSlots, "updated", DefaultTxPoolConfig.SpecialSlots)
+		conf.SpecialSlots = DefaultTxPoolConfig.SpecialSlots
+	}
 	return conf
 }
 
@@ -191,6 +202,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 // The pool separates processable transactions (which can be applied to the
 // current state) and future transactions. Transactions move between those
 // two states over time as they are received and processed.
+//
+// The processable signing and randomize transactions of the signers are kept
+// in a bounded lane of their own, out of the slots, pricing and eviction of the
+// user transactions, so that spam can't delay them.
 type TxPool struct {
 	config       TxPoolConfig
 	chainconfig  *params.ChainConfig
@@ -216,6 +231,8 @@ type TxPool struct {
 	all     map[common.Hash]*types.Transaction // All transactions to allow lookups
 	priced  *txPricedList                      // All transactions sorted by price
 
+	specials map[common.Hash]*types.Transaction // Processable special transactions of the signers, apart from all
+
 	wg sync.WaitGroup // for shutdown sync
 
 	homestead        bool
@@ -239,6 +256,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		queue:            make(map[common.Address]*txList),
 		beats:            make(map[common.Address]time.Time),
 		all:              make(map[common.Hash]*types.Transaction),
+		specials:         make(map[common.Hash]*types.Transaction),
 		chainHeadCh:      make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:         new(big.Int).SetUint64(config.PriceLimit),
 		trc21FeeCapacity: map[common.Address]*big.Int{},
@@ -313,10 +331,12 @@ func (pool *TxPool) loop() {
 			pool.mu.RLock()
 			pending, queued := pool.stats()
 			stales := pool.priced.stales
+			specials := len(pool.specials)
 			pool.mu.RUnlock()
 
+			specialGauge.Update(int64(specials))
 			if pending != prevPending || queued != prevQueued || stales != prevStales {
-				log.Debug("Transaction pool status report", "executable", pending, "queued", queued, "stales", stales)
+				log.Debug("Transaction pool status report", "executable", pending, "queued", queued, "special", specials, "stales", stales)
 				prevPending, prevQueued, prevStales = pending, queued, stales
 			}
 
@@ -547,6 +567,24 @@ func (pool *TxPool) Pending() (map[common.Address]types.Transactions, error) {
 	return pending, nil
 }
 
+// Specials retrieves the processable special transactions kept in their lane,
+// groupped by origin account and sorted by nonce. The returned transaction set
+// is a copy and can be freely modified by calling code.
+func (pool *TxPool) Specials() map[common.Address]types.Transactions {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	specials := make(map[common.Address]types.Transactions)
+	for _, tx := range pool.specials {
+		from, _ := types.Sender(pool.signer, tx) // already validated
+		specials[from] = append(specials[from], tx)
+	}
+	for _, txs := range specials {
+		sort.Sort(types.TxByNonce(txs))
+	}
+	return specials
+}
+
 // local retrieves all currently known local transactions, groupped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -604,7 +642,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	// Drop non-local transactions under our own minimal accepted gas price
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
 	if !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0 {
-		if !tx.IsSpecialTransaction() || (pool.IsSigner != nil && !pool.IsSigner(from)) {
+		if !pool.isSpecial(from, tx) {
 			return ErrUnderpriced
 		}
 	}
@@ -674,7 +712,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	// If the transaction is already known, discard it
 	hash := tx.Hash()
-	if pool.all[hash] != nil {
+	if pool.lookup(hash) != nil {
 		log.Trace("Discarding already known transaction", "hash", hash)
 		return false, fmt.Errorf("known transaction: %x", hash)
 	}
@@ -686,7 +724,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		return false, err
 	}
 	from, _ := types.Sender(pool.signer, tx) // already validated
-	if tx.IsSpecialTransaction() && pool.IsSigner != nil && pool.IsSigner(from) && pool.pendingState.GetNonce(from) == tx.Nonce() {
+	if pool.isSpecial(from, tx) && pool.pendingState.GetNonce(from) == tx.Nonce() {
 		return pool.promoteSpecialTx(from, tx)
 	}
 	// If the transaction pool is full, discard underpriced transactions
@@ -716,8 +754,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		}
 		// New transaction is better, replace old one
 		if old != nil {
-			delete(pool.all, old.Hash())
-			pool.priced.Removed()
+			pool.forget(old.Hash())
 			pendingReplaceCounter.Inc(1)
 		}
 		pool.all[tx.Hash()] = tx
@@ -750,6 +787,9 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 //
 // Note, this method assumes the pool lock is held!
 func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, error) {
+	// Demoted special transactions leave their lane until processable again
+	delete(pool.specials, hash)
+
 	// Try to insert the transaction into the future queue
 	from, _ := types.Sender(pool.signer, tx) // already validated
 	if pool.queue[from] == nil {
@@ -763,8 +803,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	}
 	// Discard any previous transaction and mark this
 	if old != nil {
-		delete(pool.all, old.Hash())
-		pool.priced.Removed()
+		pool.forget(old.Hash())
 		queuedReplaceCounter.Inc(1)
 	}
 	pool.all[hash] = tx
@@ -797,21 +836,26 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	inserted, old := list.Add(tx, pool.config.PriceBump)
 	if !inserted {
 		// An older transaction was better, discard this
-		delete(pool.all, hash)
-		pool.priced.Removed()
+		pool.forget(hash)
 
 		pendingDiscardCounter.Inc(1)
 		return
 	}
 	// Otherwise discard any previous transaction and mark this
 	if old != nil {
-		delete(pool.all, old.Hash())
-		pool.priced.Removed()
+		pool.forget(old.Hash())
 
 		pendingReplaceCounter.Inc(1)
 	}
-	// Failsafe to work around direct pending inserts (tests)
-	if pool.all[hash] == nil {
+	// Move the special transactions of the signers into their lane if it has room
+	if pool.isSpecial(addr, tx) && uint64(len(pool.specials)) < pool.config.SpecialSlots {
+		if pool.all[hash] != nil {
+			pool.forget(hash)
+		}
+		pool.specials[hash] = tx
+		specialAcceptCounter.Inc(1)
+	} else if pool.all[hash] == nil {
+		// Failsafe to work around direct pending inserts (tests)
 		pool.all[hash] = tx
 		pool.priced.Put(tx)
 	}
@@ -822,6 +866,11 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	go pool.txFeed.Send(TxPreEvent{tx})
 }
 
+// promoteSpecialTx adds a processable special transaction of a signer to its
+// lane, the pending nonce of the signer being the one of the transaction. The
+// transaction is kept with the user ones if the lane is full.
+//
+// Note, this method assumes the pool lock is held!
 func (pool *TxPool) promoteSpecialTx(addr common.Address, tx *types.Transaction) (bool, error) {
 	// Try to insert the transaction into the pending queue
 	if pool.pending[addr] == nil {
@@ -830,12 +879,12 @@ func (pool *TxPool) promoteSpecialTx(addr common.Address, tx *types.Transaction)
 	list := pool.pending[addr]
 	old := list.txs.Get(tx.Nonce())
 	if old != nil && old.IsSpecialTransaction() {
+		specialDiscardCounter.Inc(1)
 		return false, ErrDuplicateSpecialTransaction
 	}
 	// Otherwise discard any previous transaction and mark this
 	if old != nil {
-		delete(pool.all, old.Hash())
-		pool.priced.Removed()
+		pool.forget(old.Hash())
 		pendingReplaceCounter.Inc(1)
 	}
 	list.txs.Put(tx)
@@ -845,9 +894,12 @@ func (pool *TxPool) promoteSpecialTx(addr common.Address, tx *types.Transaction)
 	if gas := tx.Gas(); list.gascap < gas {
 		list.gascap = gas
 	}
-	// Failsafe to work around direct pending inserts (tests)
-	if pool.all[tx.Hash()] == nil {
+	if uint64(len(pool.specials)) < pool.config.SpecialSlots {
+		pool.specials[tx.Hash()] = tx
+		specialAcceptCounter.Inc(1)
+	} else {
 		pool.all[tx.Hash()] = tx
+		pool.priced.Put(tx)
 	}
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.beats[addr] = time.Now()
@@ -947,7 +999,7 @@ func (pool *TxPool) Status(hashes []common.Hash) []TxStatus {
 
 	status := make([]TxStatus, len(hashes))
 	for i, hash := range hashes {
-		if tx := pool.all[hash]; tx != nil {
+		if tx := pool.lookup(hash); tx != nil {
 			from, _ := types.Sender(pool.signer, tx) // already validated
 			if pool.pending[from] != nil && pool.pending[from].txs.items[tx.Nonce()] != nil {
 				status[i] = TxStatusPending
@@ -965,22 +1017,46 @@ func (pool *TxPool) Get(hash common.Hash) *types.Transaction {
 	pool.mu.RLock()
 	defer pool.mu.RUnlock()
 
+	return pool.lookup(hash)
+}
+
+// lookup returns a transaction of the pool, be it in the special lane or not.
+func (pool *TxPool) lookup(hash common.Hash) *types.Transaction {
+	if tx := pool.specials[hash]; tx != nil {
+		return tx
+	}
 	return pool.all[hash]
 }
 
+// isSpecial reports whether a transaction belongs to the special lane, being a
+// signing or randomize transaction of a signer.
+func (pool *TxPool) isSpecial(from common.Address, tx *types.Transaction) bool {
+	return tx.IsSpecialTransaction() && pool.IsSigner != nil && pool.IsSigner(from)
+}
+
+// forget drops a transaction taken out of the pending or queued lists from the
+// lookups of the pool.
+func (pool *TxPool) forget(hash common.Hash) {
+	if _, ok := pool.specials[hash]; ok {
+		delete(pool.specials, hash)
+		return
+	}
+	delete(pool.all, hash)
+	pool.priced.Removed()
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 func (pool *TxPool) removeTx(hash common.Hash) {
 	// Fetch the transaction we wish to delete
-	tx, ok := pool.all[hash]
-	if !ok {
+	tx := pool.lookup(hash)
+	if tx == nil {
 		return
 	}
 	addr, _ := types.Sender(pool.signer, tx) // already validated during insertion
 
 	// Remove it from the list of known transactions
-	delete(pool.all, hash)
-	pool.priced.Removed()
+	pool.forget(hash)
 
 	// Remove the transaction from the pending lists and reset the account nonce
 	if pending := pool.pending[addr]; pending != nil {
@@ -1034,16 +1110,14 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		for _, tx := range list.Forward(pool.currentState.GetNonce(addr)) {
 			hash := tx.Hash()
 			log.Trace("Removed old queued transaction", "hash", hash)
-			delete(pool.all, hash)
-			pool.priced.Removed()
+			pool.forget(hash)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas, pool.trc21FeeCapacity)
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable queued transaction", "hash", hash)
-			delete(pool.all, hash)
-			pool.priced.Removed()
+			pool.forget(hash)
 			queuedNofundsCounter.Inc(1)
 		}
 		// Gather all executable transactions and promote them
@@ -1056,8 +1130,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		if !pool.locals.contains(addr) {
 			for _, tx := range list.Cap(int(pool.config.AccountQueue)) {
 				hash := tx.Hash()
-				delete(pool.all, hash)
-				pool.priced.Removed()
+				pool.forget(hash)
 				queuedRateLimitCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
@@ -1067,19 +1140,28 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			delete(pool.queue, addr)
 		}
 	}
-	// If the pending limit is overflown, start equalizing allowances
+	// If the pending limit is overflown, start equalizing allowances, leaving
+	// the special lane out of the slots
 	pending := uint64(0)
 	for _, list := range pool.pending {
 		pending += uint64(list.Len())
 	}
+	pending -= uint64(len(pool.specials))
+
 	if pending > pool.config.GlobalSlots {
 		pendingBeforeCap := pending
+
+		specials := make(map[common.Address]int)
+		for _, tx := range pool.specials {
+			from, _ := types.Sender(pool.signer, tx) // already validated
+			specials[from]++
+		}
 		// Assemble a spam order to penalize large transactors first
 		spammers := prque.New()
 		for addr, list := range pool.pending {
 			// Only evict transactions from high rollers
-			if !pool.locals.contains(addr) && uint64(list.Len()) > pool.config.AccountSlots {
-				spammers.Push(addr, float32(list.Len()))
+			if users := list.Len() - specials[addr]; !pool.locals.contains(addr) && uint64(users) > pool.config.AccountSlots {
+				spammers.Push(addr, float32(users))
 			}
 		}
 		// Gradually drop transactions from offenders
@@ -1101,8 +1183,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 						for _, tx := range list.Cap(list.Len() - 1) {
 							// Drop the transaction from the global pools too
 							hash := tx.Hash()
-							delete(pool.all, hash)
-							pool.priced.Removed()
+							pool.forget(hash)
 
 							// Update the account nonce to the dropped transaction
 							if nonce := tx.Nonce(); pool.pendingState.GetNonce(offenders[i]) > nonce {
@@ -1123,8 +1204,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 					for _, tx := range list.Cap(list.Len() - 1) {
 						// Drop the transaction from the global pools too
 						hash := tx.Hash()
-						delete(pool.all, hash)
-						pool.priced.Removed()
+						pool.forget(hash)
 
 						// Update the account nonce to the dropped transaction
 						if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr) > nonce {
@@ -1192,16 +1272,14 @@ func (pool *TxPool) demoteUnexecutables() {
 		for _, tx := range list.Forward(nonce) {
 			hash := tx.Hash()
 			log.Trace("Removed old pending transaction", "hash", hash)
-			delete(pool.all, hash)
-			pool.priced.Removed()
+			pool.forget(hash)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas, pool.trc21FeeCapacity)
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
-			delete(pool.all, hash)
-			pool.priced.Removed()
+			pool.forget(hash)
 			pendingNofundsCounter.Inc(1)
 		}
 		for _, tx := range invalids {
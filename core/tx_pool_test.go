@@ -93,13 +93,14 @@ func validateTxPoolInternals(pool *TxPool) error {
 	pool.mu.RLock()
 	defer pool.mu.RUnlock()
 
-	// Ensure the total transaction set is consistent with pending + queued
+	// Ensure the total transaction set is consistent with pending + queued,
+	// the special lane being kept out of the priced list
 	pending, queued := pool.stats()
-	if total := len(pool.all); total != pending+queued {
+	if total := len(pool.all) + len(pool.specials); total != pending+queued {
 		return fmt.Errorf("total transaction count %d != %d pending + %d queued", total, pending, queued)
 	}
-	if priced := pool.priced.items.Len() - pool.priced.stales; priced != pending+queued {
-		return fmt.Errorf("total priced transaction count %d != %d pending + %d queued", priced, pending, queued)
+	if priced := pool.priced.items.Len() - pool.priced.stales; priced != pending+queued-len(pool.specials) {
+		return fmt.Errorf("total priced transaction count %d != %d pending + %d queued - %d special", priced, pending, queued, len(pool.specials))
 	}
 	// Ensure the next nonce to assign is the correct one
 	for addr, txs := range pool.pending {
@@ -1684,6 +1685,98 @@ func TestTransactionStatusCheck(t *testing.T) {
 	}
 }
 
+// specialTransaction creates a block signing transaction, which only the
+// signers may send for free.
+func specialTransaction(nonce uint64, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, common.HexToAddress(common.BlockSigners), big.NewInt(0), 200000, big.NewInt(0), nil), types.HomesteadSigner{}, key)
+	return tx
+}
+
+// Tests that the special transactions of the signers are kept in their own
+// bounded lane, out of the reach of the user transactions flooding the pool,
+// and with the user transactions once the lane is full.
+func TestSpecialTransactionLane(t *testing.T) {
+	t.Parallel()
+
+	// Create the pool to test the lane with
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.GlobalSlots = config.AccountSlots
+	config.SpecialSlots = 2
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	signers := make([]*ecdsa.PrivateKey, 3)
+	isSigner := make(map[common.Address]bool)
+	for i := 0; i < len(signers); i++ {
+		signers[i], _ = crypto.GenerateKey()
+		isSigner[crypto.PubkeyToAddress(signers[i].PublicKey)] = true
+	}
+	pool.IsSigner = func(address common.Address) bool { return isSigner[address] }
+
+	// Only the signers may send free special transactions, as many as the lane holds
+	user, _ := crypto.GenerateKey()
+	if err := pool.AddRemote(specialTransaction(0, user)); err != ErrUnderpriced {
+		t.Fatalf("user special transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	specials := types.Transactions{specialTransaction(0, signers[0]), specialTransaction(0, signers[1])}
+	for i, tx := range specials {
+		if err := pool.AddRemote(tx); err != nil {
+			t.Fatalf("special transaction %d rejected: %v", i, err)
+		}
+	}
+	overflow := specialTransaction(0, signers[2])
+	if err := pool.AddRemote(overflow); err != nil {
+		t.Fatalf("special transaction over the lane rejected: %v", err)
+	}
+	if pool.Get(overflow.Hash()) == nil || len(pool.Specials()) != 2 {
+		t.Fatalf("special transaction over the lane not kept with the user ones")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Flood the pool with user transactions, overflowing the global slots
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+		txs := types.Transactions{}
+		for j := 0; j < int(config.AccountSlots)*2; j++ {
+			txs = append(txs, transaction(uint64(j), 100000, key))
+		}
+		pool.AddRemotes(txs)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	for i, status := range pool.Status([]common.Hash{specials[0].Hash(), specials[1].Hash()}) {
+		if status != TxStatusPending {
+			t.Fatalf("special transaction %d status mismatch: have %v, want %v", i, status, TxStatusPending)
+		}
+	}
+	if lane := pool.Specials(); len(lane) != 2 {
+		t.Fatalf("special lane senders mismatch: have %d, want 2", len(lane))
+	}
+	// Include the first special transaction, freeing a slot of the lane
+	pool.currentState.SetNonce(crypto.PubkeyToAddress(signers[0].PublicKey), 1)
+	pool.lockedReset(nil, nil)
+
+	next := specialTransaction(1, signers[0])
+	if err := pool.AddRemote(next); err != nil {
+		t.Fatalf("special transaction rejected after inclusion: %v", err)
+	}
+	if lane := pool.Specials(); len(lane[crypto.PubkeyToAddress(signers[0].PublicKey)]) != 1 {
+		t.Fatalf("special transaction not moved into the freed lane slot")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Benchmarks the speed of validating the contents of the pending queue of the
 // transaction pool.
 func BenchmarkPendingDemotion100(b *testing.B)   { benchmarkPendingDemotion(b, 100) }
@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/common/hexutil"
+	"github.com/tomochain/tomochain/crypto"
+)
+
+// ErrInvalidAttestationSig is returned if the signature of an attestation is
+// malformed or its signer can't be recovered.
+var ErrInvalidAttestationSig = errors.New("invalid attestation signature")
+
+// Attestation is a masternode's consensus level confirmation that it has
+// verified and imported a block. Since TIPAttestation attestations replace the
+// sign transactions sent to the BlockSigner contract: they are gossiped on the
+// eth protocol and collected into the header of one of the following blocks.
+//go:generate gencodec -type Attestation -field-override attestationMarshaling -out gen_attestation_json.go
+
+type Attestation struct {
+	Number    uint64      `json:"number"    gencodec:"required"` // Number of the attested block
+	BlockHash common.Hash `json:"blockHash" gencodec:"required"` // Hash of the attested block
+	Signature []byte      `json:"signature" gencodec:"required"` // Masternode signature over SigHash
+
+	// caches
+	hash atomic.Value
+	from atomic.Value
+}
+
+// field type overrides for gencodec
+type attestationMarshaling struct {
+	Number    hexutil.Uint64
+	Signature hexutil.Bytes
+}
+
+// NewAttestation creates an unsigned attestation for the given block.
+func NewAttestation(number uint64, hash common.Hash) *Attestation {
+	return &Attestation{Number: number, BlockHash: hash}
+}
+
+// SigHash returns the hash which is signed by the attesting masternode.
+func (a *Attestation) SigHash() common.Hash {
+	return rlpHash([]interface{}{
+		a.Number,
+		a.BlockHash,
+	})
+}
+
+// Hash returns the hash identifying the signed attestation.
+func (a *Attestation) Hash() common.Hash {
+	if hash := a.hash.Load(); hash != nil {
+		return hash.(common.Hash)
+	}
+	v := rlpHash(a)
+	a.hash.Store(v)
+	return v
+}
+
+// WithSignature returns a new attestation with the given signature.
+func (a *Attestation) WithSignature(sig []byte) *Attestation {
+	cpy := &Attestation{Number: a.Number, BlockHash: a.BlockHash}
+	cpy.Signature = common.CopyBytes(sig)
+	return cpy
+}
+
+// Signer recovers the address of the masternode which signed the attestation.
+// The result is cached so that attestations read from headers are only
+// recovered once.
+func (a *Attestation) Signer() (common.Address, error) {
+	if from := a.from.Load(); from != nil {
+		return from.(common.Address), nil
+	}
+	if len(a.Signature) != 65 {
+		return common.Address{}, ErrInvalidAttestationSig
+	}
+	pubkey, err := crypto.Ecrecover(a.SigHash().Bytes(), a.Signature)
+	if err != nil {
+		return common.Address{}, ErrInvalidAttestationSig
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	a.from.Store(signer)
+	return signer, nil
+}
+
+// Attestations is a list of attestations.
+type Attestations []*Attestation
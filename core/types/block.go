@@ -87,6 +87,12 @@ type Header struct {
 	Validators  []byte         `json:"validators"       gencodec:"required"`
 	Validator   []byte         `json:"validator"        gencodec:"required"`
 	Penalties   []byte         `json:"penalties"        gencodec:"required"`
+
+	// Attestations and Evidences are only present after TIPAttestation and
+	// TIPSlashing. The fields are optional so that headers without them keep
+	// their original encoding.
+	Attestations []*Attestation `json:"attestations" rlp:"optional"`
+	Evidences    []*Evidence    `json:"evidences"    rlp:"optional"`
 }
 
 // field type overrides for gencodec
@@ -97,6 +103,9 @@ type headerMarshaling struct {
 	GasUsed    hexutil.Uint64
 	Time       *hexutil.Big
 	Extra      hexutil.Bytes
+	Validators hexutil.Bytes
+	Validator  hexutil.Bytes
+	Penalties  hexutil.Bytes
 	Hash       common.Hash `json:"hash"` // adds call to Hash() in MarshalJSON
 }
 
@@ -127,7 +136,7 @@ func (h *Header) HashNoNonce() common.Hash {
 
 // HashNoNonce returns the hash which is used as input for the proof-of-work search.
 func (h *Header) HashNoValidator() common.Hash {
-	return rlpHash([]interface{}{
+	fields := []interface{}{
 		h.ParentHash,
 		h.UncleHash,
 		h.Coinbase,
@@ -146,7 +155,14 @@ func (h *Header) HashNoValidator() common.Hash {
 		h.Validators,
 		[]byte{},
 		h.Penalties,
-	})
+	}
+	if len(h.Attestations) > 0 || len(h.Evidences) > 0 {
+		fields = append(fields, h.Attestations)
+	}
+	if len(h.Evidences) > 0 {
+		fields = append(fields, h.Evidences)
+	}
+	return rlpHash(fields)
 }
 
 // Size returns the approximate memory used by all internal contents. It is used
@@ -285,6 +301,14 @@ func CopyHeader(h *Header) *Header {
 		cpy.Validator = make([]byte, len(h.Validator))
 		copy(cpy.Validator, h.Validator)
 	}
+	if len(h.Attestations) > 0 {
+		cpy.Attestations = make([]*Attestation, len(h.Attestations))
+		copy(cpy.Attestations, h.Attestations)
+	}
+	if len(h.Evidences) > 0 {
+		cpy.Evidences = make([]*Evidence, len(h.Evidences))
+		copy(cpy.Evidences, h.Evidences)
+	}
 	return &cpy
 }
 
@@ -353,6 +377,9 @@ func (b *Block) Extra() []byte            { return common.CopyBytes(b.header.Ext
 func (b *Block) Penalties() []byte        { return common.CopyBytes(b.header.Penalties) }
 func (b *Block) Validator() []byte        { return common.CopyBytes(b.header.Validator) }
 
+func (b *Block) Attestations() []*Attestation { return b.header.Attestations }
+func (b *Block) Evidences() []*Evidence       { return b.header.Evidences }
+
 func (b *Block) Header() *Header { return CopyHeader(b.header) }
 
 // Body returns the non-header content of the block.
@@ -17,11 +17,13 @@
 package types
 
 import (
+	"encoding/json"
 	"math/big"
 	"testing"
 
 	"bytes"
 	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/crypto"
 	"github.com/tomochain/tomochain/rlp"
 	"reflect"
 )
@@ -59,3 +61,128 @@ func TestBlockEncoding(t *testing.T) {
 		t.Errorf("encoded block mismatch:\ngot:  %x\nwant: %x", ourBlockEnc, blockEnc)
 	}
 }
+
+func TestHeaderAttestationsEncoding(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	header := &Header{Number: big.NewInt(16), Difficulty: big.NewInt(1), Time: big.NewInt(1), Extra: []byte{}}
+	plain := header.Hash()
+
+	attestation := NewAttestation(15, common.HexToHash("0x1234"))
+	sig, err := crypto.Sign(attestation.SigHash().Bytes(), key)
+	if err != nil {
+		t.Fatal("sign error: ", err)
+	}
+	header.Attestations = []*Attestation{attestation.WithSignature(sig)}
+	if header.Hash() == plain {
+		t.Fatal("attestations are not part of the header hash")
+	}
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	var decoded Header
+	if err := rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if decoded.Hash() != header.Hash() {
+		t.Errorf("hash mismatch: got %x, want %x", decoded.Hash(), header.Hash())
+	}
+	if len(decoded.Attestations) != 1 {
+		t.Fatalf("attestations mismatch: got %d, want 1", len(decoded.Attestations))
+	}
+	signer, err := decoded.Attestations[0].Signer()
+	if err != nil {
+		t.Fatal("recover error: ", err)
+	}
+	if signer != addr {
+		t.Errorf("signer mismatch: got %x, want %x", signer, addr)
+	}
+	decoded.Attestations = nil
+	if decoded.Hash() != plain {
+		t.Errorf("header without attestations changed hash: got %x, want %x", decoded.Hash(), plain)
+	}
+}
+
+func TestHeaderEvidencesEncoding(t *testing.T) {
+	header := &Header{Number: big.NewInt(20), Difficulty: big.NewInt(1), Time: big.NewInt(1), Extra: []byte{}}
+	plain := header.Hash()
+
+	first := &Header{ParentHash: common.HexToHash("0x01"), Number: big.NewInt(18), Difficulty: big.NewInt(1), Time: big.NewInt(1), Extra: []byte{}}
+	second := CopyHeader(first)
+	second.Time = big.NewInt(2)
+	header.Evidences = []*Evidence{NewEvidence(first, second)}
+	if header.Hash() == plain {
+		t.Fatal("evidences are not part of the header hash")
+	}
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	var decoded Header
+	if err := rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if decoded.Hash() != header.Hash() {
+		t.Errorf("hash mismatch: got %x, want %x", decoded.Hash(), header.Hash())
+	}
+	if len(decoded.Attestations) != 0 || len(decoded.Evidences) != 1 {
+		t.Fatalf("fields mismatch: got %d attestations and %d evidences, want 0 and 1", len(decoded.Attestations), len(decoded.Evidences))
+	}
+	if decoded.Evidences[0].First.Hash() != first.Hash() || decoded.Evidences[0].Second.Hash() != second.Hash() {
+		t.Errorf("evidence headers mismatch")
+	}
+	decoded.Evidences = nil
+	if decoded.Hash() != plain {
+		t.Errorf("header without evidences changed hash: got %x, want %x", decoded.Hash(), plain)
+	}
+}
+
+func TestHeaderAttestationsJSON(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	attestation := NewAttestation(15, common.HexToHash("0x1234"))
+	sig, err := crypto.Sign(attestation.SigHash().Bytes(), key)
+	if err != nil {
+		t.Fatal("sign error: ", err)
+	}
+	header := &Header{Number: big.NewInt(16), Difficulty: big.NewInt(1), Time: big.NewInt(1), Extra: []byte{}}
+	header.Attestations = []*Attestation{attestation.WithSignature(sig)}
+
+	enc, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	var decoded Header
+	if err := json.Unmarshal(enc, &decoded); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if decoded.Hash() != header.Hash() {
+		t.Errorf("hash mismatch: got %x, want %x", decoded.Hash(), header.Hash())
+	}
+}
+
+func TestHeaderEvidencesJSON(t *testing.T) {
+	first := &Header{ParentHash: common.HexToHash("0x01"), Number: big.NewInt(18), Difficulty: big.NewInt(1), Time: big.NewInt(1), Extra: []byte{}, Validator: []byte{0x01}}
+	second := CopyHeader(first)
+	second.Time = big.NewInt(2)
+
+	header := &Header{Number: big.NewInt(20), Difficulty: big.NewInt(1), Time: big.NewInt(1), Extra: []byte{}}
+	header.Evidences = []*Evidence{NewValidationEvidence(first, second)}
+
+	enc, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	var decoded Header
+	if err := json.Unmarshal(enc, &decoded); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if decoded.Hash() != header.Hash() {
+		t.Errorf("hash mismatch: got %x, want %x", decoded.Hash(), header.Hash())
+	}
+	if len(decoded.Evidences) != 1 || decoded.Evidences[0].Kind != ValidationEvidence {
+		t.Fatalf("evidences mismatch: got %v", decoded.Evidences)
+	}
+}
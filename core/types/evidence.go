@@ -0,0 +1,75 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"sync/atomic"
+
+	"github.com/tomochain/tomochain/common"
+)
+
+// EvidenceKind tells which signature of the conflicting headers of an evidence
+// the offender made.
+type EvidenceKind uint8
+
+const (
+	// SealEvidence proves that a creator sealed both headers.
+	SealEvidence EvidenceKind = iota
+
+	// ValidationEvidence proves that an M2 validator double validated both
+	// headers, sealed by the same creator.
+	ValidationEvidence
+)
+
+// Evidence proves that a masternode equivocated by sealing or validating two
+// different headers on top of the same parent. Since TIPSlashing evidences are
+// included in the header of a later block and the offender is penalized at the
+// next checkpoint.
+type Evidence struct {
+	First  *Header      `json:"first"`               // First header signed by the offender
+	Second *Header      `json:"second"`              // Conflicting header with the same number and parent
+	Kind   EvidenceKind `json:"kind" rlp:"optional"` // Signature of the headers the offender made
+
+	// caches
+	hash atomic.Value
+}
+
+// NewEvidence creates an evidence of a creator sealing two conflicting headers.
+func NewEvidence(first, second *Header) *Evidence {
+	return &Evidence{First: CopyHeader(first), Second: CopyHeader(second)}
+}
+
+// NewValidationEvidence creates an evidence of an M2 validator validating two
+// conflicting headers.
+func NewValidationEvidence(first, second *Header) *Evidence {
+	return &Evidence{First: CopyHeader(first), Second: CopyHeader(second), Kind: ValidationEvidence}
+}
+
+// Number returns the number of the conflicting headers.
+func (e *Evidence) Number() uint64 { return e.First.Number.Uint64() }
+
+// Hash returns the hash identifying the evidence.
+func (e *Evidence) Hash() common.Hash {
+	if hash := e.hash.Load(); hash != nil {
+		return hash.(common.Hash)
+	}
+	v := rlpHash(e)
+	e.hash.Store(v)
+	return v
+}
+
+// Evidences is a list of evidences.
+type Evidences []*Evidence
@@ -15,22 +15,27 @@ var _ = (*headerMarshaling)(nil)
 
 func (h Header) MarshalJSON() ([]byte, error) {
 	type Header struct {
-		ParentHash  common.Hash    `json:"parentHash"       gencodec:"required"`
-		UncleHash   common.Hash    `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase    common.Address `json:"miner"            gencodec:"required"`
-		Root        common.Hash    `json:"stateRoot"        gencodec:"required"`
-		TxHash      common.Hash    `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash common.Hash    `json:"receiptsRoot"     gencodec:"required"`
-		Bloom       Bloom          `json:"logsBloom"        gencodec:"required"`
-		Difficulty  *hexutil.Big   `json:"difficulty"       gencodec:"required"`
-		Number      *hexutil.Big   `json:"number"           gencodec:"required"`
-		GasLimit    hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
-		GasUsed     hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
-		Time        *hexutil.Big   `json:"timestamp"        gencodec:"required"`
-		Extra       hexutil.Bytes  `json:"extraData"        gencodec:"required"`
-		MixDigest   common.Hash    `json:"mixHash"          gencodec:"required"`
-		Nonce       BlockNonce     `json:"nonce"            gencodec:"required"`
-		Hash        common.Hash    `json:"hash"`
+		ParentHash   common.Hash    `json:"parentHash"       gencodec:"required"`
+		UncleHash    common.Hash    `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase     common.Address `json:"miner"            gencodec:"required"`
+		Root         common.Hash    `json:"stateRoot"        gencodec:"required"`
+		TxHash       common.Hash    `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash  common.Hash    `json:"receiptsRoot"     gencodec:"required"`
+		Bloom        Bloom          `json:"logsBloom"        gencodec:"required"`
+		Difficulty   *hexutil.Big   `json:"difficulty"       gencodec:"required"`
+		Number       *hexutil.Big   `json:"number"           gencodec:"required"`
+		GasLimit     hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
+		GasUsed      hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
+		Time         *hexutil.Big   `json:"timestamp"        gencodec:"required"`
+		Extra        hexutil.Bytes  `json:"extraData"        gencodec:"required"`
+		MixDigest    common.Hash    `json:"mixHash"          gencodec:"required"`
+		Nonce        BlockNonce     `json:"nonce"            gencodec:"required"`
+		Validators   hexutil.Bytes  `json:"validators"       gencodec:"required"`
+		Validator    hexutil.Bytes  `json:"validator"        gencodec:"required"`
+		Penalties    hexutil.Bytes  `json:"penalties"        gencodec:"required"`
+		Attestations []*Attestation `json:"attestations" rlp:"optional"`
+		Evidences    []*Evidence    `json:"evidences"    rlp:"optional"`
+		Hash         common.Hash    `json:"hash"`
 	}
 	var enc Header
 	enc.ParentHash = h.ParentHash
@@ -48,27 +53,37 @@ func (h Header) MarshalJSON() ([]byte, error) {
 	enc.Extra = h.Extra
 	enc.MixDigest = h.MixDigest
 	enc.Nonce = h.Nonce
+	enc.Validators = h.Validators
+	enc.Validator = h.Validator
+	enc.Penalties = h.Penalties
+	enc.Attestations = h.Attestations
+	enc.Evidences = h.Evidences
 	enc.Hash = h.Hash()
 	return json.Marshal(&enc)
 }
 
 func (h *Header) UnmarshalJSON(input []byte) error {
 	type Header struct {
-		ParentHash  *common.Hash    `json:"parentHash"       gencodec:"required"`
-		UncleHash   *common.Hash    `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase    *common.Address `json:"miner"            gencodec:"required"`
-		Root        *common.Hash    `json:"stateRoot"        gencodec:"required"`
-		TxHash      *common.Hash    `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash *common.Hash    `json:"receiptsRoot"     gencodec:"required"`
-		Bloom       *Bloom          `json:"logsBloom"        gencodec:"required"`
-		Difficulty  *hexutil.Big    `json:"difficulty"       gencodec:"required"`
-		Number      *hexutil.Big    `json:"number"           gencodec:"required"`
-		GasLimit    *hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
-		GasUsed     *hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
-		Time        *hexutil.Big    `json:"timestamp"        gencodec:"required"`
-		Extra       *hexutil.Bytes  `json:"extraData"        gencodec:"required"`
-		MixDigest   *common.Hash    `json:"mixHash"          gencodec:"required"`
-		Nonce       *BlockNonce     `json:"nonce"            gencodec:"required"`
+		ParentHash   *common.Hash    `json:"parentHash"       gencodec:"required"`
+		UncleHash    *common.Hash    `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase     *common.Address `json:"miner"            gencodec:"required"`
+		Root         *common.Hash    `json:"stateRoot"        gencodec:"required"`
+		TxHash       *common.Hash    `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash  *common.Hash    `json:"receiptsRoot"     gencodec:"required"`
+		Bloom        *Bloom          `json:"logsBloom"        gencodec:"required"`
+		Difficulty   *hexutil.Big    `json:"difficulty"       gencodec:"required"`
+		Number       *hexutil.Big    `json:"number"           gencodec:"required"`
+		GasLimit     *hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
+		GasUsed      *hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
+		Time         *hexutil.Big    `json:"timestamp"        gencodec:"required"`
+		Extra        *hexutil.Bytes  `json:"extraData"        gencodec:"required"`
+		MixDigest    *common.Hash    `json:"mixHash"          gencodec:"required"`
+		Nonce        *BlockNonce     `json:"nonce"            gencodec:"required"`
+		Validators   *hexutil.Bytes  `json:"validators"       gencodec:"required"`
+		Validator    *hexutil.Bytes  `json:"validator"        gencodec:"required"`
+		Penalties    *hexutil.Bytes  `json:"penalties"        gencodec:"required"`
+		Attestations []*Attestation  `json:"attestations" rlp:"optional"`
+		Evidences    []*Evidence     `json:"evidences"    rlp:"optional"`
 	}
 	var dec Header
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -134,5 +149,23 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 		return errors.New("missing required field 'nonce' for Header")
 	}
 	h.Nonce = *dec.Nonce
+	if dec.Validators == nil {
+		return errors.New("missing required field 'validators' for Header")
+	}
+	h.Validators = *dec.Validators
+	if dec.Validator == nil {
+		return errors.New("missing required field 'validator' for Header")
+	}
+	h.Validator = *dec.Validator
+	if dec.Penalties == nil {
+		return errors.New("missing required field 'penalties' for Header")
+	}
+	h.Penalties = *dec.Penalties
+	if dec.Attestations != nil {
+		h.Attestations = dec.Attestations
+	}
+	if dec.Evidences != nil {
+		h.Evidences = dec.Evidences
+	}
 	return nil
 }
@@ -0,0 +1,42 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/tomochain/tomochain/common"
+)
+
+// InternalTx is a call, contract creation or self destruct made by a
+// transaction from within the EVM. These are not part of the consensus data,
+// they are gathered by tracing the transactions of the canonical blocks.
+type InternalTx struct {
+	TxHash       common.Hash
+	TxIndex      uint
+	TraceAddress []uint64 // Position of the call in the call tree of the transaction
+
+	Type  string // CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE, CREATE2 or SELFDESTRUCT
+	From  common.Address
+	To    common.Address // Created contract for CREATE and CREATE2, beneficiary for SELFDESTRUCT
+	Value *big.Int
+
+	Gas     uint64
+	GasUsed uint64
+	Input   []byte
+	Output  []byte
+	Error   string
+}
@@ -18,14 +18,17 @@ package vm
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"math/big"
 
 	"github.com/tomochain/tomochain/common"
 	"github.com/tomochain/tomochain/common/math"
 	"github.com/tomochain/tomochain/crypto"
+	"github.com/tomochain/tomochain/crypto/blake2b"
 	"github.com/tomochain/tomochain/crypto/bn256"
 	"github.com/tomochain/tomochain/params"
+	"github.com/tomochain/tomochain/tomox/tomox_state"
 	"golang.org/x/crypto/ripemd160"
 )
 
@@ -59,6 +62,48 @@ var PrecompiledContractsByzantium = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{8}): &bn256Pairing{},
 }
 
+// PrecompiledContractsIstanbul contains the default set of pre-compiled Ethereum
+// contracts used after TIPIstanbul.
+var PrecompiledContractsIstanbul = map[common.Address]PrecompiledContract{
+	common.BytesToAddress([]byte{1}): &ecrecover{},
+	common.BytesToAddress([]byte{2}): &sha256hash{},
+	common.BytesToAddress([]byte{3}): &ripemd160hash{},
+	common.BytesToAddress([]byte{4}): &dataCopy{},
+	common.BytesToAddress([]byte{5}): &bigModExp{},
+	common.BytesToAddress([]byte{6}): &bn256AddIstanbul{},
+	common.BytesToAddress([]byte{7}): &bn256ScalarMulIstanbul{},
+	common.BytesToAddress([]byte{8}): &bn256PairingIstanbul{},
+	common.BytesToAddress([]byte{9}): &blake2F{},
+}
+
+// PrecompiledContractsTomoX returns the given pre-compiled contracts extended
+// with the read-only TomoX ones, which serve the order books of tomoxState:
+//
+//	0x41: best bid price and volume of a pair
+//	0x42: best ask price and volume of a pair
+//	0x43: last traded price of a pair
+//	0x44: volume of a pair at a price level
+//
+// Every contract takes the base and the quote token of the pair as two 32 byte
+// words, the volume one followed by the price and the side (0 for bids, 1 for
+// asks) words. A pair without an order book, or a nil tomoxState, reads as an
+// empty order book: every returned word is zero.
+//
+// The contracts read a copy of tomoxState made on their first run, so that the
+// EVMs sharing it neither race on it nor pay for the copy unless they read it.
+func PrecompiledContractsTomoX(precompiles map[common.Address]PrecompiledContract, tomoxState *tomox_state.TomoXStateDB) map[common.Address]PrecompiledContract {
+	contracts := make(map[common.Address]PrecompiledContract, len(precompiles)+4)
+	for addr, contract := range precompiles {
+		contracts[addr] = contract
+	}
+	books := &tomoxOrderBooks{shared: tomoxState}
+	contracts[common.BytesToAddress([]byte{0x41})] = &tomoxBestBid{books: books}
+	contracts[common.BytesToAddress([]byte{0x42})] = &tomoxBestAsk{books: books}
+	contracts[common.BytesToAddress([]byte{0x43})] = &tomoxLastPrice{books: books}
+	contracts[common.BytesToAddress([]byte{0x44})] = &tomoxVolume{books: books}
+	return contracts
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
 	gas := p.RequiredGas(input)
@@ -271,6 +316,22 @@ func newTwistPoint(blob []byte) (*bn256.G2, error) {
 	return p, nil
 }
 
+// runBn256Add implements the bn256 point addition shared by the Byzantium
+// and Istanbul pre-compiles.
+func runBn256Add(input []byte) ([]byte, error) {
+	x, err := newCurvePoint(getData(input, 0, 64))
+	if err != nil {
+		return nil, err
+	}
+	y, err := newCurvePoint(getData(input, 64, 64))
+	if err != nil {
+		return nil, err
+	}
+	res := new(bn256.G1)
+	res.Add(x, y)
+	return res.Marshal(), nil
+}
+
 // bn256Add implements a native elliptic curve point addition.
 type bn256Add struct{}
 
@@ -280,16 +341,31 @@ func (c *bn256Add) RequiredGas(input []byte) uint64 {
 }
 
 func (c *bn256Add) Run(input []byte) ([]byte, error) {
-	x, err := newCurvePoint(getData(input, 0, 64))
-	if err != nil {
-		return nil, err
-	}
-	y, err := newCurvePoint(getData(input, 64, 64))
+	return runBn256Add(input)
+}
+
+// bn256AddIstanbul implements a native elliptic curve point addition with the
+// gas repricing of TIPIstanbul.
+type bn256AddIstanbul struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bn256AddIstanbul) RequiredGas(input []byte) uint64 {
+	return params.Bn256AddGasIstanbul
+}
+
+func (c *bn256AddIstanbul) Run(input []byte) ([]byte, error) {
+	return runBn256Add(input)
+}
+
+// runBn256ScalarMul implements the bn256 scalar multiplication shared by the
+// Byzantium and Istanbul pre-compiles.
+func runBn256ScalarMul(input []byte) ([]byte, error) {
+	p, err := newCurvePoint(getData(input, 0, 64))
 	if err != nil {
 		return nil, err
 	}
 	res := new(bn256.G1)
-	res.Add(x, y)
+	res.ScalarMult(p, new(big.Int).SetBytes(getData(input, 64, 32)))
 	return res.Marshal(), nil
 }
 
@@ -302,13 +378,20 @@ func (c *bn256ScalarMul) RequiredGas(input []byte) uint64 {
 }
 
 func (c *bn256ScalarMul) Run(input []byte) ([]byte, error) {
-	p, err := newCurvePoint(getData(input, 0, 64))
-	if err != nil {
-		return nil, err
-	}
-	res := new(bn256.G1)
-	res.ScalarMult(p, new(big.Int).SetBytes(getData(input, 64, 32)))
-	return res.Marshal(), nil
+	return runBn256ScalarMul(input)
+}
+
+// bn256ScalarMulIstanbul implements a native elliptic curve scalar
+// multiplication with the gas repricing of TIPIstanbul.
+type bn256ScalarMulIstanbul struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bn256ScalarMulIstanbul) RequiredGas(input []byte) uint64 {
+	return params.Bn256ScalarMulGasIstanbul
+}
+
+func (c *bn256ScalarMulIstanbul) Run(input []byte) ([]byte, error) {
+	return runBn256ScalarMul(input)
 }
 
 var (
@@ -322,15 +405,9 @@ var (
 	errBadPairingInput = errors.New("bad elliptic curve pairing size")
 )
 
-// bn256Pairing implements a pairing pre-compile for the bn256 curve
-type bn256Pairing struct{}
-
-// RequiredGas returns the gas required to execute the pre-compiled contract.
-func (c *bn256Pairing) RequiredGas(input []byte) uint64 {
-	return params.Bn256PairingBaseGas + uint64(len(input)/192)*params.Bn256PairingPerPointGas
-}
-
-func (c *bn256Pairing) Run(input []byte) ([]byte, error) {
+// runBn256Pairing implements the bn256 pairing check shared by the Byzantium
+// and Istanbul pre-compiles.
+func runBn256Pairing(input []byte) ([]byte, error) {
 	// Handle some corner cases cheaply
 	if len(input)%192 > 0 {
 		return nil, errBadPairingInput
@@ -358,3 +435,226 @@ func (c *bn256Pairing) Run(input []byte) ([]byte, error) {
 	}
 	return false32Byte, nil
 }
+
+// bn256Pairing implements a pairing pre-compile for the bn256 curve
+type bn256Pairing struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bn256Pairing) RequiredGas(input []byte) uint64 {
+	return params.Bn256PairingBaseGas + uint64(len(input)/192)*params.Bn256PairingPerPointGas
+}
+
+func (c *bn256Pairing) Run(input []byte) ([]byte, error) {
+	return runBn256Pairing(input)
+}
+
+// bn256PairingIstanbul implements a pairing pre-compile for the bn256 curve
+// with the gas repricing of TIPIstanbul.
+type bn256PairingIstanbul struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bn256PairingIstanbul) RequiredGas(input []byte) uint64 {
+	return params.Bn256PairingBaseGasIstanbul + uint64(len(input)/192)*params.Bn256PairingPerPointGasIstanbul
+}
+
+func (c *bn256PairingIstanbul) Run(input []byte) ([]byte, error) {
+	return runBn256Pairing(input)
+}
+
+const (
+	// blake2FInputLength is the exact size of a BLAKE2F pre-compile input.
+	blake2FInputLength = 213
+
+	blake2FFinalBlockBytes    = byte(1)
+	blake2FNonFinalBlockBytes = byte(0)
+)
+
+var (
+	// errBlake2FInvalidInputLength is returned if the BLAKE2F input is not
+	// exactly blake2FInputLength bytes long.
+	errBlake2FInvalidInputLength = errors.New("invalid input length")
+
+	// errBlake2FInvalidFinalFlag is returned if the final block indicator of
+	// the BLAKE2F input is neither 0 nor 1.
+	errBlake2FInvalidFinalFlag = errors.New("invalid final flag")
+)
+
+// blake2F implements the BLAKE2b F compression function pre-compile (EIP-152).
+type blake2F struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *blake2F) RequiredGas(input []byte) uint64 {
+	// If the input is malformed, we can't calculate the gas, return 0 and let the
+	// actual call choke and fault.
+	if len(input) != blake2FInputLength {
+		return 0
+	}
+	return uint64(binary.BigEndian.Uint32(input[0:4])) * params.Blake2FRoundGas
+}
+
+func (c *blake2F) Run(input []byte) ([]byte, error) {
+	// Make sure the input is valid (correct length and final flag)
+	if len(input) != blake2FInputLength {
+		return nil, errBlake2FInvalidInputLength
+	}
+	if input[212] != blake2FNonFinalBlockBytes && input[212] != blake2FFinalBlockBytes {
+		return nil, errBlake2FInvalidFinalFlag
+	}
+	// Parse the input into the BLAKE2b call parameters
+	var (
+		rounds = binary.BigEndian.Uint32(input[0:4])
+		final  = (input[212] == blake2FFinalBlockBytes)
+
+		h [8]uint64
+		m [16]uint64
+		t [2]uint64
+	)
+	for i := 0; i < 8; i++ {
+		offset := 4 + i*8
+		h[i] = binary.LittleEndian.Uint64(input[offset : offset+8])
+	}
+	for i := 0; i < 16; i++ {
+		offset := 68 + i*8
+		m[i] = binary.LittleEndian.Uint64(input[offset : offset+8])
+	}
+	t[0] = binary.LittleEndian.Uint64(input[196:204])
+	t[1] = binary.LittleEndian.Uint64(input[204:212])
+
+	// Execute the compression function, extract and return the result
+	blake2b.F(&h, m, t, final, rounds)
+
+	output := make([]byte, 64)
+	for i := 0; i < 8; i++ {
+		offset := i * 8
+		binary.LittleEndian.PutUint64(output[offset:offset+8], h[i])
+	}
+	return output, nil
+}
+
+var (
+	// errTomoXInvalidSide is returned by the TomoX volume pre-compile if the
+	// side word is neither 0 nor 1.
+	errTomoXInvalidSide = errors.New("invalid order side")
+)
+
+// tomoxOrderBooks is the order book state read by the TomoX pre-compiles of an
+// EVM, copied from the one shared by the EVMs of the block on first use.
+type tomoxOrderBooks struct {
+	shared *tomox_state.TomoXStateDB
+	state  *tomox_state.TomoXStateDB
+}
+
+// get returns the copy of the shared order book state, nil if there is none.
+func (b *tomoxOrderBooks) get() *tomox_state.TomoXStateDB {
+	if b.state == nil && b.shared != nil {
+		b.state = b.shared.Copy()
+	}
+	return b.state
+}
+
+// tomoxOrderBook reads the pair of a TomoX pre-compile input, returning the
+// order book state and the hash of the pair's order book if the state is set
+// and holds it.
+func tomoxOrderBook(books *tomoxOrderBooks, input []byte) (*tomox_state.TomoXStateDB, common.Hash, bool) {
+	var (
+		baseToken  = common.BytesToAddress(getData(input, 0, 32))
+		quoteToken = common.BytesToAddress(getData(input, 32, 32))
+		orderBook  = tomox_state.GetOrderBookHash(baseToken, quoteToken)
+		tomoxState = books.get()
+	)
+	return tomoxState, orderBook, tomoxState != nil && tomoxState.Exist(orderBook)
+}
+
+// tomoxWord left pads a possibly nil big integer into a 32 byte word.
+func tomoxWord(v *big.Int) []byte {
+	if v == nil {
+		return make([]byte, 32)
+	}
+	return common.LeftPadBytes(v.Bytes(), 32)
+}
+
+// tomoxBestBid implements a native read of the best bid of a TomoX pair.
+type tomoxBestBid struct {
+	books *tomoxOrderBooks
+}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *tomoxBestBid) RequiredGas(input []byte) uint64 {
+	return params.TomoXReadGas
+}
+
+func (c *tomoxBestBid) Run(input []byte) ([]byte, error) {
+	tomoxState, orderBook, ok := tomoxOrderBook(c.books, input)
+	if !ok {
+		return make([]byte, 64), nil
+	}
+	price, volume := tomoxState.GetBestBidPrice(orderBook)
+	return append(tomoxWord(price), tomoxWord(volume)...), nil
+}
+
+// tomoxBestAsk implements a native read of the best ask of a TomoX pair.
+type tomoxBestAsk struct {
+	books *tomoxOrderBooks
+}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *tomoxBestAsk) RequiredGas(input []byte) uint64 {
+	return params.TomoXReadGas
+}
+
+func (c *tomoxBestAsk) Run(input []byte) ([]byte, error) {
+	tomoxState, orderBook, ok := tomoxOrderBook(c.books, input)
+	if !ok {
+		return make([]byte, 64), nil
+	}
+	price, volume := tomoxState.GetBestAskPrice(orderBook)
+	return append(tomoxWord(price), tomoxWord(volume)...), nil
+}
+
+// tomoxLastPrice implements a native read of the last traded price of a
+// TomoX pair.
+type tomoxLastPrice struct {
+	books *tomoxOrderBooks
+}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *tomoxLastPrice) RequiredGas(input []byte) uint64 {
+	return params.TomoXReadGas
+}
+
+func (c *tomoxLastPrice) Run(input []byte) ([]byte, error) {
+	tomoxState, orderBook, ok := tomoxOrderBook(c.books, input)
+	if !ok {
+		return make([]byte, 32), nil
+	}
+	return tomoxWord(tomoxState.GetPrice(orderBook)), nil
+}
+
+// tomoxVolume implements a native read of the volume of a TomoX pair at a
+// price level.
+type tomoxVolume struct {
+	books *tomoxOrderBooks
+}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *tomoxVolume) RequiredGas(input []byte) uint64 {
+	return params.TomoXReadGas
+}
+
+func (c *tomoxVolume) Run(input []byte) ([]byte, error) {
+	var side string
+	switch word := new(big.Int).SetBytes(getData(input, 96, 32)); {
+	case word.Sign() == 0:
+		side = tomox_state.Bid
+	case word.Cmp(common.Big1) == 0:
+		side = tomox_state.Ask
+	default:
+		return nil, errTomoXInvalidSide
+	}
+	tomoxState, orderBook, ok := tomoxOrderBook(c.books, input)
+	if !ok {
+		return make([]byte, 32), nil
+	}
+	price := new(big.Int).SetBytes(getData(input, 64, 32))
+	return tomoxWord(tomoxState.GetVolume(orderBook, price, side)), nil
+}
@@ -17,11 +17,15 @@
 package vm
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
 	"testing"
 
 	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/params"
+	"github.com/tomochain/tomochain/tomox/tomox_state"
 )
 
 // precompiledTest defines the input/output pairs for precompiled contract tests.
@@ -336,6 +340,28 @@ var bn256PairingTests = []precompiledTest{
 	},
 }
 
+// blake2FTests are the test and benchmark data for the BLAKE2F precompiled
+// contract, hashing "abc" as in the EIP-152 examples.
+var blake2FTests = []precompiledTest{
+	{
+		input:    "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		expected: "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+		name:     "vector 5",
+	},
+}
+
+// blake2FMalformedTests are the invalid inputs of the BLAKE2F precompiled
+// contract from the EIP-152 examples.
+var blake2FMalformedTests = []struct {
+	input    string
+	expected error
+}{
+	{"", errBlake2FInvalidInputLength},
+	{"0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b616263000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000003000000000000000000000000000000", errBlake2FInvalidInputLength},
+	{"0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b6162630000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000030000000000000000000000000000000100", errBlake2FInvalidInputLength},
+	{"0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000002", errBlake2FInvalidFinalFlag},
+}
+
 func testPrecompiled(addr string, test precompiledTest, t *testing.T) {
 	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
 	in := common.Hex2Bytes(test.input)
@@ -481,3 +507,135 @@ func BenchmarkPrecompiledBn256Pairing(bench *testing.B) {
 		benchmarkPrecompiled("08", test, bench)
 	}
 }
+
+// Tests the sample inputs from the BLAKE2b compression function EIP 152.
+func TestPrecompiledBlake2F(t *testing.T) {
+	p := PrecompiledContractsIstanbul[common.HexToAddress("09")]
+	for _, test := range blake2FTests {
+		in := common.Hex2Bytes(test.input)
+		if gas := p.RequiredGas(in); gas != 12 {
+			t.Errorf("%s: gas mismatch: have %d, want %d", test.name, gas, 12)
+		}
+		if res, err := p.Run(in); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if common.Bytes2Hex(res) != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, common.Bytes2Hex(res))
+		}
+	}
+	for i, test := range blake2FMalformedTests {
+		if _, err := p.Run(common.Hex2Bytes(test.input)); err != test.expected {
+			t.Errorf("malformed %d: error mismatch: have %v, want %v", i, err, test.expected)
+		}
+	}
+}
+
+// Tests that the bn256 pre-compiles are repriced after TIPIstanbul while still
+// producing the same results.
+func TestPrecompiledBn256Istanbul(t *testing.T) {
+	tests := []struct {
+		addr  string
+		input string
+		gas   uint64
+	}{
+		{"06", bn256AddTests[0].input, params.Bn256AddGasIstanbul},
+		{"07", bn256ScalarMulTests[0].input, params.Bn256ScalarMulGasIstanbul},
+		{"08", bn256PairingTests[0].input, params.Bn256PairingBaseGasIstanbul + 2*params.Bn256PairingPerPointGasIstanbul},
+	}
+	for _, test := range tests {
+		var (
+			addr      = common.HexToAddress(test.addr)
+			in        = common.Hex2Bytes(test.input)
+			istanbul  = PrecompiledContractsIstanbul[addr]
+			byzantium = PrecompiledContractsByzantium[addr]
+		)
+		if gas := istanbul.RequiredGas(in); gas != test.gas {
+			t.Errorf("%s: gas mismatch: have %d, want %d", test.addr, gas, test.gas)
+		}
+		have, err := istanbul.Run(in)
+		if err != nil {
+			t.Fatalf("%s: %v", test.addr, err)
+		}
+		want, _ := byzantium.Run(in)
+		if common.Bytes2Hex(have) != common.Bytes2Hex(want) {
+			t.Errorf("%s: result mismatch: have %x, want %x", test.addr, have, want)
+		}
+	}
+}
+
+// Tests that the TomoX pre-compiles serve the order book of a pair.
+func TestPrecompiledTomoX(t *testing.T) {
+	var (
+		baseToken  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		quoteToken = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		orderBook  = tomox_state.GetOrderBookHash(baseToken, quoteToken)
+		pair       = append(common.LeftPadBytes(baseToken[:], 32), common.LeftPadBytes(quoteToken[:], 32)...)
+	)
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := tomox_state.New(common.Hash{}, tomox_state.NewDatabase(db))
+
+	orders := []tomox_state.OrderItem{
+		{OrderID: 1, Quantity: big.NewInt(5), Price: big.NewInt(90), Side: tomox_state.Bid},
+		{OrderID: 2, Quantity: big.NewInt(7), Price: big.NewInt(95), Side: tomox_state.Bid},
+		{OrderID: 3, Quantity: big.NewInt(3), Price: big.NewInt(100), Side: tomox_state.Ask},
+		{OrderID: 4, Quantity: big.NewInt(4), Price: big.NewInt(100), Side: tomox_state.Ask},
+	}
+	for _, order := range orders {
+		order.Signature = &tomox_state.Signature{V: 1, R: common.HexToHash("01"), S: common.HexToHash("02")}
+		statedb.InsertOrderItem(orderBook, common.BigToHash(new(big.Int).SetUint64(order.OrderID)), order)
+	}
+	statedb.SetPrice(orderBook, big.NewInt(97))
+
+	word := func(v int64) string { return common.Bytes2Hex(common.LeftPadBytes(big.NewInt(v).Bytes(), 32)) }
+	level := func(price, side byte) []byte {
+		input := append(common.CopyBytes(pair), common.LeftPadBytes([]byte{price}, 32)...)
+		return append(input, common.LeftPadBytes([]byte{side}, 32)...)
+	}
+	precompiles := PrecompiledContractsTomoX(PrecompiledContractsByzantium, statedb)
+	tests := []struct {
+		addr     byte
+		input    []byte
+		expected string
+	}{
+		{0x41, pair, word(95) + word(7)},
+		{0x42, pair, word(100) + word(7)},
+		{0x43, pair, word(97)},
+		{0x44, level(90, 0), word(5)},
+		{0x44, level(100, 1), word(7)},
+		{0x43, make([]byte, 64), word(0)},
+	}
+	for i, test := range tests {
+		p := precompiles[common.BytesToAddress([]byte{test.addr})]
+		if gas := p.RequiredGas(test.input); gas != params.TomoXReadGas {
+			t.Errorf("test %d: gas mismatch: have %d, want %d", i, gas, params.TomoXReadGas)
+		}
+		res, err := p.Run(test.input)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if common.Bytes2Hex(res) != test.expected {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, common.Bytes2Hex(res))
+		}
+	}
+	volume := precompiles[common.BytesToAddress([]byte{0x44})]
+	if _, err := volume.Run(level(90, 2)); err != errTomoXInvalidSide {
+		t.Errorf("invalid side error mismatch: have %v, want %v", err, errTomoXInvalidSide)
+	}
+	// The order books are read from a copy, made once on the first run only
+	if books := PrecompiledContractsTomoX(PrecompiledContractsByzantium, statedb)[common.BytesToAddress([]byte{0x41})].(*tomoxBestBid).books; books.state != nil {
+		t.Errorf("order books copied before a run")
+	}
+	if books := volume.(*tomoxVolume).books; books.state == nil || books.state == statedb {
+		t.Errorf("order books not copied on run")
+	}
+	// Without order books every pair reads as empty
+	empty := PrecompiledContractsTomoX(PrecompiledContractsByzantium, nil)
+	for i, test := range tests {
+		res, err := empty[common.BytesToAddress([]byte{test.addr})].Run(test.input)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if want := make([]byte, len(test.expected)/2); !bytes.Equal(res, want) {
+			t.Errorf("test %d: expected %x, got %x", i, want, res)
+		}
+	}
+}
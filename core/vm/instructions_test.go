@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/crypto"
 	"github.com/tomochain/tomochain/params"
 )
 
@@ -196,6 +197,145 @@ func TestSLT(t *testing.T) {
 	testTwoOperandOp(t, tests, opSlt)
 }
 
+func TestCreate2Addresses(t *testing.T) {
+	type testcase struct {
+		origin   string
+		salt     string
+		code     string
+		expected string
+	}
+
+	for i, tt := range []testcase{
+		{
+			origin:   "0x0000000000000000000000000000000000000000",
+			salt:     "0x0000000000000000000000000000000000000000",
+			code:     "0x00",
+			expected: "0x4d1a2e2bb4f88f0250f26ffff098b0b30b26bf38",
+		},
+		{
+			origin:   "0xdeadbeef00000000000000000000000000000000",
+			salt:     "0x0000000000000000000000000000000000000000",
+			code:     "0x00",
+			expected: "0xB928f69Bb1D91Cd65274e3c79d8986362984fDA3",
+		},
+		{
+			origin:   "0xdeadbeef00000000000000000000000000000000",
+			salt:     "0xfeed000000000000000000000000000000000000",
+			code:     "0x00",
+			expected: "0xD04116cDd17beBE565EB2422F2497E06cC1C9833",
+		},
+		{
+			origin:   "0x0000000000000000000000000000000000000000",
+			salt:     "0x0000000000000000000000000000000000000000",
+			code:     "0xdeadbeef",
+			expected: "0x70f2b2914A2a4b783FaEFb75f459A580616Fcb5e",
+		},
+		{
+			origin:   "0x00000000000000000000000000000000deadbeef",
+			salt:     "0xcafebabe",
+			code:     "0xdeadbeef",
+			expected: "0x60f3f640a8508fC6a86d45DF051962668E1e8AC7",
+		},
+		{
+			origin:   "0x00000000000000000000000000000000deadbeef",
+			salt:     "0xcafebabe",
+			code:     "0xdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+			expected: "0x1d8bfDC5D46DC4f61D6b6115972536eBE6A8854C",
+		},
+		{
+			origin:   "0x0000000000000000000000000000000000000000",
+			salt:     "0x0000000000000000000000000000000000000000",
+			code:     "0x",
+			expected: "0xE33C0C7F7df4809055C3ebA6c09CFe4BaF1BD9e0",
+		},
+	} {
+		origin := common.BytesToAddress(common.FromHex(tt.origin))
+		salt := common.BytesToHash(common.FromHex(tt.salt))
+		code := common.FromHex(tt.code)
+		address := crypto.CreateAddress2(origin, salt, crypto.Keccak256(code))
+		expected := common.BytesToAddress(common.FromHex(tt.expected))
+		if address != expected {
+			t.Errorf("test %d: expected %s, got %s", i, expected.String(), address.String())
+		}
+	}
+}
+
+func TestCreate2Gas(t *testing.T) {
+	tests := []struct {
+		size     int64
+		expected uint64
+	}{
+		{0, 32000},
+		{4, 32006},
+		{44, 32012},
+	}
+	for i, test := range tests {
+		stack := newstack()
+		stack.push(big.NewInt(0))         // salt
+		stack.push(big.NewInt(test.size)) // size
+		stack.push(big.NewInt(0))         // offset
+		stack.push(big.NewInt(0))         // endowment
+		gas, err := gasCreate2(params.GasTable{}, nil, nil, stack, NewMemory(), 0)
+		if err != nil {
+			t.Fatalf("test %d: failed to compute gas: %v", i, err)
+		}
+		if gas != test.expected {
+			t.Errorf("test %d: gas mismatch: have %d, want %d", i, gas, test.expected)
+		}
+	}
+}
+
+func TestTIPCreate2InstructionSet(t *testing.T) {
+	// Constantinople doesn't enable CREATE2 and EXTCODEHASH before TIPCreate2
+	config := *params.TestChainConfig
+	config.ConstantinopleBlock = big.NewInt(0)
+
+	env := NewEVM(Context{BlockNumber: big.NewInt(0)}, nil, &config, Config{})
+	if table := env.Interpreter().cfg.JumpTable; table[CREATE2].valid || table[EXTCODEHASH].valid {
+		t.Fatal("CREATE2 and EXTCODEHASH must not be valid before TIPCreate2")
+	}
+	if !env.Interpreter().cfg.JumpTable[SHL].valid {
+		t.Fatal("the constantinople shift instructions must be valid after constantinople")
+	}
+	// TIPCreate2 enables them on the byzantium instructions
+	defer func(tip *big.Int) { common.TIPCreate2 = tip }(common.TIPCreate2)
+	common.TIPCreate2 = big.NewInt(0)
+
+	env = NewEVM(Context{BlockNumber: big.NewInt(0)}, nil, params.TestChainConfig, Config{})
+	if table := env.Interpreter().cfg.JumpTable; !table[CREATE2].valid || !table[EXTCODEHASH].valid {
+		t.Fatal("CREATE2 and EXTCODEHASH must be valid after TIPCreate2")
+	}
+	if env.Interpreter().cfg.JumpTable[SHL].valid {
+		t.Fatal("TIPCreate2 must not enable the constantinople shift instructions")
+	}
+}
+
+func TestOpChainID(t *testing.T) {
+	var (
+		env   = NewEVM(Context{}, nil, params.TestChainConfig, Config{})
+		stack = newstack()
+		pc    = uint64(0)
+	)
+	opChainID(&pc, env, nil, nil, stack)
+	if have := stack.pop(); have.Cmp(params.TestChainConfig.ChainId) != 0 {
+		t.Errorf("chain id mismatch: have %v, want %v", have, params.TestChainConfig.ChainId)
+	}
+	if env.Interpreter().cfg.JumpTable[CHAINID].valid {
+		t.Fatal("CHAINID must not be valid before TIPIstanbul")
+	}
+	// TIPIstanbul enables the Istanbul instructions only
+	defer func(tip *big.Int) { common.TIPIstanbul = tip }(common.TIPIstanbul)
+	common.TIPIstanbul = big.NewInt(0)
+
+	table := NewEVM(Context{BlockNumber: big.NewInt(0)}, nil, params.TestChainConfig, Config{}).Interpreter().cfg.JumpTable
+	if !table[CHAINID].valid || !table[SELFBALANCE].valid {
+		t.Fatal("Istanbul instructions must be valid after TIPIstanbul")
+	}
+	if table[SHL].valid || table[CREATE2].valid {
+		t.Fatal("TIPIstanbul must not enable the constantinople and TIPCreate2 instructions")
+	}
+}
+
 func opBenchmark(bench *testing.B, op func(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error), args ...string) {
 	var (
 		env   = NewEVM(Context{}, nil, params.TestChainConfig, Config{})
@@ -71,6 +71,13 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 		default:
 			cfg.JumpTable = frontierInstructionSet
 		}
+		// TIPCreate2 and TIPIstanbul are scheduled apart from the Ethereum forks
+		if evm.ChainConfig().IsTIPCreate2(evm.BlockNumber) {
+			enableTIPCreate2(&cfg.JumpTable)
+		}
+		if evm.ChainConfig().IsTIPIstanbul(evm.BlockNumber) {
+			enableTIPIstanbul(&cfg.JumpTable)
+		}
 	}
 
 	return &Interpreter{
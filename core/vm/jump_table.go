@@ -83,6 +83,43 @@ func NewConstantinopleInstructionSet() [256]operation {
 	return instructionSet
 }
 
+// enableTIPCreate2 adds the TIPCreate2 instructions to the given instruction
+// set, whichever fork it belongs to.
+func enableTIPCreate2(instructionSet *[256]operation) {
+	instructionSet[EXTCODEHASH] = operation{
+		execute:       opExtCodeHash,
+		gasCost:       gasExtCodeHash,
+		validateStack: makeStackFunc(1, 1),
+		valid:         true,
+	}
+	instructionSet[CREATE2] = operation{
+		execute:       opCreate2,
+		gasCost:       gasCreate2,
+		validateStack: makeStackFunc(4, 1),
+		memorySize:    memoryCreate2,
+		valid:         true,
+		writes:        true,
+		returns:       true,
+	}
+}
+
+// enableTIPIstanbul adds the TIPIstanbul instructions to the given instruction
+// set, whichever fork it belongs to.
+func enableTIPIstanbul(instructionSet *[256]operation) {
+	instructionSet[CHAINID] = operation{
+		execute:       opChainID,
+		gasCost:       constGasFunc(GasQuickStep),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+	instructionSet[SELFBALANCE] = operation{
+		execute:       opSelfBalance,
+		gasCost:       constGasFunc(GasFastStep),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+}
+
 // NewByzantiumInstructionSet returns the frontier, homestead and
 // byzantium instructions.
 func NewByzantiumInstructionSet() [256]operation {
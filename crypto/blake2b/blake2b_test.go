@@ -0,0 +1,50 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package blake2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// TestF checks that a single final compression of a short message matches
+// the reference BLAKE2b-512 digest.
+func TestF(t *testing.T) {
+	for _, msg := range [][]byte{nil, []byte("abc"), bytes.Repeat([]byte{0xaa}, 128)} {
+		h := iv
+		h[0] ^= 0x01010000 ^ 64
+
+		var block [128]byte
+		copy(block[:], msg)
+		var m [16]uint64
+		for i := range m {
+			m[i] = binary.LittleEndian.Uint64(block[i*8:])
+		}
+		F(&h, m, [2]uint64{uint64(len(msg)), 0}, true, 12)
+
+		have := make([]byte, 64)
+		for i, word := range h {
+			binary.LittleEndian.PutUint64(have[i*8:], word)
+		}
+		if want := blake2b.Sum512(msg); !bytes.Equal(have, want[:]) {
+			t.Errorf("digest mismatch for %x: have %x, want %x", msg, have, want)
+		}
+	}
+}
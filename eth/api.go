@@ -242,6 +242,24 @@ func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	return true
 }
 
+// RotateSigner schedules the given account to take over block sealing from the
+// current masternode key at the given future block.
+func (api *PrivateAdminAPI) RotateSigner(signer common.Address, number hexutil.Uint64) (bool, error) {
+	if err := api.eth.RotateSigner(signer, uint64(number)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PruneState starts deleting the stale state trie nodes in the background. An
+// interrupted pruning is resumed on the next startup.
+func (api *PrivateAdminAPI) PruneState() (bool, error) {
+	if err := api.eth.BlockChain().StartStatePruning(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // ImportChain imports a blockchain from a local file.
 func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	// Make sure the can access the file to import
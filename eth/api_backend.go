@@ -41,8 +41,10 @@ import (
 	"github.com/tomochain/tomochain/core/vm"
 	"github.com/tomochain/tomochain/eth/downloader"
 	"github.com/tomochain/tomochain/eth/gasprice"
+	"github.com/tomochain/tomochain/eth/tracers"
 	"github.com/tomochain/tomochain/ethclient"
 	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/internal/ethapi"
 	"github.com/tomochain/tomochain/event"
 	"github.com/tomochain/tomochain/log"
 	"github.com/tomochain/tomochain/params"
@@ -133,14 +135,38 @@ func (b *EthApiBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.eth.blockchain.GetTdByHash(blockHash)
 }
 
-func (b *EthApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
+func (b *EthApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config, blockOverrides *ethapi.BlockOverrides) (*vm.EVM, func() error, error) {
 	state.SetBalance(msg.From(), math.MaxBig256)
 	vmError := func() error { return nil }
 
 	context := core.NewEVMContext(msg, header, b.eth.BlockChain(), nil)
+	blockOverrides.Apply(&context)
+	tomoxState, err := b.eth.BlockChain().PrecompileTomoXState(header)
+	if err != nil {
+		return nil, vmError, err
+	}
+	context.TomoXState = tomoxState
 	return vm.NewEVM(context, state, b.eth.chainConfig, vmCfg), vmError, nil
 }
 
+func (b *EthApiBackend) NewStateDiffTracer() (ethapi.StateDiffTracer, error) {
+	return NewStateDiffTracer()
+}
+
+// NewStateDiffTracer returns the prestate tracer in diff mode, which reports the
+// accounts changed by a transaction.
+func NewStateDiffTracer() (ethapi.StateDiffTracer, error) {
+	tracer, err := tracers.NewTracer("prestateTracer", json.RawMessage(`{"diffMode":true}`))
+	if err != nil {
+		return nil, err
+	}
+	diffTracer, ok := tracer.(ethapi.StateDiffTracer)
+	if !ok {
+		return nil, errors.New("prestate tracer does not trace transactions")
+	}
+	return diffTracer, nil
+}
+
 func (b *EthApiBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return b.eth.BlockChain().SubscribeRemovedLogsEvent(ch)
 }
@@ -231,6 +257,14 @@ func (b *EthApiBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, sections
 }
 
+func (b *EthApiBackend) LogIndexStatus() (uint64, uint64) {
+	if b.eth.logIndexer == nil {
+		return 0, 0
+	}
+	sections, _, _ := b.eth.logIndexer.Sections()
+	return logIndexSectionSize, sections
+}
+
 func (b *EthApiBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	for i := 0; i < bloomFilterThreads; i++ {
 		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, b.eth.bloomRequests)
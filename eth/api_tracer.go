@@ -19,6 +19,7 @@ package eth
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -34,10 +35,13 @@ import (
 	"github.com/tomochain/tomochain/core/types"
 	"github.com/tomochain/tomochain/core/vm"
 	"github.com/tomochain/tomochain/eth/tracers"
+	"github.com/tomochain/tomochain/ethdb"
 	"github.com/tomochain/tomochain/internal/ethapi"
 	"github.com/tomochain/tomochain/log"
 	"github.com/tomochain/tomochain/rlp"
 	"github.com/tomochain/tomochain/rpc"
+	"github.com/tomochain/tomochain/tomox"
+	"github.com/tomochain/tomochain/tomox/tomox_state"
 	"github.com/tomochain/tomochain/trie"
 )
 
@@ -55,9 +59,10 @@ const (
 // TraceConfig holds extra parameters to trace functions.
 type TraceConfig struct {
 	*vm.LogConfig
-	Tracer  *string
-	Timeout *string
-	Reexec  *uint64
+	Tracer       *string
+	TracerConfig json.RawMessage // Configuration of the native tracers
+	Timeout      *string
+	Reexec       *uint64
 }
 
 // txTraceResult is the result of a single transaction trace.
@@ -69,10 +74,11 @@ type txTraceResult struct {
 // blockTraceTask represents a single block trace task when an entire chain is
 // being traced.
 type blockTraceTask struct {
-	statedb *state.StateDB   // Intermediate state prepped for tracing
-	block   *types.Block     // Block to trace the transactions from
-	rootref common.Hash      // Trie root reference held for this task
-	results []*txTraceResult // Trace results procudes by the task
+	statedb    *state.StateDB            // Intermediate state prepped for tracing
+	tomoxState *tomox_state.TomoXStateDB // Order books read by the TomoX precompiled contracts
+	block      *types.Block              // Block to trace the transactions from
+	rootref    common.Hash               // Trie root reference held for this task
+	results    []*txTraceResult          // Trace results procudes by the task
 }
 
 // blockTraceResult represets the results of tracing a single block when an entire
@@ -201,6 +207,7 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 					}
 					msg, _ := tx.AsMessage(signer, balacne,task.block.Number())
 					vmctx := core.NewEVMContext(msg, task.block.Header(), api.eth.blockchain, nil)
+					vmctx.TomoXState = task.tomoxState
 
 					res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config)
 					if err != nil {
@@ -273,8 +280,13 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 			if number > origin {
 				txs := block.Transactions()
 
+				tomoxState, err := api.eth.blockchain.PrecompileTomoXState(block.Header())
+				if err != nil {
+					failed = err
+					break
+				}
 				select {
-				case tasks <- &blockTraceTask{statedb: statedb.Copy(), block: block, rootref: proot, results: make([]*txTraceResult, len(txs))}:
+				case tasks <- &blockTraceTask{statedb: statedb.Copy(), tomoxState: tomoxState, block: block, rootref: proot, results: make([]*txTraceResult, len(txs))}:
 				case <-notifier.Closed():
 					return
 				}
@@ -410,6 +422,10 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 	if err != nil {
 		return nil, err
 	}
+	tomoxState, err := api.eth.blockchain.PrecompileTomoXState(block.Header())
+	if err != nil {
+		return nil, err
+	}
 	// Execute all the transaction contained within the block concurrently
 	var (
 		signer = types.MakeSigner(api.config, block.Number())
@@ -440,6 +456,7 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 				}
 				msg, _ := txs[task.index].AsMessage(signer, balacne,block.Number())
 				vmctx := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
+				vmctx.TomoXState = tomoxState
 
 				res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config)
 				if err != nil {
@@ -465,6 +482,7 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 		// Generate the next state snapshot fast without tracing
 		msg, _ := tx.AsMessage(signer, balacne,block.Number())
 		vmctx := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
+		vmctx.TomoXState = tomoxState
 
 		vmenv := vm.NewEVM(vmctx, statedb, api.config, vm.Config{})
 		owner := common.Address{}
@@ -572,6 +590,88 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, hash common.Ha
 	return api.traceTx(ctx, msg, vmctx, statedb, config)
 }
 
+// TraceOrderTransaction replays the order matching of the block containing the
+// given matching transaction, returning the steps the TomoX matching engine
+// took for each order of the transaction.
+func (api *PrivateDebugAPI) TraceOrderTransaction(ctx context.Context, hash common.Hash) ([]*tomox.OrderTrace, error) {
+	tomoX := api.eth.GetTomoX()
+	if tomoX == nil {
+		return nil, errors.New("TomoX service not running")
+	}
+	tx, blockHash, blockNumber, _ := core.GetTransaction(api.eth.ChainDb(), hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %x not found", hash)
+	}
+	if !tx.IsMatchingTransaction() {
+		return nil, fmt.Errorf("transaction %x is not an order matching transaction", hash)
+	}
+	block := api.eth.blockchain.GetBlock(blockHash, blockNumber)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", blockHash)
+	}
+	parent := api.eth.blockchain.GetBlock(block.ParentHash(), blockNumber-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %x not found", block.ParentHash())
+	}
+	// The orders are matched on the parent state, before the block is processed
+	statedb, err := api.eth.blockchain.StateAt(parent.Root())
+	if err != nil {
+		return nil, fmt.Errorf("state of block #%d unavailable: %v", parent.NumberU64(), err)
+	}
+	tomoxState, err := tomoX.GetTomoxState(parent)
+	if err != nil {
+		return nil, err
+	}
+	author, err := api.eth.engine.Author(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	batches, err := core.ExtractMatchingTransactions(block.Transactions())
+	if err != nil {
+		return nil, err
+	}
+	// Apply the orders of the block up to the transaction as the block validation
+	// does, tracing its own
+	for _, batch := range batches {
+		if batch.TxHash != hash {
+			err := core.ApplyTxMatchBatch(statedb, batch, func(order *tomox_state.OrderItem, orderBook common.Hash) error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				_, _, err := tomoX.ApplyOrder(author, api.eth.blockchain, statedb, tomoxState, orderBook, order)
+				return err
+			})
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				log.Warn("Failed to replay order matching", "tx", batch.TxHash, "err", err)
+			}
+			continue
+		}
+		var (
+			traces   []*tomox.OrderTrace
+			traceErr error
+		)
+		err := core.ApplyTxMatchBatch(statedb, batch, func(order *tomox_state.OrderItem, orderBook common.Hash) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			logger := tomox.NewOrderLogger(order)
+			trades, rejects, err := tomoX.ApplyOrderWithTracer(author, api.eth.blockchain, statedb, tomoxState, orderBook, order, logger)
+			traces = append(traces, logger.Result(trades, rejects, err))
+			traceErr = err
+			return err
+		})
+		// A failing order is reported in its trace, ending the matching
+		if err != nil && err != traceErr {
+			return nil, err
+		}
+		return traces, nil
+	}
+	return nil, fmt.Errorf("transaction %x not found in block %x", hash, blockHash)
+}
+
 // traceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
@@ -590,15 +690,18 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 				return nil, err
 			}
 		}
-		// Constuct the JavaScript tracer to execute with
-		if tracer, err = tracers.New(*config.Tracer); err != nil {
+		// Constuct the native or JavaScript tracer to execute with
+		resultTracer, err := tracers.NewTracer(*config.Tracer, config.TracerConfig)
+		if err != nil {
 			return nil, err
 		}
+		tracer = resultTracer
+
 		// Handle timeouts and RPC cancellations
 		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 		go func() {
 			<-deadlineCtx.Done()
-			tracer.(*tracers.Tracer).Stop(errors.New("execution timeout"))
+			resultTracer.Stop(errors.New("execution timeout"))
 		}()
 		defer cancel()
 
@@ -611,11 +714,18 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 	// Run the transaction with tracing enabled.
 	vmenv := vm.NewEVM(vmctx, statedb, api.config, vm.Config{Debug: true, Tracer: tracer})
 
+	txTracer, _ := tracer.(tracers.TxTracer)
+	if txTracer != nil {
+		txTracer.CaptureTxStart(vmenv, message.From(), message.To())
+	}
 	owner := common.Address{}
 	ret, gas, failed, err := core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.Gas()), owner)
 	if err != nil {
 		return nil, fmt.Errorf("tracing failed: %v", err)
 	}
+	if txTracer != nil {
+		txTracer.CaptureTxEnd()
+	}
 	// Depending on the tracer type, format and return the output
 	switch tracer := tracer.(type) {
 	case *vm.StructLogger:
@@ -626,7 +736,7 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
 		}, nil
 
-	case *tracers.Tracer:
+	case tracers.ResultTracer:
 		return tracer.GetResult()
 
 	default:
@@ -649,6 +759,10 @@ func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, ree
 	if err != nil {
 		return nil, vm.Context{}, nil, err
 	}
+	tomoxState, err := api.eth.blockchain.PrecompileTomoXState(block.Header())
+	if err != nil {
+		return nil, vm.Context{}, nil, err
+	}
 	// Recompute transactions up to the target index.
 	signer := types.MakeSigner(api.config, block.Number())
 	feeCapacity := state.GetTRC21FeeCapacityFromState(statedb)
@@ -662,6 +776,7 @@ func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, ree
 		// Assemble the transaction call message and return if the requested offset
 		msg, _ := tx.AsMessage(signer, balacne,block.Number())
 		context := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
+		context.TomoXState = tomoxState
 		if idx == txIndex {
 			return msg, context, statedb, nil
 		}
@@ -675,3 +790,229 @@ func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, ree
 	}
 	return nil, vm.Context{}, nil, fmt.Errorf("tx index %d out of range for block %x", txIndex, blockHash)
 }
+
+const (
+	// maxTraceFilterResults is the maximum number of internal transactions
+	// returned by a single trace_filter call.
+	maxTraceFilterResults = 1000
+
+	// maxTraceFilterBlocks is the maximum number of blocks a trace_filter call
+	// without addresses walks.
+	maxTraceFilterBlocks = 10000
+)
+
+// PublicTraceAPI provides the search of the internal transactions gathered by
+// the internal transaction indexer.
+type PublicTraceAPI struct {
+	db      ethdb.Database
+	indexer *core.ChainIndexer
+}
+
+// NewPublicTraceAPI creates a new API definition for the internal transactions
+// indexed by the given indexer.
+func NewPublicTraceAPI(db ethdb.Database, indexer *core.ChainIndexer) *PublicTraceAPI {
+	return &PublicTraceAPI{db: db, indexer: indexer}
+}
+
+// TraceFilterArgs are the criteria of a search of the internal transactions.
+// The internal transactions match if they were sent by any of the from
+// addresses and received by any of the to addresses, an empty list matching any
+// address.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"` // Number of matching internal transactions to skip
+	Count       *uint64          `json:"count"` // Maximum number of internal transactions to return
+}
+
+// RPCInternalTx is an internal transaction reported by trace_filter.
+type RPCInternalTx struct {
+	BlockHash        common.Hash    `json:"blockHash"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint   `json:"transactionPosition"`
+	TraceAddress     []uint64       `json:"traceAddress"`
+	Type             string         `json:"type"`
+	From             common.Address `json:"from"`
+	To               common.Address `json:"to"`
+	Value            *hexutil.Big   `json:"value"`
+	Gas              hexutil.Uint64 `json:"gas"`
+	GasUsed          hexutil.Uint64 `json:"gasUsed"`
+	Input            hexutil.Bytes  `json:"input"`
+	Output           hexutil.Bytes  `json:"output,omitempty"`
+	Error            string         `json:"error,omitempty"`
+}
+
+// Filter returns the internal transactions of the canonical blocks in the range
+// matching the addresses, in chain order. Blocks past the ones indexed so far
+// are left out, blocks before the indexer started are rejected, and so is a
+// range of more than maxTraceFilterBlocks blocks without addresses.
+func (api *PublicTraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]*RPCInternalTx, error) {
+	sections, _, _ := api.indexer.Sections()
+	if sections == 0 {
+		return []*RPCInternalTx{}, nil
+	}
+	head, start := sections-1, core.GetInternalTxIndexStart(api.db)
+
+	from, to := start, head
+	if args.FromBlock != nil {
+		from = head
+		if *args.FromBlock >= 0 {
+			from = uint64(*args.FromBlock)
+		}
+	}
+	if from < start {
+		return nil, fmt.Errorf("block #%d before the first indexed block #%d", from, start)
+	}
+	if args.ToBlock != nil && *args.ToBlock >= 0 && uint64(*args.ToBlock) < head {
+		to = uint64(*args.ToBlock)
+	}
+	if len(args.FromAddress) == 0 && len(args.ToAddress) == 0 && to >= from && to-from >= maxTraceFilterBlocks {
+		return nil, fmt.Errorf("range of %d blocks above the limit of %d without addresses", to-from+1, maxTraceFilterBlocks)
+	}
+	var after, count uint64 = 0, maxTraceFilterResults
+	if args.After != nil {
+		after = *args.After
+	}
+	if args.Count != nil {
+		if *args.Count > maxTraceFilterResults {
+			return nil, fmt.Errorf("count %d above the limit of %d", *args.Count, maxTraceFilterResults)
+		}
+		count = *args.Count
+	}
+	results := []*RPCInternalTx{}
+	if from > to || count == 0 {
+		return results, nil
+	}
+	// Gather the matching internal transactions, walking the blocks in order or
+	// merging the lookup entries of the addresses if there are any
+	var (
+		number  uint64
+		hash    common.Hash
+		txs     []*types.InternalTx
+		loaded  bool
+		skipped uint64
+	)
+	load := func(n uint64) []*types.InternalTx {
+		if !loaded || number != n {
+			number, loaded = n, true
+			if hash, txs = core.GetInternalTxs(api.db, n); hash != core.GetCanonicalHash(api.db, n) {
+				// Left over from a block reorged away, not yet indexed again
+				txs = nil
+			}
+		}
+		return txs
+	}
+	visit := func(n uint64, index uint32) bool {
+		blockTxs := load(n)
+		if int(index) >= len(blockTxs) {
+			return true
+		}
+		tx := blockTxs[index]
+		if !containsAddress(args.FromAddress, tx.From) || !containsAddress(args.ToAddress, tx.To) {
+			return true
+		}
+		if skipped < after {
+			skipped++
+			return true
+		}
+		results = append(results, newRPCInternalTx(tx, hash, n))
+		return uint64(len(results)) < count
+	}
+	addrs := args.FromAddress
+	if len(addrs) == 0 {
+		addrs = args.ToAddress
+	}
+	if len(addrs) == 0 {
+		for n := from; n <= to; n++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			for i := range load(n) {
+				if !visit(n, uint32(i)) {
+					return results, nil
+				}
+			}
+		}
+		return results, nil
+	}
+	iterators := make([]*core.InternalTxIterator, 0, len(addrs))
+	defer func() {
+		for _, it := range iterators {
+			it.Release()
+		}
+	}()
+	var live []*core.InternalTxIterator
+	for _, addr := range addrs {
+		it := core.NewInternalTxIterator(api.db, addr, from, to)
+		iterators = append(iterators, it)
+		if it.Next() {
+			live = append(live, it)
+		}
+	}
+	var (
+		lastNumber uint64
+		lastIndex  uint32
+		visited    bool
+	)
+	for len(live) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Pick the earliest lookup entry, skipping duplicates across addresses
+		next := 0
+		for i, it := range live {
+			if it.Number < live[next].Number || (it.Number == live[next].Number && it.Index < live[next].Index) {
+				next = i
+			}
+		}
+		it := live[next]
+		if !visited || it.Number != lastNumber || it.Index != lastIndex {
+			if !visit(it.Number, it.Index) {
+				break
+			}
+			lastNumber, lastIndex, visited = it.Number, it.Index, true
+		}
+		if !it.Next() {
+			live = append(live[:next], live[next+1:]...)
+		}
+	}
+	return results, nil
+}
+
+// containsAddress reports whether the address is in the list, or the list is
+// empty.
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	if len(addrs) == 0 {
+		return true
+	}
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// newRPCInternalTx returns the internal transaction of the given block in its
+// RPC form.
+func newRPCInternalTx(tx *types.InternalTx, blockHash common.Hash, blockNumber uint64) *RPCInternalTx {
+	return &RPCInternalTx{
+		BlockHash:        blockHash,
+		BlockNumber:      hexutil.Uint64(blockNumber),
+		TransactionHash:  tx.TxHash,
+		TransactionIndex: hexutil.Uint(tx.TxIndex),
+		TraceAddress:     tx.TraceAddress,
+		Type:             tx.Type,
+		From:             tx.From,
+		To:               tx.To,
+		Value:            (*hexutil.Big)(tx.Value),
+		Gas:              hexutil.Uint64(tx.Gas),
+		GasUsed:          hexutil.Uint64(tx.GasUsed),
+		Input:            tx.Input,
+		Output:           tx.Output,
+		Error:            tx.Error,
+	}
+}
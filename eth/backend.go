@@ -95,6 +95,9 @@ type Ethereum struct {
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
 
+	internalTxIndexer *core.ChainIndexer // Internal transaction indexer operating during block imports, if enabled
+	logIndexer        *core.ChainIndexer // Log indexer operating during block imports, if enabled
+
 	ApiBackend *EthApiBackend
 
 	miner     *miner.Miner
@@ -164,7 +167,7 @@ func New(ctx *node.ServiceContext, config *Config, tomoXServ *tomox.TomoX) (*Eth
 	}
 	var (
 		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout, Snapshot: config.Snapshot, ParallelTxs: config.ParallelTxs}
 	)
 	if eth.chainConfig.Posv != nil {
 		c := eth.engine.(*posv.Posv)
@@ -184,6 +187,25 @@ func New(ctx *node.ServiceContext, config *Config, tomoXServ *tomox.TomoX) (*Eth
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
 
+	if config.InternalTxIndex {
+		eth.internalTxIndexer = NewInternalTxIndexer(chainDb, eth.blockchain)
+
+		// Only an archive node holds the state to trace the past blocks, start
+		// with the head block otherwise
+		if sections, _, _ := eth.internalTxIndexer.Sections(); sections == 0 && !config.NoPruning {
+			if head := eth.blockchain.CurrentBlock(); head.NumberU64() > 0 {
+				log.Info("Indexing internal transactions from the head block", "number", head.NumberU64())
+				eth.internalTxIndexer.AddKnownSectionHead(head.NumberU64(), head.Hash())
+				core.WriteInternalTxIndexStart(chainDb, head.NumberU64()+1)
+			}
+		}
+		eth.internalTxIndexer.Start(eth.blockchain)
+	}
+	if config.LogIndex {
+		eth.logIndexer = NewLogIndexer(chainDb)
+		eth.logIndexer.Start(eth.blockchain)
+	}
+
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
@@ -237,7 +259,15 @@ func New(ctx *node.ServiceContext, config *Config, tomoXServ *tomox.TomoX) (*Eth
 				return nil
 			}
 			if block.NumberU64()%common.MergeSignRange == 0 || !eth.chainConfig.IsTIP2019(block.Number()) {
-				if err := contracts.CreateTransactionSign(chainConfig, eth.txPool, eth.accountManager, block, chainDb); err != nil {
+				if eth.chainConfig.IsTIPAttestation(block.Number()) && c.IsAttester(eth.blockchain, block.Header()) {
+					attestation, err := c.Attest(eth.blockchain, block.Header())
+					if err != nil {
+						log.Warn("Fail to attest importing block", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+					} else {
+						eth.eventMux.Post(core.NewAttestationEvent{Attestation: attestation})
+					}
+				}
+				if err := contracts.CreateTransactionSign(chainConfig, eth.txPool, eth.accountManager, block, chainDb, c.SignerAt(block.NumberU64())); err != nil {
 					return fmt.Errorf("Fail to create tx sign for importing block: %v", err)
 				}
 			}
@@ -265,6 +295,9 @@ func New(ctx *node.ServiceContext, config *Config, tomoXServ *tomox.TomoX) (*Eth
 					return block, false, err
 				}
 				header := block.Header()
+				if err := c.CheckValidation(eth.blockchain, header, eb); err != nil {
+					return block, false, err
+				}
 				sighash, err := wallet.SignHash(accounts.Account{Address: eb}, posv.SigHash(header).Bytes())
 				if err != nil || sighash == nil {
 					log.Error("Can't get signature hash of m2", "sighash", sighash, "err", err)
@@ -282,7 +315,15 @@ func New(ctx *node.ServiceContext, config *Config, tomoXServ *tomox.TomoX) (*Eth
 		// Hook prepares validators M2 for the current epoch at checkpoint block
 		c.HookValidator = func(header *types.Header, signers []common.Address) ([]byte, error) {
 			start := time.Now()
-			validators, err := GetValidators(eth.blockchain, signers)
+			var (
+				validators []byte
+				err        error
+			)
+			if chainConfig.IsTIPCommitReveal(header.Number) {
+				validators, err = GetValidatorsFromReveals(eth.blockchain, header, signers)
+			} else {
+				validators, err = GetValidators(eth.blockchain, signers)
+			}
 			if err != nil {
 				return []byte{}, err
 			}
@@ -431,6 +472,21 @@ func New(ctx *node.ServiceContext, config *Config, tomoXServ *tomox.TomoX) (*Eth
 								}
 							}
 						}
+						// Check signer attested?
+						if bheader := chain.GetHeader(bhash, blockNumber); bheader != nil {
+							for _, attestation := range bheader.Attestations {
+								from, err := attestation.Signer()
+								if err != nil || !mapBlockHash[attestation.BlockHash] {
+									continue
+								}
+								for j, addr := range penComebacks {
+									if from == addr {
+										penComebacks = append(penComebacks[:j], penComebacks[j+1:]...)
+										break
+									}
+								}
+							}
+						}
 					} else {
 						break
 					}
@@ -438,6 +494,29 @@ func New(ctx *node.ServiceContext, config *Config, tomoXServ *tomox.TomoX) (*Eth
 
 				log.Debug("Time Calculated HookPenaltyTIPSigning ", "block", header.Number, "hash", header.Hash().Hex(), "pen comeback nodes", len(penComebacks), "not enough miner", len(penalties), "time", common.PrettyDuration(time.Since(start)))
 				penalties = append(penalties, penComebacks...)
+
+				// Masternodes which withheld their randomize opening are penalized
+				if chain.Config().IsTIPCommitReveal(header.Number) {
+					parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+					if parent == nil {
+						return nil, consensus.ErrUnknownAncestor
+					}
+					statedb, err := eth.blockchain.StateAt(parent.Root)
+					if err != nil {
+						return nil, err
+					}
+					// Only the masternodes which were signers during the whole
+					// commit-reveal window had the chance to commit and reveal
+					participants, err := revealParticipants(c, chain, header, listBlockHash, preMasternodes)
+					if err != nil {
+						return nil, err
+					}
+					_, missing := contracts.GetRandomizeReveals(statedb, header.Number.Uint64(), participants)
+					for _, addr := range missing {
+						log.Debug("Find a node don't reveal randomize", "addr", addr.Hex())
+					}
+					penalties = append(penalties, common.RemoveItemFromArray(missing, penalties)...)
+				}
 				if chain.Config().IsTIPRandomize(header.Number) {
 					return penalties, nil
 				}
@@ -556,7 +635,15 @@ func New(ctx *node.ServiceContext, config *Config, tomoXServ *tomox.TomoX) (*Eth
 			number := header.Number.Int64()
 			if number > 0 && number%common.EpocBlockRandomize == 0 {
 				start := time.Now()
-				validators, err := GetValidators(eth.blockchain, signers)
+				var (
+					validators []byte
+					err        error
+				)
+				if chainConfig.IsTIPCommitReveal(header.Number) {
+					validators, err = GetValidatorsFromReveals(eth.blockchain, header, signers)
+				} else {
+					validators, err = GetValidators(eth.blockchain, signers)
+				}
 				log.Debug("Time Calculated HookVerifyMNs ", "block", header.Number.Uint64(), "time", common.PrettyDuration(time.Since(start)))
 				if err != nil {
 					return err
@@ -590,6 +677,9 @@ func New(ctx *node.ServiceContext, config *Config, tomoXServ *tomox.TomoX) (*Eth
 		}
 
 	}
+	// Finish a state pruning interrupted by a shutdown or a crash
+	eth.blockchain.ResumeStatePruning()
+
 	return eth, nil
 }
 
@@ -612,11 +702,19 @@ func makeExtraData(extra []byte) []byte {
 
 // CreateDB creates the chain database.
 func CreateDB(ctx *node.ServiceContext, config *Config, name string) (ethdb.Database, error) {
-	db, err := ctx.OpenDatabase(name, config.DatabaseCache, config.DatabaseHandles)
+	var (
+		db  ethdb.Database
+		err error
+	)
+	if config.DatabaseFreezer != "" {
+		db, err = ctx.OpenDatabaseWithFreezer(name, config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer)
+	} else {
+		db, err = ctx.OpenDatabase(name, config.DatabaseCache, config.DatabaseHandles)
+	}
 	if err != nil {
 		return nil, err
 	}
-	if db, ok := db.(*ethdb.LDBDatabase); ok {
+	if db, ok := db.(ethdb.DiskDatabase); ok {
 		db.Meter("eth/db/chaindata/")
 	}
 	return db, nil
@@ -662,6 +760,15 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Append the internal transaction search if they are indexed
+	if s.internalTxIndexer != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "trace",
+			Version:   "1.0",
+			Service:   NewPublicTraceAPI(s.chainDb, s.internalTxIndexer),
+			Public:    true,
+		})
+	}
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -817,6 +924,46 @@ func (s *Ethereum) StartStaking(local bool) error {
 	return nil
 }
 
+// RotateSigner schedules signer to take over block sealing from the current
+// PoSV signer at the given future block, without restarting the node. Both
+// addresses must be masternode candidates of the same owner in the
+// TomoValidator contract, and the new key must be able to sign locally.
+func (s *Ethereum) RotateSigner(signer common.Address, number uint64) error {
+	engine, ok := s.engine.(*posv.Posv)
+	if !ok {
+		return errors.New("signer rotation is only supported by the PoSV engine")
+	}
+	current := engine.Signer()
+	if current == (common.Address{}) {
+		return errors.New("staking is not started")
+	}
+	if signer == current {
+		return fmt.Errorf("%x is already the signer", signer)
+	}
+	if head := s.blockchain.CurrentHeader().Number.Uint64(); number <= head+1 {
+		return fmt.Errorf("rotation block %d is not in the future, head is %d", number, head)
+	}
+	// The new key must be available before it's scheduled, otherwise turns are missed
+	wallet, err := s.accountManager.Find(accounts.Account{Address: signer})
+	if err != nil {
+		return fmt.Errorf("signer missing: %v", err)
+	}
+	statedb, err := s.blockchain.State()
+	if err != nil {
+		return err
+	}
+	owner := state.GetCandidateOwner(statedb, signer)
+	if owner == (common.Address{}) {
+		return fmt.Errorf("%x is not a masternode candidate", signer)
+	}
+	if currentOwner := state.GetCandidateOwner(statedb, current); currentOwner != owner {
+		return fmt.Errorf("candidates %x and %x have different owners", current, signer)
+	}
+	engine.ScheduleAuthorize(number, signer, wallet.SignHash)
+	log.Info("Scheduled signer rotation", "number", number, "old", current, "new", signer, "owner", owner)
+	return nil
+}
+
 func (s *Ethereum) StopStaking() {
 	s.miner.Stop()
 }
@@ -875,6 +1022,12 @@ func (s *Ethereum) Stop() error {
 		s.stopDbUpgrade()
 	}
 	s.bloomIndexer.Close()
+	if s.internalTxIndexer != nil {
+		s.internalTxIndexer.Close()
+	}
+	if s.logIndexer != nil {
+		s.logIndexer.Close()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
@@ -924,6 +1077,68 @@ func GetValidators(bc *core.BlockChain, masternodes []common.Address) ([]byte, e
 	return nil, core.ErrNotFoundM1
 }
 
+// GetValidatorsFromReveals computes the M2 validators of a checkpoint from the
+// randomize openings revealed by the masternodes before it. Masternodes which
+// withheld their opening don't contribute to the seed and are penalized.
+func GetValidatorsFromReveals(bc *core.BlockChain, header *types.Header, masternodes []common.Address) ([]byte, error) {
+	if bc.Config().Posv == nil {
+		return nil, core.ErrNotPoSV
+	}
+	if len(masternodes) == 0 {
+		return nil, core.ErrNotFoundM1
+	}
+	parent := bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	statedb, err := bc.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	reveals, _ := contracts.GetRandomizeReveals(statedb, header.Number.Uint64(), masternodes)
+	seed := contracts.RandomizeSeed(header.Number.Uint64(), reveals)
+	return contracts.BuildValidatorFromM2(contracts.GenM2FromSeed(seed, int64(len(masternodes)))), nil
+}
+
+// revealParticipants filters the masternodes of the epoch ending at a checkpoint
+// down to the ones which were signers during its whole commit-reveal window,
+// blockHashes holding the hashes of the epoch blocks from the parent of the
+// checkpoint backwards. Masternodes which joined or left the signers during the
+// window never had the chance to commit and reveal.
+func revealParticipants(c *posv.Posv, chain consensus.ChainReader, header *types.Header, blockHashes []common.Hash, masternodes []common.Address) ([]common.Address, error) {
+	epoch := chain.Config().Posv.Epoch
+	if epoch <= common.EpocBlockSecret {
+		return nil, nil
+	}
+	number := header.Number.Uint64()
+	var snaps []*posv.Snapshot
+	for _, n := range []uint64{number - epoch + common.EpocBlockSecret, number - 1} {
+		windowHeader := chain.GetHeader(blockHashes[number-1-n], n)
+		if windowHeader == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		snap, err := c.GetSnapshot(chain, windowHeader)
+		if err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, snap)
+	}
+	var participants []common.Address
+	for _, masternode := range masternodes {
+		signer := true
+		for _, snap := range snaps {
+			if _, ok := snap.Signers[masternode]; !ok {
+				signer = false
+				break
+			}
+		}
+		if signer {
+			participants = append(participants, masternode)
+		}
+	}
+	return participants, nil
+}
+
 func rewardInflation(chainReward *big.Int, number uint64, blockPerYear uint64) *big.Int {
 	if blockPerYear*2 <= number && number < blockPerYear*6 {
 		chainReward.Div(chainReward, new(big.Int).SetUint64(2))
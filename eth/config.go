@@ -79,9 +79,13 @@ type Config struct {
 	Genesis *core.Genesis `toml:",omitempty"`
 
 	// Protocol options
-	NetworkId uint64 // Network ID to use for selecting peers to connect to
-	SyncMode  downloader.SyncMode
-	NoPruning bool
+	NetworkId       uint64 // Network ID to use for selecting peers to connect to
+	SyncMode        downloader.SyncMode
+	NoPruning       bool
+	Snapshot        bool // Whether to maintain a flat snapshot of the recent states
+	ParallelTxs     int  // Number of workers executing the transactions of a block in parallel
+	InternalTxIndex bool // Whether to trace the imported blocks to index their internal transactions
+	LogIndex        bool // Whether to index the logs by address and first topic
 
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
@@ -91,6 +95,7 @@ type Config struct {
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
+	DatabaseFreezer    string `toml:",omitempty"` // Ancient store directory, relative to the chain database
 	TrieCache          int
 	TrieTimeout        time.Duration
 
@@ -27,6 +27,7 @@ import (
 
 	"github.com/tomochain/tomochain"
 	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/consensus/posv"
 	"github.com/tomochain/tomochain/core"
 	"github.com/tomochain/tomochain/core/types"
 	"github.com/tomochain/tomochain/ethdb"
@@ -34,6 +35,7 @@ import (
 	"github.com/tomochain/tomochain/log"
 	"github.com/tomochain/tomochain/metrics"
 	"github.com/tomochain/tomochain/params"
+	"github.com/tomochain/tomochain/tomox/tomox_state"
 )
 
 var (
@@ -91,6 +93,7 @@ var (
 	errNoSyncActive            = errors.New("no sync active")
 	errTooOld                  = errors.New("peer doesn't speak recent enough protocol version (need version >= 62)")
 	errEnoughBlock             = errors.New("downloader download enough block")
+	errNoSnapSync              = errors.New("no snap sync")
 )
 
 type Downloader struct {
@@ -108,10 +111,12 @@ type Downloader struct {
 	syncStatsChainOrigin uint64 // Origin block number where syncing started at
 	syncStatsChainHeight uint64 // Highest block number known when syncing started
 	syncStatsState       stateSyncStats
+	syncStatsTomoXState  stateSyncStats
 	syncStatsLock        sync.RWMutex // Lock protecting the sync stats fields
 
 	lightchain LightChain
 	blockchain BlockChain
+	snapSyncer SnapSyncer // Downloads the state in ranges if set, the node data being the fallback
 
 	// Callbacks
 	dropPeer peerDropFn // Drops a peer for misbehaving
@@ -198,6 +203,23 @@ type BlockChain interface {
 	InsertReceiptChain(types.Blocks, []types.Receipts) (int, error)
 }
 
+// TomoXChain is implemented by the chains running the TomoX service, whose
+// state is fast synced along with the chain state.
+type TomoXChain interface {
+	// TomoXService returns the TomoX service, nil if it doesn't run.
+	TomoXService() posv.TomoXService
+}
+
+// SnapSyncer downloads the chain state and the TomoX state of the pivot block
+// in trie ranges rather than node by node.
+type SnapSyncer interface {
+	// Sync downloads the chain state with the given root.
+	Sync(root common.Hash, cancel chan struct{}) error
+
+	// SyncTomoX downloads the TomoX state with the given root.
+	SyncTomoX(root common.Hash, cancel chan struct{}) error
+}
+
 // New creates a new downloader to fetch hashes and blocks from remote peers.
 func New(mode SyncMode, stateDb ethdb.Database, mux *event.TypeMux, chain BlockChain, lightchain LightChain, dropPeer peerDropFn) *Downloader {
 	if lightchain == nil {
@@ -234,6 +256,12 @@ func New(mode SyncMode, stateDb ethdb.Database, mux *event.TypeMux, chain BlockC
 	return dl
 }
 
+// SetSnapSyncer sets the syncer downloading the state of the fast sync pivot
+// over the snap protocol. It must be called before any sync starts.
+func (d *Downloader) SetSnapSyncer(syncer SnapSyncer) {
+	d.snapSyncer = syncer
+}
+
 // Progress retrieves the synchronisation boundaries, specifically the origin
 // block where synchronisation started at (may have failed/suspended); the block
 // or header sync is currently at; and the latest known block which the sync targets.
@@ -261,6 +289,9 @@ func (d *Downloader) Progress() ethereum.SyncProgress {
 		HighestBlock:  d.syncStatsChainHeight,
 		PulledStates:  d.syncStatsState.processed,
 		KnownStates:   d.syncStatsState.processed + d.syncStatsState.pending,
+
+		PulledTomoXStates: d.syncStatsTomoXState.processed,
+		KnownTomoXStates:  d.syncStatsTomoXState.processed + d.syncStatsTomoXState.pending,
 	}
 }
 
@@ -1542,6 +1573,9 @@ func (d *Downloader) commitFastSyncData(results []*fetchResult, stateSync *state
 func (d *Downloader) commitPivotBlock(result *fetchResult) error {
 	block := types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles)
 	log.Debug("Committing fast sync pivot as new head", "number", block.Number(), "hash", block.Hash())
+	if err := d.syncPivotTomoXState(block); err != nil {
+		return err
+	}
 	if _, err := d.blockchain.InsertReceiptChain([]*types.Block{block}, []types.Receipts{result.Receipts}); err != nil {
 		return err
 	}
@@ -1552,6 +1586,40 @@ func (d *Downloader) commitPivotBlock(result *fetchResult) error {
 	return nil
 }
 
+// syncPivotTomoXState downloads the TomoX state committed to by the pivot block
+// into the TomoX database, and verifies it's complete under its root. Nothing
+// is done if the block commits to no TomoX state or the TomoX service doesn't
+// run.
+func (d *Downloader) syncPivotTomoXState(block *types.Block) error {
+	chain, ok := d.blockchain.(TomoXChain)
+	if !ok {
+		return nil
+	}
+	tomoXService := chain.TomoXService()
+	if tomoXService == nil {
+		return nil
+	}
+	root, err := tomoXService.GetTomoxStateRoot(block)
+	if err != nil {
+		return err
+	}
+	if root == tomox_state.EmptyRoot {
+		return nil
+	}
+	db, ok := tomoXService.GetStateCache().TrieDB().DiskDB().(ethdb.Database)
+	if !ok {
+		return nil
+	}
+	log.Info("Syncing TomoX state of the pivot block", "number", block.Number(), "root", root)
+	if err := d.syncTomoXState(root, db).Wait(); err != nil {
+		return err
+	}
+	if err := tomox_state.VerifyState(root, db); err != nil {
+		return fmt.Errorf("TomoX state %x incomplete: %v", root, err)
+	}
+	return nil
+}
+
 // DeliverHeaders injects a new batch of block headers received from a remote
 // node into the download schedule.
 func (d *Downloader) DeliverHeaders(id string, headers []*types.Header) (err error) {
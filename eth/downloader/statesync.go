@@ -28,6 +28,7 @@ import (
 	"github.com/tomochain/tomochain/crypto/sha3"
 	"github.com/tomochain/tomochain/ethdb"
 	"github.com/tomochain/tomochain/log"
+	"github.com/tomochain/tomochain/tomox/tomox_state"
 	"github.com/tomochain/tomochain/trie"
 )
 
@@ -59,7 +60,17 @@ type stateSyncStats struct {
 
 // syncState starts downloading state with the given root hash.
 func (d *Downloader) syncState(root common.Hash) *stateSync {
-	s := newStateSync(d, root)
+	return d.startStateSync(newStateSync(d, root))
+}
+
+// syncTomoXState starts downloading the TomoX state with the given root hash
+// into the TomoX database.
+func (d *Downloader) syncTomoXState(root common.Hash, db ethdb.Database) *stateSync {
+	return d.startStateSync(newTomoXStateSync(d, root, db))
+}
+
+// startStateSync hands a state sync over to the state fetcher.
+func (d *Downloader) startStateSync(s *stateSync) *stateSync {
 	select {
 	case d.stateSyncStart <- s:
 	case <-d.quitCh:
@@ -212,7 +223,13 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 // stateSync schedules requests for downloading a particular state trie defined
 // by a given state root.
 type stateSync struct {
-	d *Downloader // Downloader instance to access and manage current peerset
+	d    *Downloader // Downloader instance to access and manage current peerset
+	root common.Hash // State root being synced
+	name string      // Kind of state being synced, for the logs
+
+	db    ethdb.Database                                     // Database the state is written into
+	stats *stateSyncStats                                    // Progress counters of the kind of state
+	snap  func(root common.Hash, cancel chan struct{}) error // Snap sync of the state, nil if unavailable
 
 	sched  *trie.TrieSync             // State trie sync scheduler defining the tasks
 	keccak hash.Hash                  // Keccak256 hasher to verify deliveries with
@@ -237,8 +254,12 @@ type stateTask struct {
 // newStateSync creates a new state trie download scheduler. This method does not
 // yet start the sync. The user needs to call run to initiate.
 func newStateSync(d *Downloader, root common.Hash) *stateSync {
-	return &stateSync{
+	s := &stateSync{
 		d:       d,
+		root:    root,
+		name:    "state",
+		db:      d.stateDB,
+		stats:   &d.syncStatsState,
 		sched:   state.NewStateSync(root, d.stateDB),
 		keccak:  sha3.NewKeccak256(),
 		tasks:   make(map[common.Hash]*stateTask),
@@ -246,16 +267,84 @@ func newStateSync(d *Downloader, root common.Hash) *stateSync {
 		cancel:  make(chan struct{}),
 		done:    make(chan struct{}),
 	}
+	if d.snapSyncer != nil {
+		s.snap = d.snapSyncer.Sync
+	}
+	return s
+}
+
+// newTomoXStateSync creates a new TomoX state trie download scheduler, which
+// also retrieves the order books and orders of every exchange.
+func newTomoXStateSync(d *Downloader, root common.Hash, db ethdb.Database) *stateSync {
+	s := &stateSync{
+		d:       d,
+		root:    root,
+		name:    "TomoX state",
+		db:      db,
+		stats:   &d.syncStatsTomoXState,
+		sched:   tomox_state.NewStateSync(root, db),
+		keccak:  sha3.NewKeccak256(),
+		tasks:   make(map[common.Hash]*stateTask),
+		deliver: make(chan *stateReq),
+		cancel:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if d.snapSyncer != nil {
+		s.snap = d.snapSyncer.SyncTomoX
+	}
+	return s
 }
 
 // run starts the task assignment and response processing loop, blocking until
 // it finishes, and finally notifying any goroutines waiting for the loop to
 // finish.
 func (s *stateSync) run() {
+	if s.err = s.snapSync(); s.err != errNoSnapSync {
+		close(s.done)
+		return
+	}
 	s.err = s.loop()
 	close(s.done)
 }
 
+// snapSync downloads the state over the snap protocol if the downloader has
+// a snap syncer, returning errNoSnapSync if the node data retrieval is to be
+// used instead.
+func (s *stateSync) snapSync() error {
+	if s.snap == nil {
+		return errNoSnapSync
+	}
+	s.d.cancelLock.RLock()
+	cancelCh := s.d.cancelCh
+	s.d.cancelLock.RUnlock()
+
+	// Abort the snap sync on either of the cancellations
+	cancel := make(chan struct{})
+	defer close(cancel)
+
+	abort := make(chan struct{})
+	go func() {
+		select {
+		case <-s.cancel:
+		case <-cancelCh:
+		case <-cancel:
+			return
+		}
+		close(abort)
+	}()
+	err := s.snap(s.root, abort)
+	if err == nil {
+		return nil
+	}
+	select {
+	case <-abort:
+		return errCancelStateFetch
+	default:
+	}
+	log.Warn("Snap "+s.name+" sync failed, retrieving node data", "root", s.root, "err", err)
+	return errNoSnapSync
+}
+
 // Wait blocks until the sync is done or canceled.
 func (s *stateSync) Wait() error {
 	<-s.done
@@ -322,7 +411,7 @@ func (s *stateSync) commit(force bool) error {
 		return nil
 	}
 	start := time.Now()
-	b := s.d.stateDB.NewBatch()
+	b := s.db.NewBatch()
 	s.sched.Commit(b)
 	if err := b.Write(); err != nil {
 		return fmt.Errorf("DB write error: %v", err)
@@ -459,15 +548,15 @@ func (s *stateSync) updateStats(written, duplicate, unexpected int, duration tim
 	s.d.syncStatsLock.Lock()
 	defer s.d.syncStatsLock.Unlock()
 
-	s.d.syncStatsState.pending = uint64(s.sched.Pending())
-	s.d.syncStatsState.processed += uint64(written)
-	s.d.syncStatsState.duplicate += uint64(duplicate)
-	s.d.syncStatsState.unexpected += uint64(unexpected)
+	s.stats.pending = uint64(s.sched.Pending())
+	s.stats.processed += uint64(written)
+	s.stats.duplicate += uint64(duplicate)
+	s.stats.unexpected += uint64(unexpected)
 
 	if written > 0 || duplicate > 0 || unexpected > 0 {
-		log.Info("Imported new state entries", "count", written, "elapsed", common.PrettyDuration(duration), "processed", s.d.syncStatsState.processed, "pending", s.d.syncStatsState.pending, "retry", len(s.tasks), "duplicate", s.d.syncStatsState.duplicate, "unexpected", s.d.syncStatsState.unexpected)
+		log.Info("Imported new "+s.name+" entries", "count", written, "elapsed", common.PrettyDuration(duration), "processed", s.stats.processed, "pending", s.stats.pending, "retry", len(s.tasks), "duplicate", s.stats.duplicate, "unexpected", s.stats.unexpected)
 	}
 	if written > 0 {
-		core.WriteTrieSyncProgress(s.d.stateDB, s.d.syncStatsState.processed)
+		core.WriteTrieSyncProgress(s.db, s.stats.processed)
 	}
 }
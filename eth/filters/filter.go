@@ -19,6 +19,7 @@ package filters
 import (
 	"context"
 	"math/big"
+	"sort"
 
 	"github.com/tomochain/tomochain/common"
 	"github.com/tomochain/tomochain/core"
@@ -43,6 +44,7 @@ type Backend interface {
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
+	LogIndexStatus() (uint64, uint64)
 }
 
 // Filter can be used to retrieve and filter logs.
@@ -114,22 +116,85 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		logs []*types.Log
 		err  error
 	)
-	size, sections := f.backend.BloomStatus()
-	if indexed := sections * size; indexed > uint64(f.begin) {
+	size, sections := f.backend.LogIndexStatus()
+	if indexed := sections * size; indexed > uint64(f.begin) && f.logIndexed() {
 		if indexed > end {
-			logs, err = f.indexedLogs(ctx, end)
+			logs, err = f.logIndexedLogs(ctx, end)
 		} else {
-			logs, err = f.indexedLogs(ctx, indexed-1)
+			logs, err = f.logIndexedLogs(ctx, indexed-1)
 		}
 		if err != nil {
 			return logs, err
 		}
 	}
+	size, sections = f.backend.BloomStatus()
+	if indexed := sections * size; indexed > uint64(f.begin) && uint64(f.begin) <= end {
+		var found []*types.Log
+		if indexed > end {
+			found, err = f.indexedLogs(ctx, end)
+		} else {
+			found, err = f.indexedLogs(ctx, indexed-1)
+		}
+		logs = append(logs, found...)
+		if err != nil {
+			return logs, err
+		}
+	}
 	rest, err := f.unindexedLogs(ctx, end)
 	logs = append(logs, rest...)
 	return logs, err
 }
 
+// logIndexed returns whether the filter can be served by the log index, which
+// requires the addresses and the first topics of the logs.
+func (f *Filter) logIndexed() bool {
+	return len(f.addresses) > 0 && len(f.topics) > 0 && len(f.topics[0]) > 0
+}
+
+// logIndexedLogs returns the logs matching the filter criteria based on the log
+// index, checking the blocks it holds entries for the filter addresses and first
+// topics.
+func (f *Filter) logIndexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
+	// Gather the blocks holding logs of any of the addresses with any of the topics
+	matches := make(map[uint64]struct{})
+	for _, addr := range f.addresses {
+		for _, topic := range f.topics[0] {
+			it := core.NewLogIndexIterator(f.db, addr, topic, uint64(f.begin), end)
+			for it.Next() {
+				matches[it.Number] = struct{}{}
+			}
+			it.Release()
+		}
+	}
+	numbers := make([]uint64, 0, len(matches))
+	for number := range matches {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	// Retrieve the blocks found and pull any truly matching logs
+	var logs []*types.Log
+
+	for _, number := range numbers {
+		if err := ctx.Err(); err != nil {
+			return logs, err
+		}
+		f.begin = int64(number) + 1
+
+		header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if header == nil || err != nil {
+			return logs, err
+		}
+		found, err := f.checkMatches(ctx, header)
+		if err != nil {
+			return logs, err
+		}
+		logs = append(logs, found...)
+	}
+	f.begin = int64(end) + 1
+	return logs, nil
+}
+
 // indexedLogs returns the logs matching the filter criteria based on the bloom
 // bits indexed available locally or via the network.
 func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
@@ -241,3 +241,95 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+// logIndexBackend is a test backend with a log index over the first sections
+// of the chain.
+type logIndexBackend struct {
+	*testBackend
+	sections uint64
+}
+
+func (b *logIndexBackend) LogIndexStatus() (uint64, uint64) {
+	return 32, b.sections
+}
+
+// Tests that the filters search the blocks of the log index for the logs of
+// the addresses with the first topics, and the rest of the chain as before.
+func TestLogIndexFilters(t *testing.T) {
+	var (
+		db, _      = ethdb.NewMemDatabase()
+		mux        = new(event.TypeMux)
+		txFeed     = new(event.Feed)
+		rmLogsFeed = new(event.Feed)
+		logsFeed   = new(event.Feed)
+		chainFeed  = new(event.Feed)
+		backend    = &logIndexBackend{&testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}, 3}
+		key1, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr       = crypto.PubkeyToAddress(key1.PublicKey)
+
+		hash1 = common.BytesToHash([]byte("topic1"))
+		hash2 = common.BytesToHash([]byte("topic2"))
+		hash3 = common.BytesToHash([]byte("topic3"))
+	)
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 100, func(i int, gen *core.BlockGen) {
+		var topic common.Hash
+		switch i {
+		case 9, 19:
+			topic = hash1
+		case 29:
+			topic = hash2
+		case 98:
+			topic = hash3
+		default:
+			return
+		}
+		receipt := types.NewReceipt(nil, false, 0)
+		receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{topic}}}
+		gen.AddUncheckedReceipt(receipt)
+	})
+	for i, block := range chain {
+		core.WriteBlock(db, block)
+		if err := core.WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+			t.Fatalf("failed to insert block number: %v", err)
+		}
+		if err := core.WriteHeadBlockHash(db, block.Hash()); err != nil {
+			t.Fatalf("failed to insert block number: %v", err)
+		}
+		if err := core.WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts[i]); err != nil {
+			t.Fatal("error writing block receipts:", err)
+		}
+		// Index the first 96 blocks, but the log of block 20
+		if block.NumberU64() < 96 && block.NumberU64() != 20 {
+			if err := core.WriteLogIndexEntries(db, block.NumberU64(), receipts[i]); err != nil {
+				t.Fatal("error writing log index entries:", err)
+			}
+		}
+	}
+	// Leave behind the entry of a block reorged away
+	stale := types.Receipts{{Logs: []*types.Log{{Address: addr, Topics: []common.Hash{hash1}}}}}
+	if err := core.WriteLogIndexEntries(db, 50, stale); err != nil {
+		t.Fatal("error writing log index entries:", err)
+	}
+	filter := func(begin, end int64, addresses []common.Address, topics [][]common.Hash) []*types.Log {
+		logs, err := New(backend, begin, end, addresses, topics).Logs(context.Background())
+		if err != nil {
+			t.Fatalf("failed to filter logs: %v", err)
+		}
+		return logs
+	}
+	// Blocks missing from the log index are not searched when it can be used
+	if logs := filter(0, -1, []common.Address{addr}, [][]common.Hash{{hash1, hash2, hash3}}); len(logs) != 3 || logs[0].Topics[0] != hash1 || logs[1].Topics[0] != hash2 || logs[2].Topics[0] != hash3 {
+		t.Errorf("indexed logs mismatch: have %v, want the logs of blocks 10, 30 and 99", logs)
+	}
+	if logs := filter(11, 96, []common.Address{addr}, [][]common.Hash{{hash1}}); len(logs) != 0 {
+		t.Errorf("indexed logs mismatch: have %v, want none", logs)
+	}
+	// Filters without addresses or first topics are not served by the log index
+	if logs := filter(0, -1, nil, [][]common.Hash{{hash1}}); len(logs) != 2 {
+		t.Errorf("unindexed logs mismatch: have %d logs, want 2", len(logs))
+	}
+	if logs := filter(0, -1, []common.Address{addr}, nil); len(logs) != 4 {
+		t.Errorf("unindexed logs mismatch: have %d logs, want 4", len(logs))
+	}
+}
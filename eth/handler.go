@@ -30,10 +30,12 @@ import (
 	"github.com/tomochain/tomochain/common"
 	"github.com/tomochain/tomochain/consensus"
 	"github.com/tomochain/tomochain/consensus/misc"
+	"github.com/tomochain/tomochain/consensus/posv"
 	"github.com/tomochain/tomochain/core"
 	"github.com/tomochain/tomochain/core/types"
 	"github.com/tomochain/tomochain/eth/downloader"
 	"github.com/tomochain/tomochain/eth/fetcher"
+	"github.com/tomochain/tomochain/eth/snap"
 	"github.com/tomochain/tomochain/ethdb"
 	"github.com/tomochain/tomochain/event"
 	"github.com/tomochain/tomochain/log"
@@ -41,6 +43,7 @@ import (
 	"github.com/tomochain/tomochain/p2p/discover"
 	"github.com/tomochain/tomochain/params"
 	"github.com/tomochain/tomochain/rlp"
+	"github.com/tomochain/tomochain/trie"
 )
 
 const (
@@ -74,10 +77,12 @@ type ProtocolManager struct {
 	orderpool   orderPool
 	blockchain  *core.BlockChain
 	chainconfig *params.ChainConfig
+	engine      consensus.Engine
 	maxPeers    int
 
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
+	snapSyncer *snap.Syncer
 	peers      *peerSet
 
 	SubProtocols []p2p.Protocol
@@ -88,6 +93,7 @@ type ProtocolManager struct {
 	txSub         event.Subscription
 	orderTxSub    event.Subscription
 	minedBlockSub *event.TypeMuxSubscription
+	attestSub     *event.TypeMuxSubscription
 
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh   chan *peer
@@ -124,6 +130,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		txpool:       txpool,
 		blockchain:   blockchain,
 		chainconfig:  config,
+		engine:       engine,
 		peers:        newPeerSet(),
 		newPeerCh:    make(chan *peer),
 		noMorePeers:  make(chan struct{}),
@@ -180,8 +187,13 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	if len(manager.SubProtocols) == 0 {
 		return nil, errIncompatibleConfig
 	}
+	// Serve and sync the state in trie ranges over snap alongside eth
+	manager.snapSyncer = snap.NewSyncer(manager, manager.removePeer)
+	manager.SubProtocols = append(manager.SubProtocols, snap.MakeProtocols(manager, manager.snapSyncer)...)
+
 	// Construct the different synchronisation mechanisms
 	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
+	manager.downloader.SetSnapSyncer(manager.snapSyncer)
 
 	validator := func(header *types.Header) error {
 		return engine.VerifyHeader(blockchain, header, true)
@@ -213,6 +225,21 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	return manager, nil
 }
 
+// StateDatabase implements snap.Backend, returning the trie database of the
+// chain state.
+func (pm *ProtocolManager) StateDatabase() *trie.Database {
+	return pm.blockchain.StateCache().TrieDB()
+}
+
+// TomoXDatabase implements snap.Backend, returning the trie database of the
+// TomoX state, nil if the TomoX service doesn't run.
+func (pm *ProtocolManager) TomoXDatabase() *trie.Database {
+	if cache := pm.blockchain.TomoXStateCache(); cache != nil {
+		return cache.TrieDB()
+	}
+	return nil
+}
+
 func (pm *ProtocolManager) addOrderPoolProtocol(orderpool orderPool) {
 	pm.orderpool = orderpool
 }
@@ -253,6 +280,10 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
 	go pm.minedBroadcastLoop()
 
+	// broadcast attestations
+	pm.attestSub = pm.eventMux.Subscribe(core.NewAttestationEvent{})
+	go pm.attestationBroadcastLoop()
+
 	// start sync handlers
 	go pm.syncer()
 	go pm.txsyncLoop()
@@ -267,6 +298,7 @@ func (pm *ProtocolManager) Stop() {
 	}
 
 	pm.minedBlockSub.Unsubscribe() // quits blockBroadcastLoop
+	pm.attestSub.Unsubscribe()     // quits attestationBroadcastLoop
 
 	// Quit the sync loop.
 	// After this send has completed, no new peers will be accepted.
@@ -760,6 +792,33 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			pm.orderpool.AddRemotes(txs)
 		}
 
+	case p.version >= eth64 && msg.Code == AttestationMsg:
+		// Attestations are only meaningful against a fresh chain
+		if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+			break
+		}
+		var attestations []*types.Attestation
+		if err := msg.Decode(&attestations); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		c, ok := pm.engine.(*posv.Posv)
+		if !ok {
+			break
+		}
+		for i, attestation := range attestations {
+			if attestation == nil {
+				return errResp(ErrDecode, "attestation %d is nil", i)
+			}
+			p.MarkAttestation(attestation.Hash())
+			if err := c.AddAttestation(pm.blockchain, attestation); err != nil {
+				if err != posv.ErrKnownAttestation {
+					log.Trace("Discarded attestation", "number", attestation.Number, "hash", attestation.BlockHash, "err", err)
+				}
+				continue
+			}
+			pm.eventMux.Post(core.NewAttestationEvent{Attestation: attestation})
+		}
+
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
@@ -822,6 +881,17 @@ func (pm *ProtocolManager) OrderBroadcastTx(hash common.Hash, tx *types.OrderTra
 	log.Trace("Broadcast order transaction", "hash", hash, "recipients", len(peers))
 }
 
+// BroadcastAttestation will propagate an attestation to all eth/64 peers which
+// are not known to already have it.
+func (pm *ProtocolManager) BroadcastAttestation(attestation *types.Attestation) {
+	hash := attestation.Hash()
+	peers := pm.peers.PeersWithoutAttestation(hash)
+	for _, peer := range peers {
+		peer.SendAttestations([]*types.Attestation{attestation})
+	}
+	log.Trace("Broadcast attestation", "number", attestation.Number, "hash", attestation.BlockHash, "recipients", len(peers))
+}
+
 // Mined broadcast loop
 func (self *ProtocolManager) minedBroadcastLoop() {
 	// automatically stops if unsubscribe
@@ -834,6 +904,17 @@ func (self *ProtocolManager) minedBroadcastLoop() {
 	}
 }
 
+// attestationBroadcastLoop broadcasts local and verified remote attestations
+func (self *ProtocolManager) attestationBroadcastLoop() {
+	// automatically stops if unsubscribe
+	for obj := range self.attestSub.Chan() {
+		switch ev := obj.Data.(type) {
+		case core.NewAttestationEvent:
+			self.BroadcastAttestation(ev.Attestation)
+		}
+	}
+}
+
 func (self *ProtocolManager) txBroadcastLoop() {
 	for {
 		select {
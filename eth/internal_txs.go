@@ -0,0 +1,247 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/common/hexutil"
+	"github.com/tomochain/tomochain/consensus/misc"
+	"github.com/tomochain/tomochain/core"
+	"github.com/tomochain/tomochain/core/state"
+	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/core/vm"
+	"github.com/tomochain/tomochain/eth/tracers"
+	"github.com/tomochain/tomochain/ethdb"
+)
+
+const (
+	// internalTxConfirms is the number of confirmation blocks before a block is
+	// traced. Reorgs are rolled back, so blocks are traced as soon as imported,
+	// while their parent state is most likely still around.
+	internalTxConfirms = 0
+
+	// internalTxThrottling is the time to wait between tracing two consecutive
+	// blocks. It's useful when catching up with the chain to prevent disk overload.
+	internalTxThrottling = 10 * time.Millisecond
+)
+
+// InternalTxIndexer implements a core.ChainIndexer, tracing the transactions of
+// every canonical block to store the calls, contract creations and self
+// destructs made within them, indexed by the addresses sending and receiving
+// them. Each section of the indexer is a single block, so that a reorg rolls
+// back the blocks replaced only.
+type InternalTxIndexer struct {
+	db    ethdb.Database   // database instance to write index data and metadata into
+	chain *core.BlockChain // blockchain to trace the blocks of
+
+	batch ethdb.Batch // batch of the block being indexed
+	err   error       // error the block failed to be traced with
+}
+
+// NewInternalTxIndexer returns a chain indexer that traces the canonical blocks
+// to index their internal transactions.
+func NewInternalTxIndexer(db ethdb.Database, chain *core.BlockChain) *core.ChainIndexer {
+	backend := &InternalTxIndexer{
+		db:    db,
+		chain: chain,
+	}
+	table := ethdb.NewTable(db, string(core.InternalTxIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, 1, internalTxConfirms, internalTxThrottling, "internaltxs")
+}
+
+// Reset implements core.ChainIndexerBackend, dropping the internal transactions
+// indexed for a block of the same number before a reorg.
+func (b *InternalTxIndexer) Reset(section uint64, lastSectionHead common.Hash) error {
+	b.batch, b.err = b.db.NewBatch(), nil
+	core.DeleteInternalTxs(b.db, b.batch, section)
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, tracing the transactions of the
+// block to gather its internal transactions.
+func (b *InternalTxIndexer) Process(header *types.Header) {
+	txs, err := b.trace(header)
+	if err != nil {
+		b.err = err
+		return
+	}
+	if len(txs) > 0 {
+		b.err = core.WriteInternalTxs(b.batch, header.Hash(), header.Number.Uint64(), txs)
+	}
+}
+
+// Commit implements core.ChainIndexerBackend, writing the internal transactions
+// of the block out into the database.
+func (b *InternalTxIndexer) Commit() error {
+	if b.err != nil {
+		return b.err
+	}
+	return b.batch.Write()
+}
+
+// trace executes the transactions of the block on the state of its parent the
+// way the block processing does, running the call tracer on each.
+func (b *InternalTxIndexer) trace(header *types.Header) ([]*types.InternalTx, error) {
+	block := b.chain.GetBlock(header.Hash(), header.Number.Uint64())
+	if block == nil {
+		return nil, fmt.Errorf("block #%d [%x…] not found", header.Number, header.Hash().Bytes()[:4])
+	}
+	if len(block.Transactions()) == 0 {
+		return nil, nil
+	}
+	parent := b.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %x not found", block.ParentHash())
+	}
+	statedb, err := b.chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, fmt.Errorf("state of block #%d unavailable: %v", parent.NumberU64(), err)
+	}
+	config := b.chain.Config()
+	if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(block.Number()) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	if common.TIPSigning.Cmp(header.Number) == 0 {
+		statedb.DeleteAddress(common.HexToAddress(common.BlockSigners))
+	}
+	core.InitSignerInTransactions(config, header, block.Transactions())
+	tomoxState, err := b.chain.PrecompileTomoXState(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		internals  []*types.InternalTx
+		usedGas    = new(uint64)
+		gp         = new(core.GasPool).AddGas(block.GasLimit())
+		balanceFee = state.GetTRC21FeeCapacityFromState(statedb)
+	)
+	for i, tx := range block.Transactions() {
+		callTracer, err := tracers.NewTracer("callTracer", nil)
+		if err != nil {
+			return nil, err
+		}
+		tracer := &selfDestructRecorder{ResultTracer: callTracer}
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		_, gas, err, tokenFeeUsed := core.ApplyTransaction(config, balanceFee, b.chain, nil, gp, statedb, tomoxState, header, tx, usedGas, vm.Config{Debug: true, Tracer: tracer})
+		if err != nil {
+			return nil, fmt.Errorf("tx %x failed: %v", tx.Hash(), err)
+		}
+		if tokenFeeUsed {
+			fee := new(big.Int).SetUint64(gas)
+			if header.Number.Cmp(common.TIPTRC21Fee) > 0 {
+				fee = fee.Mul(fee, common.TRC21GasPrice)
+			}
+			balanceFee[*tx.To()] = new(big.Int).Sub(balanceFee[*tx.To()], fee)
+		}
+		result, err := tracer.GetResult()
+		if err != nil {
+			return nil, fmt.Errorf("tx %x tracing failed: %v", tx.Hash(), err)
+		}
+		call := new(tracedCall)
+		if err := json.Unmarshal(result, call); err != nil {
+			return nil, err
+		}
+		call.fillSelfDestructs(tracer.destructs)
+		internals = call.flatten(internals, tx.Hash(), uint(i), nil)
+	}
+	return internals, nil
+}
+
+// selfDestructRecorder runs the call tracer, recording the beneficiary and the
+// balance moved by the self destructs, which it reports by type only.
+type selfDestructRecorder struct {
+	tracers.ResultTracer
+	destructs []*tracedCall // Self destructs in execution order
+}
+
+// CaptureState implements vm.Tracer, recording the self destructs before
+// running the call tracer.
+func (r *selfDestructRecorder) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if op == vm.SELFDESTRUCT && err == nil {
+		from := contract.Address()
+		r.destructs = append(r.destructs, &tracedCall{
+			Type:  op.String(),
+			From:  from,
+			To:    common.BigToAddress(stack.Back(0)),
+			Value: (*hexutil.Big)(new(big.Int).Set(env.StateDB.GetBalance(from))),
+		})
+	}
+	return r.ResultTracer.CaptureState(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+}
+
+// tracedCall is a call reported by the call tracer.
+type tracedCall struct {
+	Type    string         `json:"type"`
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	Value   *hexutil.Big   `json:"value"`
+	Gas     hexutil.Uint64 `json:"gas"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Input   hexutil.Bytes  `json:"input"`
+	Output  hexutil.Bytes  `json:"output"`
+	Error   string         `json:"error"`
+	Calls   []*tracedCall  `json:"calls"`
+}
+
+// fillSelfDestructs sets the sender, beneficiary and value of the self destructs
+// made within the call from the recorded ones, which are in execution order,
+// returning the ones left.
+func (c *tracedCall) fillSelfDestructs(destructs []*tracedCall) []*tracedCall {
+	for _, call := range c.Calls {
+		if call.Type == "SELFDESTRUCT" && len(destructs) > 0 {
+			call.From, call.To, call.Value = destructs[0].From, destructs[0].To, destructs[0].Value
+			destructs = destructs[1:]
+		}
+		destructs = call.fillSelfDestructs(destructs)
+	}
+	return destructs
+}
+
+// flatten appends the calls made within the call to the internal transactions,
+// depth first, each one at its position in the call tree.
+func (c *tracedCall) flatten(internals []*types.InternalTx, txHash common.Hash, txIndex uint, position []uint64) []*types.InternalTx {
+	for i, call := range c.Calls {
+		traceAddress := append(append([]uint64{}, position...), uint64(i))
+
+		value := new(big.Int)
+		if call.Value != nil {
+			value = call.Value.ToInt()
+		}
+		internals = append(internals, &types.InternalTx{
+			TxHash:       txHash,
+			TxIndex:      txIndex,
+			TraceAddress: traceAddress,
+			Type:         call.Type,
+			From:         call.From,
+			To:           call.To,
+			Value:        value,
+			Gas:          uint64(call.Gas),
+			GasUsed:      uint64(call.GasUsed),
+			Input:        call.Input,
+			Output:       call.Output,
+			Error:        call.Error,
+		})
+		internals = call.flatten(internals, txHash, txIndex, traceAddress)
+	}
+	return internals
+}
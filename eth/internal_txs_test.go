@@ -0,0 +1,209 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/consensus/ethash"
+	"github.com/tomochain/tomochain/core"
+	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/core/vm"
+	"github.com/tomochain/tomochain/crypto"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/params"
+	"github.com/tomochain/tomochain/rpc"
+)
+
+// waitIndexed waits until the indexer indexed the given block.
+func waitIndexed(t *testing.T, indexer *core.ChainIndexer, block *types.Block) {
+	for i := 0; i < 500; i++ {
+		if sections, _, _ := indexer.Sections(); sections == block.NumberU64()+1 && indexer.SectionHead(block.NumberU64()) == block.Hash() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("block #%d not indexed", block.NumberU64())
+}
+
+// Tests that the internal transactions of the imported blocks are indexed by
+// address, searched in pages and rolled back on reorgs.
+func TestInternalTxIndex(t *testing.T) {
+	var (
+		db, _     = ethdb.NewMemDatabase()
+		key, _    = crypto.GenerateKey()
+		addr      = crypto.PubkeyToAddress(key.PublicKey)
+		forwarder = common.Address{0x0a}
+		recipient = common.Address{0x0b}
+		other     = common.Address{0x0c}
+	)
+	// The forwarder sends 1 wei to the recipient on every call
+	code := append(common.FromHex("6000600060006000600173"), recipient.Bytes()...)
+	code = append(code, common.FromHex("5af15000")...)
+
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			addr:      {Balance: big.NewInt(1000000000000000)},
+			forwarder: {Code: code, Balance: big.NewInt(1000)},
+		},
+	}
+	genesis := gspec.MustCommit(db)
+	signer := types.HomesteadSigner{}
+
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(addr), forwarder, new(big.Int), 100000, big.NewInt(1), nil), signer, key)
+		block.AddTx(tx)
+	})
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	indexer := NewInternalTxIndexer(db, chain)
+	defer indexer.Close()
+	indexer.Start(chain)
+	waitIndexed(t, indexer, blocks[2])
+
+	api := NewPublicTraceAPI(db, indexer)
+	filter := func(args TraceFilterArgs) []*RPCInternalTx {
+		txs, err := api.Filter(context.Background(), args)
+		if err != nil {
+			t.Fatalf("failed to filter internal transactions: %v", err)
+		}
+		return txs
+	}
+	txs := filter(TraceFilterArgs{ToAddress: []common.Address{recipient}})
+	if len(txs) != 3 {
+		t.Fatalf("internal transaction count mismatch: have %d, want 3", len(txs))
+	}
+	for i, tx := range txs {
+		if tx.Type != "CALL" || tx.From != forwarder || tx.To != recipient || tx.Value.ToInt().Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("internal transaction %d mismatch: %+v", i, tx)
+		}
+		if uint64(tx.BlockNumber) != blocks[i].NumberU64() || tx.BlockHash != blocks[i].Hash() || tx.TransactionHash != blocks[i].Transactions()[0].Hash() {
+			t.Errorf("internal transaction %d position mismatch: %+v", i, tx)
+		}
+		if len(tx.TraceAddress) != 1 || tx.TraceAddress[0] != 0 {
+			t.Errorf("internal transaction %d trace address mismatch: have %v, want [0]", i, tx.TraceAddress)
+		}
+	}
+	// Search the internal transactions by page and by block range
+	after, count := uint64(1), uint64(1)
+	if txs := filter(TraceFilterArgs{FromAddress: []common.Address{forwarder}, After: &after, Count: &count}); len(txs) != 1 || uint64(txs[0].BlockNumber) != 2 {
+		t.Errorf("paged search mismatch: have %+v, want the call of block 2", txs)
+	}
+	if txs := filter(TraceFilterArgs{After: &after}); len(txs) != 2 || uint64(txs[0].BlockNumber) != 2 {
+		t.Errorf("paged search without address mismatch: have %+v, want the calls of blocks 2 and 3", txs)
+	}
+	fromBlock, toBlock := rpc.BlockNumber(2), rpc.BlockNumber(2)
+	if txs := filter(TraceFilterArgs{FromBlock: &fromBlock, ToBlock: &toBlock, ToAddress: []common.Address{recipient, forwarder}}); len(txs) != 1 || uint64(txs[0].BlockNumber) != 2 {
+		t.Errorf("block range search mismatch: have %+v, want the call of block 2", txs)
+	}
+	if txs := filter(TraceFilterArgs{FromAddress: []common.Address{forwarder}, ToAddress: []common.Address{other}}); len(txs) != 0 {
+		t.Errorf("unmatched search returned %d internal transactions", len(txs))
+	}
+	// Reorg the last two blocks away, their internal transactions must go
+	fork, _ := core.GenerateChain(gspec.Config, blocks[0], ethash.NewFaker(), db, 3, func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(addr), other, big.NewInt(1), 21000, big.NewInt(1), nil), signer, key)
+		block.AddTx(tx)
+	})
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	waitIndexed(t, indexer, fork[2])
+
+	if txs := filter(TraceFilterArgs{ToAddress: []common.Address{recipient}}); len(txs) != 1 || txs[0].BlockHash != blocks[0].Hash() {
+		t.Errorf("search after reorg mismatch: have %+v, want the call of block 1", txs)
+	}
+}
+
+// Tests that the self destructs are indexed with their beneficiary and balance,
+// that the CREATE2 calls are indexed, and that searches before the first indexed
+// block are rejected.
+func TestInternalTxIndexDestructAndCreate2(t *testing.T) {
+	defer func(old *big.Int) { common.TIPCreate2 = old }(common.TIPCreate2)
+	common.TIPCreate2 = big.NewInt(0)
+
+	var (
+		db, _       = ethdb.NewMemDatabase()
+		key, _      = crypto.GenerateKey()
+		addr        = crypto.PubkeyToAddress(key.PublicKey)
+		destructor  = common.Address{0x0a}
+		factory     = common.Address{0x0b}
+		beneficiary = common.Address{0x0c}
+	)
+	// The destructor sends its balance to the beneficiary, the factory deploys
+	// an empty contract with CREATE2
+	code := append(common.FromHex("73"), beneficiary.Bytes()...)
+	code = append(code, 0xff)
+
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			addr:       {Balance: big.NewInt(1000000000000000)},
+			destructor: {Code: code, Balance: big.NewInt(1000)},
+			factory:    {Code: common.FromHex("6000600060006000f55000"), Balance: new(big.Int)},
+		},
+	}
+	genesis := gspec.MustCommit(db)
+	signer := types.HomesteadSigner{}
+
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, func(i int, block *core.BlockGen) {
+		to := destructor
+		if i == 1 {
+			to = factory
+		}
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(addr), to, new(big.Int), 100000, big.NewInt(1), nil), signer, key)
+		block.AddTx(tx)
+	})
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	indexer := NewInternalTxIndexer(db, chain)
+	defer indexer.Close()
+	indexer.Start(chain)
+	waitIndexed(t, indexer, blocks[1])
+
+	api := NewPublicTraceAPI(db, indexer)
+	txs, err := api.Filter(context.Background(), TraceFilterArgs{})
+	if err != nil {
+		t.Fatalf("failed to filter internal transactions: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("internal transaction count mismatch: have %d, want 2", len(txs))
+	}
+	if tx := txs[0]; tx.Type != "SELFDESTRUCT" || tx.From != destructor || tx.To != beneficiary || tx.Value.ToInt().Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("self destruct mismatch: %+v", tx)
+	}
+	if tx := txs[1]; tx.Type != "CREATE2" || tx.From != factory || tx.To != crypto.CreateAddress2(factory, common.Hash{}, crypto.Keccak256(nil)) {
+		t.Errorf("CREATE2 mismatch: %+v", tx)
+	}
+	// Blocks before the indexer started are rejected
+	core.WriteInternalTxIndexStart(db, 2)
+	fromBlock := rpc.BlockNumber(1)
+	if _, err := api.Filter(context.Background(), TraceFilterArgs{FromBlock: &fromBlock}); err == nil {
+		t.Errorf("search before the first indexed block succeeded")
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"time"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/core"
+	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/params"
+)
+
+const (
+	// logIndexSectionSize is the number of blocks in a section of the log index,
+	// the same as the bloom bits so that both index the same blocks.
+	logIndexSectionSize = params.BloomBitsBlocks
+
+	// logIndexConfirms is the number of confirmation blocks before a log index
+	// section is written. Entries of the blocks reorged away are left behind, the
+	// logs of the blocks found are checked anyway.
+	logIndexConfirms = 256
+
+	// logIndexThrottling is the time to wait between processing two consecutive
+	// index sections. It's useful when catching up with the chain to prevent disk
+	// overload.
+	logIndexThrottling = 100 * time.Millisecond
+)
+
+// LogIndexer implements a core.ChainIndexer, building up an index of the
+// blocks holding logs by log address and first topic, so that the searches of
+// the events of a contract are not bound to scan the bloom bits of the whole
+// range.
+type LogIndexer struct {
+	db    ethdb.Database // database instance to write index data and metadata into
+	batch ethdb.Batch    // batch of the section being indexed
+	err   error          // error a block of the section failed to be indexed with
+}
+
+// NewLogIndexer returns a chain indexer that indexes the logs of the canonical
+// chain by address and first topic.
+func NewLogIndexer(db ethdb.Database) *core.ChainIndexer {
+	backend := &LogIndexer{
+		db: db,
+	}
+	table := ethdb.NewTable(db, string(core.LogIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, logIndexSectionSize, logIndexConfirms, logIndexThrottling, "logindex")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new log index section.
+func (b *LogIndexer) Reset(section uint64, lastSectionHead common.Hash) error {
+	b.batch, b.err = b.db.NewBatch(), nil
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the logs of a block into
+// the index.
+func (b *LogIndexer) Process(header *types.Header) {
+	if b.err != nil || header.Bloom == (types.Bloom{}) {
+		return
+	}
+	receipts := core.GetBlockReceipts(b.db, header.Hash(), header.Number.Uint64())
+	b.err = core.WriteLogIndexEntries(b.batch, header.Number.Uint64(), receipts)
+}
+
+// Commit implements core.ChainIndexerBackend, writing the log index section out
+// into the database.
+func (b *LogIndexer) Commit() error {
+	if b.err != nil {
+		return b.err
+	}
+	return b.batch.Write()
+}
@@ -40,6 +40,7 @@ const (
 	maxKnownTxs      = 32768 // Maximum transactions hashes to keep in the known list (prevent DOS)
 	maxKnownOrderTxs = 32768 // Maximum transactions hashes to keep in the known list (prevent DOS)
 	maxKnownBlocks   = 1024  // Maximum block hashes to keep in the known list (prevent DOS)
+	maxKnownAttests  = 16384 // Maximum attestation hashes to keep in the known list (prevent DOS)
 	handshakeTimeout = 5 * time.Second
 )
 
@@ -68,6 +69,7 @@ type peer struct {
 	knownTxs    mapset.Set                // Set of transaction hashes known to be known by this peer
 	knownBlocks mapset.Set                // Set of block hashes known to be known by this peer
 	knownOrderTxs mapset.Set // Set of order transaction hashes known to be known by this peer
+	knownAttests  mapset.Set // Set of attestation hashes known to be known by this peer
 }
 
 func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
@@ -81,6 +83,7 @@ func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 		knownTxs:      mapset.NewSet(),
 		knownBlocks:   mapset.NewSet(),
 		knownOrderTxs: mapset.NewSet(),
+		knownAttests:  mapset.NewSet(),
 	}
 }
 
@@ -144,6 +147,16 @@ func (p *peer) MarkOrderTransaction(hash common.Hash) {
 	p.knownOrderTxs.Add(hash)
 }
 
+// MarkAttestation marks an attestation as known for the peer, ensuring that it
+// will never be propagated to this particular peer.
+func (p *peer) MarkAttestation(hash common.Hash) {
+	// If we reached the memory allowance, drop a previously known attestation hash
+	for p.knownAttests.Cardinality() >= maxKnownAttests {
+		p.knownAttests.Pop()
+	}
+	p.knownAttests.Add(hash)
+}
+
 // SendTransactions sends transactions to the peer and includes the hashes
 // in its transaction hash set for future reference.
 func (p *peer) SendTransactions(txs types.Transactions) error {
@@ -162,6 +175,15 @@ func (p *peer) SendOrderTransactions(txs types.OrderTransactions) error {
 	return p2p.Send(p.rw, OrderTxMsg, txs)
 }
 
+// SendAttestations sends attestations to the peer and includes the hashes
+// in its attestation hash set for future reference.
+func (p *peer) SendAttestations(attestations []*types.Attestation) error {
+	for _, attestation := range attestations {
+		p.knownAttests.Add(attestation.Hash())
+	}
+	return p2p.Send(p.rw, AttestationMsg, attestations)
+}
+
 // SendNewBlockHashes announces the availability of a number of blocks through
 // a hash notification.
 func (p *peer) SendNewBlockHashes(hashes []common.Hash, numbers []uint64) error {
@@ -479,6 +501,21 @@ func (ps *peerSet) OrderPeersWithoutTx(hash common.Hash) []*peer {
 	return list
 }
 
+// PeersWithoutAttestation retrieves a list of peers speaking eth/64 that do not
+// have a given attestation in their set of known hashes.
+func (ps *peerSet) PeersWithoutAttestation(hash common.Hash) []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		if p.version >= eth64 && !p.knownAttests.Contains(hash) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
 // BestPeer retrieves the known peer with the currently highest total difficulty.
 func (ps *peerSet) BestPeer() *peer {
 	ps.lock.RLock()
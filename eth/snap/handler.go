@@ -0,0 +1,231 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/p2p"
+	"github.com/tomochain/tomochain/trie"
+)
+
+// Backend provides the tries served over the snap protocol and the databases
+// the syncer writes into.
+type Backend interface {
+	// StateDatabase returns the trie database of the chain state, which also
+	// holds the contract codes.
+	StateDatabase() *trie.Database
+
+	// TomoXDatabase returns the trie database of the TomoX state, nil if the
+	// TomoX service doesn't run.
+	TomoXDatabase() *trie.Database
+}
+
+// MakeProtocols constructs the p2p protocol definitions for snap, serving the
+// tries of the backend and delivering the responses to the syncer.
+func MakeProtocols(backend Backend, syncer *Syncer) []p2p.Protocol {
+	protocols := make([]p2p.Protocol, len(ProtocolVersions))
+	for i, version := range ProtocolVersions {
+		version := version // Closure for the run
+		protocols[i] = p2p.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  ProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				peer := newPeer(version, p, rw)
+
+				syncer.Register(peer)
+				defer syncer.Unregister(peer.id)
+
+				return handle(backend, syncer, peer)
+			},
+		}
+	}
+	return protocols
+}
+
+// handle is the callback invoked to manage the life cycle of a snap peer. When
+// this function terminates, the peer is disconnected.
+func handle(backend Backend, syncer *Syncer, peer *Peer) error {
+	for {
+		if err := handleMsg(backend, syncer, peer); err != nil {
+			peer.Log().Debug("Snap message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// handleMsg is invoked whenever an inbound message is received from a remote
+// peer. The remote connection is torn down upon returning any error.
+func handleMsg(backend Backend, syncer *Syncer, peer *Peer) error {
+	// Read the next message from the remote peer, and ensure it's fully consumed
+	msg, err := peer.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(errMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	switch msg.Code {
+	case GetTrieRangeMsg:
+		var req getTrieRangeData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(errDecode, "msg %v: %v", msg, err)
+		}
+		return peer.sendTrieRange(serveTrieRange(backend, &req))
+
+	case TrieRangeMsg:
+		res := new(trieRangeData)
+		if err := msg.Decode(res); err != nil {
+			return errResp(errDecode, "msg %v: %v", msg, err)
+		}
+		syncer.deliver(peer.id, res.ID, res)
+
+	case GetByteCodesMsg:
+		var req getByteCodesData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(errDecode, "msg %v: %v", msg, err)
+		}
+		codes := serveBlobs(req.Hashes, req.Bytes, maxCodeLookups, backend.StateDatabase())
+		return peer.sendByteCodes(&byteCodesData{ID: req.ID, Codes: codes})
+
+	case ByteCodesMsg:
+		res := new(byteCodesData)
+		if err := msg.Decode(res); err != nil {
+			return errResp(errDecode, "msg %v: %v", msg, err)
+		}
+		syncer.deliver(peer.id, res.ID, res)
+
+	case GetTrieNodesMsg:
+		var req getTrieNodesData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(errDecode, "msg %v: %v", msg, err)
+		}
+		nodes := serveBlobs(req.Hashes, req.Bytes, maxNodeLookups, backend.StateDatabase(), backend.TomoXDatabase())
+		return peer.sendTrieNodes(&trieNodesData{ID: req.ID, Nodes: nodes})
+
+	case TrieNodesMsg:
+		res := new(trieNodesData)
+		if err := msg.Decode(res); err != nil {
+			return errResp(errDecode, "msg %v: %v", msg, err)
+		}
+		syncer.deliver(peer.id, res.ID, res)
+
+	default:
+		return errResp(errInvalidMsg, "%v", msg.Code)
+	}
+	return nil
+}
+
+// openTrie opens the chain state or TomoX state trie with the given root, nil
+// if neither is available.
+func openTrie(backend Backend, root common.Hash) *trie.Trie {
+	for _, triedb := range []*trie.Database{backend.StateDatabase(), backend.TomoXDatabase()} {
+		if triedb == nil {
+			continue
+		}
+		if tr, err := trie.New(root, triedb); err == nil {
+			return tr
+		}
+	}
+	return nil
+}
+
+// serveTrieRange gathers the leaves of a trie range along with the proof of
+// its edges, or an empty response if the trie is unavailable.
+func serveTrieRange(backend Backend, req *getTrieRangeData) *trieRangeData {
+	res := &trieRangeData{ID: req.ID}
+
+	tr := openTrie(backend, req.Root)
+	if tr == nil {
+		return res
+	}
+	limit := req.Bytes
+	if limit > softResponseLimit {
+		limit = softResponseLimit
+	}
+	var (
+		size      uint64
+		exhausted = true
+	)
+	it := trie.NewIterator(tr.NodeIterator(req.Origin[:]))
+	for it.Next() {
+		if bytes.Compare(it.Key, req.Origin[:]) < 0 {
+			continue
+		}
+		res.Keys = append(res.Keys, common.CopyBytes(it.Key))
+		res.Values = append(res.Values, common.CopyBytes(it.Value))
+
+		size += uint64(len(it.Key) + len(it.Value))
+		if bytes.Compare(it.Key, req.Limit[:]) >= 0 || size >= limit || len(res.Keys) >= maxRangeLeaves {
+			exhausted = false
+			break
+		}
+	}
+	if it.Err != nil {
+		return &trieRangeData{ID: req.ID}
+	}
+	// The whole trie needs no proof, any other range is proven by its edges
+	if req.Origin == (common.Hash{}) && exhausted {
+		return res
+	}
+	proof, _ := ethdb.NewMemDatabase()
+	if err := tr.Prove(req.Origin[:], 0, proof); err != nil {
+		return &trieRangeData{ID: req.ID}
+	}
+	if len(res.Keys) > 0 {
+		if err := tr.Prove(res.Keys[len(res.Keys)-1], 0, proof); err != nil {
+			return &trieRangeData{ID: req.ID}
+		}
+	}
+	for _, key := range proof.Keys() {
+		node, _ := proof.Get(key)
+		res.Proof = append(res.Proof, node)
+	}
+	return res
+}
+
+// serveBlobs gathers the trie nodes or contract codes with the given hashes
+// from the first database holding them, skipping the unknown ones.
+func serveBlobs(hashes []common.Hash, limit uint64, max int, triedbs ...*trie.Database) [][]byte {
+	if limit > softResponseLimit {
+		limit = softResponseLimit
+	}
+	var (
+		blobs [][]byte
+		size  uint64
+	)
+	for _, hash := range hashes {
+		if size >= limit || len(blobs) >= max {
+			break
+		}
+		for _, triedb := range triedbs {
+			if triedb == nil {
+				continue
+			}
+			if blob, err := triedb.Node(hash); err == nil && len(blob) > 0 {
+				blobs = append(blobs, blob)
+				size += uint64(len(blob))
+				break
+			}
+		}
+	}
+	return blobs
+}
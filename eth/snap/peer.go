@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"fmt"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/p2p"
+)
+
+// Peer is a remote peer speaking the snap protocol.
+type Peer struct {
+	id string
+
+	*p2p.Peer
+	rw p2p.MsgReadWriter
+
+	version uint // Protocol version negotiated
+}
+
+func newPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
+	id := p.ID()
+
+	return &Peer{
+		Peer:    p,
+		rw:      rw,
+		version: version,
+		id:      fmt.Sprintf("%x", id[:8]),
+	}
+}
+
+// RequestTrieRange fetches the leaves of a trie from origin on, up to the
+// first leaf past limit.
+func (p *Peer) RequestTrieRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching trie range", "root", root, "origin", origin, "limit", limit, "bytes", common.StorageSize(bytes))
+	return p2p.Send(p.rw, GetTrieRangeMsg, &getTrieRangeData{ID: id, Root: root, Origin: origin, Limit: limit, Bytes: bytes})
+}
+
+// RequestByteCodes fetches a batch of contract codes by hash.
+func (p *Peer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching contract codes", "count", len(hashes), "bytes", common.StorageSize(bytes))
+	return p2p.Send(p.rw, GetByteCodesMsg, &getByteCodesData{ID: id, Hashes: hashes, Bytes: bytes})
+}
+
+// RequestTrieNodes fetches a batch of trie nodes by hash.
+func (p *Peer) RequestTrieNodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching trie nodes", "count", len(hashes), "bytes", common.StorageSize(bytes))
+	return p2p.Send(p.rw, GetTrieNodesMsg, &getTrieNodesData{ID: id, Hashes: hashes, Bytes: bytes})
+}
+
+// sendTrieRange sends a range of trie leaves along with its proof.
+func (p *Peer) sendTrieRange(res *trieRangeData) error {
+	return p2p.Send(p.rw, TrieRangeMsg, res)
+}
+
+// sendByteCodes sends a batch of contract codes.
+func (p *Peer) sendByteCodes(res *byteCodesData) error {
+	return p2p.Send(p.rw, ByteCodesMsg, res)
+}
+
+// sendTrieNodes sends a batch of trie nodes.
+func (p *Peer) sendTrieNodes(res *trieNodesData) error {
+	return p2p.Send(p.rw, TrieNodesMsg, res)
+}
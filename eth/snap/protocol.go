@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package snap implements the snap sub-protocol, which serves contiguous
+// ranges of trie leaves with Merkle range proofs, and a syncer downloading
+// the chain state and the TomoX state in ranges before healing the trie nodes
+// the ranges left out.
+package snap
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tomochain/tomochain/common"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	snap1 = 1
+)
+
+// ProtocolName is the official short name of the protocol used during capability negotiation.
+const ProtocolName = "snap"
+
+// ProtocolVersions are the supported versions of the snap protocol (first is primary).
+var ProtocolVersions = []uint{snap1}
+
+// ProtocolLengths are the number of implemented message corresponding to different protocol versions.
+var ProtocolLengths = []uint64{6}
+
+const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
+
+// snap protocol message codes
+const (
+	GetTrieRangeMsg = 0x00
+	TrieRangeMsg    = 0x01
+	GetByteCodesMsg = 0x02
+	ByteCodesMsg    = 0x03
+	GetTrieNodesMsg = 0x04
+	TrieNodesMsg    = 0x05
+)
+
+const (
+	softResponseLimit = 2 * 1024 * 1024 // Target maximum size of returned ranges, codes or nodes
+	maxRangeLeaves    = 16384           // Amount of leaves to be served in a single range
+	maxCodeLookups    = 1024            // Amount of contract codes to be served in a single request
+	maxNodeLookups    = 1024            // Amount of trie nodes to be served in a single request
+)
+
+var (
+	errMsgTooLarge = errors.New("message too long")
+	errDecode      = errors.New("invalid message")
+	errInvalidMsg  = errors.New("invalid message code")
+)
+
+func errResp(err error, format string, v ...interface{}) error {
+	return fmt.Errorf("%v - %v", err, fmt.Sprintf(format, v...))
+}
+
+// getTrieRangeData represents a request for the leaves of a trie from Origin
+// on, up to the first leaf past Limit or until Bytes are gathered.
+type getTrieRangeData struct {
+	ID     uint64      // Request ID to match up the response with
+	Root   common.Hash // Root of the trie, either a chain state or a TomoX state one
+	Origin common.Hash // Key of the first leaf to retrieve
+	Limit  common.Hash // Key after which to stop retrieving leaves
+	Bytes  uint64      // Soft limit at which to stop returning data
+}
+
+// trieRangeData is the response to a trie range request, proven by the paths
+// of the origin and of the last key. The proof is omitted if the leaves are the
+// whole trie, and both are empty if the trie is unavailable.
+type trieRangeData struct {
+	ID     uint64   // ID of the request this is a response for
+	Keys   [][]byte // Keys of the leaves in increasing order
+	Values [][]byte // Values of the leaves
+	Proof  [][]byte // Trie nodes on the paths of the origin and of the last key
+}
+
+// getByteCodesData represents a request for contract codes by hash.
+type getByteCodesData struct {
+	ID     uint64        // Request ID to match up the response with
+	Hashes []common.Hash // Code hashes to retrieve the code for
+	Bytes  uint64        // Soft limit at which to stop returning data
+}
+
+// byteCodesData is the response to a contract code request, in request order
+// with the unknown codes skipped.
+type byteCodesData struct {
+	ID    uint64   // ID of the request this is a response for
+	Codes [][]byte // Requested contract codes
+}
+
+// getTrieNodesData represents a request for trie nodes by hash, used to heal
+// the parts of the tries the ranges left out.
+type getTrieNodesData struct {
+	ID     uint64        // Request ID to match up the response with
+	Hashes []common.Hash // Hashes of the trie nodes to retrieve
+	Bytes  uint64        // Soft limit at which to stop returning data
+}
+
+// trieNodesData is the response to a trie node request, in request order with
+// the unknown nodes skipped.
+type trieNodesData struct {
+	ID    uint64   // ID of the request this is a response for
+	Nodes [][]byte // Requested trie nodes
+}
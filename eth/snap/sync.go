@@ -0,0 +1,634 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/core/state"
+	"github.com/tomochain/tomochain/crypto"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/log"
+	"github.com/tomochain/tomochain/rlp"
+	"github.com/tomochain/tomochain/tomox/tomox_state"
+	"github.com/tomochain/tomochain/trie"
+)
+
+const (
+	rangeRequestBytes = 512 * 1024       // Soft size limit of the requested trie ranges
+	requestTimeout    = 10 * time.Second // Maximum time to wait for a response
+	stateSegments     = 16               // Number of key space segments of the account trie fetched concurrently
+	logInterval       = 8 * time.Second  // Interval between sync progress reports
+)
+
+var (
+	// ErrNoPeers is returned if no peer speaking snap can serve the sync.
+	ErrNoPeers = errors.New("no snap peers available")
+
+	// ErrCancelled is returned if the sync was cancelled.
+	ErrCancelled = errors.New("snap sync cancelled")
+
+	// ErrNoTomoX is returned if the TomoX state is synced without the TomoX
+	// service running.
+	ErrNoTomoX = errors.New("TomoX service not running")
+
+	errTimeout       = errors.New("request timed out")
+	errPeerDropped   = errors.New("peer dropped")
+	errUnsupportedDB = errors.New("trie database can't be written")
+)
+
+var (
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+	emptyCode = crypto.Keccak256Hash(nil)
+)
+
+// trieSpec tells how to download a kind of trie.
+type trieSpec struct {
+	// onLeaf returns the tries and the contract codes a leaf references, nil
+	// if the leaves reference nothing.
+	onLeaf func(leaf []byte) ([]childTrie, []common.Hash, error)
+
+	// newSync creates the scheduler healing the trie and the tries below it.
+	newSync func(root common.Hash, db trie.DatabaseReader) *trie.TrieSync
+}
+
+// childTrie is a trie referenced by a leaf.
+type childTrie struct {
+	root common.Hash
+	spec *trieSpec
+}
+
+var (
+	storageSpec = &trieSpec{
+		newSync: func(root common.Hash, db trie.DatabaseReader) *trie.TrieSync {
+			return trie.NewTrieSync(root, db, nil)
+		},
+	}
+	stateSpec = &trieSpec{
+		onLeaf: func(leaf []byte) ([]childTrie, []common.Hash, error) {
+			var account state.Account
+			if err := rlp.DecodeBytes(leaf, &account); err != nil {
+				return nil, nil, err
+			}
+			var (
+				children []childTrie
+				codes    []common.Hash
+			)
+			if account.Root != emptyRoot {
+				children = append(children, childTrie{root: account.Root, spec: storageSpec})
+			}
+			if hash := common.BytesToHash(account.CodeHash); hash != emptyCode {
+				codes = append(codes, hash)
+			}
+			return children, codes, nil
+		},
+		newSync: state.NewStateSync,
+	}
+	tomoxSpecs = make(map[tomox_state.TrieKind]*trieSpec)
+)
+
+func init() {
+	for _, kind := range []tomox_state.TrieKind{tomox_state.ExchangeTrie, tomox_state.OrderBookTrie, tomox_state.OrderTrie} {
+		kind := kind
+		tomoxSpecs[kind] = &trieSpec{
+			onLeaf: func(leaf []byte) ([]childTrie, []common.Hash, error) {
+				refs, err := tomox_state.ChildTries(kind, leaf)
+				if err != nil {
+					return nil, nil, err
+				}
+				children := make([]childTrie, 0, len(refs))
+				for _, ref := range refs {
+					children = append(children, childTrie{root: ref.Root, spec: tomoxSpecs[ref.Kind]})
+				}
+				return children, nil, nil
+			},
+			newSync: func(root common.Hash, db trie.DatabaseReader) *trie.TrieSync {
+				return tomox_state.NewTrieSync(root, kind, db)
+			},
+		}
+	}
+}
+
+// request is a request in flight, waiting for the response of a peer.
+type request struct {
+	peer     string
+	response chan interface{} // Delivered response, nil if the peer dropped
+}
+
+// Syncer downloads the chain state and the TomoX state from the snap peers:
+// the leaves of every trie are fetched in ranges verified by their proofs and
+// written as the trie nodes they make up, after which the nodes on the edges
+// of the ranges are healed one by one.
+type Syncer struct {
+	backend  Backend
+	dropPeer func(id string) // Drops a peer for misbehaving
+
+	peers   map[string]*Peer    // Registered snap peers
+	idle    map[string]struct{} // Peers without a request in flight
+	wake    chan struct{}       // Closed and replaced whenever a peer becomes available
+	pending map[uint64]*request // Requests in flight by ID
+	nextID  uint64
+
+	leaves uint64 // Number of trie leaves downloaded in ranges (atomic)
+	nodes  uint64 // Number of trie nodes healed (atomic)
+	codes  uint64 // Number of contract codes downloaded (atomic)
+
+	lock sync.Mutex
+}
+
+// NewSyncer creates a syncer writing into the databases of the backend.
+func NewSyncer(backend Backend, dropPeer func(id string)) *Syncer {
+	return &Syncer{
+		backend:  backend,
+		dropPeer: dropPeer,
+		peers:    make(map[string]*Peer),
+		idle:     make(map[string]struct{}),
+		wake:     make(chan struct{}),
+		pending:  make(map[uint64]*request),
+	}
+}
+
+// Register adds a peer to serve the sync.
+func (s *Syncer) Register(peer *Peer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.peers[peer.id] = peer
+	s.idle[peer.id] = struct{}{}
+	s.notify()
+}
+
+// Unregister removes a peer, failing its request in flight.
+func (s *Syncer) Unregister(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.peers, id)
+	delete(s.idle, id)
+	for reqID, req := range s.pending {
+		if req.peer == id {
+			req.response <- nil
+			delete(s.pending, reqID)
+		}
+	}
+	s.notify()
+}
+
+// notify wakes up the requests waiting for a peer. The lock must be held.
+func (s *Syncer) notify() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+// deliver hands a response over to the request waiting for it.
+func (s *Syncer) deliver(peer string, id uint64, res interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	req := s.pending[id]
+	if req == nil || req.peer != peer {
+		log.Debug("Unrequested snap response", "peer", peer, "id", id)
+		return
+	}
+	req.response <- res
+	delete(s.pending, id)
+}
+
+// reserve waits for an idle peer not excluded and marks it busy.
+func (s *Syncer) reserve(exclude map[string]struct{}, cancel chan struct{}) (*Peer, error) {
+	for {
+		s.lock.Lock()
+		available := false
+		for id, peer := range s.peers {
+			if _, ok := exclude[id]; ok {
+				continue
+			}
+			available = true
+			if _, ok := s.idle[id]; ok {
+				delete(s.idle, id)
+				s.lock.Unlock()
+				return peer, nil
+			}
+		}
+		wake := s.wake
+		s.lock.Unlock()
+
+		if !available {
+			return nil, ErrNoPeers
+		}
+		select {
+		case <-wake:
+		case <-cancel:
+			return nil, ErrCancelled
+		}
+	}
+}
+
+// release marks a peer idle again if it's still registered.
+func (s *Syncer) release(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.peers[id]; ok {
+		s.idle[id] = struct{}{}
+		s.notify()
+	}
+}
+
+// request sends a request to an idle peer not excluded and waits for the
+// response, returning the peer it was sent to.
+func (s *Syncer) request(exclude map[string]struct{}, cancel chan struct{}, send func(peer *Peer, id uint64) error) (string, interface{}, error) {
+	peer, err := s.reserve(exclude, cancel)
+	if err != nil {
+		return "", nil, err
+	}
+	defer s.release(peer.id)
+
+	s.lock.Lock()
+	s.nextID++
+	id, req := s.nextID, &request{peer: peer.id, response: make(chan interface{}, 1)}
+	s.pending[id] = req
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.pending, id)
+		s.lock.Unlock()
+	}()
+	if err := send(peer, id); err != nil {
+		return peer.id, nil, err
+	}
+	timer := time.NewTimer(requestTimeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-req.response:
+		if res == nil {
+			return peer.id, nil, errPeerDropped
+		}
+		return peer.id, res, nil
+	case <-timer.C:
+		return peer.id, nil, errTimeout
+	case <-cancel:
+		return peer.id, nil, ErrCancelled
+	}
+}
+
+// Sync downloads the chain state with the given root, along with the storage
+// tries and the contract codes of its accounts.
+func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
+	db, ok := s.backend.StateDatabase().DiskDB().(ethdb.Database)
+	if !ok {
+		return errUnsupportedDB
+	}
+	return s.sync("state", root, stateSpec, db, stateSegments, cancel)
+}
+
+// SyncTomoX downloads the TomoX state with the given root, along with the
+// order books and orders of its exchanges.
+func (s *Syncer) SyncTomoX(root common.Hash, cancel chan struct{}) error {
+	triedb := s.backend.TomoXDatabase()
+	if triedb == nil {
+		return ErrNoTomoX
+	}
+	db, ok := triedb.DiskDB().(ethdb.Database)
+	if !ok {
+		return errUnsupportedDB
+	}
+	return s.sync("TomoX state", root, tomoxSpecs[tomox_state.ExchangeTrie], db, 1, cancel)
+}
+
+// sync downloads a trie and everything below it, reporting the progress.
+func (s *Syncer) sync(name string, root common.Hash, spec *trieSpec, db ethdb.Database, segments int, cancel chan struct{}) error {
+	s.lock.Lock()
+	peers := len(s.peers)
+	s.lock.Unlock()
+	if peers == 0 {
+		return ErrNoPeers
+	}
+	var (
+		start = time.Now()
+		done  = make(chan struct{})
+	)
+	atomic.StoreUint64(&s.leaves, 0)
+	atomic.StoreUint64(&s.nodes, 0)
+	atomic.StoreUint64(&s.codes, 0)
+
+	go func() {
+		ticker := time.NewTicker(logInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				log.Info("Syncing "+name+" over snap", "root", root, "leaves", atomic.LoadUint64(&s.leaves), "healed", atomic.LoadUint64(&s.nodes), "codes", atomic.LoadUint64(&s.codes), "elapsed", common.PrettyDuration(time.Since(start)))
+			case <-done:
+				return
+			}
+		}
+	}()
+	defer close(done)
+
+	if err := s.syncTrie(root, spec, db, segments, cancel); err != nil {
+		return err
+	}
+	log.Info("Synced "+name+" over snap", "root", root, "leaves", atomic.LoadUint64(&s.leaves), "healed", atomic.LoadUint64(&s.nodes), "codes", atomic.LoadUint64(&s.codes), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// syncTrie downloads the leaves of a trie in ranges over concurrent segments
+// of the key space, then heals the nodes on the edges of the ranges.
+func (s *Syncer) syncTrie(root common.Hash, spec *trieSpec, db ethdb.Database, segments int, cancel chan struct{}) error {
+	if root == emptyRoot {
+		return nil
+	}
+	if has, _ := db.Has(root[:]); has {
+		return nil
+	}
+	// Abort every segment as soon as one of them fails
+	var (
+		abort = make(chan struct{})
+		once  sync.Once
+		stop  = func() { once.Do(func() { close(abort) }) }
+		errc  = make(chan error, segments)
+	)
+	defer stop()
+	go func() {
+		select {
+		case <-cancel:
+			stop()
+		case <-abort:
+		}
+	}()
+	for i := 0; i < segments; i++ {
+		origin, limit := segment(i, segments)
+		go func() {
+			err := s.syncRange(root, spec, db, origin, limit, abort)
+			if err != nil {
+				stop()
+			}
+			errc <- err
+		}()
+	}
+	var err error
+	for i := 0; i < segments; i++ {
+		if e := <-errc; e != nil && err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		select {
+		case <-cancel:
+			return ErrCancelled
+		default:
+			return err
+		}
+	}
+	return s.heal(root, spec, db, cancel)
+}
+
+// segment returns the key range of the i-th of n segments of the key space.
+func segment(i, n int) (common.Hash, common.Hash) {
+	var (
+		space = new(big.Int).Lsh(common.Big1, 256)
+		step  = new(big.Int).Div(space, big.NewInt(int64(n)))
+	)
+	origin := common.BigToHash(new(big.Int).Mul(step, big.NewInt(int64(i))))
+	if i == n-1 {
+		return origin, common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	}
+	return origin, common.BigToHash(new(big.Int).Sub(new(big.Int).Mul(step, big.NewInt(int64(i+1))), common.Big1))
+}
+
+// syncRange downloads the leaves of a trie between origin and limit, along
+// with the tries and codes they reference, and writes the trie nodes of each
+// range. A range is written only once everything it references is stored,
+// as the healing doesn't descend into stored nodes.
+func (s *Syncer) syncRange(root common.Hash, spec *trieSpec, db ethdb.Database, origin, limit common.Hash, cancel chan struct{}) error {
+	exclude := make(map[string]struct{})
+	for {
+		keys, values, more, err := s.fetchRange(root, origin, limit, exclude, cancel)
+		if err != nil {
+			return err
+		}
+		// Drop the leaves past the segment, fetched by the next one
+		for len(keys) > 0 && bytes.Compare(keys[len(keys)-1], limit[:]) > 0 {
+			keys, values, more = keys[:len(keys)-1], values[:len(values)-1], false
+		}
+		if spec.onLeaf != nil {
+			var codes []common.Hash
+			for _, value := range values {
+				children, hashes, err := spec.onLeaf(value)
+				if err != nil {
+					return err
+				}
+				for _, child := range children {
+					if err := s.syncTrie(child.root, child.spec, db, 1, cancel); err != nil {
+						return err
+					}
+				}
+				codes = append(codes, hashes...)
+			}
+			if err := s.syncCodes(codes, db, exclude, cancel); err != nil {
+				return err
+			}
+		}
+		if err := writeRange(db, keys, values); err != nil {
+			return err
+		}
+		atomic.AddUint64(&s.leaves, uint64(len(keys)))
+
+		if !more || len(keys) == 0 {
+			return nil
+		}
+		next := new(big.Int).Add(new(big.Int).SetBytes(keys[len(keys)-1]), common.Big1)
+		if next.BitLen() > 256 {
+			return nil
+		}
+		origin = common.BigToHash(next)
+	}
+}
+
+// fetchRange retrieves a range of leaves from origin on and verifies its
+// proof, returning whether the trie holds more leaves past it.
+func (s *Syncer) fetchRange(root, origin, limit common.Hash, exclude map[string]struct{}, cancel chan struct{}) ([][]byte, [][]byte, bool, error) {
+	for {
+		peer, res, err := s.request(exclude, cancel, func(p *Peer, id uint64) error {
+			return p.RequestTrieRange(id, root, origin, limit, rangeRequestBytes)
+		})
+		if err == ErrNoPeers || err == ErrCancelled {
+			return nil, nil, false, err
+		}
+		if err != nil {
+			log.Debug("Trie range request failed", "peer", peer, "err", err)
+			continue
+		}
+		data := res.(*trieRangeData)
+		if len(data.Keys) == 0 && len(data.Proof) == 0 {
+			// The peer doesn't have the trie, try another one
+			exclude[peer] = struct{}{}
+			continue
+		}
+		var proof trie.DatabaseReader
+		if len(data.Proof) > 0 {
+			memdb, _ := ethdb.NewMemDatabase()
+			for _, node := range data.Proof {
+				memdb.Put(crypto.Keccak256(node), node)
+			}
+			proof = memdb
+		}
+		more, err := trie.VerifyRangeProof(root, origin[:], data.Keys, data.Values, proof)
+		if err != nil {
+			log.Warn("Invalid trie range, dropping peer", "peer", peer, "root", root, "origin", origin, "err", err)
+			exclude[peer] = struct{}{}
+			s.dropPeer(peer)
+			continue
+		}
+		return data.Keys, data.Values, more, nil
+	}
+}
+
+// writeRange writes the trie nodes made up by a range of leaves. The nodes of
+// the subtries within the range are the ones of the full trie, while the ones
+// on its edges are superseded by the healing.
+func writeRange(db ethdb.Database, keys, values [][]byte) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	triedb := trie.NewDatabase(db)
+	tr, _ := trie.New(common.Hash{}, triedb)
+	for i, key := range keys {
+		if err := tr.TryUpdate(key, values[i]); err != nil {
+			return err
+		}
+	}
+	root, err := tr.Commit(nil)
+	if err != nil {
+		return err
+	}
+	return triedb.Commit(root, false)
+}
+
+// syncCodes downloads the contract codes missing from the database.
+func (s *Syncer) syncCodes(hashes []common.Hash, db ethdb.Database, exclude map[string]struct{}, cancel chan struct{}) error {
+	missing := make(map[common.Hash]struct{})
+	for _, hash := range hashes {
+		if has, _ := db.Has(hash[:]); !has {
+			missing[hash] = struct{}{}
+		}
+	}
+	for len(missing) > 0 {
+		batch := make([]common.Hash, 0, maxCodeLookups)
+		for hash := range missing {
+			if len(batch) == maxCodeLookups {
+				break
+			}
+			batch = append(batch, hash)
+		}
+		peer, res, err := s.request(exclude, cancel, func(p *Peer, id uint64) error {
+			return p.RequestByteCodes(id, batch, softResponseLimit)
+		})
+		if err == ErrNoPeers || err == ErrCancelled {
+			return err
+		}
+		if err != nil {
+			log.Debug("Contract code request failed", "peer", peer, "err", err)
+			continue
+		}
+		codes := res.(*byteCodesData).Codes
+		if len(codes) == 0 {
+			exclude[peer] = struct{}{}
+			continue
+		}
+		for _, code := range codes {
+			hash := crypto.Keccak256Hash(code)
+			if _, ok := missing[hash]; !ok {
+				continue
+			}
+			if err := db.Put(hash[:], code); err != nil {
+				return err
+			}
+			delete(missing, hash)
+			atomic.AddUint64(&s.codes, 1)
+		}
+	}
+	return nil
+}
+
+// heal downloads the trie nodes missing below the root one by one, which are
+// the ones on the edges of the ranges.
+func (s *Syncer) heal(root common.Hash, spec *trieSpec, db ethdb.Database, cancel chan struct{}) error {
+	var (
+		sched   = spec.newSync(root, db)
+		exclude = make(map[string]struct{})
+		retry   []common.Hash
+	)
+	for sched.Pending() > 0 {
+		hashes := retry
+		if len(hashes) < maxNodeLookups {
+			hashes = append(hashes, sched.Missing(maxNodeLookups-len(hashes))...)
+		}
+		peer, res, err := s.request(exclude, cancel, func(p *Peer, id uint64) error {
+			return p.RequestTrieNodes(id, hashes, softResponseLimit)
+		})
+		if err == ErrNoPeers || err == ErrCancelled {
+			return err
+		}
+		retry = hashes
+		if err != nil {
+			log.Debug("Trie node request failed", "peer", peer, "err", err)
+			continue
+		}
+		nodes := res.(*trieNodesData).Nodes
+		if len(nodes) == 0 {
+			exclude[peer] = struct{}{}
+			continue
+		}
+		requested := make(map[common.Hash]struct{}, len(hashes))
+		for _, hash := range hashes {
+			requested[hash] = struct{}{}
+		}
+		for _, node := range nodes {
+			hash := crypto.Keccak256Hash(node)
+			if _, ok := requested[hash]; !ok {
+				continue
+			}
+			if _, _, err := sched.Process([]trie.SyncResult{{Hash: hash, Data: node}}); err != nil {
+				return err
+			}
+			delete(requested, hash)
+			atomic.AddUint64(&s.nodes, 1)
+		}
+		retry = retry[:0:0]
+		for hash := range requested {
+			retry = append(retry, hash)
+		}
+		batch := db.NewBatch()
+		if _, err := sched.Commit(batch); err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
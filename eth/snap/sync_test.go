@@ -0,0 +1,148 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/core/state"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/p2p"
+	"github.com/tomochain/tomochain/p2p/discover"
+	"github.com/tomochain/tomochain/trie"
+)
+
+// testBackend serves and stores the chain state of a test node.
+type testBackend struct {
+	db     ethdb.Database
+	triedb *trie.Database
+}
+
+func newTestBackend() *testBackend {
+	db, _ := ethdb.NewMemDatabase()
+	return &testBackend{db: db, triedb: trie.NewDatabase(db)}
+}
+
+func (b *testBackend) StateDatabase() *trie.Database { return b.triedb }
+func (b *testBackend) TomoXDatabase() *trie.Database { return nil }
+
+// makeTestState creates a state with the given number of accounts, a part of
+// which have storage and code, and commits it to the backend.
+func makeTestState(t *testing.T, backend *testBackend, accounts int) common.Hash {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(backend.db))
+	for i := 0; i < accounts; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		statedb.SetBalance(addr, big.NewInt(int64(i+1)))
+		statedb.SetNonce(addr, uint64(i))
+		if i%5 == 0 {
+			statedb.SetCode(addr, []byte{byte(i), byte(i >> 8), 0x01})
+		}
+		if i%7 == 0 {
+			for j := 0; j < 20; j++ {
+				statedb.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(i*j+1))))
+			}
+		}
+	}
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := statedb.Database().TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+	return root
+}
+
+// connect links two syncers through a message pipe, each serving the state
+// of its backend to the other.
+func connect(local *Syncer, localBackend Backend, remote *Syncer, remoteBackend Backend) {
+	app, net := p2p.MsgPipe()
+
+	var localID, remoteID discover.NodeID
+	rand.Read(localID[:])
+	rand.Read(remoteID[:])
+
+	localPeer := newPeer(snap1, p2p.NewPeer(remoteID, "remote", nil), app)
+	remotePeer := newPeer(snap1, p2p.NewPeer(localID, "local", nil), net)
+
+	local.Register(localPeer)
+	remote.Register(remotePeer)
+
+	go handle(localBackend, local, localPeer)
+	go handle(remoteBackend, remote, remotePeer)
+}
+
+// Tests that the chain state is synced in ranges along with the storage tries
+// and codes of its accounts, leaving no node missing.
+func TestStateSync(t *testing.T) {
+	source := newTestBackend()
+	root := makeTestState(t, source, 2000)
+
+	dest := newTestBackend()
+	syncer := NewSyncer(dest, func(id string) { t.Errorf("peer %s dropped", id) })
+	connect(syncer, dest, NewSyncer(source, func(string) {}), source)
+
+	if err := syncer.Sync(root, make(chan struct{})); err != nil {
+		t.Fatalf("failed to sync state: %v", err)
+	}
+	statedb, err := state.New(root, state.NewDatabase(dest.db))
+	if err != nil {
+		t.Fatalf("failed to open synced state: %v", err)
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		t.Fatalf("synced state incomplete: %v", it.Error)
+	}
+	for i := 0; i < 2000; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		if balance := statedb.GetBalance(addr); balance.Cmp(big.NewInt(int64(i+1))) != 0 {
+			t.Fatalf("account %d: balance mismatch: have %v, want %d", i, balance, i+1)
+		}
+		if i%7 == 0 {
+			if value := statedb.GetState(addr, common.BigToHash(big.NewInt(3))); value != common.BigToHash(big.NewInt(int64(3*i+1))) {
+				t.Fatalf("account %d: storage mismatch: have %x", i, value)
+			}
+		}
+	}
+}
+
+// Tests that syncing without peers fails right away.
+func TestStateSyncNoPeers(t *testing.T) {
+	syncer := NewSyncer(newTestBackend(), func(string) {})
+	if err := syncer.Sync(common.HexToHash("0x01"), make(chan struct{})); err != ErrNoPeers {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrNoPeers)
+	}
+	if err := syncer.SyncTomoX(common.HexToHash("0x01"), make(chan struct{})); err != ErrNoTomoX {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrNoTomoX)
+	}
+}
+
+// Tests that a peer missing the state isn't asked again for it, failing the
+// sync once no peer can serve it.
+func TestStateSyncUnavailable(t *testing.T) {
+	dest := newTestBackend()
+	syncer := NewSyncer(dest, func(string) {})
+	connect(syncer, dest, NewSyncer(newTestBackend(), func(string) {}), newTestBackend())
+
+	if err := syncer.Sync(common.HexToHash("0x01"), make(chan struct{})); err != ErrNoPeers {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrNoPeers)
+	}
+}
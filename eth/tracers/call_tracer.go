@@ -0,0 +1,287 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/common/hexutil"
+	"github.com/tomochain/tomochain/core/vm"
+	"github.com/tomochain/tomochain/log"
+)
+
+func init() {
+	register("callTracer", newCallTracer)
+}
+
+// callFrame is a call made by a transaction, reported with the fields and in
+// the order of the JavaScript call tracer.
+type callFrame struct {
+	Type    string          `json:"type"`
+	From    *common.Address `json:"from,omitempty"`
+	To      *common.Address `json:"to,omitempty"`
+	Value   *hexutil.Big    `json:"value,omitempty"`
+	Gas     *hexutil.Uint64 `json:"gas,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Input   *hexutil.Bytes  `json:"input,omitempty"`
+	Output  *hexutil.Bytes  `json:"output,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Time    string          `json:"time,omitempty"`
+	Calls   []*callFrame    `json:"calls,omitempty"`
+
+	gasIn   uint64 // Gas available before the call
+	gasCost uint64 // Cost of the call opcode
+	outOff  int64  // Memory offset of the call output
+	outLen  int64  // Memory size of the call output
+}
+
+// callTracer is the native implementation of the JavaScript call tracer, which
+// reports all the internal calls made by a transaction.
+type callTracer struct {
+	callstack []*callFrame // Current recursive call stack of the EVM execution
+	descended bool         // Whether an inner call was just entered
+	call      callFrame    // Outer call of the transaction
+	callErr   error        // Error the outer call ended with
+	interrupt uint32       // Atomic flag to signal execution interruption
+	reason    error        // Reason of the interruption
+	err       error        // Error, if one has occurred
+}
+
+// newCallTracer creates a native call tracer, which has no configuration.
+func newCallTracer(cfg json.RawMessage) (ResultTracer, error) {
+	return &callTracer{callstack: []*callFrame{{}}}, nil
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing with
+// the outer call of the transaction.
+func (t *callTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.call.Type = "CALL"
+	if create {
+		t.call.Type = "CREATE"
+	}
+	t.call.From, t.call.To = &from, &to
+	t.call.Input = (*hexutil.Bytes)(&input)
+	t.call.Gas = (*hexutil.Uint64)(&gas)
+	t.call.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	return nil
+}
+
+// CaptureState implements the Tracer interface to follow the calls entered and
+// returned from by the opcodes.
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err != nil {
+		return nil
+	}
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.err = t.reason
+		return nil
+	}
+	if err != nil {
+		t.fault(err)
+		return nil
+	}
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		inOff, inLen := stackPeek(stack, 1).Int64(), stackPeek(stack, 2).Int64()
+		from := contract.Address()
+		input := hexutil.Bytes(memorySlice(memory, inOff, inOff+inLen))
+
+		t.callstack = append(t.callstack, &callFrame{
+			Type:    op.String(),
+			From:    &from,
+			Input:   &input,
+			Value:   (*hexutil.Big)(new(big.Int).Set(stackPeek(stack, 0))),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		t.descended = true
+		return nil
+
+	case vm.SELFDESTRUCT:
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, &callFrame{Type: op.String()})
+		return nil
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// Skip any pre-compile invocations, those are just fancy opcodes
+		to := common.BigToAddress(stackPeek(stack, 1))
+		if env.IsPrecompile(to) {
+			return nil
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		inOff, inLen := stackPeek(stack, 2+off).Int64(), stackPeek(stack, 3+off).Int64()
+		from := contract.Address()
+		input := hexutil.Bytes(memorySlice(memory, inOff, inOff+inLen))
+
+		call := &callFrame{
+			Type:    op.String(),
+			From:    &from,
+			To:      &to,
+			Input:   &input,
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  stackPeek(stack, 4+off).Int64(),
+			outLen:  stackPeek(stack, 5+off).Int64(),
+		}
+		if off == 1 {
+			call.Value = (*hexutil.Big)(new(big.Int).Set(stackPeek(stack, 2)))
+		}
+		t.callstack = append(t.callstack, call)
+		t.descended = true
+		return nil
+	}
+	// If an inner call was just entered, retrieve its true gas allowance, which
+	// depends on the stipend and the 63/64 rule. Calls to plain accounts are not
+	// entered, their gas is left out.
+	if t.descended {
+		if depth >= len(t.callstack) {
+			allowance := hexutil.Uint64(gas)
+			t.callstack[len(t.callstack)-1].Gas = &allowance
+		}
+		t.descended = false
+	}
+	if op == vm.REVERT {
+		t.callstack[len(t.callstack)-1].Error = "execution reverted"
+		return nil
+	}
+	if depth == len(t.callstack)-1 {
+		// An inner call returned, pop it off and gather its results
+		call := t.callstack[len(t.callstack)-1]
+		t.callstack = t.callstack[:len(t.callstack)-1]
+
+		ret := stackPeek(stack, 0)
+		if call.Type == vm.CREATE.String() || call.Type == vm.CREATE2.String() {
+			gasUsed := hexutil.Uint64(call.gasIn - call.gasCost - gas)
+			call.GasUsed = &gasUsed
+
+			if ret.Sign() != 0 {
+				to := common.BigToAddress(ret)
+				output := hexutil.Bytes(env.StateDB.GetCode(to))
+				call.To, call.Output = &to, &output
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		} else if call.Gas != nil {
+			gasUsed := hexutil.Uint64(call.gasIn - call.gasCost + uint64(*call.Gas) - gas)
+			call.GasUsed = &gasUsed
+
+			if ret.Sign() != 0 {
+				output := hexutil.Bytes(memorySlice(memory, call.outOff, call.outOff+call.outLen))
+				call.Output = &output
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		}
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, call)
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to record a failed opcode.
+func (t *callTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err == nil {
+		t.fault(err)
+	}
+	return nil
+}
+
+// fault fails the innermost call, which consumes all of its gas.
+func (t *callTracer) fault(err error) {
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.callstack[len(t.callstack)-1].Error != "" {
+		return
+	}
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+
+	call.Error = err.Error()
+	if call.Gas != nil {
+		gasUsed := *call.Gas
+		call.GasUsed = &gasUsed
+	}
+	// Flatten the failed call into its parent, unless it is the outer one
+	if len(t.callstack) > 0 {
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, call)
+		return
+	}
+	t.callstack = append(t.callstack, call)
+}
+
+// CaptureEnd implements the Tracer interface to gather the results of the
+// outer call.
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	t.call.Output = (*hexutil.Bytes)(&output)
+	t.call.GasUsed = (*hexutil.Uint64)(&gasUsed)
+	t.call.Time = d.String()
+	t.callErr = err
+	return nil
+}
+
+// GetResult returns the outer call of the transaction with all the calls made
+// within it.
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	result := t.call
+	result.Calls = t.callstack[0].Calls
+
+	if t.callstack[0].Error != "" {
+		result.Error = t.callstack[0].Error
+	} else if t.callErr != nil {
+		result.Error = t.callErr.Error()
+	}
+	if result.Error != "" {
+		result.Output = nil
+	}
+	return json.Marshal(&result)
+}
+
+// Stop terminates the tracing at the next opcode with the given error.
+func (t *callTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// stackPeek returns the n-th item from the top of the stack, or zero for a
+// stack that short.
+func stackPeek(stack *vm.Stack, n int) *big.Int {
+	data := stack.Data()
+	if len(data) <= n {
+		log.Warn("Tracer accessed out of bound stack", "size", len(data), "index", n)
+		return new(big.Int)
+	}
+	return data[len(data)-n-1]
+}
+
+// memorySlice returns a copy of the memory between the given offsets, or
+// nothing if they are out of its bounds.
+func memorySlice(memory *vm.Memory, begin, end int64) []byte {
+	if begin < 0 || begin > end || int64(memory.Len()) < end {
+		log.Warn("Tracer accessed out of bound memory", "available", memory.Len(), "offset", begin, "size", end-begin)
+		return nil
+	}
+	return memory.Get(begin, end-begin)
+}
@@ -92,7 +92,7 @@ func _4byte_tracerJs() (*asset, error) {
 	return a, nil
 }
 
-var _call_tracerJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd4\x59\xdf\x6f\x1b\x37\xf2\x7f\x96\xfe\x8a\x49\x1e\x6a\x09\x51\x24\x27\xe9\xb7\x5f\xc0\xae\x7a\xd0\x39\x4a\x6a\xc0\x8d\x03\x5b\x69\x10\x04\x79\xa0\x76\x67\x25\xd6\x5c\x72\x4b\x72\x2d\xef\xa5\xfe\xdf\x0f\x33\xe4\xae\x56\x3f\xec\xe8\x7a\xb8\x43\xef\x45\xd0\x2e\x67\x86\xc3\x99\xcf\xfc\xe2\x8e\x46\x70\x66\x8a\xca\xca\xc5\xd2\xc3\xcb\xe3\x17\xff\x0f\xb3\x25\xc2\xc2\x3c\x47\xbf\x44\x8b\x65\x0e\x93\xd2\x2f\x8d\x75\xdd\xd1\x08\x66\x4b\xe9\x20\x93\x0a\x41\x3a\x28\x84\xf5\x60\x32\xf0\x5b\xf4\x4a\xce\xad\xb0\xd5\xb0\x3b\x1a\x05\x9e\xbd\xcb\x24\x21\xb3\x88\xe0\x4c\xe6\x57\xc2\xe2\x09\x54\xa6\x84\x44\x68\xb0\x98\x4a\xe7\xad\x9c\x97\x1e\x41\x7a\x10\x3a\x1d\x19\x0b\xb9\x49\x65\x56\x91\x48\xe9\xa1\xd4\x29\x5a\xde\xda\xa3\xcd\x5d\xad\xc7\xdb\x77\x1f\xe0\x02\x9d\x43\x0b\x6f\x51\xa3\x15\x0a\xde\x97\x73\x25\x13\xb8\x90\x09\x6a\x87\x20\x1c\x14\xf4\xc6\x2d\x31\x85\x39\x8b\x23\xc6\x37\xa4\xca\x75\x54\x05\xde\x98\x52\xa7\xc2\x4b\xa3\x07\x80\x92\x34\x87\x5b\xb4\x4e\x1a\x0d\xaf\xea\xad\xa2\xc0\x01\x18\x4b\x42\x7a\xc2\xd3\x01\x2c\x98\x82\xf8\xfa\x20\x74\x05\x4a\xf8\x35\xeb\x01\x06\x59\x9f\x3b\x05\xa9\x79\x9b\xa5\x29\x10\xfc\x52\x78\x3a\xf5\x4a\x2a\x05\x73\x84\xd2\x61\x56\xaa\x01\x49\x9b\x97\x1e\x3e\x9e\xcf\x7e\xbe\xfc\x30\x83\xc9\xbb\x4f\xf0\x71\x72\x75\x35\x79\x37\xfb\x74\x0a\x2b\xe9\x97\xa6\xf4\x80\xb7\x18\x44\xc9\xbc\x50\x12\x53\x58\x09\x6b\x85\xf6\x15\x98\x8c\x24\xfc\x32\xbd\x3a\xfb\x79\xf2\x6e\x36\xf9\xfb\xf9\xc5\xf9\xec\x13\x18\x0b\x6f\xce\x67\xef\xa6\xd7\xd7\xf0\xe6\xf2\x0a\x26\xf0\x7e\x72\x35\x3b\x3f\xfb\x70\x31\xb9\x82\xf7\x1f\xae\xde\x5f\x5e\x4f\x87\x70\x8d\xa4\x15\x12\xff\xb7\x6d\x9e\xb1\xf7\x2c\x42\x8a\x5e\x48\xe5\x6a\x4b\x7c\x32\x25\xb8\xa5\x29\x55\x0a\x4b\x71\x8b\x60\x31\x41\x79\x8b\x29\x08\x48\x4c\x51\x1d\xec\x54\x92\x25\x94\xd1\x0b\x3e\xf3\x83\x80\x84\xf3\x0c\xb4\xf1\x03\x70\x88\xf0\xe3\xd2\xfb\xe2\x64\x34\x5a\xad\x56\xc3\x85\x2e\x87\xc6\x2e\x46\x2a\x88\x73\xa3\x9f\x86\x5d\x92\x99\x08\xa5\x66\x56\x24\x68\xc9\x39\x02\xb2\x92\xcc\xaf\xcc\x4a\x83\xb7\x42\x3b\x91\x90\xab\xe9\x7f\xc2\x60\x14\x1e\xf0\x8e\x9e\xbc\x23\xd0\x82\xc5\xc2\x58\xfa\xaf\x54\x8d\x33\xa9\x3d\x5a\x2d\x14\xcb\x76\x90\x8b\x14\x61\x5e\x81\x68\x0b\x1c\xb4\x0f\x43\x30\x0a\xee\x06\xa9\x33\x63\x73\x86\xe5\xb0\xfb\xb5\xdb\x89\x1a\x3a\x2f\x92\x1b\x52\x90\xe4\x27\xa5\xb5\xa8\x3d\x99\xb2\xb4\x4e\xde\x22\x93\x40\xa0\x89\xf6\x9c\xfe\xfa\x0b\xe0\x1d\x26\x65\x90\xd4\x69\x84\x9c\xc0\xe7\xaf\xf7\x5f\x06\x5d\x16\x9d\xa2\x4b\x50\xa7\x98\xf2\xf9\x6e\x1c\xac\x96\x6c\x51\x58\xe1\xd1\x2d\xc2\x6f\xa5\xf3\x2d\x9a\xcc\x9a\x1c\x84\x06\x53\x12\xe2\xdb\xd6\x91\xda\x1b\x16\x28\xe8\xbf\x46\xcb\x1a\x0d\xbb\x9d\x86\xf9\x04\x32\xa1\x1c\xc6\x7d\x9d\xc7\x82\x4e\x23\xf5\xad\xb9\x21\xc9\xc6\x12\x84\x6d\x05\xa6\x48\x4c\x1a\x83\x81\xce\xd1\x1c\x03\xdd\xb0\xdb\x21\xbe\x13\xc8\x4a\xcd\xdb\xf6\x94\x59\x0c\x20\x9d\xf7\xe1\x6b\xb7\x43\x62\xcf\x44\xe1\x4b\x8b\x6c\x4f\xb4\xd6\x58\x07\x32\xcf\x31\x95\xc2\xa3\xaa\xba\x9d\xce\xad\xb0\x61\x01\xc6\xa0\xcc\x62\xb8\x40\x3f\xa5\xc7\x5e\xff\xb4\xdb\xe9\xc8\x0c\x7a\x61\xf5\xc9\x78\xcc\xd9\x27\x93\x1a\xd3\x20\xbe\xe3\x97\xd2\x0d\x33\x51\x2a\xdf\xec\x4b\x4c\x1d\x8b\xbe\xb4\x9a\xfe\xde\x07\x2d\x3e\x22\x18\xad\x2a\x48\x28\xcb\x88\x39\x85\xa7\xab\x9c\xc7\x3c\x1e\xce\x0d\x20\x13\x8e\x4c\x28\x33\x58\x21\x14\x16\x9f\x27\x4b\x24\xdf\xe9\x04\xa3\x96\xae\x72\xec\xd4\x31\xd0\x6e\x43\x53\x0c\xbd\x79\x57\xe6\x73\xb4\xbd\x3e\x7c\x07\xc7\x77\xd9\x71\x1f\xc6\x63\xfe\x53\xeb\x1e\x79\xa2\xbe\x24\xc5\x14\xf1\xa0\xcc\x7f\xed\xad\xd4\x8b\x70\xd6\xa8\xeb\x79\x06\x02\x34\xae\x20\x31\x9a\x41\x4d\x5e\x99\xa3\xd4\x0b\x48\x2c\x0a\x8f\xe9\x00\x44\x9a\x82\x37\x01\x79\x0d\xce\x36\xb7\x84\xef\xbe\xe3\xbd\xc6\x70\x74\x76\x35\x9d\xcc\xa6\x47\x2d\x25\xa4\xbe\xcc\xb2\xa8\x07\xf3\x0e\x0b\xc4\x9b\xde\x8b\xfe\xf0\x56\xa8\x12\x2f\xb3\xa0\x51\xa4\x9d\xea\x14\xc6\x91\xe7\xd9\x36\xcf\xcb\x0d\x1e\x62\x1a\x8d\x60\xe2\x1c\xe6\x73\x85\xbb\xb1\x17\x83\x93\xe3\xd4\x79\x4a\x4e\x04\xb4\xc4\xe4\x85\x42\x02\x50\xbd\x6b\xb4\x34\x6b\xdc\xf1\x55\x81\x27\x00\x00\xa6\x18\xf0\x0b\x82\x3d\xbf\xf0\xe6\x67\xbc\x63\x77\xd4\xd6\x22\x00\x4d\xd2\xd4\xa2\x73\xbd\x7e\x3f\x90\x4b\x5d\x94\xfe\x64\x83\x3c\xc7\xdc\xd8\x6a\xe8\x28\xf7\xf4\xf8\x68\x83\x70\xd2\x9a\x67\x21\xdc\xb9\x26\x9e\x08\xca\xb7\xc2\xf5\xd6\x4b\x67\xc6\xf9\x93\x7a\x89\x1e\xea\x35\xb6\x05\xb1\x1d\x1d\xdf\x1d\xed\x5a\xeb\xb8\xbf\x76\xfa\x8b\x1f\xfa\xc4\x72\x7f\xda\x40\xb9\xc9\x08\xc3\xa2\x74\xcb\x1e\x23\x67\xbd\xba\x8e\xfa\x31\x78\x5b\xe2\x5e\xa4\x33\x7a\x76\x91\xe3\x50\x65\x94\x36\xbc\x2d\x13\x46\xd0\x42\x70\x52\xe1\xa0\x16\x94\x64\x5d\x39\x67\x9b\x7b\x63\x1e\x04\xd2\xf5\xf4\xe2\xcd\xeb\xe9\xf5\xec\xea\xc3\xd9\xac\x0d\x27\x85\x99\x27\xa5\x36\xcf\xa0\x50\x2f\xfc\x92\xf5\x27\x71\x9b\xab\x9f\x89\xe7\xf9\x8b\x2f\xe1\x0d\x8c\xf7\x44\x77\xe7\x71\x0e\xf8\xfc\x85\x65\xdf\xef\x9a\x6f\x93\x34\x18\xf3\x6b\x00\x91\x29\xee\xdb\x39\x62\x4f\xd8\xe5\xe8\x97\x26\xe5\x3c\x98\x88\x90\x4a\x6b\x2b\xa6\x46\xe3\xc1\xc1\xd7\xab\xa3\x6f\x72\x71\x71\x04\x7f\xfc\x01\xad\xe7\xb3\xcb\xd7\xd3\xf6\xbb\xd7\xd3\x8b\xe9\xdb\xc9\x6c\xba\x4d\x7b\x3d\x9b\xcc\xce\xcf\xf8\x6d\x3f\x5a\x65\x34\x82\xeb\x1b\x59\x70\x42\xe5\x34\x65\xf2\x82\x3b\xc3\x46\x5f\x37\x00\xbf\x34\xd4\x73\xd9\x58\x2f\x32\xa1\x93\x3a\x8f\xbb\xda\x69\xde\x90\xcb\x4c\x1d\x2b\xbb\xa9\xa0\x0d\xd4\x7e\xe3\x46\xe9\xde\x5b\x8c\x9b\xa6\x3d\x6f\x6a\xbd\xd6\x06\x0d\x1e\xe1\x5c\xc7\x49\xa6\x77\xf8\x21\xe1\x6f\x70\x0c\x27\xf0\x22\x66\x92\x47\x52\xd5\x4b\x78\x46\xe2\xff\x44\xc2\x7a\xb5\x87\xf3\xaf\x99\xb6\xbc\x61\xe2\x9a\xdc\x9b\xff\x7e\x3a\x33\xa5\xbf\xcc\xb2\x13\xd8\x36\xe2\xf7\x3b\x46\x6c\xe8\x2f\x50\xef\xd2\xff\xdf\x0e\xfd\x3a\xf5\x11\xaa\x4c\x01\x4f\x76\x20\x12\x12\xcf\x93\xad\x38\x88\xc6\xe5\x6e\x86\xa5\xc1\xf8\x81\x64\xfb\x72\x13\xc3\x0f\x65\x8b\x7f\x2b\xd9\xee\xed\xca\xa8\xf7\xda\xec\xbb\x06\x60\xd1\x5b\x89\xb7\x34\x59\x1d\x39\x16\x49\xfd\xa9\x59\x09\x9d\xe0\x10\x3e\x62\x90\xa8\x11\x39\xb9\xc4\x7e\x96\xda\x11\x6e\xf1\xa8\x27\x8d\x93\x09\x43\x4c\x70\xdb\x69\x11\x72\x51\xd1\x64\x92\x95\xfa\xa6\x82\x85\x70\x90\x56\x5a\xe4\x32\x71\x41\x1e\xf7\xb2\x16\x17\xc2\xb2\x58\x8b\xbf\x97\xe8\x68\xcc\x21\x20\x8b\xc4\x97\x42\xa9\x0a\x16\x92\x66\x15\xe2\xee\xbd\x7c\x75\x7c\x0c\xce\xcb\x02\x75\x3a\x80\x1f\x5e\x8d\x7e\xf8\x1e\x6c\xa9\xb0\x3f\xec\xb6\xd2\x78\x73\xd4\xe8\x0d\x5a\x88\xe8\x79\x8d\x85\x5f\xf6\xfa\xf0\xd3\x03\xf5\xe0\x81\xe4\xbe\x97\x16\x9e\xc3\x8b\x2f\x43\xd2\x6b\xbc\x81\xdb\xe0\x49\x40\xe5\x30\x4a\xa3\xf9\xee\xf2\xf5\x65\xef\x46\x58\xa1\xc4\x1c\xfb\x27\x3c\xef\xb1\xad\x56\x22\x36\xfc\xe4\x14\x28\x94\x90\x1a\x44\x92\x98\x52\x7b\x32\x7c\xdd\xbb\xab\x8a\xf2\xfb\x91\xaf\xe5\xf1\x68\x24\x92\x04\x9d\xab\xd3\x3d\x7b\x8d\xd4\x11\x39\x71\x83\xd4\x4e\xa6\xd8\xf2\x0a\x65\x07\xc3\xa9\x39\x52\xd0\xe4\x58\x0b\xcc\x8d\xa3\x4d\xe6\x08\x2b\x4b\x73\x86\x93\x3a\xe1\x41\x3b\x45\xb2\xb6\x03\xa3\x41\x80\x32\x3c\xdd\x73\x8c\x83\xb0\x0b\x37\x0c\xf9\x9e\xb6\xa5\x9c\xa3\xcd\x6a\xb8\x09\xe4\x36\x54\xb9\xa3\xdf\x6a\x07\x34\xe0\x9d\x74\x9e\x1b\x48\xd2\x52\x3a\x08\x48\x96\x7a\x31\x80\xc2\x14\x9c\xa7\x0f\xec\x25\xaf\xa6\xbf\x4e\xaf\x9a\xe2\x7f\xb8\x13\xeb\x16\xff\x69\x33\x01\x81\xa5\xf1\xc2\x63\xfa\x74\x4f\xcf\xbe\x07\x50\xe3\x07\x00\x45\xf2\xd7\xb5\xf1\x7d\xeb\x38\x4a\x38\xbf\x76\xcc\x02\xc3\xf8\xd2\x56\xc0\x95\xca\xbb\xad\xdc\xbd\x9d\x1c\x4c\x51\x57\x08\x52\x8a\xd3\x0e\x25\xf6\x3d\x9d\x75\x34\xb8\x6f\x03\x4f\x40\xa0\x69\x25\x00\x5e\xaf\x3b\x34\x11\x72\x3e\x6b\x68\x4a\x4f\x4e\xa7\x2a\xbd\x4e\x71\x0b\xe1\x3e\x38\xf6\x6d\x4c\x72\x73\xb9\x38\xd7\xbe\x57\x2f\x9e\x6b\x78\x0e\xf5\x03\xa5\x6e\x78\xbe\x11\x2b\x7b\x72\x60\x27\x45\x85\x1e\x61\x2d\xe2\x14\xb6\x5e\x91\xa0\x70\x68\x36\x8d\x45\xbf\x5b\x82\x8f\xa3\x34\x32\xcb\x13\x8b\x7e\x88\xbf\x97\x42\xb9\xde\x71\xd3\x12\x84\x13\x78\xc3\x45\x6c\xdc\x94\xb1\xba\xce\x11\xcf\x46\x93\x11\x05\x06\xb6\x68\x8d\x9a\x2d\x9d\x87\xda\x94\xe2\xa3\x12\xa2\x88\x98\x1c\x1a\x8f\x45\xf8\xed\xeb\x32\x3b\x6d\x02\x78\xda\x94\xfd\x4c\x48\x55\x5a\x7c\x7a\x0a\x7b\x92\x8b\x2b\x6d\x26\x12\xf6\xa5\x43\xe0\x11\xd4\x81\x33\x39\x2e\xcd\x2a\x28\xb0\x2f\x45\xed\x82\xa3\xc1\xc1\x56\x91\xe0\xbb\x14\xe1\xa0\x74\x62\x81\x2d\x70\x34\x06\xaf\x1d\xb5\x77\x2e\xfe\xd3\xd0\x79\xd6\x3c\x7e\x03\x45\x61\x97\x6f\x42\xe3\x31\x6c\xec\xf5\xf2\x4e\x2f\x53\x13\x71\x47\xd3\x7a\xa8\x55\x0d\x0d\x47\x83\x9c\x7f\xc5\xef\xff\x19\xc7\x07\xcf\xc7\xdf\x43\x03\x6d\x9b\x36\x9c\x71\x93\x38\x9c\x74\xdd\xc4\x7c\x1b\x05\xcd\xea\x43\x00\x78\xa8\x3f\x22\xa8\xea\xdf\x30\xf1\x6b\xb8\x72\x4b\x43\x4f\x85\xc5\x5b\x69\x4a\xaa\x56\xf8\xbf\x34\xff\x35\xfd\xdd\x7d\xb7\x73\x1f\xef\xbc\xd8\x7d\xed\x4b\xaf\xd5\x32\xde\xd9\x86\xd6\xa8\x55\x2b\x0c\x17\xd2\x78\x15\x96\x85\xdb\xd4\x0e\xf3\x3f\x72\xf9\x15\xe3\xdd\x9b\x82\x6a\x7f\x2c\x45\xca\xa2\x48\xab\xa6\xfa\x0d\x42\xd7\x01\x4b\xa1\xd3\x38\x79\x88\x34\x95\x24\x8f\xb1\x48\x1a\x8a\x85\x90\xba\xbb\xd7\x8c\xdf\x2c\xb9\xfb\x90\xb1\xd3\xc8\xb6\xab\x66\x9c\x18\x69\xbc\x63\x8d\xbb\x07\x54\xc7\xad\x58\xda\xbe\xc7\x8b\x57\x81\x46\xbb\x32\xe7\xb6\x17\xc4\xad\x90\x4a\xd0\xa8\xc5\xed\x94\x4e\x21\x51\x28\x74\xb8\xbd\xc7\xcc\x9b\x5b\xb4\xae\x7b\x00\xc8\xff\x0c\xc6\xb7\x92\x63\xfd\x18\xcd\x71\x78\xcc\x1e\x1a\xb1\xe1\xf8\x6f\x94\xf0\x3e\xc2\xab\x65\xde\x10\x59\xd2\xf3\x87\x1d\xd4\xbe\x7b\x58\x48\x71\x83\x44\x34\x3f\xc1\x71\xab\x09\xff\xab\x04\xd9\x2e\xc4\x2e\x9a\x66\x2c\x1e\xde\x1b\x33\x00\x85\x82\x47\xa2\xfa\xb3\x4b\xdd\x7c\x3e\x36\xa1\xd5\xd1\x1b\xda\xb7\x9d\xf0\xe5\x4b\xac\x25\xd6\xd7\x1d\xa1\x8f\x9f\x23\x6a\x90\x1e\xad\xa0\xe1\x87\xd0\x15\xbf\x14\x90\x96\x8e\xc5\xb1\x5f\x24\x05\x5d\x14\x1c\xaf\xed\xa9\x3e\x4b\xbd\x18\x76\x3b\xe1\x7d\x2b\xde\x13\x7f\xb7\x8e\xf7\x50\x0c\x99\x33\x5e\x00\x34\xf3\x7f\xe2\xef\xb8\x67\xe4\x19\x79\xeb\x12\x80\xd6\xe8\x55\x18\xa0\xb7\x46\x7e\x66\x8c\x63\xff\xf6\xcd\x22\xad\xf1\xbb\x0d\x80\x33\xe9\x42\xb8\x20\x66\x2b\x24\xfc\xdd\x6e\x44\xd4\x0c\x14\x0c\x27\xfb\x19\x68\x69\x0f\xd3\xd6\x35\x04\x11\xf3\xab\xb0\x1a\x0a\xfb\x49\x7b\x35\xbc\x8a\x07\x95\x79\xcb\x36\x32\x67\xdb\xdc\x9f\xee\x4f\x72\xc7\x35\x1e\xf7\x27\x33\xb2\x79\x03\xd8\x07\x58\xdb\x83\xc5\x2e\xc9\x63\xa9\x92\xa5\xd7\x99\xed\x01\x56\x96\xde\x6a\x3d\xfc\xdd\xe1\x22\x1b\xe2\xb6\x8a\x1b\x34\xfb\x84\xc4\x3c\x13\xe9\x82\x65\x6b\x01\x01\xd5\x41\x57\x46\xb4\xfc\x07\x46\x89\xed\xf8\xa9\x97\xc0\x62\xf8\xb0\xc0\x0d\x29\x85\x8f\x99\x73\xf1\x2f\x1d\xcd\x8c\xeb\xb8\x48\xd1\x49\x8b\x29\x64\x12\x55\x0a\x26\x45\xcb\x13\xe9\x6f\xce\xe8\xf0\x09\x09\xad\x24\x89\xe1\x53\x59\xf8\x6a\xcd\x1f\xf0\xb4\x4c\xd0\x57\x90\xa1\xe0\x6f\x41\xde\x40\x21\x9c\x83\x1c\x05\xcd\xa0\x59\xa9\x54\x05\xc6\xa6\x48\xc2\x9b\xa1\x8c\x42\xd2\x40\xe9\xd0\x3a\x58\x2d\x4d\x2c\x93\xdc\xa5\x15\xd4\x74\x4a\x3f\x88\xf7\x2e\xd2\x15\x4a\x54\x20\x3d\x95\xe4\x78\xa8\x76\x94\x36\x1f\x60\xf8\x2b\x8e\xa1\xaa\xbb\x1b\xa2\xf5\x5c\xb7\x19\xa3\xfc\x9a\x9e\x36\xa3\x33\xce\x35\x9b\x71\xb9\xbe\x91\xda\x0c\xc2\xba\x6c\x6c\x46\x5a\xbb\x08\x6d\x86\x13\xaf\xf0\xd3\x66\x20\xb5\xfa\x65\x5e\x60\x70\x34\x0c\xfc\xb4\x15\x5a\xac\x65\x8c\xad\xf0\xb9\xb1\x21\xe7\xa7\x41\x04\x0c\x79\xb1\x47\xc6\xb9\xc1\x8a\x32\x71\xb0\x51\xab\xac\x84\x17\x9f\x6f\xb0\xfa\xb2\xbf\x8a\x44\x38\xb6\xe8\x9a\xb2\x51\x43\x3a\xac\x3d\x12\xc8\x8d\x16\x72\x7c\x7c\x0a\xf2\xc7\x36\x43\x5d\xf9\x40\x3e\x7b\x56\xef\xd9\x5e\xff\x2c\xbf\xd4\xd1\xd9\x20\x7e\x6b\xbd\xbf\xa1\x51\x8c\x91\x40\x43\x41\xd1\xbd\xef\xfe\x33\x00\x00\xff\xff\xb5\x25\x8b\x4d\x94\x21\x00\x00")
+var _call_tracerJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd5\x59\x6d\x6f\xdb\x38\x12\xfe\x1c\xff\x0a\xb6\x1f\x1a\x1b\x75\x1d\x37\xdd\xeb\x01\xc9\xa6\x07\x5f\xea\xb4\x01\xb2\x4d\x90\xb8\x5b\x14\x45\x3f\xd0\x12\x65\x6b\x23\x8b\x5a\x91\x8a\xeb\xeb\xe6\xbf\xdf\x33\x43\x4a\x96\x6c\xe5\x65\x7b\xb8\xc3\x5e\x3e\xb4\x96\x38\x33\x1c\xce\x3c\xf3\x46\xed\xed\x89\x63\x9d\xad\xf2\x78\x36\xb7\x62\x7f\xf8\xf2\xef\x62\x32\x57\x62\xa6\x5f\x28\x3b\x57\xb9\x2a\x16\x62\x54\xd8\xb9\xce\x4d\x67\x6f\x0f\x4b\xb1\x11\x51\x9c\x28\x81\xff\x33\x99\x5b\xa1\x23\x61\x37\xe8\x93\x78\x9a\xcb\x7c\x35\x00\x83\xe3\x69\x5d\x26\x09\x51\xae\x94\x30\x3a\xb2\x4b\x99\xab\x03\xb1\xd2\x85\x08\x64\x2a\x72\x15\xc6\xc6\xe6\xf1\xb4\xb0\xd8\xc8\x0a\x99\x86\x7b\x3a\x17\x0b\x1d\xc6\xd1\x8a\x44\xe2\x5d\x91\x86\x2a\xe7\xad\xad\xca\x17\xa6\xd4\xe3\xdd\x87\x8f\xe2\x4c\x19\x83\xb5\x77\x2a\x55\xb9\x4c\xc4\x45\x31\x4d\xe2\x40\x9c\xc5\x81\x4a\x8d\x12\x12\x8a\xd3\x1b\x33\x57\xa1\x98\xb2\x38\x62\x3c\x21\x55\xae\xbc\x2a\xe2\x44\x43\xbe\xb4\xb1\x4e\xfb\x42\xc5\xa4\xb9\xb8\x51\xb9\xc1\xb3\x78\x55\x6e\xe5\x05\xf6\x85\xce\x49\x48\x57\x5a\x3a\x40\x2e\x74\x46\x7c\x3d\x68\xbd\x12\x89\xb4\x6b\xd6\x47\x18\x64\x7d\xee\x50\xc4\x29\x6f\x33\xd7\x19\xce\x38\x87\x74\x9c\x7a\x19\x27\x89\x98\x2a\x51\x18\x15\x15\x49\x9f\xa4\x81\x58\x7c\x3a\x9d\xbc\x3f\xff\x38\x11\xa3\x0f\x9f\xc5\xa7\xd1\xe5\xe5\xe8\xc3\xe4\xf3\x21\x88\xe1\x37\xac\xaa\x1b\xe5\x44\xc5\x8b\x2c\x89\x21\x19\x47\xcc\x65\x6a\x57\x38\x09\x49\xf8\x65\x7c\x79\xfc\x1e\x2c\xa3\x7f\x9e\x9e\x9d\x4e\x3e\xe3\x3c\xe2\xe4\x74\xf2\x61\x7c\x75\x25\x4e\xce\x2f\xc5\x48\x5c\x8c\x2e\x27\xa7\xc7\x1f\xcf\x46\x97\xe2\xe2\xe3\xe5\xc5\xf9\xd5\x78\x20\xae\x14\x69\xa5\x88\xff\x61\x9b\x47\xec\x3d\xd8\x35\x54\x56\xc6\x89\x29\x2d\xf1\x19\x0e\x37\xd0\x31\x09\xc5\x5c\xde\x28\x38\x3e\x50\xf1\x0d\x34\x94\x22\x00\x26\x1f\xed\x54\x92\x25\x13\x9d\xce\xf8\xcc\x77\x02\x52\x9c\x46\x22\xd5\xb6\x2f\x0c\x94\xff\x79\x6e\x6d\x76\xb0\xb7\xb7\x5c\x2e\x07\xb3\xb4\x18\xe8\x7c\xb6\x97\x38\x71\x66\xef\xcd\xa0\x43\x32\x03\x99\x24\x93\x5c\x06\xd8\x18\xce\x91\x02\x36\x87\xf9\x13\xbd\x84\x3d\x61\x41\x23\x03\x72\x35\xfd\x0e\x18\x8c\x70\x92\xfa\x46\x4f\xd6\x10\x68\x71\x9e\x4c\xe7\xf4\x3b\x49\x4a\x9c\xc5\x29\x10\x91\xe2\x04\x24\xdb\x88\x85\x0c\x15\x50\x08\xd9\x35\x81\xfd\xfa\x61\x08\x46\xce\xdd\xe0\x85\x21\x17\x0c\xcb\x41\xe7\x7b\x67\xc7\x6b\x68\xac\x0c\xae\x49\x41\x92\x1f\x14\x79\xae\x52\x4b\xa6\x2c\x80\x3a\x18\x95\x48\x84\xa3\xf1\xf6\x1c\xff\xfa\x0b\xf4\x04\x81\x93\xb4\x53\x09\x39\x10\x5f\xbe\xdf\x7e\xed\x77\x58\x74\xa8\x0c\xac\x11\xc2\x1b\x74\xa2\x6b\x23\x96\x73\xb6\xa8\x58\xaa\x5d\x88\xfd\xad\x30\xb6\x46\x13\xe5\x7a\x01\x5d\x05\x00\x47\xa6\xa8\x59\x07\x27\xd6\x2c\x50\xd2\x6f\xb8\x8f\x35\xc2\xb6\x15\xf3\x81\x88\x64\x82\x48\x72\xfb\x1a\xab\x32\x3a\x4d\x9c\xde\xe8\x6b\x92\x0c\xf0\x00\xc2\x08\x10\x9d\x05\x3a\xf4\xc1\x40\xe7\xa8\x8e\xa1\x80\xa8\x1d\xe2\x83\xa4\x22\xe5\x6d\xbb\x89\x9e\xf5\x45\x38\xed\x09\x18\x8a\xc4\x1e\xcb\xcc\x16\x80\x20\xd9\x53\xe5\x39\x12\x1a\xe2\x61\x81\x4c\x83\x10\x4d\x56\xa0\xb9\x91\xb9\x5b\x10\x47\x02\xcc\x83\x99\xb2\x63\x7a\xec\xf6\x0e\xb1\x1a\x47\xa2\xeb\x56\x9f\x1c\x1d\x71\xf6\x89\xe2\x54\x85\x4e\xfc\x8e\x45\x5e\x1c\x44\xb2\x48\x6c\xb5\x2f\x31\xed\xe4\x0a\x7b\xa6\xf4\xf3\xd6\x69\xf1\x49\x09\x9d\x26\x2b\x98\x80\x54\x99\x52\x78\x9a\x15\x34\x5f\xf8\xc3\x99\x3e\x6c\x61\xc8\x84\xd8\x70\xa9\x44\x96\xab\x17\xc1\x5c\x91\xef\xd2\x40\x79\x2d\xc1\xc1\x4e\x3d\x12\xb4\xdb\x40\x67\x03\xab\x3f\x14\x8b\xa9\x82\xae\xe2\x99\x18\x7e\x8b\x86\x3d\x01\x2d\xe9\x47\xa9\xbb\xe7\xf1\xfa\x92\x14\x9d\xf9\x83\x32\xff\x15\xf2\x4e\x3a\x73\x67\xf5\xba\x22\x5a\xa4\x48\xd5\x12\xb1\x98\x32\xa8\xc9\x2b\x53\x05\x32\x11\xe4\x0a\x66\x0b\x01\xd4\x10\xf0\xd0\x0e\x79\x15\xce\x9a\x5b\x8a\x67\xcf\x44\x97\x36\x3b\x12\xbb\xc7\x97\xe3\xd1\x64\xbc\x2b\xfe\xf8\x43\x34\xde\xec\xef\xf6\x6a\x9a\xc5\xe9\x79\x14\x79\xe5\x58\xe0\x20\x53\xea\xba\xfb\xb2\x37\xb8\x91\x49\xa1\xce\x23\xa7\xa6\xa7\x1d\x23\xd0\x8e\x3c\xcf\xf3\x4d\x9e\xfd\x06\x0f\x31\xe1\x60\x23\xa4\x92\xc5\x34\x51\xdb\x01\xe9\x23\x96\x83\xd7\x58\xca\x58\x84\xbe\x40\x23\x71\x2a\x42\x55\xb9\xab\x37\x3f\x6b\xbc\x63\x57\x19\x8a\x17\xfe\x74\xd6\xe7\x17\x14\x0b\xfc\xc2\xea\xf7\xea\x1b\xfb\xa8\x34\x21\xa1\x6a\x14\x86\x39\xb2\x59\xb7\xd7\x73\xe4\x71\x9a\x15\xf6\xa0\x41\xbe\x50\x48\x97\xab\x81\xa1\x84\xd4\xe5\xa3\xf5\xdd\x49\x4b\x9e\x99\x34\xa7\x29\xf1\x78\xa4\xbe\x93\x90\x57\x2d\x1d\x6b\x03\x81\x7e\x89\x1e\xca\x35\xb6\x05\xb1\xed\x0e\xbf\xed\x6e\x5b\x6b\xd8\x5b\x23\xe1\xe5\xeb\x1e\xb1\xdc\x1e\x56\xf8\xae\xd2\xc4\x20\x2b\xcc\xbc\xcb\x70\x5a\xaf\xae\x53\xc1\x11\xc2\xbf\x50\xad\xf0\x67\x48\x6d\xc3\xc9\xa8\x24\xa2\x5c\x02\xbe\x80\x61\x35\x93\x9c\x69\x38\xd2\x25\x65\x5e\x53\x4c\xd9\xe6\x56\xeb\x6d\x74\x79\x28\x5d\x8d\xcf\x4e\xde\x8e\xaf\x26\x97\x1f\x8f\x27\xbb\x35\x38\x25\x2a\xb2\xa4\x54\xf3\x0c\x89\x4a\x67\x76\xce\xfa\x93\xb8\xe6\xea\x17\xe2\x79\xf1\xf2\xab\x7b\x03\xe9\xdb\x21\xbf\x73\x3f\x87\xf8\xf2\x95\x65\xdf\x76\x1e\x20\x75\xc6\xfc\xee\x40\xa4\xb3\xdb\x7a\xe2\x68\x89\xc5\x05\x72\xb0\x0e\x39\x39\x06\xd2\xe5\xd7\xd2\x8a\xa1\x4e\xd5\x9f\x8f\xc8\xd1\xd9\x59\x23\x1e\xf1\x7c\x7c\xfe\xb6\x11\xa3\x6f\xc7\x67\xe3\x77\x88\xd2\x4d\xda\xab\xc9\x08\x7d\x01\xbf\x2d\xc3\x17\xaa\x5e\x5d\xc7\x19\x67\x59\xce\x5d\x08\x1d\x6e\x17\x2b\x7d\x91\xe1\x70\x02\x6a\xc4\x72\x5f\x44\x22\x99\x06\x65\x72\x37\xa5\xd3\x70\x04\xb8\x4c\x97\xb1\xb2\x9d\x0a\xea\x40\xed\x55\x6e\x8c\xcd\x05\x2a\x9f\xdb\x34\xec\x5a\x5d\xea\xb5\x36\xa8\xf3\x08\x27\x40\x4e\x32\xdd\xc7\x1f\x52\xfc\x43\x0c\xc5\x81\x78\xe9\x33\xc9\x3d\xa9\x6a\x1f\xb1\x05\xf1\x3f\x90\xb0\x5e\xb5\x70\xfe\x35\xd3\x96\xd5\x4c\x5c\x92\xc3\xd6\xff\xf3\x74\x86\xf2\x09\x59\x07\x62\xd3\x88\x3f\x6d\x19\xb1\xa2\x3f\x53\xe9\x36\xfd\xdf\xb6\xe8\xd7\xa9\x8f\x50\x05\x28\x3c\xd9\x82\x88\x4b\x3c\x4f\x36\xe2\xc0\x1b\x97\x5b\x1c\x96\x06\x7b\xb7\x27\xdb\xfd\x26\x86\xef\xca\x16\xff\x51\xb2\x6d\x6d\xd5\xa8\x21\x6b\x36\x63\x7d\x00\x08\x8a\xa0\xcb\xc2\x90\xb1\x6b\x58\x24\x35\xad\x7a\x89\xd0\x54\x03\x74\x2d\x4e\x62\xaa\x14\x27\x17\xdf\xe4\x52\x8f\xc2\x7d\x1f\x35\xaa\x7e\x5c\x61\x88\x49\xee\x45\x01\xc3\x85\x5c\xd1\xb8\x82\xa6\xec\x7a\x85\xa4\x8e\x01\x67\x95\xca\x45\x1c\x18\x27\x8f\x1b\xdc\x5c\xcd\x64\xce\x62\x73\xf5\x7b\x81\x22\x40\xfd\x3f\x80\x8c\x0d\x0a\x08\x03\x5f\x4c\x03\x0c\x71\x77\xf7\x5f\x0d\x87\x40\x78\x9c\xe1\x24\x7d\xf1\xfa\xd5\xde\xeb\x9f\x44\x5e\x24\xaa\x37\xe8\xd4\xd2\x78\x75\x54\xef\x0d\x5a\xf0\xe8\x79\xab\x32\x3b\x47\x97\xf4\xe6\x8e\x7a\x70\x47\x72\x6f\xa5\x15\x2f\x04\x92\x38\xe9\x75\xd4\xc0\xad\xf3\xa4\x50\x68\x69\xbd\x34\x1a\xfa\xce\xdf\x9e\x77\xaf\x25\x66\x17\x39\x55\xbd\x03\x1e\x02\xd9\x56\x4b\xe9\xa7\x00\x72\x8a\xc8\x12\x09\x43\xca\x20\xc0\x00\x6a\xc9\xf0\x65\x43\x0f\x3b\x20\xbf\xef\xda\x52\x1e\xcf\x4b\xa0\x43\x44\x96\xe9\x9e\xbd\x46\xea\xc8\x05\x71\xc3\xbf\x26\x0e\x55\xcd\x2b\x94\x1d\x34\xa7\x66\x4f\x41\xe3\x64\x29\x70\x81\xb8\x4a\xd8\x5b\xcb\x9c\x86\x0f\x13\xc3\xf5\x34\x73\x86\x8a\xac\x8d\x09\x1b\x7a\xe1\x9c\x3c\xf2\x73\x8c\x23\x83\xcf\xcc\xc0\xe5\x7b\xda\x96\x72\x4e\xaa\x97\x83\x26\x90\xeb\x50\xe5\x36\x7f\xa3\x1d\x48\x81\x26\x4c\xbd\xdc\x55\x92\x96\x28\x67\x0e\xc9\x78\xd3\x17\x19\x42\x8c\xf2\xf4\x43\xe5\xcc\x27\xeb\xcb\xf1\xaf\xe3\xcb\xaa\xf8\x3f\xde\x89\x65\xdf\xff\xb4\x1a\x8b\xa0\x04\x66\x0e\x60\xf1\x69\x4b\x23\xdf\x02\xa8\xa3\x3b\x00\x45\xf2\xd7\xb5\xf1\xa2\x76\x9c\x04\x7d\xfe\xda\x31\x10\xc5\x6f\xeb\x0a\x18\xcc\x13\x66\x23\x77\x6f\x26\x07\x9d\x95\x15\x82\x94\xe2\xb4\x43\x89\x7d\xb3\xdb\x6e\x5b\xd8\xaf\xb2\x95\x73\x85\xad\x43\x52\x0a\x47\x54\x4b\x0d\xbc\x5e\xf6\x6e\xd2\x55\x03\xd6\x1d\x69\x95\xe0\x40\xf5\x7b\x9d\xfc\x80\x88\x8f\x86\xbd\xee\xd3\xdf\x34\x9e\x9d\xa6\xb6\x5b\x2e\x9e\xa6\x30\x4d\xf9\x40\x49\x1d\x8f\xf5\x28\x6a\xc9\x8e\x98\x18\x51\xcf\x94\x58\x8b\x38\x14\x1b\xaf\x48\x90\x33\x07\x1b\x0d\xba\x6f\x17\xe7\xa1\x97\x46\x06\x7b\x02\x8a\x01\xd2\x0e\x80\x89\xf7\xa5\x3d\xdc\x09\x10\x56\xf4\x77\x54\x15\xb8\xb2\x02\x12\x4f\xa3\xfd\xf0\x02\x1d\x9b\xb7\x46\xc9\x16\x4e\x5d\xd5\x0a\xd5\xbd\x12\xbc\x08\x9f\x36\x2a\x5f\x7a\x60\xb6\xf5\x9f\x3b\x75\x02\xf1\xb4\x6a\x08\x22\x19\x27\x18\x74\x9f\x1e\x8a\x96\xb4\x63\x8a\x3c\x92\x01\xfb\x92\xee\x65\x68\x62\x35\x48\x0a\x0b\x35\xd7\x4b\xa7\x40\x5b\xf2\xda\x06\x47\x85\x83\x8d\xf2\xc1\x57\x2f\xa0\x28\x8c\x9c\xa9\x1a\x38\x2a\x83\x97\x8e\x6a\x1d\xa3\x7f\x18\x3a\xcf\xab\xc7\x07\x50\xe4\x76\x79\x10\x1a\xf7\x61\xa3\xd5\xcb\x5b\x5d\x4e\x49\xc4\xbd\x4e\xed\xa1\x54\xd5\xb5\x22\x15\x72\xfe\x8c\xdf\xff\x3b\x8e\x77\x9e\xf7\xff\x3e\x36\xd0\x36\x69\xdd\x19\x9b\xc4\xee\xa4\xeb\xf6\xe6\x61\x14\x54\xab\x77\x01\xe0\xae\xce\x89\xa0\x9a\xfe\xa6\x02\xbb\x86\x2b\x37\x3b\xf4\x84\x69\xe4\x26\xd6\x05\xd5\x31\xf5\xff\x34\x19\x56\x9d\x1f\xe8\x6f\xfd\x15\x19\xbb\xaf\x7e\x47\xb6\x9c\xfb\x2b\x5e\xd7\x34\xd5\xaa\x88\xe6\x12\xeb\x6f\xce\x22\x77\xf9\xba\xc3\xfc\xf7\xdc\x95\xf9\x78\xb7\x3a\xa3\xae\xc0\x17\xa9\x24\x57\x32\x5c\x55\x75\xb1\xef\xfa\x11\x34\x22\x69\xe8\x67\x12\xd4\x84\x98\xe4\x31\x16\x49\x43\x39\x43\x37\xd3\x69\x35\xe3\x83\xc5\xb8\x0d\x19\x5b\x2d\x6e\xbd\x9e\xfa\x59\x92\x06\x3f\xd6\xb8\xf3\x88\xba\xb9\x11\x4b\x9b\xd7\x7e\xfe\xe6\x10\x43\x6b\xb1\xe0\x86\x58\xc8\x1b\x6c\x20\x69\x08\xe3\x46\x0b\xf9\x2d\x48\x14\x0c\xcc\x97\xfd\x70\x9e\xa6\xbb\xfe\xce\x23\x40\xfe\x23\x18\xdf\x48\x8e\xe5\xa3\x37\xc7\xe3\x63\xf6\xb1\x11\xeb\x8e\x7f\x92\x48\x6b\x3d\xbc\x6a\xe6\x75\x91\x15\x5b\xfe\x0e\x84\x06\xb5\xf3\xb8\x90\xe2\xd6\x89\x68\xde\x88\x61\xad\x3d\xff\xab\x04\xd9\x36\xc4\xce\xaa\x36\xcd\x1f\xde\x6a\xdd\xc7\x31\x25\x0f\x4b\xe5\x57\x9a\xb2\x2d\xbd\x6f\x76\x2b\xa3\xd7\x35\x76\x5b\xe1\xcb\xd7\x5b\x10\xe5\x2f\x42\x5c\x87\x3f\x55\x58\x89\x91\xe0\xe9\xba\x55\x10\xba\xfc\x87\x05\xd2\xd2\xb0\x38\xf6\x4b\x4c\x41\xe7\x05\xfb\x5b\x7e\xaa\xcf\x40\x0f\xc2\xdd\xbd\xaf\xc5\x7b\x60\xbf\xad\xe3\xdd\x15\x43\xe6\xf4\x57\x03\xd5\xcd\x00\xe8\xb8\x69\xe4\xe9\x79\xe3\x7a\x80\xd6\xe8\x95\x1b\xad\x37\x2e\x03\x98\xd1\x5f\x08\x6c\xde\x39\xd2\x1a\xbf\x6b\x00\x9c\x49\x81\x51\x27\x66\x23\x24\xc0\xb1\x15\x11\x25\x03\x05\xc3\x41\x3b\x03\x2d\xb5\x30\x6d\x5c\x50\x10\x31\xbf\x72\xab\xae\xb0\x1f\xd4\x57\xdd\x2b\x7f\xd0\x78\x51\xb3\x0d\x1e\xe8\xed\xed\x61\x7b\x92\x1b\x96\x78\x6c\x4f\x66\x64\xf3\x0a\xb0\x77\xb0\xd6\x47\x8e\x6d\x92\xfb\x52\x25\x4b\x2f\x33\xdb\x1d\xac\x2c\xbd\xd6\x7a\xe0\x4c\x8f\x16\x59\x11\xd7\x55\x6c\xd0\xb4\x09\xf1\x79\xc6\xd3\x39\xcb\x96\x02\x1c\xaa\x9d\xae\x8c\xe8\xf8\x5f\xca\x4b\xac\xc7\x4f\xb9\x44\xdf\xb8\xf8\x3b\x04\x37\xa4\x14\x3e\x7a\xca\xc5\xbf\x30\x34\x4d\xae\xe3\x02\xd1\x14\xe7\xf4\x25\x29\x56\x09\x82\x88\x3e\x1c\xd3\xac\xfa\x9b\xa1\x9b\x31\xfa\xe2\xa4\xf2\x98\x24\xba\x2f\x6b\xee\x23\x37\x7f\xef\x4b\xd1\xc9\xd9\x95\x88\xb0\x09\x7d\x3a\x42\xbe\xcb\x24\x66\x9e\x05\x32\x3e\x76\xa0\xaf\x81\x2b\xa1\x73\xc8\x53\xe1\x7a\x5c\xa3\x90\xd4\xf4\xc9\x2e\xa7\x4f\x66\xda\x97\x49\xee\xd2\x32\x6a\x3a\x63\xdb\xf7\x37\x32\xb1\xc1\xb8\xbf\xc2\x0b\x2a\xc9\xfe\x50\xf5\x28\xad\xbe\xd7\xf0\x47\x1f\x4d\x55\x77\x3b\x44\xcb\xc1\xae\x19\xa3\xfc\x9a\x9e\x9a\xd1\xe9\xe7\x9a\x66\x5c\xae\xef\xaa\x9a\x41\x58\x96\x8d\x66\xa4\xd5\x8b\x50\x33\x9c\x78\x85\x9f\x9a\x81\x54\xeb\x97\x79\x81\xc1\x51\x31\xf0\xd3\x46\x68\xb1\x96\x3e\xb6\xdc\xd7\xc9\x8a\x9c\x9f\xfa\x1e\x30\xe4\xc5\x2e\x19\xe7\x5a\xad\x28\x13\x3b\x1b\xd5\xca\x8a\x7b\xf1\x05\xcb\x5f\xdb\xab\x88\x87\x63\x8d\xae\x2a\x1b\x25\xa4\xdd\xda\x3d\x81\x5c\x69\x11\x1f\x0d\x0f\x45\xfc\x73\x9d\xa1\xac\x7c\x22\x7e\xfe\xbc\xdc\xb3\xbe\xfe\x25\xfe\x5a\x46\x67\x85\xf8\x8d\xf5\x5e\x43\x23\x1f\x23\x8e\x86\x82\xa2\x73\xdb\xf9\x37\x6e\x22\x7c\x1c\xc3\x21\x00\x00")
 
 func call_tracerJsBytes() ([]byte, error) {
 	return bindataRead(
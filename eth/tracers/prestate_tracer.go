@@ -0,0 +1,296 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/common/hexutil"
+	"github.com/tomochain/tomochain/core/vm"
+	"github.com/tomochain/tomochain/crypto"
+)
+
+func init() {
+	register("prestateTracer", newPrestateTracer)
+}
+
+// prestateAccount is the state of an account accessed by a transaction, with
+// the storage slots it accessed.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// diffAccount is the part of an account changed by a transaction.
+type diffAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// prestateDiff is the result of the prestate tracer in diff mode.
+type prestateDiff struct {
+	Pre  map[common.Address]*diffAccount `json:"pre"`
+	Post map[common.Address]*diffAccount `json:"post"`
+}
+
+// prestateTracerConfig is the configuration of the prestate tracer.
+type prestateTracerConfig struct {
+	DiffMode bool `json:"diffMode"` // Whether to report the changes made to the accounts
+}
+
+// prestateTracer is the native implementation of the JavaScript prestate
+// tracer, which gathers the state the transaction accessed, sufficient to
+// execute it again from a custom assembled genesis block. In diff mode, it
+// reports the state the transaction changed before and after it instead.
+//
+// Unlike the JavaScript tracer, it reads the accounts of the transaction before
+// the transaction is applied if the tracing API starts it with CaptureTxStart,
+// so the balances include the gas bought for the transaction.
+type prestateTracer struct {
+	config prestateTracerConfig
+	db     vm.StateDB
+
+	prestate  map[common.Address]*prestateAccount
+	poststate map[common.Address]*prestateAccount
+
+	started   bool           // Whether the prestate holds the accounts from before the transaction
+	from, to  common.Address // Accounts of the outer call
+	create    bool           // Whether the outer call created a contract
+	value     *big.Int       // Value sent with the outer call
+	interrupt uint32         // Atomic flag to signal execution interruption
+	reason    error          // Reason of the interruption
+	err       error          // Error, if one has occurred
+}
+
+// newPrestateTracer creates a native prestate tracer with the given JSON
+// configuration.
+func newPrestateTracer(cfg json.RawMessage) (ResultTracer, error) {
+	var config prestateTracerConfig
+	if len(cfg) > 0 {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	return &prestateTracer{config: config, prestate: make(map[common.Address]*prestateAccount)}, nil
+}
+
+// CaptureTxStart implements the TxTracer interface to read the accounts of the
+// transaction before it is applied.
+func (t *prestateTracer) CaptureTxStart(env *vm.EVM, from common.Address, to *common.Address) {
+	t.db, t.started = env.StateDB, true
+
+	t.lookupAccount(from)
+	t.lookupAccount(env.Coinbase)
+	if to != nil {
+		t.lookupAccount(*to)
+	} else {
+		t.lookupAccount(crypto.CreateAddress(from, env.StateDB.GetNonce(from)))
+	}
+}
+
+// CaptureTxEnd implements the TxTracer interface to read the accounts the
+// transaction accessed once it is applied.
+func (t *prestateTracer) CaptureTxEnd() {
+	if !t.config.DiffMode || t.db == nil {
+		return
+	}
+	t.poststate = make(map[common.Address]*prestateAccount, len(t.prestate))
+	for addr, pre := range t.prestate {
+		post := t.readAccount(addr)
+		for key := range pre.Storage {
+			post.Storage[key] = t.db.GetState(addr, key)
+		}
+		t.poststate[addr] = post
+	}
+}
+
+// CaptureStart implements the Tracer interface to record the outer call of the
+// transaction.
+func (t *prestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.from, t.to, t.create = from, to, create
+	t.value = new(big.Int).Set(value)
+	return nil
+}
+
+// CaptureState implements the Tracer interface to add the state accessed by
+// the opcodes to the prestate.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err != nil {
+		return nil
+	}
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.err = t.reason
+		return nil
+	}
+	if t.db == nil {
+		// Balance will potentially be wrong here, since this will include the
+		// value sent along with the message. It is fixed up in the result.
+		t.db = env.StateDB
+		t.lookupAccount(contract.Address())
+	}
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.BALANCE, vm.SELFDESTRUCT:
+		t.lookupAccount(common.BigToAddress(stackPeek(stack, 0)))
+	case vm.CREATE:
+		from := contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, env.StateDB.GetNonce(from)))
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(common.BigToAddress(stackPeek(stack, 1)))
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(contract.Address(), common.BigToHash(stackPeek(stack, 0)))
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface, faults don't access the state.
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd implements the Tracer interface, the end of the outer call doesn't
+// access the state.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// GetResult returns the prestate, or the changes made to it in diff mode.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	if !t.started && t.db != nil && t.value != nil {
+		// The accounts of the outer call were read after the call started: move
+		// the value back to the sender and decrement its nonce
+		t.lookupAccount(t.from)
+		t.lookupAccount(t.to)
+
+		from, to := t.prestate[t.from], t.prestate[t.to]
+		to.Balance = (*hexutil.Big)(new(big.Int).Sub(to.Balance.ToInt(), t.value))
+		from.Balance = (*hexutil.Big)(new(big.Int).Add(from.Balance.ToInt(), t.value))
+		from.Nonce--
+
+		// Any existing state at the created contract would have made the
+		// transaction invalid
+		if t.create {
+			delete(t.prestate, t.to)
+		}
+		t.started = true
+	}
+	if !t.config.DiffMode {
+		return json.Marshal(t.prestate)
+	}
+	return json.Marshal(t.diff())
+}
+
+// diff assembles the accounts changed by the transaction, before and after it.
+func (t *prestateTracer) diff() *prestateDiff {
+	result := &prestateDiff{
+		Pre:  make(map[common.Address]*diffAccount),
+		Post: make(map[common.Address]*diffAccount),
+	}
+	for addr, pre := range t.prestate {
+		post, ok := t.poststate[addr]
+		if !ok {
+			continue
+		}
+		var (
+			preDiff  = &diffAccount{Storage: make(map[common.Hash]common.Hash)}
+			postDiff = &diffAccount{Storage: make(map[common.Hash]common.Hash)}
+			modified bool
+		)
+		if pre.Balance.ToInt().Cmp(post.Balance.ToInt()) != 0 {
+			preDiff.Balance, postDiff.Balance, modified = pre.Balance, post.Balance, true
+		}
+		if pre.Nonce != post.Nonce {
+			preDiff.Nonce, postDiff.Nonce, modified = pre.Nonce, post.Nonce, true
+		}
+		if !bytes.Equal(pre.Code, post.Code) {
+			preDiff.Code, postDiff.Code, modified = pre.Code, post.Code, true
+		}
+		for key, value := range post.Storage {
+			if pre.Storage[key] != value {
+				modified = true
+				if pre.Storage[key] != (common.Hash{}) {
+					preDiff.Storage[key] = pre.Storage[key]
+				}
+				if value != (common.Hash{}) {
+					postDiff.Storage[key] = value
+				}
+			}
+		}
+		if !modified {
+			continue
+		}
+		// Report the whole account before the transaction, and only the changes
+		// made to it after
+		preDiff.Balance, preDiff.Nonce, preDiff.Code = pre.Balance, pre.Nonce, pre.Code
+		result.Pre[addr] = preDiff
+
+		// Accounts deleted by the transaction are left out after it
+		if t.db.Exist(addr) {
+			result.Post[addr] = postDiff
+		}
+	}
+	return result
+}
+
+// Stop terminates the tracing at the next opcode with the given error.
+func (t *prestateTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// lookupAccount adds the given account to the prestate, unless it is already
+// there.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate[addr]; !ok {
+		t.prestate[addr] = t.readAccount(addr)
+	}
+}
+
+// lookupStorage adds the given storage slot of the account to the prestate,
+// unless it is already there or empty.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+
+	account := t.prestate[addr]
+	if _, ok := account.Storage[key]; ok {
+		return
+	}
+	// Empty slots are left out of the prestate, but their changes are reported
+	if value := t.db.GetState(addr, key); value != (common.Hash{}) || t.config.DiffMode {
+		account.Storage[key] = value
+	}
+}
+
+// readAccount reads the current state of an account, without storage.
+func (t *prestateTracer) readAccount(addr common.Address) *prestateAccount {
+	return &prestateAccount{
+		Balance: (*hexutil.Big)(new(big.Int).Set(t.db.GetBalance(addr))),
+		Nonce:   t.db.GetNonce(addr),
+		Code:    t.db.GetCode(addr),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+}
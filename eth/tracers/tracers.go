@@ -14,19 +14,64 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-// Package tracers is a collection of JavaScript transaction tracers.
+// Package tracers is a collection of JavaScript transaction tracers, along with
+// native Go implementations of the most used ones.
 package tracers
 
 import (
+	"encoding/json"
 	"strings"
 	"unicode"
 
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/core/vm"
 	"github.com/tomochain/tomochain/eth/tracers/internal/tracers"
 )
 
+// ResultTracer is a transaction tracer assembling a JSON result, which can be
+// interrupted while tracing.
+type ResultTracer interface {
+	vm.Tracer
+
+	// GetResult returns the result of the tracing, or the error which stopped it.
+	GetResult() (json.RawMessage, error)
+
+	// Stop terminates the tracing at the next opcode with the given error.
+	Stop(err error)
+}
+
+// TxTracer is implemented by the tracers which need to look at the state before
+// and after the whole transaction is applied, outside of the EVM execution.
+type TxTracer interface {
+	// CaptureTxStart is called before the transaction buys its gas.
+	CaptureTxStart(env *vm.EVM, from common.Address, to *common.Address)
+
+	// CaptureTxEnd is called once the transaction is applied and refunded.
+	CaptureTxEnd()
+}
+
 // all contains all the built in JavaScript tracers by name.
 var all = make(map[string]string)
 
+// natives contains the constructors of the native tracers by name, which take
+// precedence over the JavaScript tracers of the same name.
+var natives = make(map[string]func(cfg json.RawMessage) (ResultTracer, error))
+
+// register adds a native tracer constructor under the given name.
+func register(name string, ctor func(cfg json.RawMessage) (ResultTracer, error)) {
+	natives[name] = ctor
+}
+
+// NewTracer creates the native tracer of the given name with its JSON
+// configuration, or falls back to a JavaScript tracer for any other name or
+// code.
+func NewTracer(code string, cfg json.RawMessage) (ResultTracer, error) {
+	if ctor, ok := natives[code]; ok {
+		return ctor(cfg)
+	}
+	return New(code)
+}
+
 // camel converts a snake cased input string into a camel cased output.
 func camel(str string) string {
 	pieces := strings.Split(str, "_")
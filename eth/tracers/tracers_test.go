@@ -29,6 +29,7 @@ import (
 	"github.com/tomochain/tomochain/common/hexutil"
 	"github.com/tomochain/tomochain/common/math"
 	"github.com/tomochain/tomochain/core"
+	"github.com/tomochain/tomochain/core/state"
 	"github.com/tomochain/tomochain/core/types"
 	"github.com/tomochain/tomochain/core/vm"
 	"github.com/tomochain/tomochain/ethdb"
@@ -119,6 +120,16 @@ type callTracerTest struct {
 // Iterates over all the input-output datasets in the tracer test harness and
 // runs the JavaScript tracers against them.
 func TestCallTracer(t *testing.T) {
+	testCallTracer(t, func() (ResultTracer, error) { return New("callTracer") })
+}
+
+// Tests that the native call tracer produces the same results as the JavaScript
+// one for all the datasets in the tracer test harness.
+func TestNativeCallTracer(t *testing.T) {
+	testCallTracer(t, func() (ResultTracer, error) { return NewTracer("callTracer", nil) })
+}
+
+func testCallTracer(t *testing.T, newTracer func() (ResultTracer, error)) {
 	files, err := ioutil.ReadDir("testdata")
 	if err != nil {
 		t.Fatalf("failed to retrieve tracer test suite: %v", err)
@@ -131,56 +142,98 @@ func TestCallTracer(t *testing.T) {
 		t.Run(camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")), func(t *testing.T) {
 			t.Parallel()
 
-			// Call tracer test found, read if from disk
-			blob, err := ioutil.ReadFile(filepath.Join("testdata", file.Name()))
+			test := readCallTracerTest(t, file.Name())
+			tracer, err := newTracer()
 			if err != nil {
-				t.Fatalf("failed to read testcase: %v", err)
+				t.Fatalf("failed to create call tracer: %v", err)
 			}
-			test := new(callTracerTest)
-			if err := json.Unmarshal(blob, test); err != nil {
-				t.Fatalf("failed to parse testcase: %v", err)
+			statedb := makePreState(test.Genesis.Alloc)
+			runTracerTest(t, test, statedb, tracer)
+
+			// Retrieve the trace result and compare against the etalon
+			res, err := tracer.GetResult()
+			if err != nil {
+				t.Fatalf("failed to retrieve trace result: %v", err)
 			}
-			// Configure a blockchain with the given prestate
-			tx := new(types.Transaction)
-			if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
-				t.Fatalf("failed to parse testcase input: %v", err)
+			ret := new(callTrace)
+			if err := json.Unmarshal(res, ret); err != nil {
+				t.Fatalf("failed to unmarshal trace result: %v", err)
 			}
-			signer := types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
-			origin, _ := signer.Sender(tx)
-
-			context := vm.Context{
-				CanTransfer: core.CanTransfer,
-				Transfer:    core.Transfer,
-				Origin:      origin,
-				Coinbase:    test.Context.Miner,
-				BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
-				Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
-				Difficulty:  (*big.Int)(test.Context.Difficulty),
-				GasLimit:    uint64(test.Context.GasLimit),
-				GasPrice:    tx.GasPrice(),
+			if !reflect.DeepEqual(ret, test.Result) {
+				t.Fatalf("trace mismatch: have %+v, want %+v", ret, test.Result)
 			}
-			db, _ := ethdb.NewMemDatabase()
-			statedb := tests.MakePreState(db, test.Genesis.Alloc)
+		})
+	}
+}
 
-			// Create the tracer, the EVM environment and run it
-			tracer, err := New("callTracer")
-			if err != nil {
-				t.Fatalf("failed to create call tracer: %v", err)
-			}
-			evm := vm.NewEVM(context, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+// Tests that the prestate gathered by the native prestate tracer is sufficient
+// to execute the transactions of the tracer test harness again, and that its
+// diff mode reports the state they changed.
+func TestNativePrestateTracer(t *testing.T) {
+	files, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "call_tracer_") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")), func(t *testing.T) {
+			t.Parallel()
 
-			msg, err := tx.AsMessage(signer, nil,common.Big0)
+			test := readCallTracerTest(t, file.Name())
+
+			// Gather the prestate and the changes made to it
+			prestateTracer, _ := NewTracer("prestateTracer", nil)
+			runTracerTest(t, test, makePreState(test.Genesis.Alloc), prestateTracer)
+			res, err := prestateTracer.GetResult()
 			if err != nil {
-				t.Fatalf("failed to prepare transaction for tracing: %v", err)
+				t.Fatalf("failed to retrieve prestate: %v", err)
 			}
-			st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
-			owner := common.Address{}
-			if _, _, _, err = st.TransitionDb(owner); err != nil {
-				t.Fatalf("failed to execute transaction: %v", err)
+			prestate := make(map[common.Address]*prestateAccount)
+			if err := json.Unmarshal(res, &prestate); err != nil {
+				t.Fatalf("failed to unmarshal prestate: %v", err)
 			}
-			// Retrieve the trace result and compare against the etalon
-			res, err := tracer.GetResult()
-			if err != nil {
+			alloc := make(core.GenesisAlloc)
+			for addr, account := range prestate {
+				alloc[addr] = core.GenesisAccount{
+					Balance: account.Balance.ToInt(),
+					Nonce:   account.Nonce,
+					Code:    account.Code,
+					Storage: account.Storage,
+				}
+			}
+			diffTracer, _ := NewTracer("prestateTracer", json.RawMessage(`{"diffMode": true}`))
+			statedb := makePreState(test.Genesis.Alloc)
+			runTracerTest(t, test, statedb, diffTracer)
+			if res, err = diffTracer.GetResult(); err != nil {
+				t.Fatalf("failed to retrieve state diff: %v", err)
+			}
+			diff := new(prestateDiff)
+			if err := json.Unmarshal(res, diff); err != nil {
+				t.Fatalf("failed to unmarshal state diff: %v", err)
+			}
+			if len(diff.Pre) == 0 || len(diff.Post) == 0 {
+				t.Fatalf("state diff missing the gas payment: %s", res)
+			}
+			for addr, post := range diff.Post {
+				if post.Balance != nil && post.Balance.ToInt().Cmp(statedb.GetBalance(addr)) != 0 {
+					t.Errorf("post balance mismatch for %x: have %v, want %v", addr, post.Balance, statedb.GetBalance(addr))
+				}
+				for key, value := range post.Storage {
+					if have := statedb.GetState(addr, key); have != value {
+						t.Errorf("post storage mismatch for %x/%x: have %x, want %x", addr, key, value, have)
+					}
+				}
+				if _, ok := diff.Pre[addr]; !ok {
+					t.Errorf("changed account %x missing from the pre state", addr)
+				}
+			}
+			// Execute the transaction again from the prestate alone
+			callTracer, _ := NewTracer("callTracer", nil)
+			runTracerTest(t, test, makePreState(alloc), callTracer)
+			if res, err = callTracer.GetResult(); err != nil {
 				t.Fatalf("failed to retrieve trace result: %v", err)
 			}
 			ret := new(callTrace)
@@ -188,8 +241,68 @@ func TestCallTracer(t *testing.T) {
 				t.Fatalf("failed to unmarshal trace result: %v", err)
 			}
 			if !reflect.DeepEqual(ret, test.Result) {
-				t.Fatalf("trace mismatch: have %+v, want %+v", ret, test.Result)
+				t.Fatalf("trace mismatch from the prestate: have %+v, want %+v", ret, test.Result)
 			}
 		})
 	}
 }
+
+// makePreState creates a state holding the given accounts in a memory database.
+func makePreState(alloc core.GenesisAlloc) *state.StateDB {
+	db, _ := ethdb.NewMemDatabase()
+	return tests.MakePreState(db, alloc)
+}
+
+// readCallTracerTest reads a dataset of the tracer test harness from disk.
+func readCallTracerTest(t *testing.T, name string) *callTracerTest {
+	blob, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read testcase: %v", err)
+	}
+	test := new(callTracerTest)
+	if err := json.Unmarshal(blob, test); err != nil {
+		t.Fatalf("failed to parse testcase: %v", err)
+	}
+	return test
+}
+
+// runTracerTest executes the transaction of a dataset of the tracer test harness
+// on the given state with the tracer, the way the tracing API does.
+func runTracerTest(t *testing.T, test *callTracerTest, statedb *state.StateDB, tracer ResultTracer) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+		t.Fatalf("failed to parse testcase input: %v", err)
+	}
+	signer := types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
+	origin, _ := signer.Sender(tx)
+
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Origin:      origin,
+		Coinbase:    test.Context.Miner,
+		BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+		Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+		Difficulty:  (*big.Int)(test.Context.Difficulty),
+		GasLimit:    uint64(test.Context.GasLimit),
+		GasPrice:    tx.GasPrice(),
+	}
+	evm := vm.NewEVM(context, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+
+	msg, err := tx.AsMessage(signer, nil, common.Big0)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	txTracer, _ := tracer.(TxTracer)
+	if txTracer != nil {
+		txTracer.CaptureTxStart(evm, msg.From(), msg.To())
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	owner := common.Address{}
+	if _, _, _, err = st.TransitionDb(owner); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	if txTracer != nil {
+		txTracer.CaptureTxEnd()
+	}
+}
@@ -276,6 +276,9 @@ type rpcProgress struct {
 	HighestBlock  hexutil.Uint64
 	PulledStates  hexutil.Uint64
 	KnownStates   hexutil.Uint64
+
+	PulledTomoXStates hexutil.Uint64
+	KnownTomoXStates  hexutil.Uint64
 }
 
 // SyncProgress retrieves the current progress of the sync algorithm. If there's
@@ -300,6 +303,9 @@ func (ec *Client) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, err
 		HighestBlock:  uint64(progress.HighestBlock),
 		PulledStates:  uint64(progress.PulledStates),
 		KnownStates:   uint64(progress.KnownStates),
+
+		PulledTomoXStates: uint64(progress.PulledTomoXStates),
+		KnownTomoXStates:  uint64(progress.KnownTomoXStates),
 	}, nil
 }
 
@@ -340,6 +346,68 @@ func (ec *Client) StorageAt(ctx context.Context, account common.Address, key com
 	return result, err
 }
 
+// AccountResult is the merkle proof of an account and of its storage slots.
+type AccountResult struct {
+	Address      common.Address
+	AccountProof []string
+	Balance      *big.Int
+	CodeHash     common.Hash
+	Nonce        uint64
+	StorageHash  common.Hash
+	StorageProof []StorageResult
+}
+
+// StorageResult is the merkle proof of a storage slot of an account.
+type StorageResult struct {
+	Key   string
+	Value *big.Int
+	Proof []string
+}
+
+// GetProof returns the merkle proof of the given account and of the given storage
+// keys of it. The block number can be nil, in which case the proof is taken from
+// the latest known block.
+func (ec *Client) GetProof(ctx context.Context, account common.Address, keys []string, blockNumber *big.Int) (*AccountResult, error) {
+	type storageResult struct {
+		Key   string       `json:"key"`
+		Value *hexutil.Big `json:"value"`
+		Proof []string     `json:"proof"`
+	}
+	type accountResult struct {
+		Address      common.Address  `json:"address"`
+		AccountProof []string        `json:"accountProof"`
+		Balance      *hexutil.Big    `json:"balance"`
+		CodeHash     common.Hash     `json:"codeHash"`
+		Nonce        hexutil.Uint64  `json:"nonce"`
+		StorageHash  common.Hash     `json:"storageHash"`
+		StorageProof []storageResult `json:"storageProof"`
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	var res accountResult
+	if err := ec.c.CallContext(ctx, &res, "eth_getProof", account, keys, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	storageResults := make([]StorageResult, 0, len(res.StorageProof))
+	for _, st := range res.StorageProof {
+		storageResults = append(storageResults, StorageResult{
+			Key:   st.Key,
+			Value: st.Value.ToInt(),
+			Proof: st.Proof,
+		})
+	}
+	return &AccountResult{
+		Address:      res.Address,
+		AccountProof: res.AccountProof,
+		Balance:      res.Balance.ToInt(),
+		CodeHash:     res.CodeHash,
+		Nonce:        uint64(res.Nonce),
+		StorageHash:  res.StorageHash,
+		StorageProof: storageResults,
+	}, nil
+}
+
 // CodeAt returns the contract code of the given account.
 // The block number can be nil, in which case the code is taken from the latest known block.
 func (ec *Client) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
@@ -440,6 +508,36 @@ func (ec *Client) CallContract(ctx context.Context, msg ethereum.CallMsg, blockN
 	return hex, nil
 }
 
+// OverrideAccount holds the fields of an account to override during a call.
+// The nil fields are left untouched, the storage slots in StateDiff are set one
+// by one.
+type OverrideAccount struct {
+	Nonce     *uint64
+	Code      []byte
+	Balance   *big.Int
+	StateDiff map[common.Hash]common.Hash
+}
+
+// BlockOverrides holds the fields of the block to override during a call. The
+// nil fields are left untouched.
+type BlockOverrides struct {
+	Number   *big.Int
+	Time     *big.Int
+	Coinbase *common.Address
+}
+
+// CallContractWithOverrides executes a message call transaction like
+// CallContract, on the state with the given accounts overridden and in a block
+// with the given fields overridden.
+func (ec *Client) CallContractWithOverrides(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int, overrides map[common.Address]OverrideAccount, blockOverrides *BlockOverrides) ([]byte, error) {
+	var hex hexutil.Bytes
+	err := ec.c.CallContext(ctx, &hex, "eth_call", toCallArg(msg), toBlockNumArg(blockNumber), toOverrideArg(overrides), toBlockOverrideArg(blockOverrides))
+	if err != nil {
+		return nil, err
+	}
+	return hex, nil
+}
+
 // PendingCallContract executes a message call transaction using the EVM.
 // The state seen by the contract call is the pending state.
 func (ec *Client) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
@@ -517,3 +615,44 @@ func toCallArg(msg ethereum.CallMsg) interface{} {
 	}
 	return arg
 }
+
+func toOverrideArg(overrides map[common.Address]OverrideAccount) interface{} {
+	if len(overrides) == 0 {
+		return nil
+	}
+	arg := make(map[common.Address]interface{}, len(overrides))
+	for addr, account := range overrides {
+		override := map[string]interface{}{}
+		if account.Nonce != nil {
+			override["nonce"] = hexutil.Uint64(*account.Nonce)
+		}
+		if account.Code != nil {
+			override["code"] = hexutil.Bytes(account.Code)
+		}
+		if account.Balance != nil {
+			override["balance"] = (*hexutil.Big)(account.Balance)
+		}
+		if account.StateDiff != nil {
+			override["stateDiff"] = account.StateDiff
+		}
+		arg[addr] = override
+	}
+	return arg
+}
+
+func toBlockOverrideArg(overrides *BlockOverrides) interface{} {
+	if overrides == nil {
+		return nil
+	}
+	arg := map[string]interface{}{}
+	if overrides.Number != nil {
+		arg["number"] = (*hexutil.Big)(overrides.Number)
+	}
+	if overrides.Time != nil {
+		arg["timestamp"] = (*hexutil.Big)(overrides.Time)
+	}
+	if overrides.Coinbase != nil {
+		arg["coinbase"] = *overrides.Coinbase
+	}
+	return arg
+}
@@ -0,0 +1,566 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger"
+	"github.com/dgraph-io/badger/y"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/log"
+	"github.com/tomochain/tomochain/metrics"
+)
+
+const (
+	badgerGCInterval     = 5 * time.Minute // Interval between the value log garbage collections
+	badgerGCDiscardRatio = 0.5             // Share of stale data a value log file is rewritten at
+	badgerMeterInterval  = 3 * time.Second // Interval between the metrics collections
+)
+
+var (
+	errReverseIteration = errors.New("reverse iteration not supported")
+	errBatchTooLarge    = errors.New("batch too large")
+)
+
+// BadgerDatabase is a database persisted with BadgerDB, which keeps the keys in
+// an LSM tree apart from the values, avoiding the write amplification and the
+// compaction stalls of LevelDB with large values. Keys must not be empty.
+type BadgerDatabase struct {
+	fn string     // filename for reporting
+	db *badger.DB // BadgerDB instance
+
+	freezer *Freezer // Optional store of the immutable chain data
+
+	diskReadMeter  metrics.Meter // Meter for measuring the effective amount of data read
+	diskWriteMeter metrics.Meter // Meter for measuring the effective amount of data written
+	lsmSizeGauge   metrics.Gauge // Gauge for tracking the size of the LSM tree
+	vlogSizeGauge  metrics.Gauge // Gauge for tracking the size of the value log
+
+	meterOnce sync.Once
+	closeOnce sync.Once
+	quit      chan struct{}  // Channel to stop the background maintenance
+	wg        sync.WaitGroup // Background maintenance goroutines
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// NewBadgerDatabase returns a BadgerDB wrapped object.
+func NewBadgerDatabase(file string, cache int, handles int) (*BadgerDatabase, error) {
+	logger := log.New("database", file)
+
+	// Ensure we have some minimal caching, used for the memory tables
+	if cache < 16 {
+		cache = 16
+	}
+	logger.Info("Allocated cache", "cache", cache)
+
+	// Open the db, truncating the value log past the last consistent write
+	// after a crash
+	opts := badger.DefaultOptions(file).
+		WithLogger(badgerLogger{logger}).
+		WithTruncate(true).
+		WithNumMemtables(2).
+		WithMaxTableSize(int64(cache / 4 * opt.MiB))
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	bdb := &BadgerDatabase{
+		fn:   file,
+		db:   db,
+		quit: make(chan struct{}),
+		log:  logger,
+	}
+	bdb.wg.Add(1)
+	go bdb.collectGarbage()
+
+	return bdb, nil
+}
+
+// Engine returns the storage engine of the database.
+func (db *BadgerDatabase) Engine() string {
+	return BadgerEngine
+}
+
+// Path returns the path to the database directory.
+func (db *BadgerDatabase) Path() string {
+	return db.fn
+}
+
+// Put puts the given key / value to the database
+func (db *BadgerDatabase) Put(key []byte, value []byte) error {
+	return db.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(common.CopyBytes(key), common.CopyBytes(value))
+	})
+}
+
+func (db *BadgerDatabase) Has(key []byte) (bool, error) {
+	err := db.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(key)
+		return err
+	})
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Get returns the given key if it's present.
+func (db *BadgerDatabase) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := db.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	return value, nil
+}
+
+// Delete deletes the key from the database
+func (db *BadgerDatabase) Delete(key []byte) error {
+	return db.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(common.CopyBytes(key))
+	})
+}
+
+// NewIterator returns an iterator over the whole database content. The
+// iterator sees a consistent snapshot of the database and only moves forward.
+func (db *BadgerDatabase) NewIterator() iterator.Iterator {
+	return db.NewIteratorWithPrefix(nil)
+}
+
+// NewIteratorWithPrefix returns a iterator to iterate over subset of database content with a particular prefix.
+func (db *BadgerDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	txn := db.db.NewTransaction(false)
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = common.CopyBytes(prefix)
+
+	return &badgerIterator{
+		txn:    txn,
+		it:     txn.NewIterator(opts),
+		prefix: opts.Prefix,
+	}
+}
+
+// Stat returns a particular internal stat of the database, the known
+// properties being badger.size, the sizes of the LSM tree and of the value
+// log, and badger.tables, the number of tables and keys of each level.
+func (db *BadgerDatabase) Stat(property string) (string, error) {
+	switch property {
+	case "badger.size":
+		lsm, vlog := db.db.Size()
+		return fmt.Sprintf("LSM: %v, value log: %v", common.StorageSize(lsm), common.StorageSize(vlog)), nil
+
+	case "badger.tables":
+		var (
+			tables []int
+			keys   []uint64
+		)
+		for _, table := range db.db.Tables(true) {
+			for len(tables) <= table.Level {
+				tables, keys = append(tables, 0), append(keys, 0)
+			}
+			tables[table.Level]++
+			keys[table.Level] += table.KeyCount
+		}
+		var stats strings.Builder
+		stats.WriteString(" Level | Tables | Keys\n")
+		stats.WriteString("-------+--------+------------\n")
+		for level := range tables {
+			fmt.Fprintf(&stats, " %5d | %6d | %10d\n", level, tables[level], keys[level])
+		}
+		return stats.String(), nil
+	}
+	return "", errUnknownProperty
+}
+
+// Compact flattens the LSM tree and rewrites the value log files holding
+// mostly stale data. BadgerDB compacts whole levels, so a range holding keys in
+// any table compacts all of them, while a range holding none is left alone.
+func (db *BadgerDatabase) Compact(start []byte, limit []byte) error {
+	if !db.holdsRange(start, limit) {
+		return nil
+	}
+	if err := db.db.Flatten(1); err != nil {
+		return err
+	}
+	for {
+		err := db.db.RunValueLogGC(badgerGCDiscardRatio)
+		if err == badger.ErrNoRewrite || err == badger.ErrRejected {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// holdsRange reports whether any table of the LSM tree holds keys from start
+// (inclusive) up to limit (exclusive), nil meaning no bound.
+func (db *BadgerDatabase) holdsRange(start []byte, limit []byte) bool {
+	for _, table := range db.db.Tables(false) {
+		left, right := y.ParseKey(table.Left), y.ParseKey(table.Right)
+		if (limit == nil || bytes.Compare(left, limit) < 0) && (start == nil || bytes.Compare(right, start) >= 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteRange deletes all the keys from start (inclusive) up to limit
+// (exclusive).
+func (db *BadgerDatabase) DeleteRange(start []byte, limit []byte) error {
+	txn := db.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	wb := db.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for it.Seek(start); it.Valid(); it.Next() {
+		key := it.Item().KeyCopy(nil)
+		if limit != nil && bytes.Compare(key, limit) >= 0 {
+			break
+		}
+		if err := wb.Delete(key); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// OpenFreezer attaches the ancient store in the given directory to the
+// database, which the chain data accessors fall back to.
+func (db *BadgerDatabase) OpenFreezer(datadir string) error {
+	freezer, err := NewFreezer(datadir)
+	if err != nil {
+		return err
+	}
+	db.freezer = freezer
+	return nil
+}
+
+// Freezer returns the ancient store of the database, nil if it has none.
+func (db *BadgerDatabase) Freezer() *Freezer {
+	return db.freezer
+}
+
+// Close stops the background maintenance and closes the database, only the
+// first call having any effect.
+func (db *BadgerDatabase) Close() {
+	db.closeOnce.Do(func() {
+		// Stop the background maintenance before closing the database
+		close(db.quit)
+		db.wg.Wait()
+
+		if db.freezer != nil {
+			if err := db.freezer.Close(); err != nil {
+				db.log.Error("Failed to close ancient database", "err", err)
+			}
+		}
+		if err := db.db.Close(); err == nil {
+			db.log.Info("Database closed")
+		} else {
+			db.log.Error("Failed to close database", "err", err)
+		}
+	})
+}
+
+// Badger returns the underlying BadgerDB instance.
+func (db *BadgerDatabase) Badger() *badger.DB {
+	return db.db
+}
+
+// collectGarbage periodically rewrites the value log files holding mostly
+// deleted or overwritten values, which BadgerDB doesn't do on its own.
+func (db *BadgerDatabase) collectGarbage() {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(badgerGCInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Keep rewriting while files qualify, bailing out on shutdown
+			for {
+				err := db.db.RunValueLogGC(badgerGCDiscardRatio)
+				if err == badger.ErrNoRewrite || err == badger.ErrRejected {
+					break
+				}
+				if err != nil {
+					db.log.Error("Value log garbage collection failed", "err", err)
+					break
+				}
+				select {
+				case <-db.quit:
+					return
+				default:
+				}
+			}
+		case <-db.quit:
+			return
+		}
+	}
+}
+
+// Meter configures the database metrics collectors. The disk traffic counters
+// of BadgerDB are shared by all the databases of the process.
+func (db *BadgerDatabase) Meter(prefix string) {
+	// Short circuit metering if the metrics system is disabled
+	if !metrics.Enabled {
+		return
+	}
+	db.meterOnce.Do(func() {
+		db.diskReadMeter = metrics.NewRegisteredMeter(prefix+"disk/read", nil)
+		db.diskWriteMeter = metrics.NewRegisteredMeter(prefix+"disk/write", nil)
+		db.lsmSizeGauge = metrics.NewRegisteredGauge(prefix+"lsm/size", nil)
+		db.vlogSizeGauge = metrics.NewRegisteredGauge(prefix+"vlog/size", nil)
+
+		db.wg.Add(1)
+		go db.meter(badgerMeterInterval)
+	})
+}
+
+// meter periodically retrieves the BadgerDB counters and reports them to the
+// metrics subsystem.
+func (db *BadgerDatabase) meter(refresh time.Duration) {
+	defer db.wg.Done()
+
+	var read, written int64
+	for {
+		lsm, vlog := db.db.Size()
+		db.lsmSizeGauge.Update(lsm)
+		db.vlogSizeGauge.Update(vlog)
+
+		newRead, newWritten := y.NumBytesRead.Value(), y.NumBytesWritten.Value()
+		db.diskReadMeter.Mark(newRead - read)
+		db.diskWriteMeter.Mark(newWritten - written)
+		read, written = newRead, newWritten
+
+		select {
+		case <-db.quit:
+			return
+		case <-time.After(refresh):
+		}
+	}
+}
+
+func (db *BadgerDatabase) NewBatch() Batch {
+	return &badgerBatch{db: db.db}
+}
+
+type badgerBatch struct {
+	db     *badger.DB
+	writes []kv
+	size   int
+}
+
+func (b *badgerBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value), false})
+	b.size += len(value)
+	return nil
+}
+
+func (b *badgerBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{common.CopyBytes(key), nil, true})
+	b.size += len(key)
+	return nil
+}
+
+// Write commits the batch in a single transaction, so a failing write leaves
+// none of it behind. A batch beyond the transaction limits of badger, which
+// the callers flushing at IdealBatchSize stay far below, fails as a whole with
+// errBatchTooLarge.
+func (b *badgerBatch) Write() error {
+	txn := b.db.NewTransaction(true)
+	defer txn.Discard()
+
+	for _, kv := range b.writes {
+		if err := badgerApply(txn, kv); err != nil {
+			if err == badger.ErrTxnTooBig {
+				return errBatchTooLarge
+			}
+			return err
+		}
+	}
+	return txn.Commit()
+}
+
+// badgerApply writes the batched put or delete into the transaction.
+func badgerApply(txn *badger.Txn, kv kv) error {
+	if kv.del {
+		return txn.Delete(kv.k)
+	}
+	return txn.Set(kv.k, kv.v)
+}
+
+func (b *badgerBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *badgerBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
+// badgerIterator adapts a forward BadgerDB iterator to the LevelDB one the
+// database users rely on.
+type badgerIterator struct {
+	txn    *badger.Txn
+	it     *badger.Iterator
+	prefix []byte
+
+	started    bool
+	key, value []byte
+	err        error
+	released   bool
+	releaser   util.Releaser
+}
+
+// load caches the entry at the iterator position, returning whether there
+// is one.
+func (it *badgerIterator) load() bool {
+	it.key, it.value = nil, nil
+	if it.released || it.err != nil || !it.it.ValidForPrefix(it.prefix) {
+		return false
+	}
+	item := it.it.Item()
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	it.key, it.value = item.KeyCopy(nil), value
+	return true
+}
+
+func (it *badgerIterator) First() bool {
+	if it.released {
+		return false
+	}
+	it.started = true
+	it.it.Seek(it.prefix)
+	return it.load()
+}
+
+func (it *badgerIterator) Last() bool {
+	it.err = errReverseIteration
+	return false
+}
+
+func (it *badgerIterator) Seek(key []byte) bool {
+	if it.released {
+		return false
+	}
+	if string(key) < string(it.prefix) {
+		key = it.prefix
+	}
+	it.started = true
+	it.it.Seek(key)
+	return it.load()
+}
+
+func (it *badgerIterator) Next() bool {
+	if it.released {
+		return false
+	}
+	if !it.started {
+		return it.First()
+	}
+	if !it.it.Valid() {
+		return false
+	}
+	it.it.Next()
+	return it.load()
+}
+
+func (it *badgerIterator) Prev() bool {
+	it.err = errReverseIteration
+	return false
+}
+
+func (it *badgerIterator) Valid() bool   { return it.key != nil }
+func (it *badgerIterator) Key() []byte   { return it.key }
+func (it *badgerIterator) Value() []byte { return it.value }
+func (it *badgerIterator) Error() error  { return it.err }
+
+func (it *badgerIterator) Release() {
+	if it.released {
+		return
+	}
+	it.released = true
+	it.key, it.value = nil, nil
+
+	it.it.Close()
+	it.txn.Discard()
+	if it.releaser != nil {
+		it.releaser.Release()
+		it.releaser = nil
+	}
+}
+
+func (it *badgerIterator) SetReleaser(releaser util.Releaser) {
+	it.releaser = releaser
+}
+
+// badgerLogger forwards the BadgerDB logs to the database logger, one level
+// down as BadgerDB reports its routine maintenance as informational.
+type badgerLogger struct {
+	log log.Logger
+}
+
+func (l badgerLogger) Errorf(format string, args ...interface{}) {
+	l.log.Error(strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
+
+func (l badgerLogger) Warningf(format string, args ...interface{}) {
+	l.log.Warn(strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
+
+func (l badgerLogger) Infof(format string, args ...interface{}) {
+	l.log.Debug(strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
+
+func (l badgerLogger) Debugf(format string, args ...interface{}) {
+	l.log.Trace(strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/tomochain/tomochain/ethdb"
+)
+
+func newTestBadger() (*ethdb.BadgerDatabase, string, func()) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "ethdb_test_")
+	if err != nil {
+		panic("failed to create test file: " + err.Error())
+	}
+	db, err := ethdb.NewBadgerDatabase(dirname, 0, 0)
+	if err != nil {
+		panic("failed to create test database: " + err.Error())
+	}
+	return db, dirname, func() {
+		db.Close()
+		os.RemoveAll(dirname)
+	}
+}
+
+func TestBadger_PutGet(t *testing.T) {
+	db, _, remove := newTestBadger()
+	defer remove()
+
+	// Badger rejects empty keys, which the database users never write
+	for _, v := range test_values[1:] {
+		if err := db.Put([]byte(v), []byte(v)); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+	}
+	if err := db.Put([]byte("empty"), nil); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	for _, v := range test_values[1:] {
+		data, err := db.Get([]byte(v))
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		if !bytes.Equal(data, []byte(v)) {
+			t.Fatalf("get returned wrong result, got %q expected %q", string(data), v)
+		}
+	}
+	if data, err := db.Get([]byte("empty")); err != nil || data == nil || len(data) != 0 {
+		t.Fatalf("empty value mismatch: have %v, %v", data, err)
+	}
+	if _, err := db.Get([]byte("missing")); err == nil {
+		t.Fatalf("get of missing key succeeded")
+	}
+	for _, v := range test_values[1:] {
+		if err := db.Delete([]byte(v)); err != nil {
+			t.Fatalf("delete %q failed: %v", v, err)
+		}
+		if has, err := db.Has([]byte(v)); has || err != nil {
+			t.Fatalf("deleted key %q still present: %v", v, err)
+		}
+	}
+}
+
+func TestBadger_BatchIterator(t *testing.T) {
+	db, _, remove := newTestBadger()
+	defer remove()
+
+	batch := db.NewBatch()
+	for i := 0; i < 100; i++ {
+		batch.Put([]byte(fmt.Sprintf("a%03d", i)), []byte{byte(i)})
+		batch.Put([]byte(fmt.Sprintf("b%03d", i)), []byte{byte(i)})
+	}
+	if has, _ := db.Has([]byte("a000")); has {
+		t.Fatalf("batch written before Write")
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch write failed: %v", err)
+	}
+	it := db.NewIteratorWithPrefix([]byte("b"))
+	defer it.Release()
+
+	count := 0
+	for it.Next() {
+		if want := fmt.Sprintf("b%03d", count); string(it.Key()) != want {
+			t.Fatalf("key %d mismatch: have %q, want %q", count, it.Key(), want)
+		}
+		if it.Value()[0] != byte(count) {
+			t.Fatalf("value %d mismatch: have %x", count, it.Value())
+		}
+		count++
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if count != 100 {
+		t.Fatalf("iterated entries mismatch: have %d, want 100", count)
+	}
+	if !it.Seek([]byte("b050")) || string(it.Key()) != "b050" {
+		t.Fatalf("seek mismatch: have %q", it.Key())
+	}
+}
+
+func TestBadger_BatchAtomic(t *testing.T) {
+	db, _, remove := newTestBadger()
+	defer remove()
+
+	if err := db.Put([]byte("deleted"), []byte("value")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	// The empty key badger rejects fails the batch after the other writes
+	batch := db.NewBatch()
+	for i := 0; i < 100; i++ {
+		batch.Put([]byte(fmt.Sprintf("a%03d", i)), []byte{byte(i)})
+	}
+	batch.Delete([]byte("deleted"))
+	batch.Put(nil, []byte("value"))
+	if err := batch.Write(); err == nil {
+		t.Fatalf("batch with empty key written")
+	}
+	for i := 0; i < 100; i++ {
+		if has, _ := db.Has([]byte(fmt.Sprintf("a%03d", i))); has {
+			t.Fatalf("key %d of failed batch written", i)
+		}
+	}
+	if has, _ := db.Has([]byte("deleted")); !has {
+		t.Fatalf("key deleted by failed batch")
+	}
+}
+
+func TestBadger_BatchTooLarge(t *testing.T) {
+	db, _, remove := newTestBadger()
+	defer remove()
+
+	// A batch beyond the transaction limits fails without writing any part
+	batch := db.NewBatch()
+	for i := 0; i < 10000; i++ {
+		batch.Put([]byte(fmt.Sprintf("a%05d", i)), []byte{byte(i)})
+	}
+	if err := batch.Write(); err == nil {
+		t.Fatalf("batch beyond the transaction limits written")
+	}
+	for i := 0; i < 10000; i++ {
+		if has, _ := db.Has([]byte(fmt.Sprintf("a%05d", i))); has {
+			t.Fatalf("key %d of failed batch written", i)
+		}
+	}
+}
+
+func TestBadger_CompactClose(t *testing.T) {
+	db, _, remove := newTestBadger()
+	defer remove()
+
+	for i := 0; i < 100; i++ {
+		if err := db.Put([]byte(fmt.Sprintf("a%03d", i)), []byte{byte(i)}); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+	}
+	if err := db.Compact([]byte("b"), nil); err != nil {
+		t.Fatalf("compaction of an empty range failed: %v", err)
+	}
+	if err := db.Compact(nil, nil); err != nil {
+		t.Fatalf("compaction failed: %v", err)
+	}
+	if value, err := db.Get([]byte("a050")); err != nil || !bytes.Equal(value, []byte{50}) {
+		t.Fatalf("value after compaction mismatch: have %x, %v", value, err)
+	}
+	// Closing again, as the removal does, is a no-op
+	db.Close()
+}
+
+func TestBadger_IterateDeleteRange(t *testing.T) {
+	db, _, remove := newTestBadger()
+	defer remove()
+	testIterateDeleteRange(db, t)
+}
+
+func TestOpenDatabaseEngines(t *testing.T) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "ethdb_test_")
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	defer os.RemoveAll(dirname)
+
+	db, err := ethdb.OpenDatabase(ethdb.BadgerEngine, dirname, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	db.Put([]byte("key"), []byte("value"))
+	db.Close()
+
+	if engine := ethdb.DetectEngine(dirname); engine != ethdb.BadgerEngine {
+		t.Fatalf("engine mismatch: have %q, want %q", engine, ethdb.BadgerEngine)
+	}
+	if _, err := ethdb.OpenDatabase(ethdb.LevelDBEngine, dirname, 0, 0); err == nil {
+		t.Fatalf("badger database opened with leveldb")
+	}
+	reopened, err := ethdb.OpenDatabase("", dirname, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer reopened.Close()
+
+	if reopened.Engine() != ethdb.BadgerEngine {
+		t.Fatalf("reopened engine mismatch: have %q", reopened.Engine())
+	}
+	if value, err := reopened.Get([]byte("key")); err != nil || string(value) != "value" {
+		t.Fatalf("reopened value mismatch: have %q, %v", value, err)
+	}
+}
@@ -35,8 +35,9 @@ import (
 var OpenFileLimit = 64
 
 type LDBDatabase struct {
-	fn string      // filename for reporting
-	db *leveldb.DB // LevelDB instance
+	fn      string      // filename for reporting
+	db      *leveldb.DB // LevelDB instance
+	freezer *Freezer    // Optional store of the immutable chain data
 
 	compTimeMeter  metrics.Meter // Meter for measuring the total time spent in database compaction
 	compReadMeter  metrics.Meter // Meter for measuring the data read during compaction
@@ -84,6 +85,11 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 	}, nil
 }
 
+// Engine returns the storage engine of the database.
+func (db *LDBDatabase) Engine() string {
+	return LevelDBEngine
+}
+
 // Path returns the path to the database directory.
 func (db *LDBDatabase) Path() string {
 	return db.fn
@@ -127,6 +133,55 @@ func (db *LDBDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
 	return db.db.NewIterator(util.BytesPrefix(prefix), nil)
 }
 
+// Stat returns a particular internal stat of the database, the properties
+// being the LevelDB ones, such as leveldb.stats or leveldb.iostats.
+func (db *LDBDatabase) Stat(property string) (string, error) {
+	return db.db.GetProperty(property)
+}
+
+// Compact flattens the underlying data store for the given key range.
+func (db *LDBDatabase) Compact(start []byte, limit []byte) error {
+	return db.db.CompactRange(util.Range{Start: start, Limit: limit})
+}
+
+// DeleteRange deletes all the keys from start (inclusive) up to limit
+// (exclusive).
+func (db *LDBDatabase) DeleteRange(start []byte, limit []byte) error {
+	it := db.db.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		batch.Delete(it.Key())
+		if batch.ValueSize() >= IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// OpenFreezer attaches the ancient store in the given directory to the
+// database, which the chain data accessors fall back to.
+func (db *LDBDatabase) OpenFreezer(datadir string) error {
+	freezer, err := NewFreezer(datadir)
+	if err != nil {
+		return err
+	}
+	db.freezer = freezer
+	return nil
+}
+
+// Freezer returns the ancient store of the database, nil if it has none.
+func (db *LDBDatabase) Freezer() *Freezer {
+	return db.freezer
+}
+
 func (db *LDBDatabase) Close() {
 	// Stop the metrics collection to avoid internal database races
 	db.quitLock.Lock()
@@ -139,6 +194,11 @@ func (db *LDBDatabase) Close() {
 			db.log.Error("Metrics collection failed", "err", err)
 		}
 	}
+	if db.freezer != nil {
+		if err := db.freezer.Close(); err != nil {
+			db.log.Error("Failed to close ancient database", "err", err)
+		}
+	}
 	err := db.db.Close()
 	if err == nil {
 		db.log.Info("Database closed")
@@ -311,6 +371,12 @@ func (b *ldbBatch) Put(key, value []byte) error {
 	return nil
 }
 
+func (b *ldbBatch) Delete(key []byte) error {
+	b.b.Delete(key)
+	b.size += len(key)
+	return nil
+}
+
 func (b *ldbBatch) Write() error {
 	return b.db.Write(b.b, nil)
 }
@@ -354,10 +420,66 @@ func (dt *table) Delete(key []byte) error {
 	return dt.db.Delete(append([]byte(dt.prefix), key...))
 }
 
+func (dt *table) NewIterator() iterator.Iterator {
+	return dt.NewIteratorWithPrefix(nil)
+}
+
+// NewIteratorWithPrefix returns an iterator over the table content with a
+// particular key prefix, the keys being stripped of the table prefix.
+func (dt *table) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	return &tableIterator{
+		Iterator: dt.db.NewIteratorWithPrefix(append([]byte(dt.prefix), prefix...)),
+		prefix:   dt.prefix,
+	}
+}
+
+func (dt *table) Stat(property string) (string, error) {
+	return dt.db.Stat(property)
+}
+
+func (dt *table) Compact(start []byte, limit []byte) error {
+	start, limit = dt.keyRange(start, limit)
+	return dt.db.Compact(start, limit)
+}
+
+func (dt *table) DeleteRange(start []byte, limit []byte) error {
+	start, limit = dt.keyRange(start, limit)
+	return dt.db.DeleteRange(start, limit)
+}
+
+// keyRange maps a key range of the table to the one of the underlying
+// database, a nil limit standing for the end of the table.
+func (dt *table) keyRange(start []byte, limit []byte) ([]byte, []byte) {
+	prefix := []byte(dt.prefix)
+	if limit == nil {
+		return append(prefix, start...), util.BytesPrefix(prefix).Limit
+	}
+	return append(prefix, start...), append(append([]byte{}, prefix...), limit...)
+}
+
 func (dt *table) Close() {
 	// Do nothing; don't close the underlying DB.
 }
 
+// tableIterator strips the table prefix off the keys of an iterator over the
+// underlying database.
+type tableIterator struct {
+	iterator.Iterator
+	prefix string
+}
+
+func (it *tableIterator) Seek(key []byte) bool {
+	return it.Iterator.Seek(append([]byte(it.prefix), key...))
+}
+
+func (it *tableIterator) Key() []byte {
+	key := it.Iterator.Key()
+	if key == nil {
+		return nil
+	}
+	return key[len(it.prefix):]
+}
+
 type tableBatch struct {
 	batch  Batch
 	prefix string
@@ -376,6 +498,10 @@ func (tb *tableBatch) Put(key, value []byte) error {
 	return tb.batch.Put(append([]byte(tb.prefix), key...), value)
 }
 
+func (tb *tableBatch) Delete(key []byte) error {
+	return tb.batch.Delete(append([]byte(tb.prefix), key...))
+}
+
 func (tb *tableBatch) Write() error {
 	return tb.batch.Write()
 }
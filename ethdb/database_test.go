@@ -25,6 +25,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/tomochain/tomochain/ethdb"
 )
 
@@ -190,3 +191,77 @@ func testParallelPutGet(db ethdb.Database, t *testing.T) {
 	}
 	pending.Wait()
 }
+
+func TestLDB_IterateDeleteRange(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testIterateDeleteRange(db, t)
+}
+
+func TestMemoryDB_IterateDeleteRange(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	testIterateDeleteRange(db, t)
+}
+
+func TestTable_IterateDeleteRange(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	db.Put([]byte("a"), []byte("outside"))
+	db.Put([]byte("tabm"), []byte("outside"))
+
+	testIterateDeleteRange(ethdb.NewTable(db, "tabl"), t)
+
+	// Entries outside of the table must survive its wiping
+	if keys := db.Keys(); len(keys) != 2 {
+		t.Fatalf("entries outside of the table mismatch: have %q", keys)
+	}
+}
+
+func testIterateDeleteRange(db ethdb.Database, t *testing.T) {
+	batch := db.NewBatch()
+	for i := 0; i < 10; i++ {
+		batch.Put([]byte(fmt.Sprintf("a%03d", i)), []byte{byte(i)})
+		batch.Put([]byte(fmt.Sprintf("b%03d", i)), []byte{byte(i)})
+	}
+	batch.Delete([]byte("a005"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch write failed: %v", err)
+	}
+	// Iterate over a prefix, skipping the key deleted in the batch
+	checkKeys(t, db.NewIteratorWithPrefix([]byte("a")), []string{"a000", "a001", "a002", "a003", "a004", "a006", "a007", "a008", "a009"})
+
+	it := db.NewIterator()
+	if !it.Seek([]byte("a005")) || string(it.Key()) != "a006" || it.Value()[0] != 6 {
+		t.Fatalf("seek mismatch: have %q", it.Key())
+	}
+	it.Release()
+
+	// Delete a range spanning both prefixes, then everything
+	if err := db.DeleteRange([]byte("a003"), []byte("b007")); err != nil {
+		t.Fatalf("range deletion failed: %v", err)
+	}
+	checkKeys(t, db.NewIterator(), []string{"a000", "a001", "a002", "b007", "b008", "b009"})
+
+	if err := db.Compact(nil, nil); err != nil {
+		t.Fatalf("compaction failed: %v", err)
+	}
+	if err := db.DeleteRange(nil, nil); err != nil {
+		t.Fatalf("range deletion failed: %v", err)
+	}
+	checkKeys(t, db.NewIterator(), nil)
+}
+
+// checkKeys iterates over a database and checks the keys walked over.
+func checkKeys(t *testing.T, it iterator.Iterator, want []string) {
+	defer it.Release()
+
+	var have []string
+	for it.Next() {
+		have = append(have, string(it.Key()))
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if fmt.Sprint(have) != fmt.Sprint(want) {
+		t.Fatalf("iterated keys mismatch: have %q, want %q", have, want)
+	}
+}
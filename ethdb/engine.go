@@ -0,0 +1,87 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Storage engines the databases can be persisted with.
+const (
+	LevelDBEngine = "leveldb"
+	BadgerEngine  = "badger"
+)
+
+// Engines are the supported storage engines, the first being the default.
+var Engines = []string{LevelDBEngine, BadgerEngine}
+
+// DiskDatabase is a database persisted on disk by one of the storage engines.
+type DiskDatabase interface {
+	Database
+	AncientDatabase
+
+	// Engine returns the storage engine of the database.
+	Engine() string
+
+	// Path returns the path to the database directory.
+	Path() string
+
+	// OpenFreezer attaches the ancient store in the given directory.
+	OpenFreezer(datadir string) error
+
+	// Meter starts collecting the database metrics under the given prefix.
+	Meter(prefix string)
+}
+
+// OpenDatabase opens the database in the given directory with a storage
+// engine, creating it if the directory holds none. An empty engine picks the
+// one the existing database was created with, or the default one for a new
+// database. Opening an existing database with another engine than its own
+// fails, as its content has to be converted first.
+func OpenDatabase(engine string, file string, cache int, handles int) (DiskDatabase, error) {
+	existing := DetectEngine(file)
+	if engine == "" {
+		engine = existing
+	}
+	if engine == "" {
+		engine = Engines[0]
+	}
+	if existing != "" && existing != engine {
+		return nil, fmt.Errorf("database %s was created with %s, convert it to use %s", file, existing, engine)
+	}
+	switch engine {
+	case LevelDBEngine:
+		return NewLDBDatabase(file, cache, handles)
+	case BadgerEngine:
+		return NewBadgerDatabase(file, cache, handles)
+	default:
+		return nil, fmt.Errorf("unknown database engine %q", engine)
+	}
+}
+
+// DetectEngine returns the storage engine of the database in the given
+// directory, the empty string if there is none.
+func DetectEngine(file string) string {
+	if _, err := os.Stat(filepath.Join(file, "CURRENT")); err == nil {
+		return LevelDBEngine
+	}
+	if logs, _ := filepath.Glob(filepath.Join(file, "*.vlog")); len(logs) > 0 {
+		return BadgerEngine
+	}
+	return ""
+}
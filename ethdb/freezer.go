@@ -0,0 +1,201 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tomochain/tomochain/log"
+)
+
+// The tables of the freezer, each holding one kind of chain data per block.
+const (
+	FreezerHashTable       = "hashes"   // Canonical block hashes
+	FreezerHeaderTable     = "headers"  // RLP encoded block headers
+	FreezerBodiesTable     = "bodies"   // RLP encoded block bodies
+	FreezerReceiptTable    = "receipts" // RLP encoded block receipts, in storage format
+	FreezerDifficultyTable = "diffs"    // RLP encoded total difficulties
+)
+
+// freezerTables lists the tables of the freezer and whether they are compressed.
+var freezerTables = map[string]bool{
+	FreezerHashTable:       false,
+	FreezerHeaderTable:     false,
+	FreezerBodiesTable:     true,
+	FreezerReceiptTable:    true,
+	FreezerDifficultyTable: false,
+}
+
+// errUnknownTable is returned if a table which is not part of the freezer is
+// accessed.
+var errUnknownTable = errors.New("unknown table")
+
+// Freezer is an append-only store of the immutable chain data, which is moved
+// out of the key-value database once it's old enough not to be reorganised.
+// It keeps the canonical blocks in flat files indexed by block number.
+type Freezer struct {
+	frozen uint64 // Number of blocks frozen in every table, accessed atomically
+
+	tables map[string]*freezerTable
+	lock   sync.Mutex // Mutex serialising the appends and truncations
+}
+
+// NewFreezer opens the freezer in the given directory, creating it if needed.
+// Tables left out of sync by an unclean shutdown are truncated to the blocks
+// stored in all of them.
+func NewFreezer(datadir string) (*Freezer, error) {
+	if err := os.MkdirAll(datadir, 0755); err != nil {
+		return nil, err
+	}
+	freezer := &Freezer{tables: make(map[string]*freezerTable)}
+	for name, compress := range freezerTables {
+		table, err := newFreezerTable(datadir, name, compress)
+		if err != nil {
+			freezer.Close()
+			return nil, err
+		}
+		freezer.tables[name] = table
+	}
+	frozen := uint64(0)
+	for i, name := range freezer.tableNames() {
+		if items := freezer.tables[name].Items(); i == 0 || items < frozen {
+			frozen = items
+		}
+	}
+	if err := freezer.truncate(frozen); err != nil {
+		freezer.Close()
+		return nil, err
+	}
+	atomic.StoreUint64(&freezer.frozen, frozen)
+
+	log.Info("Opened ancient database", "path", datadir, "blocks", frozen)
+	return freezer, nil
+}
+
+// tableNames returns the names of the freezer tables.
+func (f *Freezer) tableNames() []string {
+	names := make([]string, 0, len(freezerTables))
+	for name := range freezerTables {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Ancients returns the number of blocks frozen, which is the number of the
+// first block still in the key-value database.
+func (f *Freezer) Ancients() uint64 {
+	return atomic.LoadUint64(&f.frozen)
+}
+
+// HasAncient reports whether the given block is frozen in the table.
+func (f *Freezer) HasAncient(kind string, number uint64) bool {
+	_, ok := f.tables[kind]
+	return ok && number < atomic.LoadUint64(&f.frozen)
+}
+
+// Ancient retrieves the frozen data of a block from the given table.
+func (f *Freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	table, ok := f.tables[kind]
+	if !ok {
+		return nil, errUnknownTable
+	}
+	if number >= atomic.LoadUint64(&f.frozen) {
+		return nil, errOutOfBounds
+	}
+	return table.Retrieve(number)
+}
+
+// AppendAncient freezes the data of the next block. Either every table gets
+// the block or the partial write is rolled back.
+func (f *Freezer) AppendAncient(number uint64, hash, header, body, receipts, td []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	frozen := atomic.LoadUint64(&f.frozen)
+	if number != frozen {
+		return errOutOrderInsertion
+	}
+	blobs := map[string][]byte{
+		FreezerHashTable:       hash,
+		FreezerHeaderTable:     header,
+		FreezerBodiesTable:     body,
+		FreezerReceiptTable:    receipts,
+		FreezerDifficultyTable: td,
+	}
+	for name, blob := range blobs {
+		if err := f.tables[name].Append(number, blob); err != nil {
+			if rerr := f.truncate(frozen); rerr != nil {
+				log.Error("Failed to roll back ancient append", "number", number, "err", rerr)
+			}
+			return fmt.Errorf("failed to append to %s table: %v", name, err)
+		}
+	}
+	atomic.StoreUint64(&f.frozen, frozen+1)
+	return nil
+}
+
+// TruncateAncients discards the frozen blocks from the given number on.
+func (f *Freezer) TruncateAncients(items uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if atomic.LoadUint64(&f.frozen) <= items {
+		return nil
+	}
+	if err := f.truncate(items); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&f.frozen, items)
+	return nil
+}
+
+// truncate discards the items of every table past the given number.
+func (f *Freezer) truncate(items uint64) error {
+	for _, table := range f.tables {
+		if err := table.Truncate(items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync flushes the frozen data to disk.
+func (f *Freezer) Sync() error {
+	for _, table := range f.tables {
+		if err := table.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases the files of the freezer.
+func (f *Freezer) Close() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/snappy"
+)
+
+// indexEntrySize is the size of an index entry, the end offset of an item in
+// the data file.
+const indexEntrySize = 8
+
+var (
+	// errClosed is returned if an operation attempts to access a closed table.
+	errClosed = errors.New("closed")
+
+	// errOutOfBounds is returned if the item requested is not contained within
+	// the table.
+	errOutOfBounds = errors.New("out of bounds")
+
+	// errOutOrderInsertion is returned if the item number to append doesn't
+	// follow the last stored one.
+	errOutOrderInsertion = errors.New("the append operation is out-order")
+)
+
+// freezerTable is an append-only table of items numbered from zero. The items
+// are stored back to back in a data file, while an index file holds the end
+// offset of every item.
+type freezerTable struct {
+	items    uint64 // Number of items stored in the table, accessed atomically
+	size     uint64 // Size of the data file
+	compress bool   // Whether the items are snappy compressed
+
+	index *os.File // File holding the end offset of every item
+	data  *os.File // File holding the item contents
+
+	lock sync.RWMutex // Mutex protecting the files and the size
+}
+
+// newFreezerTable opens the given table in the directory, creating it if it
+// doesn't exist yet and repairing the damages of an unclean shutdown.
+func newFreezerTable(dir, name string, compress bool) (*freezerTable, error) {
+	ext := ".rdat"
+	if compress {
+		ext = ".cdat"
+	}
+	index, err := os.OpenFile(filepath.Join(dir, name+".ridx"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(filepath.Join(dir, name+ext), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		index.Close()
+		return nil, err
+	}
+	t := &freezerTable{
+		compress: compress,
+		index:    index,
+		data:     data,
+	}
+	if err := t.repair(); err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// repair truncates the index and data files to the last item fully written.
+// Data is written before its index entry, so trailing data without an entry
+// is discarded, as well as the entries pointing past the end of the data.
+func (t *freezerTable) repair() error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	items := uint64(stat.Size()) / indexEntrySize
+	if stat, err = t.data.Stat(); err != nil {
+		return err
+	}
+	size := uint64(stat.Size())
+
+	for ; items > 0; items-- {
+		end, err := t.offset(items)
+		if err != nil {
+			return err
+		}
+		if end <= size {
+			size = end
+			break
+		}
+	}
+	if items == 0 {
+		size = 0
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	t.size = size
+	atomic.StoreUint64(&t.items, items)
+	return nil
+}
+
+// offset returns the end offset of the given number of items.
+func (t *freezerTable) offset(items uint64) (uint64, error) {
+	if items == 0 {
+		return 0, nil
+	}
+	var entry [indexEntrySize]byte
+	if _, err := t.index.ReadAt(entry[:], int64((items-1)*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(entry[:]), nil
+}
+
+// Items returns the number of items stored in the table.
+func (t *freezerTable) Items() uint64 {
+	return atomic.LoadUint64(&t.items)
+}
+
+// Append stores the next item of the table.
+func (t *freezerTable) Append(item uint64, blob []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if item != atomic.LoadUint64(&t.items) {
+		return errOutOrderInsertion
+	}
+	if t.compress {
+		blob = snappy.Encode(nil, blob)
+	}
+	if _, err := t.data.WriteAt(blob, int64(t.size)); err != nil {
+		return err
+	}
+	var entry [indexEntrySize]byte
+	binary.BigEndian.PutUint64(entry[:], t.size+uint64(len(blob)))
+	if _, err := t.index.WriteAt(entry[:], int64(item*indexEntrySize)); err != nil {
+		return err
+	}
+	t.size += uint64(len(blob))
+	atomic.AddUint64(&t.items, 1)
+	return nil
+}
+
+// Retrieve returns the item with the given number.
+func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil {
+		return nil, errClosed
+	}
+	if item >= atomic.LoadUint64(&t.items) {
+		return nil, errOutOfBounds
+	}
+	start, err := t.offset(item)
+	if err != nil {
+		return nil, err
+	}
+	end, err := t.offset(item + 1)
+	if err != nil {
+		return nil, err
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	if t.compress {
+		return snappy.Decode(nil, blob)
+	}
+	return blob, nil
+}
+
+// Truncate discards the items past the given number.
+func (t *freezerTable) Truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if atomic.LoadUint64(&t.items) <= items {
+		return nil
+	}
+	size, err := t.offset(items)
+	if err != nil {
+		return err
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	t.size = size
+	atomic.StoreUint64(&t.items, items)
+	return nil
+}
+
+// Sync flushes the data and then the index file to disk.
+func (t *freezerTable) Sync() error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+// close releases the files of the table.
+func (t *freezerTable) close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return nil
+	}
+	var errs []error
+	if err := t.index.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := t.data.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	t.index, t.data = nil, nil
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// appendTestBlocks freezes blocks with contents derived from their number.
+func appendTestBlocks(t *testing.T, f *Freezer, from, to uint64) {
+	for i := from; i < to; i++ {
+		blob := []byte(fmt.Sprintf("block-%d", i))
+		if err := f.AppendAncient(i, blob, blob, blob, blob, blob); err != nil {
+			t.Fatalf("block %d: failed to append: %v", i, err)
+		}
+	}
+}
+
+// checkTestBlocks verifies the frozen blocks appended by appendTestBlocks.
+func checkTestBlocks(t *testing.T, f *Freezer, items uint64) {
+	if frozen := f.Ancients(); frozen != items {
+		t.Fatalf("frozen blocks mismatch: have %d, want %d", frozen, items)
+	}
+	for i := uint64(0); i < items; i++ {
+		for name := range freezerTables {
+			blob, err := f.Ancient(name, i)
+			if err != nil {
+				t.Fatalf("block %d: failed to retrieve from %s: %v", i, name, err)
+			}
+			if want := []byte(fmt.Sprintf("block-%d", i)); !bytes.Equal(blob, want) {
+				t.Fatalf("block %d: %s mismatch: have %q, want %q", i, name, blob, want)
+			}
+		}
+	}
+	if _, err := f.Ancient(FreezerHashTable, items); err != errOutOfBounds {
+		t.Fatalf("retrieval past the end: have %v, want %v", err, errOutOfBounds)
+	}
+}
+
+func TestFreezerAppendTruncate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := NewFreezer(dir)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	appendTestBlocks(t, f, 0, 100)
+	if err := f.AppendAncient(101, nil, nil, nil, nil, nil); err != errOutOrderInsertion {
+		t.Fatalf("out of order append: have %v, want %v", err, errOutOrderInsertion)
+	}
+	checkTestBlocks(t, f, 100)
+
+	if err := f.TruncateAncients(60); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+	checkTestBlocks(t, f, 60)
+	appendTestBlocks(t, f, 60, 80)
+	f.Close()
+
+	// Reopen the freezer and ensure everything's there
+	if f, err = NewFreezer(dir); err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	defer f.Close()
+	checkTestBlocks(t, f, 80)
+}
+
+// Tests that a freezer left behind with partially written blocks is repaired
+// to the blocks fully written in every table.
+func TestFreezerRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := NewFreezer(dir)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	appendTestBlocks(t, f, 0, 10)
+	f.Close()
+
+	// Simulate a crash after writing the data of a new header, and before
+	// appending the last body to the index
+	data, err := os.OpenFile(filepath.Join(dir, FreezerHeaderTable+".rdat"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Write([]byte("garbage"))
+	data.Close()
+
+	index := filepath.Join(dir, FreezerBodiesTable+".ridx")
+	stat, err := os.Stat(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(index, stat.Size()-indexEntrySize-3); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = NewFreezer(dir); err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	checkTestBlocks(t, f, 8)
+	appendTestBlocks(t, f, 8, 12)
+	f.Close()
+
+	if f, err = NewFreezer(dir); err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	defer f.Close()
+	checkTestBlocks(t, f, 12)
+}
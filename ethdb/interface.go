@@ -16,7 +16,10 @@
 
 package ethdb
 
-import "github.com/tomochain/tomochain/common"
+import (
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/tomochain/tomochain/common"
+)
 
 // Code using batches should try to add this much data to the batch.
 // The value was determined empirically.
@@ -27,14 +30,65 @@ type Putter interface {
 	Put(key []byte, value []byte) error
 }
 
+// Deleter wraps the database delete operation supported by both batches and regular databases.
+type Deleter interface {
+	Delete(key []byte) error
+}
+
+// Iteratee wraps the iterator creation of a database. The iterators walk the
+// keys in ascending order and must be released once done with.
+type Iteratee interface {
+	// NewIterator returns an iterator over the whole database content.
+	NewIterator() iterator.Iterator
+
+	// NewIteratorWithPrefix returns an iterator over the database content
+	// with a particular key prefix.
+	NewIteratorWithPrefix(prefix []byte) iterator.Iterator
+}
+
+// Stater wraps the retrieval of the internal statistics of a database.
+type Stater interface {
+	// Stat returns a particular internal stat of the database, an error if
+	// the database doesn't know of the property.
+	Stat(property string) (string, error)
+}
+
+// Compacter wraps the compaction of a database.
+type Compacter interface {
+	// Compact flattens the underlying data store for the given key range,
+	// discarding the deleted and overwritten versions and rearranging the
+	// data to cut the cost of accessing it. A nil start is treated as a key
+	// before all keys and a nil limit as a key after all keys, so a nil range
+	// compacts the whole database.
+	Compact(start []byte, limit []byte) error
+}
+
+// RangeDeleter wraps the deletion of a key range of a database.
+type RangeDeleter interface {
+	// DeleteRange deletes all the keys from start (inclusive) up to limit
+	// (exclusive), with the same nil bounds as Compact.
+	DeleteRange(start []byte, limit []byte) error
+}
+
 // Database wraps all database operations. All methods are safe for concurrent use.
 type Database interface {
 	Putter
+	Deleter
 	Get(key []byte) ([]byte, error)
 	Has(key []byte) (bool, error)
-	Delete(key []byte) error
 	Close()
 	NewBatch() Batch
+
+	Iteratee
+	Stater
+	Compacter
+	RangeDeleter
+}
+
+// AncientDatabase is a database which may keep the immutable chain data in an
+// append-only freezer.
+type AncientDatabase interface {
+	Freezer() *Freezer
 }
 
 // TomoxDatabase interface
@@ -46,6 +100,7 @@ type TomoxDatabase interface {
 // when Write is called. Batch cannot be used concurrently.
 type Batch interface {
 	Putter
+	Deleter
 	ValueSize() int // amount of data in the batch
 	Write() error
 	// Reset resets the batch for reuse
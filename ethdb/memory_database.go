@@ -17,12 +17,20 @@
 package ethdb
 
 import (
+	"bytes"
 	"errors"
+	"sort"
+	"strings"
 	"sync"
 
+	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/tomochain/tomochain/common"
 )
 
+// errUnknownProperty is returned when the stat of a property the database
+// doesn't know of is requested.
+var errUnknownProperty = errors.New("unknown property")
+
 /*
  * This is a test memory database. Do not use for any production it does not get persisted
  */
@@ -88,6 +96,62 @@ func (db *MemDatabase) Delete(key []byte) error {
 	return nil
 }
 
+// NewIterator returns an iterator over a snapshot of the whole database
+// content.
+func (db *MemDatabase) NewIterator() iterator.Iterator {
+	return db.NewIteratorWithPrefix(nil)
+}
+
+// NewIteratorWithPrefix returns an iterator over a snapshot of the database
+// content with a particular key prefix.
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var keys []string
+	for key := range db.db {
+		if strings.HasPrefix(key, string(prefix)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	array := &memArray{
+		keys:   make([][]byte, len(keys)),
+		values: make([][]byte, len(keys)),
+	}
+	for i, key := range keys {
+		array.keys[i] = []byte(key)
+		array.values[i] = common.CopyBytes(db.db[key])
+	}
+	return iterator.NewArrayIterator(array)
+}
+
+// Stat returns a particular internal stat of the database, of which the
+// memory database has none.
+func (db *MemDatabase) Stat(property string) (string, error) {
+	return "", errUnknownProperty
+}
+
+// Compact is a no-op, the memory database having nothing to flatten.
+func (db *MemDatabase) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// DeleteRange deletes all the keys from start (inclusive) up to limit
+// (exclusive).
+func (db *MemDatabase) DeleteRange(start []byte, limit []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	for key := range db.db {
+		if key >= string(start) && (limit == nil || key < string(limit)) {
+			delete(db.db, key)
+		}
+	}
+	return nil
+}
+
 func (db *MemDatabase) Close() {}
 
 func (db *MemDatabase) NewBatch() Batch {
@@ -96,7 +160,24 @@ func (db *MemDatabase) NewBatch() Batch {
 
 func (db *MemDatabase) Len() int { return len(db.db) }
 
-type kv struct{ k, v []byte }
+type kv struct {
+	k, v []byte
+	del  bool
+}
+
+// memArray is a sorted snapshot of memory database entries, walked by the
+// LevelDB array iterator.
+type memArray struct {
+	keys, values [][]byte
+}
+
+func (a *memArray) Len() int { return len(a.keys) }
+
+func (a *memArray) Search(key []byte) int {
+	return sort.Search(len(a.keys), func(i int) bool { return bytes.Compare(a.keys[i], key) >= 0 })
+}
+
+func (a *memArray) Index(i int) ([]byte, []byte) { return a.keys[i], a.values[i] }
 
 type memBatch struct {
 	db     *MemDatabase
@@ -105,16 +186,26 @@ type memBatch struct {
 }
 
 func (b *memBatch) Put(key, value []byte) error {
-	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value)})
+	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value), false})
 	b.size += len(value)
 	return nil
 }
 
+func (b *memBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{common.CopyBytes(key), nil, true})
+	b.size += len(key)
+	return nil
+}
+
 func (b *memBatch) Write() error {
 	b.db.lock.Lock()
 	defer b.db.lock.Unlock()
 
 	for _, kv := range b.writes {
+		if kv.del {
+			delete(b.db.db, string(kv.k))
+			continue
+		}
 		b.db.db[string(kv.k)] = kv.v
 	}
 	return nil
@@ -0,0 +1,1015 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package graphql provides a GraphQL interface to the chain and TomoX data.
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/common/hexutil"
+	"github.com/tomochain/tomochain/core"
+	"github.com/tomochain/tomochain/core/state"
+	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/eth/filters"
+	"github.com/tomochain/tomochain/internal/ethapi"
+	"github.com/tomochain/tomochain/rpc"
+	"github.com/tomochain/tomochain/tomox/tomox_state"
+)
+
+// Keys of the candidates result of the GetCandidates RPC method.
+const (
+	fieldCandidates = "candidates"
+	fieldStatus     = "status"
+	fieldCapacity   = "capacity"
+)
+
+// maxBlocksRange is the maximum number of blocks returned by a blocks query.
+const maxBlocksRange = 1000
+
+var (
+	errStateNotFound   = errors.New("state not found")
+	errTomoXNotFound   = errors.New("TomoX service not found")
+	errNoFilterBackend = errors.New("log filtering not supported by the backend")
+	errBlockNumber     = errors.New("block number out of range")
+)
+
+// Account represents an account at a specific block.
+type Account struct {
+	backend     ethapi.Backend
+	address     common.Address
+	blockNumber rpc.BlockNumber
+}
+
+// getState fetches the state the account is read from.
+func (a *Account) getState(ctx context.Context) (*state.StateDB, error) {
+	statedb, _, err := a.backend.StateAndHeaderByNumber(ctx, a.blockNumber)
+	if statedb == nil && err == nil {
+		err = errStateNotFound
+	}
+	return statedb, err
+}
+
+func (a *Account) Address(ctx context.Context) (common.Address, error) {
+	return a.address, nil
+}
+
+func (a *Account) Balance(ctx context.Context) (hexutil.Big, error) {
+	statedb, err := a.getState(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*statedb.GetBalance(a.address)), nil
+}
+
+func (a *Account) TransactionCount(ctx context.Context) (hexutil.Uint64, error) {
+	statedb, err := a.getState(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return hexutil.Uint64(statedb.GetNonce(a.address)), nil
+}
+
+func (a *Account) Code(ctx context.Context) (hexutil.Bytes, error) {
+	statedb, err := a.getState(ctx)
+	if err != nil {
+		return hexutil.Bytes{}, err
+	}
+	return hexutil.Bytes(statedb.GetCode(a.address)), nil
+}
+
+func (a *Account) Storage(ctx context.Context, args struct{ Slot common.Hash }) (common.Hash, error) {
+	statedb, err := a.getState(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return statedb.GetState(a.address, args.Slot), nil
+}
+
+// Log represents an individual log message. All arguments are mandatory.
+type Log struct {
+	backend     ethapi.Backend
+	transaction *Transaction
+	log         *types.Log
+}
+
+func (l *Log) Transaction(ctx context.Context) *Transaction {
+	return l.transaction
+}
+
+func (l *Log) Account(ctx context.Context) *Account {
+	return &Account{
+		backend:     l.backend,
+		address:     l.log.Address,
+		blockNumber: rpc.BlockNumber(l.log.BlockNumber),
+	}
+}
+
+func (l *Log) Index(ctx context.Context) int32 {
+	return int32(l.log.Index)
+}
+
+func (l *Log) Topics(ctx context.Context) []common.Hash {
+	return l.log.Topics
+}
+
+func (l *Log) Data(ctx context.Context) hexutil.Bytes {
+	return hexutil.Bytes(l.log.Data)
+}
+
+// Transaction represents a transaction, mined or pending. The transaction and
+// the block of it are fetched by hash when first needed if not given.
+type Transaction struct {
+	backend ethapi.Backend
+	hash    common.Hash
+	tx      *types.Transaction
+	block   *Block
+	index   uint64
+
+	lock sync.Mutex // guards the fetching of the fields resolved in parallel
+}
+
+// resolve returns the internal transaction object, fetching it if needed.
+func (t *Transaction) resolve(ctx context.Context) (*types.Transaction, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.tx != nil {
+		return t.tx, nil
+	}
+	tx, blockHash, _, index := core.GetTransaction(t.backend.ChainDb(), t.hash)
+	if tx == nil {
+		t.tx = t.backend.GetPoolTransaction(t.hash)
+		return t.tx, nil
+	}
+	block, err := t.backend.GetBlock(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if block != nil {
+		t.block = &Block{backend: t.backend, block: block}
+	}
+	t.tx, t.index = tx, index
+	return t.tx, nil
+}
+
+// blockNumber returns the number of the block of the transaction, the latest
+// block if it's pending.
+func (t *Transaction) blockNumber() rpc.BlockNumber {
+	if t.block == nil {
+		return rpc.LatestBlockNumber
+	}
+	return rpc.BlockNumber(t.block.block.NumberU64())
+}
+
+func (t *Transaction) Hash(ctx context.Context) common.Hash {
+	return t.hash
+}
+
+func (t *Transaction) InputData(ctx context.Context) (hexutil.Bytes, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return hexutil.Bytes{}, err
+	}
+	return hexutil.Bytes(tx.Data()), nil
+}
+
+func (t *Transaction) Gas(ctx context.Context) (hexutil.Uint64, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return 0, err
+	}
+	return hexutil.Uint64(tx.Gas()), nil
+}
+
+func (t *Transaction) GasPrice(ctx context.Context) (hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*tx.GasPrice()), nil
+}
+
+func (t *Transaction) Value(ctx context.Context) (hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*tx.Value()), nil
+}
+
+func (t *Transaction) Nonce(ctx context.Context) (hexutil.Uint64, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return 0, err
+	}
+	return hexutil.Uint64(tx.Nonce()), nil
+}
+
+func (t *Transaction) To(ctx context.Context) (*Account, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	to := tx.To()
+	if to == nil {
+		return nil, nil
+	}
+	return &Account{
+		backend:     t.backend,
+		address:     *to,
+		blockNumber: t.blockNumber(),
+	}, nil
+}
+
+func (t *Transaction) From(ctx context.Context) (*Account, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		signer = types.NewEIP155Signer(tx.ChainId())
+	}
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		backend:     t.backend,
+		address:     from,
+		blockNumber: t.blockNumber(),
+	}, nil
+}
+
+func (t *Transaction) Block(ctx context.Context) (*Block, error) {
+	if _, err := t.resolve(ctx); err != nil {
+		return nil, err
+	}
+	return t.block, nil
+}
+
+func (t *Transaction) Index(ctx context.Context) (*int32, error) {
+	if _, err := t.resolve(ctx); err != nil {
+		return nil, err
+	}
+	if t.block == nil {
+		return nil, nil
+	}
+	index := int32(t.index)
+	return &index, nil
+}
+
+// getReceipt returns the receipt associated with this transaction, if any.
+func (t *Transaction) getReceipt(ctx context.Context) (*types.Receipt, error) {
+	if _, err := t.resolve(ctx); err != nil {
+		return nil, err
+	}
+	if t.block == nil {
+		return nil, nil
+	}
+	receipts, err := t.backend.GetReceipts(ctx, t.block.block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if t.index >= uint64(len(receipts)) {
+		return nil, nil
+	}
+	return receipts[t.index], nil
+}
+
+func (t *Transaction) Status(ctx context.Context) (*hexutil.Uint64, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	ret := hexutil.Uint64(receipt.Status)
+	return &ret, nil
+}
+
+func (t *Transaction) GasUsed(ctx context.Context) (*hexutil.Uint64, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	ret := hexutil.Uint64(receipt.GasUsed)
+	return &ret, nil
+}
+
+func (t *Transaction) CumulativeGasUsed(ctx context.Context) (*hexutil.Uint64, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	ret := hexutil.Uint64(receipt.CumulativeGasUsed)
+	return &ret, nil
+}
+
+func (t *Transaction) CreatedContract(ctx context.Context) (*Account, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil || receipt.ContractAddress == (common.Address{}) {
+		return nil, err
+	}
+	return &Account{
+		backend:     t.backend,
+		address:     receipt.ContractAddress,
+		blockNumber: t.blockNumber(),
+	}, nil
+}
+
+func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	ret := make([]*Log, 0, len(receipt.Logs))
+	for _, log := range receipt.Logs {
+		ret = append(ret, &Log{
+			backend:     t.backend,
+			transaction: t,
+			log:         log,
+		})
+	}
+	return &ret, nil
+}
+
+// Block represents a block of the chain.
+type Block struct {
+	backend ethapi.Backend
+	block   *types.Block
+}
+
+func (b *Block) Number(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(b.block.NumberU64())
+}
+
+func (b *Block) Hash(ctx context.Context) common.Hash {
+	return b.block.Hash()
+}
+
+func (b *Block) Parent(ctx context.Context) (*Block, error) {
+	if b.block.NumberU64() == 0 {
+		return nil, nil
+	}
+	parent, err := b.backend.GetBlock(ctx, b.block.ParentHash())
+	if err != nil || parent == nil {
+		return nil, err
+	}
+	return &Block{backend: b.backend, block: parent}, nil
+}
+
+func (b *Block) Nonce(ctx context.Context) hexutil.Bytes {
+	nonce := b.block.Header().Nonce
+	return hexutil.Bytes(nonce[:])
+}
+
+func (b *Block) TransactionsRoot(ctx context.Context) common.Hash {
+	return b.block.TxHash()
+}
+
+func (b *Block) TransactionCount(ctx context.Context) int32 {
+	return int32(len(b.block.Transactions()))
+}
+
+func (b *Block) StateRoot(ctx context.Context) common.Hash {
+	return b.block.Root()
+}
+
+func (b *Block) ReceiptsRoot(ctx context.Context) common.Hash {
+	return b.block.ReceiptHash()
+}
+
+func (b *Block) Miner(ctx context.Context) *Account {
+	return b.Account(ctx, struct{ Address common.Address }{b.block.Coinbase()})
+}
+
+func (b *Block) ExtraData(ctx context.Context) hexutil.Bytes {
+	return hexutil.Bytes(b.block.Extra())
+}
+
+func (b *Block) GasLimit(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(b.block.GasLimit())
+}
+
+func (b *Block) GasUsed(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(b.block.GasUsed())
+}
+
+func (b *Block) Timestamp(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*b.block.Time())
+}
+
+func (b *Block) LogsBloom(ctx context.Context) hexutil.Bytes {
+	return hexutil.Bytes(b.block.Bloom().Bytes())
+}
+
+func (b *Block) MixHash(ctx context.Context) common.Hash {
+	return b.block.MixDigest()
+}
+
+func (b *Block) Difficulty(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*b.block.Difficulty())
+}
+
+func (b *Block) TotalDifficulty(ctx context.Context) (hexutil.Big, error) {
+	td := b.backend.GetTd(b.block.Hash())
+	if td == nil {
+		return hexutil.Big{}, errors.New("total difficulty not found")
+	}
+	return hexutil.Big(*td), nil
+}
+
+func (b *Block) Transactions(ctx context.Context) []*Transaction {
+	txs := b.block.Transactions()
+	ret := make([]*Transaction, 0, len(txs))
+	for i, tx := range txs {
+		ret = append(ret, &Transaction{
+			backend: b.backend,
+			hash:    tx.Hash(),
+			tx:      tx,
+			block:   b,
+			index:   uint64(i),
+		})
+	}
+	return ret
+}
+
+func (b *Block) TransactionAt(ctx context.Context, args struct{ Index int32 }) *Transaction {
+	txs := b.block.Transactions()
+	if args.Index < 0 || int(args.Index) >= len(txs) {
+		return nil
+	}
+	tx := txs[args.Index]
+	return &Transaction{
+		backend: b.backend,
+		hash:    tx.Hash(),
+		tx:      tx,
+		block:   b,
+		index:   uint64(args.Index),
+	}
+}
+
+// BlockFilterCriteria encapsulates criteria passed to a `logs` accessor inside
+// a block.
+type BlockFilterCriteria struct {
+	Addresses *[]common.Address // restricts matches to events created by specific contracts
+	Topics    *[][]common.Hash  // restricts matches to particular event topics
+}
+
+func (b *Block) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) ([]*Log, error) {
+	var addresses []common.Address
+	if args.Filter.Addresses != nil {
+		addresses = *args.Filter.Addresses
+	}
+	var topics [][]common.Hash
+	if args.Filter.Topics != nil {
+		topics = *args.Filter.Topics
+	}
+	number := b.block.Number().Int64()
+	return runFilter(ctx, b.backend, number, number, addresses, topics)
+}
+
+func (b *Block) Account(ctx context.Context, args struct{ Address common.Address }) *Account {
+	return &Account{
+		backend:     b.backend,
+		address:     args.Address,
+		blockNumber: rpc.BlockNumber(b.block.NumberU64()),
+	}
+}
+
+func (b *Block) Signers(ctx context.Context) ([]common.Address, error) {
+	return ethapi.NewPublicBlockChainAPI(b.backend).GetBlockSignersByHash(ctx, b.block.Hash())
+}
+
+func (b *Block) Finality(ctx context.Context) (int32, error) {
+	finality, err := ethapi.NewPublicBlockChainAPI(b.backend).GetBlockFinalityByHash(ctx, b.block.Hash())
+	return int32(finality), err
+}
+
+func (b *Block) Masternodes(ctx context.Context) ([]common.Address, error) {
+	return ethapi.NewPublicBlockChainAPI(b.backend).GetMasternodes(ctx, b.block)
+}
+
+func (b *Block) Penalties(ctx context.Context) []common.Address {
+	return common.ExtractAddressFromBytes(b.block.Penalties())
+}
+
+// FilterCriteria encapsulates the arguments to `logs` on the root resolver object.
+type FilterCriteria struct {
+	FromBlock *hexutil.Uint64   // beginning of the queried range, nil means latest block
+	ToBlock   *hexutil.Uint64   // end of the range, nil means latest block
+	Addresses *[]common.Address // restricts matches to events created by specific contracts
+	Topics    *[][]common.Hash  // restricts matches to particular event topics
+}
+
+// runFilter searches the logs of the given block range matching the given
+// addresses and topics.
+func runFilter(ctx context.Context, backend ethapi.Backend, begin, end int64, addresses []common.Address, topics [][]common.Hash) ([]*Log, error) {
+	fb, ok := backend.(filters.Backend)
+	if !ok {
+		return nil, errNoFilterBackend
+	}
+	logs, err := filters.New(fb, begin, end, addresses, topics).Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*Log, 0, len(logs))
+	for _, log := range logs {
+		ret = append(ret, &Log{
+			backend:     backend,
+			transaction: &Transaction{backend: backend, hash: log.TxHash},
+			log:         log,
+		})
+	}
+	return ret, nil
+}
+
+// Voter represents an account that voted for a masternode candidate.
+type Voter struct {
+	address  common.Address
+	capacity *big.Int
+}
+
+func (v *Voter) Address(ctx context.Context) common.Address {
+	return v.address
+}
+
+func (v *Voter) Capacity(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*v.capacity)
+}
+
+// Candidate represents a masternode candidate of an epoch. The owner and the
+// voters of it are read from the state at the given block.
+type Candidate struct {
+	backend     ethapi.Backend
+	address     common.Address
+	capacity    *big.Int
+	status      string
+	blockNumber rpc.BlockNumber
+}
+
+func (c *Candidate) Address(ctx context.Context) common.Address {
+	return c.address
+}
+
+func (c *Candidate) Capacity(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*c.capacity)
+}
+
+func (c *Candidate) Status(ctx context.Context) string {
+	return c.status
+}
+
+func (c *Candidate) Owner(ctx context.Context) (common.Address, error) {
+	statedb, _, err := c.backend.StateAndHeaderByNumber(ctx, c.blockNumber)
+	if statedb == nil || err != nil {
+		return common.Address{}, err
+	}
+	return state.GetCandidateOwner(statedb, c.address), nil
+}
+
+func (c *Candidate) Voters(ctx context.Context) ([]*Voter, error) {
+	statedb, _, err := c.backend.StateAndHeaderByNumber(ctx, c.blockNumber)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	voters := state.GetVoters(statedb, c.address)
+	ret := make([]*Voter, 0, len(voters))
+	for _, voter := range voters {
+		ret = append(ret, &Voter{
+			address:  voter,
+			capacity: state.GetVoterCap(statedb, c.address, voter),
+		})
+	}
+	return ret, nil
+}
+
+// Epoch represents an epoch of the masternode consensus.
+type Epoch struct {
+	backend    ethapi.Backend
+	requested  rpc.EpochNumber // epoch number as requested, possibly the latest one
+	number     rpc.EpochNumber
+	checkpoint rpc.BlockNumber
+}
+
+func (e *Epoch) Number(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(e.number)
+}
+
+func (e *Epoch) Checkpoint(ctx context.Context) (*Block, error) {
+	block, err := e.backend.BlockByNumber(ctx, e.checkpoint)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	return &Block{backend: e.backend, block: block}, nil
+}
+
+func (e *Epoch) Candidates(ctx context.Context) ([]*Candidate, error) {
+	result, err := ethapi.NewPublicBlockChainAPI(e.backend).GetCandidates(ctx, e.requested)
+	if err != nil {
+		return nil, err
+	}
+	statuses, _ := result[fieldCandidates].(map[string]map[string]interface{})
+
+	// The candidates of the latest epoch are read from the current state
+	blockNumber := e.checkpoint
+	if e.requested == rpc.LatestEpochNumber {
+		blockNumber = rpc.LatestBlockNumber
+	}
+	ret := make([]*Candidate, 0, len(statuses))
+	for address, fields := range statuses {
+		capacity, _ := fields[fieldCapacity].(*big.Int)
+		if capacity == nil {
+			capacity = new(big.Int)
+		}
+		status, _ := fields[fieldStatus].(string)
+		ret = append(ret, &Candidate{
+			backend:     e.backend,
+			address:     common.HexToAddress(address),
+			capacity:    capacity,
+			status:      status,
+			blockNumber: blockNumber,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if cmp := ret[i].capacity.Cmp(ret[j].capacity); cmp != 0 {
+			return cmp > 0
+		}
+		return ret[i].address.Hex() < ret[j].address.Hex()
+	})
+	return ret, nil
+}
+
+// Order represents an order of a TomoX order book.
+type Order struct {
+	order tomox_state.OrderItem
+}
+
+// bigOrZero returns the given value as a GraphQL big integer, zero if nil.
+func bigOrZero(value *big.Int) hexutil.Big {
+	if value == nil {
+		return hexutil.Big{}
+	}
+	return hexutil.Big(*value)
+}
+
+func (o *Order) Id(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(o.order.OrderID)
+}
+
+func (o *Order) Hash(ctx context.Context) common.Hash {
+	return o.order.Hash
+}
+
+func (o *Order) TxHash(ctx context.Context) common.Hash {
+	return o.order.TxHash
+}
+
+func (o *Order) UserAddress(ctx context.Context) common.Address {
+	return o.order.UserAddress
+}
+
+func (o *Order) ExchangeAddress(ctx context.Context) common.Address {
+	return o.order.ExchangeAddress
+}
+
+func (o *Order) BaseToken(ctx context.Context) common.Address {
+	return o.order.BaseToken
+}
+
+func (o *Order) QuoteToken(ctx context.Context) common.Address {
+	return o.order.QuoteToken
+}
+
+func (o *Order) PairName(ctx context.Context) string {
+	return o.order.PairName
+}
+
+func (o *Order) Side(ctx context.Context) string {
+	return o.order.Side
+}
+
+func (o *Order) Type(ctx context.Context) string {
+	return o.order.Type
+}
+
+func (o *Order) Status(ctx context.Context) string {
+	return o.order.Status
+}
+
+func (o *Order) Price(ctx context.Context) hexutil.Big {
+	return bigOrZero(o.order.Price)
+}
+
+func (o *Order) Quantity(ctx context.Context) hexutil.Big {
+	return bigOrZero(o.order.Quantity)
+}
+
+func (o *Order) FilledAmount(ctx context.Context) hexutil.Big {
+	return bigOrZero(o.order.FilledAmount)
+}
+
+func (o *Order) Nonce(ctx context.Context) hexutil.Big {
+	return bigOrZero(o.order.Nonce)
+}
+
+// PriceVolume represents the best price of a side of an order book.
+type PriceVolume struct {
+	price  *big.Int
+	volume *big.Int
+}
+
+func (p *PriceVolume) Price(ctx context.Context) hexutil.Big {
+	return bigOrZero(p.price)
+}
+
+func (p *PriceVolume) Volume(ctx context.Context) hexutil.Big {
+	return bigOrZero(p.volume)
+}
+
+// PriceLevel represents the orders at a price of a side of an order book.
+type PriceLevel struct {
+	price  *big.Int
+	volume *big.Int
+	orders []*Order
+}
+
+func (p *PriceLevel) Price(ctx context.Context) hexutil.Big {
+	return bigOrZero(p.price)
+}
+
+func (p *PriceLevel) Volume(ctx context.Context) hexutil.Big {
+	return bigOrZero(p.volume)
+}
+
+func (p *PriceLevel) Orders(ctx context.Context) []*Order {
+	return p.orders
+}
+
+// OrderBook represents the order book of a TomoX trading pair at a specific
+// block. The state of it is not safe for concurrent use, so the accesses of the
+// fields resolved in parallel are serialized.
+type OrderBook struct {
+	baseToken  common.Address
+	quoteToken common.Address
+	orderBook  common.Hash
+
+	state *tomox_state.TomoXStateDB
+	lock  sync.Mutex
+}
+
+func (ob *OrderBook) BaseToken(ctx context.Context) common.Address {
+	return ob.baseToken
+}
+
+func (ob *OrderBook) QuoteToken(ctx context.Context) common.Address {
+	return ob.quoteToken
+}
+
+func (ob *OrderBook) BestBid(ctx context.Context) *PriceVolume {
+	ob.lock.Lock()
+	defer ob.lock.Unlock()
+
+	price, volume := ob.state.GetBestBidPrice(ob.orderBook)
+	if price == nil || price.Sign() == 0 {
+		return nil
+	}
+	return &PriceVolume{price: price, volume: volume}
+}
+
+func (ob *OrderBook) BestAsk(ctx context.Context) *PriceVolume {
+	ob.lock.Lock()
+	defer ob.lock.Unlock()
+
+	price, volume := ob.state.GetBestAskPrice(ob.orderBook)
+	if price == nil || price.Sign() == 0 {
+		return nil
+	}
+	return &PriceVolume{price: price, volume: volume}
+}
+
+func (ob *OrderBook) Bids(ctx context.Context) ([]*PriceLevel, error) {
+	ob.lock.Lock()
+	defer ob.lock.Unlock()
+
+	levels, err := ob.state.DumpBidTrie(ob.orderBook)
+	if err != nil {
+		return nil, err
+	}
+	ret := ob.priceLevels(levels)
+	sort.Slice(ret, func(i, j int) bool { return ret[i].price.Cmp(ret[j].price) > 0 })
+	return ret, nil
+}
+
+func (ob *OrderBook) Asks(ctx context.Context) ([]*PriceLevel, error) {
+	ob.lock.Lock()
+	defer ob.lock.Unlock()
+
+	levels, err := ob.state.DumpAskTrie(ob.orderBook)
+	if err != nil {
+		return nil, err
+	}
+	ret := ob.priceLevels(levels)
+	sort.Slice(ret, func(i, j int) bool { return ret[i].price.Cmp(ret[j].price) < 0 })
+	return ret, nil
+}
+
+// priceLevels assembles the price levels of a dumped side of the order book,
+// with the orders of each one by id.
+func (ob *OrderBook) priceLevels(levels map[*big.Int]tomox_state.DumpOrderList) []*PriceLevel {
+	ret := make([]*PriceLevel, 0, len(levels))
+	for price, level := range levels {
+		orders := make([]*Order, 0, len(level.Orders))
+		for id := range level.Orders {
+			orders = append(orders, &Order{order: ob.state.GetOrder(ob.orderBook, common.BigToHash(id))})
+		}
+		sort.Slice(orders, func(i, j int) bool { return orders[i].order.OrderID < orders[j].order.OrderID })
+		ret = append(ret, &PriceLevel{price: price, volume: level.Volume, orders: orders})
+	}
+	return ret
+}
+
+func (ob *OrderBook) Order(ctx context.Context, args struct{ Id hexutil.Uint64 }) *Order {
+	ob.lock.Lock()
+	defer ob.lock.Unlock()
+
+	order := ob.state.GetOrder(ob.orderBook, common.BigToHash(new(big.Int).SetUint64(uint64(args.Id))))
+	if order.Quantity == nil || order.Quantity.Sign() == 0 {
+		return nil
+	}
+	return &Order{order: order}
+}
+
+// Resolver is the top-level object in the GraphQL hierarchy.
+type Resolver struct {
+	backend ethapi.Backend
+}
+
+func (r *Resolver) Block(ctx context.Context, args struct {
+	Number *hexutil.Uint64
+	Hash   *common.Hash
+}) (*Block, error) {
+	var (
+		block *types.Block
+		err   error
+	)
+	switch {
+	case args.Number != nil && args.Hash != nil:
+		return nil, errors.New("only one of number or hash may be specified")
+	case args.Hash != nil:
+		block, err = r.backend.GetBlock(ctx, *args.Hash)
+	case args.Number != nil:
+		if uint64(*args.Number) > math.MaxInt64 {
+			return nil, errBlockNumber
+		}
+		block, err = r.backend.BlockByNumber(ctx, rpc.BlockNumber(*args.Number))
+	default:
+		block, err = r.backend.BlockByNumber(ctx, rpc.LatestBlockNumber)
+	}
+	if err != nil || block == nil {
+		return nil, err
+	}
+	return &Block{backend: r.backend, block: block}, nil
+}
+
+func (r *Resolver) Blocks(ctx context.Context, args struct {
+	From hexutil.Uint64
+	To   *hexutil.Uint64
+}) ([]*Block, error) {
+	// The range ends at the head at most
+	from := uint64(args.From)
+	to := r.backend.CurrentBlock().NumberU64()
+	if args.To != nil && uint64(*args.To) < to {
+		to = uint64(*args.To)
+	}
+	if to < from {
+		return []*Block{}, nil
+	}
+	if to-from >= maxBlocksRange {
+		return nil, fmt.Errorf("range of %d blocks above the limit of %d", to-from+1, maxBlocksRange)
+	}
+	ret := []*Block{}
+	for number := from; number <= to; number++ {
+		block, err := r.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			break
+		}
+		ret = append(ret, &Block{backend: r.backend, block: block})
+	}
+	return ret, nil
+}
+
+func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) (*Transaction, error) {
+	tx := &Transaction{backend: r.backend, hash: args.Hash}
+	if _, err := tx.resolve(ctx); err != nil || tx.tx == nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (r *Resolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria }) ([]*Log, error) {
+	// Convert the RPC block numbers into internal representations
+	begin := rpc.LatestBlockNumber.Int64()
+	if args.Filter.FromBlock != nil {
+		begin = int64(*args.Filter.FromBlock)
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if args.Filter.ToBlock != nil {
+		end = int64(*args.Filter.ToBlock)
+	}
+	var addresses []common.Address
+	if args.Filter.Addresses != nil {
+		addresses = *args.Filter.Addresses
+	}
+	var topics [][]common.Hash
+	if args.Filter.Topics != nil {
+		topics = *args.Filter.Topics
+	}
+	return runFilter(ctx, r.backend, begin, end, addresses, topics)
+}
+
+func (r *Resolver) GasPrice(ctx context.Context) (hexutil.Big, error) {
+	price, err := r.backend.SuggestPrice(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*price), nil
+}
+
+func (r *Resolver) ProtocolVersion(ctx context.Context) int32 {
+	return int32(r.backend.ProtocolVersion())
+}
+
+func (r *Resolver) Epoch(ctx context.Context, args struct{ Number *hexutil.Uint64 }) *Epoch {
+	requested := rpc.LatestEpochNumber
+	if args.Number != nil {
+		requested = rpc.EpochNumber(*args.Number)
+	}
+	checkpoint, number := ethapi.NewPublicBlockChainAPI(r.backend).GetPreviousCheckpointFromEpoch(ctx, requested)
+	return &Epoch{
+		backend:    r.backend,
+		requested:  requested,
+		number:     number,
+		checkpoint: checkpoint,
+	}
+}
+
+func (r *Resolver) OrderBook(ctx context.Context, args struct {
+	BaseToken  common.Address
+	QuoteToken common.Address
+	Block      *hexutil.Uint64
+}) (*OrderBook, error) {
+	tomoxService := r.backend.TomoxService()
+	if tomoxService == nil {
+		return nil, errTomoXNotFound
+	}
+	block := r.backend.CurrentBlock()
+	if args.Block != nil {
+		if uint64(*args.Block) > math.MaxInt64 {
+			return nil, errBlockNumber
+		}
+		var err error
+		if block, err = r.backend.BlockByNumber(ctx, rpc.BlockNumber(*args.Block)); err != nil {
+			return nil, err
+		}
+	}
+	if block == nil {
+		return nil, nil
+	}
+	tomoxState, err := tomoxService.GetTomoxState(block)
+	if err != nil {
+		return nil, err
+	}
+	orderBook := tomox_state.GetOrderBookHash(args.BaseToken, args.QuoteToken)
+	if !tomoxState.Exist(orderBook) {
+		return nil, nil
+	}
+	return &OrderBook{
+		baseToken:  args.BaseToken,
+		quoteToken: args.QuoteToken,
+		orderBook:  orderBook,
+		state:      tomoxState,
+	}, nil
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/common/hexutil"
+	"github.com/tomochain/tomochain/consensus/ethash"
+	"github.com/tomochain/tomochain/core"
+	"github.com/tomochain/tomochain/core/state"
+	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/core/vm"
+	"github.com/tomochain/tomochain/crypto"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/internal/ethapi"
+	"github.com/tomochain/tomochain/params"
+	"github.com/tomochain/tomochain/rpc"
+)
+
+// testBackend answers the queries of the chain data from a local chain, the
+// methods not needed by the tests are left unimplemented.
+type testBackend struct {
+	ethapi.Backend
+	db    ethdb.Database
+	chain *core.BlockChain
+}
+
+func (b *testBackend) ChainDb() ethdb.Database { return b.db }
+
+func (b *testBackend) CurrentBlock() *types.Block { return b.chain.CurrentBlock() }
+
+func (b *testBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Block, error) {
+	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
+		return b.chain.CurrentBlock(), nil
+	}
+	return b.chain.GetBlockByNumber(uint64(blockNr)), nil
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return b.chain.GetBlockByHash(hash), nil
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	block, _ := b.BlockByNumber(ctx, blockNr)
+	if block == nil {
+		return nil, nil, nil
+	}
+	statedb, err := b.chain.StateAt(block.Root())
+	return statedb, block.Header(), err
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return core.GetBlockReceipts(b.db, hash, core.GetBlockNumber(b.db, hash)), nil
+}
+
+func (b *testBackend) GetTd(hash common.Hash) *big.Int { return b.chain.GetTdByHash(hash) }
+
+func (b *testBackend) GetPoolTransaction(hash common.Hash) *types.Transaction { return nil }
+
+// Tests that the schema is backed by resolvers for all of its fields.
+func TestBuildSchema(t *testing.T) {
+	if _, err := New(nil); err != nil {
+		t.Fatalf("failed to build the schema: %v", err)
+	}
+}
+
+// Tests that the blocks, transactions and accounts of the chain are queried
+// through the GraphQL endpoint.
+func TestQueryBlocks(t *testing.T) {
+	var (
+		db, _     = ethdb.NewMemDatabase()
+		key, _    = crypto.GenerateKey()
+		addr      = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.Address{0x0b}
+	)
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{addr: {Balance: big.NewInt(1000000000000000)}},
+	}
+	genesis := gspec.MustCommit(db)
+	signer := types.HomesteadSigner{}
+
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(addr), recipient, big.NewInt(1000), 21000, big.NewInt(1), nil), signer, key)
+		block.AddTx(tx)
+	})
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	service, err := New(&testBackend{db: db, chain: chain})
+	if err != nil {
+		t.Fatalf("failed to create the service: %v", err)
+	}
+	server := httptest.NewServer(service.HTTPHandlers()[Path])
+	defer server.Close()
+
+	query := func(query string, result interface{}) {
+		body, _ := json.Marshal(map[string]string{"query": query})
+		resp, err := http.Post(server.URL, "application/json", strings.NewReader(string(body)))
+		if err != nil {
+			t.Fatalf("failed to post query: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var res struct {
+			Data   json.RawMessage
+			Errors []interface{}
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(res.Errors) > 0 {
+			t.Fatalf("query failed: %v", res.Errors)
+		}
+		if err := json.Unmarshal(res.Data, result); err != nil {
+			t.Fatalf("failed to decode data: %v", err)
+		}
+	}
+	var blockRes struct {
+		Block struct {
+			Number       string
+			Hash         common.Hash
+			Parent       struct{ Hash common.Hash }
+			Transactions []struct {
+				Hash   common.Hash
+				From   struct{ Address common.Address }
+				To     struct{ Balance string }
+				Status string
+			}
+		}
+	}
+	query(`{ block(number: 1) { number hash parent { hash } transactions { hash from { address } to { balance } status } } }`, &blockRes)
+
+	block := blockRes.Block
+	if block.Number != "0x1" || block.Hash != blocks[0].Hash() || block.Parent.Hash != genesis.Hash() {
+		t.Fatalf("block mismatch: %+v", block)
+	}
+	if len(block.Transactions) != 1 {
+		t.Fatalf("transaction count mismatch: have %d, want 1", len(block.Transactions))
+	}
+	tx := block.Transactions[0]
+	if tx.Hash != blocks[0].Transactions()[0].Hash() || tx.From.Address != addr || tx.Status != "0x1" {
+		t.Errorf("transaction mismatch: %+v", tx)
+	}
+	// The balance of the recipient is read at the block of the transaction
+	if tx.To.Balance != "0x3e8" {
+		t.Errorf("recipient balance mismatch: have %s, want 0x3e8", tx.To.Balance)
+	}
+	var txRes struct {
+		Transaction struct {
+			Block   struct{ Number string }
+			Index   int
+			GasUsed string
+		}
+	}
+	query(`{ transaction(hash: "`+blocks[1].Transactions()[0].Hash().Hex()+`") { block { number } index gasUsed } }`, &txRes)
+	if txRes.Transaction.Block.Number != "0x2" || txRes.Transaction.Index != 0 || txRes.Transaction.GasUsed != "0x5208" {
+		t.Errorf("transaction mismatch: %+v", txRes.Transaction)
+	}
+}
+
+// Tests that the block ranges are clamped to the head and that block numbers
+// out of range are rejected.
+func TestResolveBlockRanges(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	gspec := &core.Genesis{Config: params.TestChainConfig}
+	genesis := gspec.MustCommit(db)
+
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, nil)
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	resolver := &Resolver{backend: &testBackend{db: db, chain: chain}}
+
+	to := hexutil.Uint64(math.MaxUint64)
+	res, err := resolver.Blocks(context.Background(), struct {
+		From hexutil.Uint64
+		To   *hexutil.Uint64
+	}{From: 1, To: &to})
+	if err != nil {
+		t.Fatalf("failed to resolve the blocks: %v", err)
+	}
+	if len(res) != 2 || res[0].block.Hash() != blocks[0].Hash() || res[1].block.Hash() != blocks[1].Hash() {
+		t.Errorf("block range not clamped to the head: have %d blocks", len(res))
+	}
+	if _, err := resolver.Block(context.Background(), struct {
+		Number *hexutil.Uint64
+		Hash   *common.Hash
+	}{Number: &to}); err != errBlockNumber {
+		t.Errorf("block number error mismatch: have %v, want %v", err, errBlockNumber)
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+const schema string = `
+    # Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.
+    scalar Bytes32
+    # Address is a 20 byte account address, represented as 0x-prefixed hexadecimal.
+    scalar Address
+    # Bytes is an arbitrary length binary string, represented as 0x-prefixed hexadecimal.
+    # An empty byte string is represented as '0x'.
+    scalar Bytes
+    # BigInt is a large integer. Input is accepted as either a JSON number or as a string.
+    # Strings may be either decimal or 0x-prefixed hexadecimal. Output values are all
+    # 0x-prefixed hexadecimal.
+    scalar BigInt
+    # Long is a 64 bit unsigned integer, accepted as a JSON number or as a 0x-prefixed
+    # hexadecimal string and output as 0x-prefixed hexadecimal.
+    scalar Long
+
+    schema {
+        query: Query
+    }
+
+    # Account is an account at a particular block.
+    type Account {
+        # Address is the address owning the account.
+        address: Address!
+        # Balance is the balance of the account, in wei.
+        balance: BigInt!
+        # TransactionCount is the number of transactions sent from this account,
+        # or in the case of a contract, the number of contracts created.
+        transactionCount: Long!
+        # Code contains the smart contract code for this account, if the account
+        # is a (non-self-destructed) contract.
+        code: Bytes!
+        # Storage provides access to the storage of a contract account, indexed
+        # by its 32 byte slot identifier.
+        storage(slot: Bytes32!): Bytes32!
+    }
+
+    # Log is an event emitted by a smart contract during the execution of a
+    # transaction.
+    type Log {
+        # Index is the index of this log in the block.
+        index: Int!
+        # Account is the account which generated this log, at the block of it.
+        account: Account!
+        # Topics is a list of 0-4 indexed topics for the log.
+        topics: [Bytes32!]!
+        # Data is unindexed data for this log.
+        data: Bytes!
+        # Transaction is the transaction that generated this log entry.
+        transaction: Transaction!
+    }
+
+    # Transaction is a value transfer or contract call.
+    type Transaction {
+        # Hash is the hash of this transaction.
+        hash: Bytes32!
+        # Nonce is the nonce of the account this transaction was generated with.
+        nonce: Long!
+        # Index is the index of this transaction in the parent block. This will
+        # be null if the transaction has not yet been mined.
+        index: Int
+        # From is the account that sent this transaction, at the block of it or
+        # at the latest block if pending.
+        from: Account!
+        # To is the account the transaction was sent to. This is null for
+        # contract-creating transactions.
+        to: Account
+        # Value is the value, in wei, sent along with this transaction.
+        value: BigInt!
+        # GasPrice is the price offered to miners for gas, in wei per unit.
+        gasPrice: BigInt!
+        # Gas is the maximum amount of gas this transaction can consume.
+        gas: Long!
+        # InputData is the data supplied to the target of the transaction.
+        inputData: Bytes!
+        # Block is the block this transaction was mined in. This will be null if
+        # the transaction has not yet been mined.
+        block: Block
+
+        # Status is the return status of the transaction. This will be 1 if the
+        # transaction succeeded, or 0 if it failed. If the transaction has not
+        # yet been mined, this field will be null.
+        status: Long
+        # GasUsed is the amount of gas that was used processing this transaction.
+        # If the transaction has not yet been mined, this field will be null.
+        gasUsed: Long
+        # CumulativeGasUsed is the total gas used in the block up to and including
+        # this transaction. If the transaction has not yet been mined, this field
+        # will be null.
+        cumulativeGasUsed: Long
+        # CreatedContract is the account that was created by a contract creation
+        # transaction. If the transaction was not a contract creation transaction,
+        # or it has not yet been mined, this field will be null.
+        createdContract: Account
+        # Logs is a list of log entries emitted by this transaction. If the
+        # transaction has not yet been mined, this field will be null.
+        logs: [Log!]
+    }
+
+    # BlockFilterCriteria encapsulates log filter criteria for a filter applied
+    # to a single block.
+    input BlockFilterCriteria {
+        # Addresses is a list of addresses that are of interest. If this list is
+        # empty, results will not be filtered by address.
+        addresses: [Address!]
+        # Topics list restricts matches to particular event topics. Each event
+        # has a list of topics. Topics matches a prefix of that list. An empty
+        # element array matches any topic. Non-empty elements represent an
+        # alternative that matches any of the contained topics.
+        topics: [[Bytes32!]!]
+    }
+
+    # Block is a block of the chain.
+    type Block {
+        # Number is the number of this block, starting at 0 for the genesis block.
+        number: Long!
+        # Hash is the block hash of this block.
+        hash: Bytes32!
+        # Parent is the parent block of this block.
+        parent: Block
+        # Nonce is the block nonce, an 8 byte sequence.
+        nonce: Bytes!
+        # TransactionsRoot is the keccak256 hash of the root of the trie of
+        # transactions in this block.
+        transactionsRoot: Bytes32!
+        # TransactionCount is the number of transactions in this block.
+        transactionCount: Int!
+        # StateRoot is the keccak256 hash of the state trie after this block was
+        # processed.
+        stateRoot: Bytes32!
+        # ReceiptsRoot is the keccak256 hash of the trie of transaction receipts
+        # in this block.
+        receiptsRoot: Bytes32!
+        # Miner is the account that mined this block, at the block of it.
+        miner: Account!
+        # ExtraData is an arbitrary data field supplied by the miner.
+        extraData: Bytes!
+        # GasLimit is the maximum amount of gas that was available to
+        # transactions in this block.
+        gasLimit: Long!
+        # GasUsed is the amount of gas that was used executing transactions in
+        # this block.
+        gasUsed: Long!
+        # Timestamp is the unix timestamp at which this block was mined.
+        timestamp: BigInt!
+        # LogsBloom is a bloom filter that can be used to check if a block may
+        # contain log entries matching a filter.
+        logsBloom: Bytes!
+        # MixHash is the hash that was used as an input to the PoW process.
+        mixHash: Bytes32!
+        # Difficulty is a measure of the difficulty of mining this block.
+        difficulty: BigInt!
+        # TotalDifficulty is the sum of all difficulty values up to and including
+        # this block.
+        totalDifficulty: BigInt!
+        # Transactions is a list of transactions associated with this block.
+        transactions: [Transaction!]!
+        # TransactionAt returns the transaction at the specified index. If the
+        # transaction is out of bounds, null is returned.
+        transactionAt(index: Int!): Transaction
+        # Logs returns a filtered set of logs from this block.
+        logs(filter: BlockFilterCriteria!): [Log!]!
+        # Account fetches an account at the block of it.
+        account(address: Address!): Account!
+        # Signers are the masternodes that signed this block.
+        signers: [Address!]!
+        # Finality is the percentage of the masternodes that signed this block.
+        finality: Int!
+        # Masternodes are the masternodes of the epoch of this block.
+        masternodes: [Address!]!
+        # Penalties are the masternodes penalized at this block, if a checkpoint.
+        penalties: [Address!]!
+    }
+
+    # FilterCriteria encapsulates log filter criteria for searching log entries.
+    input FilterCriteria {
+        # FromBlock is the block at which to start searching, inclusive. Defaults
+        # to the latest block if not supplied.
+        fromBlock: Long
+        # ToBlock is the block at which to stop searching, inclusive. Defaults
+        # to the latest block if not supplied.
+        toBlock: Long
+        # Addresses is a list of addresses that are of interest. If this list is
+        # empty, results will not be filtered by address.
+        addresses: [Address!]
+        # Topics list restricts matches to particular event topics. Each event
+        # has a list of topics. Topics matches a prefix of that list. An empty
+        # element array matches any topic. Non-empty elements represent an
+        # alternative that matches any of the contained topics.
+        topics: [[Bytes32!]!]
+    }
+
+    # Voter is an account that voted for a masternode candidate.
+    type Voter {
+        # Address is the address of the voter.
+        address: Address!
+        # Capacity is the amount the voter staked on the candidate, in wei.
+        capacity: BigInt!
+    }
+
+    # Candidate is a masternode candidate of an epoch.
+    type Candidate {
+        # Address is the coinbase address of the candidate.
+        address: Address!
+        # Owner is the account that proposed the candidate.
+        owner: Address!
+        # Capacity is the total amount staked on the candidate, in wei.
+        capacity: BigInt!
+        # Status is one of MASTERNODE, SLASHED and PROPOSED.
+        status: String!
+        # Voters are the accounts that voted for the candidate.
+        voters: [Voter!]!
+    }
+
+    # Epoch is an epoch of the masternode consensus.
+    type Epoch {
+        # Number is the number of this epoch.
+        number: Long!
+        # Checkpoint is the checkpoint block the candidates of this epoch are
+        # taken at.
+        checkpoint: Block
+        # Candidates are the masternode candidates of this epoch, by capacity.
+        candidates: [Candidate!]!
+    }
+
+    # Order is an order of a TomoX order book.
+    type Order {
+        id: Long!
+        hash: Bytes32!
+        txHash: Bytes32!
+        userAddress: Address!
+        exchangeAddress: Address!
+        baseToken: Address!
+        quoteToken: Address!
+        pairName: String!
+        # Side is either BUY or SELL.
+        side: String!
+        type: String!
+        status: String!
+        price: BigInt!
+        quantity: BigInt!
+        filledAmount: BigInt!
+        nonce: BigInt!
+    }
+
+    # PriceVolume is the best price of a side of an order book with the volume
+    # at it.
+    type PriceVolume {
+        price: BigInt!
+        volume: BigInt!
+    }
+
+    # PriceLevel is the orders at a price of a side of an order book.
+    type PriceLevel {
+        price: BigInt!
+        volume: BigInt!
+        orders: [Order!]!
+    }
+
+    # OrderBook is the order book of a TomoX trading pair at a particular block.
+    type OrderBook {
+        baseToken: Address!
+        quoteToken: Address!
+        # BestBid is the highest bid of the order book, null if there is none.
+        bestBid: PriceVolume
+        # BestAsk is the lowest ask of the order book, null if there is none.
+        bestAsk: PriceVolume
+        # Bids are the price levels of the bids, best first.
+        bids: [PriceLevel!]!
+        # Asks are the price levels of the asks, best first.
+        asks: [PriceLevel!]!
+        # Order returns the order with the given id, null if there is none.
+        order(id: Long!): Order
+    }
+
+    type Query {
+        # Block fetches a block by number or by hash. If neither is supplied,
+        # the most recent known block is returned.
+        block(number: Long, hash: Bytes32): Block
+        # Blocks returns all the blocks between two numbers, inclusive. If to is
+        # not supplied, it defaults to the most recent known block.
+        blocks(from: Long!, to: Long): [Block!]!
+        # Transaction returns a transaction specified by its hash.
+        transaction(hash: Bytes32!): Transaction
+        # Logs returns log entries matching the provided filter.
+        logs(filter: FilterCriteria!): [Log!]!
+        # GasPrice returns the node's estimate of a gas price sufficient to
+        # ensure a transaction is mined in a timely fashion.
+        gasPrice: BigInt!
+        # ProtocolVersion returns the current wire protocol version number.
+        protocolVersion: Int!
+        # Epoch returns a masternode epoch by number. If the number is not
+        # supplied, the current epoch is returned.
+        epoch(number: Long): Epoch!
+        # OrderBook returns the TomoX order book of a trading pair at a block.
+        # If the block is not supplied, the most recent known block is used.
+        orderBook(baseToken: Address!, quoteToken: Address!, block: Long): OrderBook
+    }
+`
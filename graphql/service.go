@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"net/http"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/tomochain/tomochain/internal/ethapi"
+	"github.com/tomochain/tomochain/p2p"
+	"github.com/tomochain/tomochain/rpc"
+)
+
+// Path is the path the GraphQL endpoint is mounted at on the HTTP RPC server.
+const Path = "/graphql"
+
+// Service encapsulates a GraphQL endpoint, served next to the HTTP RPC of the
+// node.
+type Service struct {
+	handler http.Handler // handler of the GraphQL queries
+}
+
+// New constructs a new GraphQL service answering the queries from the given
+// backend.
+func New(backend ethapi.Backend) (*Service, error) {
+	s, err := graphqlgo.ParseSchema(schema, &Resolver{backend: backend})
+	if err != nil {
+		return nil, err
+	}
+	return &Service{handler: &relay.Handler{Schema: s}}, nil
+}
+
+// Protocols implements node.Service, returning the P2P network protocols used
+// by the GraphQL service (nil as it doesn't use the devp2p overlay network).
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning the RPC API endpoints provided by the
+// GraphQL service (nil as it provides none of its own).
+func (s *Service) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, the queries are served by the HTTP endpoint
+// of the node, so there is nothing to start.
+func (s *Service) Start(server *p2p.Server) error { return nil }
+
+// Stop implements node.Service, there is nothing to stop.
+func (s *Service) Stop() error { return nil }
+
+// HTTPHandlers implements node.HTTPService, returning the GraphQL handler to
+// mount next to the HTTP RPC.
+func (s *Service) HTTPHandlers() map[string]http.Handler {
+	return map[string]http.Handler{Path: s.handler}
+}
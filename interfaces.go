@@ -103,6 +103,9 @@ type SyncProgress struct {
 	HighestBlock  uint64 // Highest alleged block number in the chain
 	PulledStates  uint64 // Number of state trie entries already downloaded
 	KnownStates   uint64 // Total number of state trie entries known about
+
+	PulledTomoXStates uint64 // Number of TomoX state trie entries already downloaded
+	KnownTomoXStates  uint64 // Total number of TomoX state trie entries known about
 }
 
 // ChainSyncReader wraps access to the node's current sync status. If there's no
@@ -19,16 +19,16 @@ package ethapi
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/tomochain/tomochain/tomox/tomox_state"
 	"math/big"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/tomochain/tomochain/accounts"
 	"github.com/tomochain/tomochain/accounts/abi/bind"
 	"github.com/tomochain/tomochain/accounts/keystore"
@@ -43,15 +43,18 @@ import (
 	"github.com/tomochain/tomochain/core/types"
 	"github.com/tomochain/tomochain/core/vm"
 	"github.com/tomochain/tomochain/crypto"
+	"github.com/tomochain/tomochain/ethdb"
 	"github.com/tomochain/tomochain/log"
 	"github.com/tomochain/tomochain/p2p"
 	"github.com/tomochain/tomochain/params"
 	"github.com/tomochain/tomochain/rlp"
 	"github.com/tomochain/tomochain/rpc"
+	"github.com/tomochain/tomochain/trie"
 )
 
 const (
 	defaultGasPrice = 50 * params.Shannon
+	maxBundleCalls  = 100 // Maximum number of calls of a bundle
 	// statuses of candidates
 	statusMasternode = "MASTERNODE"
 	statusSlashed    = "SLASHED"
@@ -93,6 +96,8 @@ func (s *PublicEthereumAPI) ProtocolVersion() hexutil.Uint {
 // - highestBlock:  block number of the highest block header this node has received from peers
 // - pulledStates:  number of state entries processed until now
 // - knownStates:   number of known state entries that still need to be pulled
+// - pulledTomoXStates: number of TomoX state entries processed until now
+// - knownTomoXStates:  number of known TomoX state entries that still need to be pulled
 func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 	progress := s.b.Downloader().Progress()
 
@@ -107,6 +112,9 @@ func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 		"highestBlock":  hexutil.Uint64(progress.HighestBlock),
 		"pulledStates":  hexutil.Uint64(progress.PulledStates),
 		"knownStates":   hexutil.Uint64(progress.KnownStates),
+
+		"pulledTomoXStates": hexutil.Uint64(progress.PulledTomoXStates),
+		"knownTomoXStates":  hexutil.Uint64(progress.KnownTomoXStates),
 	}, nil
 }
 
@@ -626,6 +634,80 @@ func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.A
 	return res[:], state.Error()
 }
 
+// AccountResult is the result of a GetProof call, the EIP-1186 proof of an
+// account and of its storage slots.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []string        `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the proof of a storage slot of an account.
+type StorageResult struct {
+	Key   string       `json:"key"`
+	Value *hexutil.Big `json:"value"`
+	Proof []string     `json:"proof"`
+}
+
+// GetProof returns the merkle proof of the account at the given address and of
+// the given storage slots of it, for the given block number.
+func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*AccountResult, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	var (
+		storageTrie  = state.StorageTrie(address)
+		storageHash  = types.EmptyRootHash
+		codeHash     = state.GetCodeHash(address)
+		storageProof = make([]StorageResult, len(storageKeys))
+	)
+	// A missing account has neither code nor storage
+	if storageTrie != nil {
+		storageHash = storageTrie.Hash()
+	} else {
+		codeHash = crypto.Keccak256Hash(nil)
+	}
+	for i, key := range storageKeys {
+		if storageTrie == nil {
+			storageProof[i] = StorageResult{Key: key, Value: &hexutil.Big{}, Proof: []string{}}
+			continue
+		}
+		var proof trie.ProofList
+		if err := storageTrie.Prove(crypto.Keccak256(common.HexToHash(key).Bytes()), 0, &proof); err != nil {
+			return nil, err
+		}
+		value := state.GetState(address, common.HexToHash(key))
+		storageProof[i] = StorageResult{Key: key, Value: (*hexutil.Big)(value.Big()), Proof: toHexSlice(proof)}
+	}
+	accountProof, err := state.GetProof(address)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountResult{
+		Address:      address,
+		AccountProof: toHexSlice(accountProof),
+		Balance:      (*hexutil.Big)(state.GetBalance(address)),
+		CodeHash:     codeHash,
+		Nonce:        hexutil.Uint64(state.GetNonce(address)),
+		StorageHash:  storageHash,
+		StorageProof: storageProof,
+	}, state.Error()
+}
+
+// toHexSlice encodes the nodes of a merkle proof into hex strings.
+func toHexSlice(b [][]byte) []string {
+	r := make([]string, len(b))
+	for i := range b {
+		r[i] = hexutil.Encode(b[i])
+	}
+	return r
+}
+
 func (s *PublicBlockChainAPI) GetBlockSignersByHash(ctx context.Context, blockHash common.Hash) ([]common.Address, error) {
 	block, err := s.b.GetBlock(ctx, blockHash)
 	if err != nil || block == nil {
@@ -1023,7 +1105,68 @@ type CallArgs struct {
 	Data     hexutil.Bytes   `json:"data"`
 }
 
-func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
+// OverrideAccount holds the fields of an account to override during the
+// execution of a call. The storage slots in StateDiff are set one by one, the
+// others are left untouched.
+type OverrideAccount struct {
+	Nonce     *hexutil.Uint64              `json:"nonce"`
+	Code      *hexutil.Bytes               `json:"code"`
+	Balance   *hexutil.Big                 `json:"balance"`
+	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
+}
+
+// StateOverride is the collection of the accounts overridden during the
+// execution of a call.
+type StateOverride map[common.Address]OverrideAccount
+
+// Apply overrides the fields of the accounts in the given state.
+func (diff *StateOverride) Apply(statedb *state.StateDB) {
+	if diff == nil {
+		return
+	}
+	for addr, account := range *diff {
+		if account.Nonce != nil {
+			statedb.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Code != nil {
+			statedb.SetCode(addr, *account.Code)
+		}
+		if account.Balance != nil {
+			statedb.SetBalance(addr, (*big.Int)(account.Balance))
+		}
+		if account.StateDiff != nil {
+			for key, value := range *account.StateDiff {
+				statedb.SetState(addr, key, value)
+			}
+		}
+	}
+}
+
+// BlockOverrides holds the fields of the block to override during the
+// execution of a call.
+type BlockOverrides struct {
+	Number   *hexutil.Big    `json:"number"`
+	Time     *hexutil.Big    `json:"timestamp"`
+	Coinbase *common.Address `json:"coinbase"`
+}
+
+// Apply overrides the block fields of the given EVM context.
+func (diff *BlockOverrides) Apply(vmctx *vm.Context) {
+	if diff == nil {
+		return
+	}
+	if diff.Number != nil {
+		vmctx.BlockNumber = diff.Number.ToInt()
+	}
+	if diff.Time != nil {
+		vmctx.Time = diff.Time.ToInt()
+	}
+	if diff.Coinbase != nil {
+		vmctx.Coinbase = *diff.Coinbase
+	}
+}
+
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
 	statedb, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
@@ -1064,11 +1207,16 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	// this makes sure resources are cleaned up.
 	defer cancel()
 
-	// Get a new instance of the EVM.
-	evm, vmError, err := s.b.GetEVM(ctx, msg, statedb, header, vmCfg)
+	// Get a new instance of the EVM, the rules of the overridden block number
+	// apply to the call.
+	evm, vmError, err := s.b.GetEVM(ctx, msg, statedb, header, vmCfg, blockOverrides)
 	if err != nil {
 		return nil, 0, false, err
 	}
+	// Override the state once the EVM is set up, so that the balance of the
+	// sender can be overridden too
+	overrides.Apply(statedb)
+
 	// Wait for the context to be done and cancel the evm. Even if the
 	// EVM has finished, cancelling may be done (repeatedly)
 	go func() {
@@ -1087,10 +1235,11 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	return res, gas, failed, err
 }
 
-// Call executes the given transaction on the state for the given block number.
+// Call executes the given transaction on the state for the given block number,
+// with the accounts and the block fields overridden if given.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
-func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := s.doCall(ctx, args, blockNr, vm.Config{}, 5*time.Second)
+func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides) (hexutil.Bytes, error) {
+	result, _, _, err := s.doCall(ctx, args, blockNr, overrides, blockOverrides, vm.Config{}, 5*time.Second)
 	return (hexutil.Bytes)(result), err
 }
 
@@ -1119,7 +1268,7 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	executable := func(gas uint64) bool {
 		args.Gas = hexutil.Uint64(gas)
 
-		_, _, failed, err := s.doCall(ctx, args, rpc.LatestBlockNumber, vm.Config{}, 0)
+		_, _, failed, err := s.doCall(ctx, args, rpc.LatestBlockNumber, nil, nil, vm.Config{}, 0)
 		if err != nil || failed {
 			return false
 		}
@@ -1143,6 +1292,193 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	return hexutil.Uint64(hi), nil
 }
 
+// BundleCall is a call of a bundle, either a signed transaction or the fields of
+// an unsigned call.
+type BundleCall struct {
+	CallArgs
+	Raw hexutil.Bytes `json:"raw"` // Signed transaction, the call fields are ignored if set
+}
+
+// BundleCallResult is the outcome of a call of a bundle.
+type BundleCallResult struct {
+	TxHash           common.Hash     `json:"txHash"`
+	From             common.Address  `json:"from"`
+	To               *common.Address `json:"to"`
+	GasUsed          hexutil.Uint64  `json:"gasUsed"`
+	Failed           bool            `json:"failed"`
+	ReturnData       hexutil.Bytes   `json:"returnData"`
+	Logs             []*types.Log    `json:"logs"`
+	TRC21Fee         *hexutil.Big    `json:"trc21Fee,omitempty"`         // Fee paid from the capacity of the TRC21 token called
+	TRC21FeeCapacity *hexutil.Big    `json:"trc21FeeCapacity,omitempty"` // Fee capacity of the token left after the call
+	StateDiff        json.RawMessage `json:"stateDiff,omitempty"`        // Accounts changed by the call, before and after it
+	Error            string          `json:"error,omitempty"`
+}
+
+// StateDiffTracer is a tracer reporting the accounts changed by a transaction,
+// before and after it.
+type StateDiffTracer interface {
+	vm.Tracer
+
+	// CaptureTxStart is called before the transaction buys its gas.
+	CaptureTxStart(env *vm.EVM, from common.Address, to *common.Address)
+	// CaptureTxEnd is called once the transaction is applied and refunded.
+	CaptureTxEnd()
+	// GetResult returns the accounts changed, or the error which stopped the
+	// tracing.
+	GetResult() (json.RawMessage, error)
+}
+
+// CallBundle executes the given calls one after the other on the state for the
+// given block number, with the accounts and the block fields overridden if
+// given, each call seeing the changes made by the ones before. Unlike Call, the
+// senders pay for the gas at the given price, unsigned calls are free without.
+// It doesn't make any changes in the state/blockchain.
+func (s *PublicBlockChainAPI) CallBundle(ctx context.Context, calls []BundleCall, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides) ([]*BundleCallResult, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM call bundle finished", "runtime", time.Since(start)) }(time.Now())
+
+	if len(calls) > maxBundleCalls {
+		return nil, fmt.Errorf("too many calls: have %d, maximum %d", len(calls), maxBundleCalls)
+	}
+	statedb, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	overrides.Apply(statedb)
+
+	// Setup context so it may be cancelled once the bundle has completed
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Wait for the context to be done and cancel the evm of the running call,
+	// the calls left not being run
+	var (
+		evmLock sync.Mutex
+		evm     *vm.EVM
+	)
+	go func() {
+		<-ctx.Done()
+		evmLock.Lock()
+		defer evmLock.Unlock()
+		if evm != nil {
+			evm.Cancel()
+		}
+	}()
+
+	// The calls run in the overridden block, if any
+	number := header.Number
+	if blockOverrides != nil && blockOverrides.Number != nil {
+		number = blockOverrides.Number.ToInt()
+	}
+	var (
+		config      = s.b.ChainConfig()
+		signer      = types.MakeSigner(config, number)
+		feeCapacity = state.GetTRC21FeeCapacityFromState(statedb)
+		gp          = new(core.GasPool).AddGas(header.GasLimit)
+		results     = make([]*BundleCallResult, 0, len(calls))
+	)
+	for i, call := range calls {
+		// Assemble the message of the call, paying the fee with the TRC21 token
+		// called if it has capacity left
+		var (
+			msg    types.Message
+			txHash common.Hash
+		)
+		if len(call.Raw) > 0 {
+			tx := new(types.Transaction)
+			if err := rlp.DecodeBytes(call.Raw, tx); err != nil {
+				return nil, fmt.Errorf("call %d: %v", i, err)
+			}
+			var balanceFee *big.Int
+			if tx.To() != nil {
+				balanceFee = feeCapacity[*tx.To()]
+			}
+			if msg, err = tx.AsMessage(signer, balanceFee, number); err != nil {
+				return nil, fmt.Errorf("call %d: %v", i, err)
+			}
+			txHash = tx.Hash()
+		} else {
+			var balanceFee *big.Int
+			if call.To != nil {
+				balanceFee = feeCapacity[*call.To]
+			}
+			gas := uint64(call.Gas)
+			if gas == 0 {
+				gas = gp.Gas()
+			}
+			nonce := statedb.GetNonce(call.From)
+			msg = types.NewMessage(call.From, call.To, nonce, call.Value.ToInt(), gas, call.GasPrice.ToInt(), call.Data, false, balanceFee)
+			if call.To == nil {
+				txHash = types.NewContractCreation(nonce, msg.Value(), gas, msg.GasPrice(), msg.Data()).Hash()
+			} else {
+				txHash = types.NewTransaction(nonce, *call.To, msg.Value(), gas, msg.GasPrice(), msg.Data()).Hash()
+			}
+		}
+		result := &BundleCallResult{TxHash: txHash, From: msg.From(), To: msg.To()}
+		results = append(results, result)
+
+		// Trace the changes made by the call on the state
+		tracer, err := s.b.NewStateDiffTracer()
+		if err != nil {
+			return nil, err
+		}
+		// The EVM of the backend funds the sender for free calls, keep its balance
+		balance := new(big.Int).Set(statedb.GetBalance(msg.From()))
+		callEVM, vmError, err := s.b.GetEVM(ctx, msg, statedb, header, vm.Config{Debug: true, Tracer: tracer}, blockOverrides)
+		if err != nil {
+			return nil, err
+		}
+		statedb.SetBalance(msg.From(), balance)
+
+		evmLock.Lock()
+		if err := ctx.Err(); err != nil {
+			evmLock.Unlock()
+			return nil, err
+		}
+		evm = callEVM
+		evmLock.Unlock()
+
+		statedb.Prepare(txHash, header.Hash(), i)
+		tracer.CaptureTxStart(callEVM, msg.From(), msg.To())
+		res, gas, failed, err := core.ApplyMessage(callEVM, msg, gp, statedb.GetOwner(callEVM.Coinbase))
+		if err := vmError(); err != nil {
+			return nil, err
+		}
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		if msg.BalanceTokenFee() != nil {
+			if failed {
+				state.PayFeeWithTRC21TxFail(statedb, msg.From(), *msg.To())
+			}
+			fee := new(big.Int).SetUint64(gas)
+			if number.Cmp(common.TIPTRC21Fee) > 0 {
+				fee = fee.Mul(fee, common.TRC21GasPrice)
+			}
+			feeCapacity[*msg.To()] = new(big.Int).Sub(feeCapacity[*msg.To()], fee)
+			state.UpdateTRC21Fee(statedb, map[common.Address]*big.Int{*msg.To(): feeCapacity[*msg.To()]}, fee)
+
+			result.TRC21Fee, result.TRC21FeeCapacity = (*hexutil.Big)(fee), (*hexutil.Big)(feeCapacity[*msg.To()])
+		}
+		statedb.Finalise(config.IsEIP158(number))
+
+		tracer.CaptureTxEnd()
+		if result.StateDiff, err = tracer.GetResult(); err != nil {
+			return nil, err
+		}
+		result.GasUsed, result.Failed, result.ReturnData = hexutil.Uint64(gas), failed, res
+		result.Logs = statedb.GetLogs(txHash)
+		if result.Logs == nil {
+			result.Logs = []*types.Log{}
+		}
+	}
+	// A call cancelled halfway leaves its result incomplete
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 // ExecutionResult groups all structured logs emitted by the EVM
 // while replaying a transaction in debug mode as well as transaction
 // execution status, the amount of gas used and the return value
@@ -1232,6 +1568,31 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx
 		"validator":        hexutil.Bytes(head.Validator),
 		"penalties":        hexutil.Bytes(head.Penalties),
 	}
+	if len(head.Attestations) > 0 {
+		attestations := make([]map[string]interface{}, len(head.Attestations))
+		for i, attestation := range head.Attestations {
+			signer, _ := attestation.Signer()
+			attestations[i] = map[string]interface{}{
+				"number":    hexutil.Uint64(attestation.Number),
+				"blockHash": attestation.BlockHash,
+				"signature": hexutil.Bytes(attestation.Signature),
+				"signer":    signer,
+			}
+		}
+		fields["attestations"] = attestations
+	}
+	if len(head.Evidences) > 0 {
+		evidences := make([]map[string]interface{}, len(head.Evidences))
+		for i, evidence := range head.Evidences {
+			evidences[i] = map[string]interface{}{
+				"number":     hexutil.Uint64(evidence.Number()),
+				"firstHash":  evidence.First.Hash(),
+				"secondHash": evidence.Second.Hash(),
+				"kind":       hexutil.Uint(evidence.Kind),
+			}
+		}
+		fields["evidences"] = evidences
+	}
 
 	if inclTx {
 		formatTx := func(tx *types.Transaction) (interface{}, error) {
@@ -1981,6 +2342,65 @@ func (s *PublicTomoXTransactionPoolAPI) GetOrderById(ctx context.Context, baseTo
 	return orderitem, nil
 }
 
+// OrderProofResult is the result of a tomox GetProof call, the proof of an
+// order book in the TomoX state trie and of an order in the orders trie of it.
+type OrderProofResult struct {
+	StateRoot      common.Hash            `json:"stateRoot"`
+	OrderBook      common.Hash            `json:"orderBook"`
+	OrderBookProof []string               `json:"orderBookProof"`
+	OrderRoot      common.Hash            `json:"orderRoot"`
+	OrderId        common.Hash            `json:"orderId"`
+	Order          *tomox_state.OrderItem `json:"order"`
+	OrderProof     []string               `json:"orderProof"`
+}
+
+// GetProof returns the merkle proof of the order with the given id in the order
+// book of the given pair, for the given block number. The order is nil if it
+// doesn't exist, its proof proving the absence then.
+func (s *PublicTomoXTransactionPoolAPI) GetProof(ctx context.Context, baseToken, quoteToken common.Address, orderId uint64, blockNr rpc.BlockNumber) (*OrderProofResult, error) {
+	block, err := s.b.BlockByNumber(ctx, blockNr)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	tomoxService := s.b.TomoxService()
+	if tomoxService == nil {
+		return nil, errors.New("TomoX service not found")
+	}
+	root, err := tomoxService.GetTomoxStateRoot(block)
+	if err != nil {
+		return nil, err
+	}
+	tomoxState, err := tomoxService.GetTomoxState(block)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		orderBook   = tomox_state.GetOrderBookHash(baseToken, quoteToken)
+		orderIdHash = common.BigToHash(new(big.Int).SetUint64(orderId))
+		result      = &OrderProofResult{StateRoot: root, OrderBook: orderBook, OrderId: orderIdHash, OrderProof: []string{}}
+	)
+	orderBookProof, err := tomoxState.GetOrderBookProof(orderBook)
+	if err != nil {
+		return nil, err
+	}
+	result.OrderBookProof, result.OrderRoot = toHexSlice(orderBookProof), tomoxState.GetOrderRoot(orderBook)
+
+	// A missing order book has no orders to prove
+	if !tomoxState.Exist(orderBook) {
+		return result, nil
+	}
+	orderProof, err := tomoxState.GetOrderProof(orderBook, orderIdHash)
+	if err != nil {
+		return nil, err
+	}
+	result.OrderProof = toHexSlice(orderProof)
+
+	if order := tomoxState.GetOrder(orderBook, orderIdHash); order.Quantity != nil && order.Quantity.Sign() != 0 {
+		result.Order = &order
+	}
+	return result, nil
+}
+
 // Sign calculates an ECDSA signature for:
 // keccack256("\x19Ethereum Signed Message:\n" + len(message) + message).
 //
@@ -2160,32 +2580,24 @@ func NewPrivateDebugAPI(b Backend) *PrivateDebugAPI {
 	return &PrivateDebugAPI{b: b}
 }
 
-// ChaindbProperty returns leveldb properties of the chain database.
+// ChaindbProperty returns the internal properties of the chain database. The
+// properties of a LevelDB database may be given without their leveldb prefix.
 func (api *PrivateDebugAPI) ChaindbProperty(property string) (string, error) {
-	ldb, ok := api.b.ChainDb().(interface {
-		LDB() *leveldb.DB
-	})
-	if !ok {
-		return "", fmt.Errorf("chaindbProperty does not work for memory databases")
-	}
-	if property == "" {
-		property = "leveldb.stats"
-	} else if !strings.HasPrefix(property, "leveldb.") {
-		property = "leveldb." + property
+	db := api.b.ChainDb()
+	if disk, ok := db.(ethdb.DiskDatabase); ok && disk.Engine() == ethdb.LevelDBEngine {
+		if property == "" {
+			property = "leveldb.stats"
+		} else if !strings.HasPrefix(property, "leveldb.") {
+			property = "leveldb." + property
+		}
 	}
-	return ldb.LDB().GetProperty(property)
+	return db.Stat(property)
 }
 
 func (api *PrivateDebugAPI) ChaindbCompact() error {
-	ldb, ok := api.b.ChainDb().(interface {
-		LDB() *leveldb.DB
-	})
-	if !ok {
-		return fmt.Errorf("chaindbCompact does not work for memory databases")
-	}
 	for b := byte(0); b < 255; b++ {
 		log.Info("Compacting chain database", "range", fmt.Sprintf("0x%0.2X-0x%0.2X", b, b+1))
-		err := ldb.LDB().CompactRange(util.Range{Start: []byte{b}, Limit: []byte{b + 1}})
+		err := api.b.ChainDb().Compact([]byte{b}, []byte{b + 1})
 		if err != nil {
 			log.Error("Database compaction failed", "err", err)
 			return err
@@ -2266,6 +2678,13 @@ func GetSignersFromBlocks(b Backend, blockNumber uint64, blockHash common.Hash,
 					delete(mapMN, from)
 				}
 			}
+			for _, attestation := range header.Attestations {
+				from, err := attestation.Signer()
+				if err == nil && attestation.BlockHash == blockHash && mapMN[from] {
+					addrs = append(addrs, from)
+					delete(mapMN, from)
+				}
+			}
 			if len(mapMN) == 0 {
 				break
 			}
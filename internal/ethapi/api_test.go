@@ -0,0 +1,208 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/tomochain/tomochain/accounts"
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/common/hexutil"
+	"github.com/tomochain/tomochain/common/math"
+	"github.com/tomochain/tomochain/consensus/ethash"
+	"github.com/tomochain/tomochain/core"
+	"github.com/tomochain/tomochain/core/state"
+	"github.com/tomochain/tomochain/core/types"
+	"github.com/tomochain/tomochain/core/vm"
+	"github.com/tomochain/tomochain/crypto"
+	"github.com/tomochain/tomochain/eth/tracers"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/params"
+	"github.com/tomochain/tomochain/rlp"
+	"github.com/tomochain/tomochain/rpc"
+)
+
+// testBackend is a Backend over a local chain, implementing only what the
+// calls need.
+type testBackend struct {
+	Backend
+	chain *core.BlockChain
+}
+
+// newTestBackend creates a chain of the given blocks over the given genesis.
+func newTestBackend(t *testing.T, gspec *core.Genesis, n int, gen func(int, *core.BlockGen)) *testBackend {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := gspec.MustCommit(db)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, n, gen)
+
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	return &testBackend{chain: chain}
+}
+
+func (b *testBackend) AccountManager() *accounts.Manager { return accounts.NewManager() }
+
+func (b *testBackend) ChainConfig() *params.ChainConfig { return b.chain.Config() }
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	header := b.chain.CurrentHeader()
+	if blockNr >= 0 {
+		header = b.chain.GetHeaderByNumber(uint64(blockNr))
+	}
+	statedb, err := b.chain.StateAt(header.Root)
+	return statedb, header, err
+}
+
+func (b *testBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config, blockOverrides *BlockOverrides) (*vm.EVM, func() error, error) {
+	state.SetBalance(msg.From(), math.MaxBig256)
+	context := core.NewEVMContext(msg, header, b.chain, nil)
+	blockOverrides.Apply(&context)
+	return vm.NewEVM(context, state, b.chain.Config(), vmCfg), state.Error, nil
+}
+
+func (b *testBackend) NewStateDiffTracer() (StateDiffTracer, error) {
+	tracer, err := tracers.NewTracer("prestateTracer", json.RawMessage(`{"diffMode":true}`))
+	if err != nil {
+		return nil, err
+	}
+	return tracer.(StateDiffTracer), nil
+}
+
+// Tests that the accounts and the block fields are overridden in eth_call.
+func TestCallOverrides(t *testing.T) {
+	// Byzantium starts at block 10, past the head of the chain
+	config := *params.TestChainConfig
+	config.ByzantiumBlock = big.NewInt(10)
+
+	var (
+		sender   = common.Address{0x0a}
+		contract = common.Address{0x0b}
+		slot     = common.Hash{}
+	)
+	backend := newTestBackend(t, &core.Genesis{Config: &config}, 1, func(int, *core.BlockGen) {})
+	defer backend.chain.Stop()
+	api := NewPublicBlockChainAPI(backend)
+
+	call := func(code string, account OverrideAccount, block *BlockOverrides) ([]byte, bool) {
+		bytecode := hexutil.Bytes(common.FromHex(code))
+		account.Code = &bytecode
+		overrides := StateOverride{contract: account}
+
+		res, _, failed, err := api.doCall(context.Background(), CallArgs{From: sender, To: &contract}, rpc.LatestBlockNumber, &overrides, block, vm.Config{}, 0)
+		if err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+		return res, failed
+	}
+	word := func(n int64) []byte { return common.BigToHash(big.NewInt(n)).Bytes() }
+
+	// The overridden code returns the overridden storage slot
+	stateDiff := map[common.Hash]common.Hash{slot: common.BigToHash(big.NewInt(42))}
+	if res, _ := call("60005460005260206000f3", OverrideAccount{StateDiff: &stateDiff}, nil); !bytes.Equal(res, word(42)) {
+		t.Errorf("storage override mismatch: have %x, want %x", res, word(42))
+	}
+	// The balance of the sender is overridden after the EVM funded it
+	balance := (*hexutil.Big)(big.NewInt(1000))
+	overrides := StateOverride{sender: OverrideAccount{Balance: balance}}
+	bytecode := hexutil.Bytes(common.FromHex("333160005260206000f3"))
+	overrides[contract] = OverrideAccount{Code: &bytecode}
+	if res, _, _, err := api.doCall(context.Background(), CallArgs{From: sender, To: &contract}, rpc.LatestBlockNumber, &overrides, nil, vm.Config{}, 0); err != nil || !bytes.Equal(res, word(1000)) {
+		t.Errorf("balance override mismatch: have %x (%v), want %x", res, err, word(1000))
+	}
+	// The contract created depends on the overridden nonce
+	nonce := hexutil.Uint64(5)
+	if res, _ := call("600060006000f060005260206000f3", OverrideAccount{Nonce: &nonce}, nil); common.BytesToAddress(res) != crypto.CreateAddress(contract, 5) {
+		t.Errorf("nonce override mismatch: have %x, want %x", res, crypto.CreateAddress(contract, 5))
+	}
+	// The overridden block number is seen by the call and selects its rules
+	number := (*hexutil.Big)(big.NewInt(10))
+	if res, _ := call("4360005260206000f3", OverrideAccount{}, &BlockOverrides{Number: number}); !bytes.Equal(res, word(10)) {
+		t.Errorf("block number override mismatch: have %x, want %x", res, word(10))
+	}
+	if _, failed := call("3d60005260206000f3", OverrideAccount{}, nil); !failed {
+		t.Errorf("byzantium opcode succeeded before byzantium")
+	}
+	if res, failed := call("3d60005260206000f3", OverrideAccount{}, &BlockOverrides{Number: number}); failed || !bytes.Equal(res, word(0)) {
+		t.Errorf("byzantium opcode failed at the overridden block number: have %x", res)
+	}
+}
+
+// Tests that the calls of a bundle see the changes made by the ones before, and
+// that the signed transactions are checked against the rules of the overridden
+// block number.
+func TestCallBundle(t *testing.T) {
+	// EIP155 starts at block 10, past the head of the chain
+	config := *params.TestChainConfig
+	config.EIP155Block = big.NewInt(10)
+
+	var (
+		key, _    = crypto.GenerateKey()
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.Address{0x0a}
+		contract  = common.Address{0x0b}
+	)
+	// The contract returns the balance of the recipient
+	code := append(common.FromHex("73"), recipient.Bytes()...)
+	code = append(code, common.FromHex("3160005260206000f3")...)
+
+	gspec := &core.Genesis{
+		Config: &config,
+		Alloc: core.GenesisAlloc{
+			sender:   {Balance: big.NewInt(1000000000000000)},
+			contract: {Code: code, Balance: new(big.Int)},
+		},
+	}
+	backend := newTestBackend(t, gspec, 1, func(int, *core.BlockGen) {})
+	defer backend.chain.Stop()
+	api := NewPublicBlockChainAPI(backend)
+
+	tx, _ := types.SignTx(types.NewTransaction(0, recipient, big.NewInt(1000), 21000, big.NewInt(1), nil), types.NewEIP155Signer(config.ChainId), key)
+	raw, _ := rlp.EncodeToBytes(tx)
+	calls := []BundleCall{{Raw: raw}, {CallArgs: CallArgs{From: sender, To: &contract}}}
+
+	// The replay protected transaction is only valid from EIP155 on
+	if _, err := api.CallBundle(context.Background(), calls, rpc.LatestBlockNumber, nil, nil); err == nil {
+		t.Fatalf("replay protected transaction accepted before EIP155")
+	}
+	number := (*hexutil.Big)(big.NewInt(10))
+	results, err := api.CallBundle(context.Background(), calls, rpc.LatestBlockNumber, nil, &BlockOverrides{Number: number})
+	if err != nil {
+		t.Fatalf("failed to call bundle: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("result count mismatch: have %d, want 2", len(results))
+	}
+	if res := results[0]; res.TxHash != tx.Hash() || res.From != sender || res.Failed || res.Error != "" || res.GasUsed != 21000 {
+		t.Errorf("transaction result mismatch: %+v", res)
+	}
+	if diff := string(results[0].StateDiff); !strings.Contains(diff, strings.ToLower(recipient.Hex())) {
+		t.Errorf("state diff misses the recipient: %s", diff)
+	}
+	if res := results[1]; res.From != sender || res.Failed || !bytes.Equal(res.ReturnData, common.BigToHash(big.NewInt(1000)).Bytes()) {
+		t.Errorf("call result mismatch, want the balance sent before: %+v", res)
+	}
+	// The number of calls of a bundle is bounded
+	if _, err := api.CallBundle(context.Background(), make([]BundleCall, maxBundleCalls+1), rpc.LatestBlockNumber, nil, nil); err == nil {
+		t.Errorf("bundle of %d calls accepted", maxBundleCalls+1)
+	}
+}
@@ -63,6 +63,11 @@ const (
 	waitPeriodCheckpoint = 20
 
 	txMatchGasLimit = 40000000
+
+	// specialGasReserveShare is the inverse share of the block gas limit the
+	// user transactions leave to the signing and randomize transactions of the
+	// signers, so that the ones arriving once the block is full still fit.
+	specialGasReserveShare = 4
 )
 
 // Agent can register themself with the worker
@@ -82,10 +87,14 @@ type Work struct {
 
 	state      *state.StateDB // apply state changes here
 	tomoxState *tomox_state.TomoXStateDB
+	// precompileTomoXState holds the order books committed by the parent,
+	// read by the TomoX precompiled contracts while tomoxState gets matched
+	precompileTomoXState *tomox_state.TomoXStateDB
 	ancestors  mapset.Set // ancestor set (used for checking uncle parent validity)
 	family     mapset.Set // family set (used for checking uncle invalidity)
 	uncles     mapset.Set // uncle set
 	tcount     int      // tx count in cycle
+	specialGas uint64   // gas used by the special transactions
 
 	Block *types.Block // the new block
 
@@ -422,7 +431,15 @@ func (self *worker) wait() {
 				}
 				// Send tx sign to smart contract blockSigners.
 				if block.NumberU64()%common.MergeSignRange == 0 || !self.config.IsTIP2019(block.Number()) {
-					if err := contracts.CreateTransactionSign(self.config, self.eth.TxPool(), self.eth.AccountManager(), block, self.chainDb); err != nil {
+					if self.config.IsTIPAttestation(block.Number()) {
+						attestation, err := c.Attest(self.chain, block.Header())
+						if err != nil {
+							log.Error("Fail to attest block", "number", block.NumberU64(), "err", err)
+						} else {
+							self.mux.Post(core.NewAttestationEvent{Attestation: attestation})
+						}
+					}
+					if err := contracts.CreateTransactionSign(self.config, self.eth.TxPool(), self.eth.AccountManager(), block, self.chainDb, c.SignerAt(block.NumberU64())); err != nil {
 						log.Error("Fail to create tx sign for signer", "error", "err")
 					}
 				}
@@ -459,12 +476,18 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 			return err
 		}
 	}
+	precompileTomoXState, err := self.chain.PrecompileTomoXState(header)
+	if err != nil {
+		log.Error("Failed to create mining context", "err", err)
+		return err
+	}
 
 	work := &Work{
 		config:     self.config,
 		signer:     types.NewEIP155Signer(self.config.ChainId),
 		state:      state,
 		tomoxState: tomoxState,
+		precompileTomoXState: precompileTomoXState,
 		ancestors: mapset.NewSet(),
 		family:    mapset.NewSet(),
 		uncles:    mapset.NewSet(),
@@ -521,6 +544,11 @@ func (self *worker) commitNewWork() {
 		if self.config.Posv != nil {
 			// get masternodes set from latest checkpoint
 			c := self.engine.(*posv.Posv)
+			// Follow the signer rotations scheduled on the engine
+			if signer := c.SignerAt(parent.NumberU64() + 1); signer != (common.Address{}) && signer != self.coinbase {
+				log.Info("Switching etherbase to rotated signer", "number", parent.NumberU64()+1, "old", self.coinbase, "new", signer)
+				self.coinbase = signer
+			}
 			len, preIndex, curIndex, ok, err := c.YourTurn(self.chain, parent.Header(), self.coinbase)
 			if err != nil {
 				log.Warn("Failed when trying to commit new work", "err", err)
@@ -622,6 +650,11 @@ func (self *worker) commitNewWork() {
 			log.Error("Failed to fetch pending transactions", "err", err)
 			return
 		}
+		// Split the transactions of the special lane senders out to commit them first
+		signers = make(map[common.Address]struct{})
+		for addr := range self.eth.TxPool().Specials() {
+			signers[addr] = struct{}{}
+		}
 		txs, specialTxs = types.NewTransactionsByPriceAndNonce(self.current.signer, pending, signers, feeCapacity)
 	}
 	if atomic.LoadInt32(&self.mining) == 1 {
@@ -792,6 +825,7 @@ func (env *Work) commitTransactions(mux *event.TypeMux, balanceFee map[common.Ad
 			// Everything ok, collect the logs and shift in the next transaction from the same account
 			coalescedLogs = append(coalescedLogs, logs...)
 			env.tcount++
+			env.specialGas += gas
 
 		default:
 			// Strange error, discard the transaction and get the next in line (note, the
@@ -808,6 +842,17 @@ func (env *Work) commitTransactions(mux *event.TypeMux, balanceFee map[common.Ad
 			totalFeeUsed = totalFeeUsed.Add(totalFeeUsed, fee)
 		}
 	}
+	// The user transactions leave the reserved share of the block gas to the
+	// special ones, whether they used it yet or not
+	userGas := env.header.GasLimit - env.header.GasLimit/specialGasReserveShare
+	if used := env.header.GasUsed - env.specialGas; used < userGas {
+		userGas -= used
+	} else {
+		userGas = 0
+	}
+	if userGas < gp.Gas() {
+		gp = new(core.GasPool).AddGas(userGas)
+	}
 	for {
 		// If we don't have enough gas for any further transactions then we're done
 		if gp.Gas() < params.TxGas {
@@ -931,7 +976,7 @@ func (env *Work) commitTransactions(mux *event.TypeMux, balanceFee map[common.Ad
 func (env *Work) commitTransaction(balanceFee map[common.Address]*big.Int, tx *types.Transaction, bc *core.BlockChain, coinbase common.Address, gp *core.GasPool) (error, []*types.Log, bool, uint64) {
 	snap := env.state.Snapshot()
 
-	receipt, gas, err, tokenFeeUsed := core.ApplyTransaction(env.config, balanceFee, bc, &coinbase, gp, env.state, env.header, tx, &env.header.GasUsed, vm.Config{})
+	receipt, gas, err, tokenFeeUsed := core.ApplyTransaction(env.config, balanceFee, bc, &coinbase, gp, env.state, env.precompileTomoXState, env.header, tx, &env.header.GasUsed, vm.Config{})
 	if err != nil {
 		env.state.RevertToSnapshot(snap)
 		return err, nil, false, 0
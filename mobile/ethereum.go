@@ -83,6 +83,9 @@ func (p *SyncProgress) GetHighestBlock() int64  { return int64(p.progress.Highes
 func (p *SyncProgress) GetPulledStates() int64  { return int64(p.progress.PulledStates) }
 func (p *SyncProgress) GetKnownStates() int64   { return int64(p.progress.KnownStates) }
 
+func (p *SyncProgress) GetPulledTomoXStates() int64 { return int64(p.progress.PulledTomoXStates) }
+func (p *SyncProgress) GetKnownTomoXStates() int64  { return int64(p.progress.KnownTomoXStates) }
+
 // Topics is a set of topic lists to filter events with.
 type Topics struct{ topics [][]common.Hash }
 
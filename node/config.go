@@ -66,6 +66,11 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DBEngine is the storage engine of the databases created in DataDir. If it
+	// is empty, existing databases are opened with the engine they were created
+	// with and new ones with the default engine.
+	DBEngine string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -58,10 +59,11 @@ type Node struct {
 	ipcListener net.Listener // IPC RPC listener socket to serve API requests
 	ipcHandler  *rpc.Server  // IPC RPC request handler to process the API requests
 
-	httpEndpoint  string       // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
-	httpWhitelist []string     // HTTP RPC modules to allow through this endpoint
-	httpListener  net.Listener // HTTP RPC listener socket to server API requests
-	httpHandler   *rpc.Server  // HTTP RPC request handler to process the API requests
+	httpEndpoint  string                  // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
+	httpWhitelist []string                // HTTP RPC modules to allow through this endpoint
+	httpListener  net.Listener            // HTTP RPC listener socket to server API requests
+	httpHandler   *rpc.Server             // HTTP RPC request handler to process the API requests
+	httpServices  map[string]http.Handler // HTTP handlers of the services mounted next to the HTTP RPC
 
 	wsEndpoint string       // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
 	wsListener net.Listener // Websocket RPC listener socket to server API requests
@@ -255,6 +257,20 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	for _, service := range services {
 		apis = append(apis, service.APIs()...)
 	}
+	// Gather the HTTP handlers to mount next to the HTTP RPC
+	handlers := make(map[string]http.Handler)
+	for _, service := range services {
+		if service, ok := service.(HTTPService); ok {
+			for path, handler := range service.HTTPHandlers() {
+				if _, exists := handlers[path]; exists {
+					return fmt.Errorf("duplicate HTTP handler for path %s", path)
+				}
+				handlers[path] = handler
+			}
+		}
+	}
+	n.httpServices = handlers
+
 	// Start the various API endpoints, terminating all in case of errors
 	if err := n.startInProc(apis); err != nil {
 		return err
@@ -393,8 +409,21 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewHTTPServer(cors, vhosts, handler).Serve(listener)
+	// Mount the HTTP handlers of the services next to the RPC API
+	var srv http.Handler = handler
+	if len(n.httpServices) > 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/", handler)
+		for path, h := range n.httpServices {
+			mux.Handle(path, h)
+		}
+		srv = mux
+	}
+	go rpc.NewHTTPServer(cors, vhosts, srv).Serve(listener)
 	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","))
+	for path := range n.httpServices {
+		n.log.Info("HTTP handler mounted", "url", fmt.Sprintf("http://%s%s", endpoint, path))
+	}
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
 	n.httpListener = listener
@@ -645,7 +674,36 @@ func (n *Node) OpenDatabase(name string, cache, handles int) (ethdb.Database, er
 	if n.config.DataDir == "" {
 		return ethdb.NewMemDatabase()
 	}
-	return ethdb.NewLDBDatabase(n.config.resolvePath(name), cache, handles)
+	return ethdb.OpenDatabase(n.config.DBEngine, n.config.resolvePath(name), cache, handles)
+}
+
+// OpenDatabaseWithFreezer opens an existing database with the given name (or
+// creates one if no previous can be found) from within the node's instance
+// directory, attaching the ancient store in the freezer directory. A relative
+// freezer path is resolved inside the database directory. If the node is
+// ephemeral, a memory database is returned.
+func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, freezer string) (ethdb.Database, error) {
+	if n.config.DataDir == "" {
+		return ethdb.NewMemDatabase()
+	}
+	return openDatabaseWithFreezer(n.config.DBEngine, n.config.resolvePath(name), cache, handles, freezer)
+}
+
+// openDatabaseWithFreezer opens the database at the given path and attaches
+// the ancient store to it.
+func openDatabaseWithFreezer(engine string, file string, cache, handles int, freezer string) (ethdb.Database, error) {
+	db, err := ethdb.OpenDatabase(engine, file, cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(freezer) {
+		freezer = filepath.Join(file, freezer)
+	}
+	if err := db.OpenFreezer(freezer); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
 }
 
 // ResolvePath returns the absolute path of a resource in the instance directory.
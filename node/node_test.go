@@ -18,7 +18,9 @@ package node
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"reflect"
 	"testing"
@@ -573,3 +575,47 @@ func TestAPIGather(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the HTTP handlers of the services get mounted next to the HTTP RPC.
+func TestHTTPServiceGather(t *testing.T) {
+	config := testNodeConfig()
+	config.HTTPHost, config.HTTPPort = "127.0.0.1", 0
+
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "mounted")
+	})
+	constructor := func(*ServiceContext) (Service, error) {
+		return &HTTPHandlerService{handlers: map[string]http.Handler{"/mounted": handler}}, nil
+	}
+	if err := stack.Register(constructor); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/mounted", stack.httpListener.Addr()))
+	if err != nil {
+		t.Fatalf("failed to query mounted handler: %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := ioutil.ReadAll(resp.Body); string(body) != "mounted" {
+		t.Fatalf("mounted handler response mismatch: have %q, want %q", body, "mounted")
+	}
+	// The RPC API must still be served at the root path
+	client, err := rpc.Dial(fmt.Sprintf("http://%s", stack.httpListener.Addr()))
+	if err != nil {
+		t.Fatalf("failed to connect to the HTTP RPC: %v", err)
+	}
+	defer client.Close()
+
+	var modules map[string]string
+	if err := client.Call(&modules, "rpc_modules"); err != nil {
+		t.Fatalf("failed to call the HTTP RPC: %v", err)
+	}
+}
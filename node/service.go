@@ -17,6 +17,7 @@
 package node
 
 import (
+	"net/http"
 	"reflect"
 
 	"github.com/tomochain/tomochain/accounts"
@@ -43,13 +44,24 @@ func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (et
 	if ctx.config.DataDir == "" {
 		return ethdb.NewMemDatabase()
 	}
-	db, err := ethdb.NewLDBDatabase(ctx.config.resolvePath(name), cache, handles)
+	db, err := ethdb.OpenDatabase(ctx.config.DBEngine, ctx.config.resolvePath(name), cache, handles)
 	if err != nil {
 		return nil, err
 	}
 	return db, nil
 }
 
+// OpenDatabaseWithFreezer opens an existing database with the given name (or
+// creates one if no previous can be found) from within the node's data
+// directory, attaching the ancient store in the freezer directory. A relative
+// freezer path is resolved inside the database directory.
+func (ctx *ServiceContext) OpenDatabaseWithFreezer(name string, cache int, handles int, freezer string) (ethdb.Database, error) {
+	if ctx.config.DataDir == "" {
+		return ethdb.NewMemDatabase()
+	}
+	return openDatabaseWithFreezer(ctx.config.DBEngine, ctx.config.resolvePath(name), cache, handles, freezer)
+}
+
 // ResolvePath resolves a user path into the data directory if that was relative
 // and if the user actually uses persistent storage. It will return an empty string
 // for emphemeral storage and the user's own input for absolute paths.
@@ -101,3 +113,13 @@ type Service interface {
 	// are all terminated.
 	Stop() error
 }
+
+// HTTPService is a Service that also serves HTTP requests of its own, mounted
+// next to the RPC API on the HTTP endpoint of the node.
+type HTTPService interface {
+	Service
+
+	// HTTPHandlers retrieves the HTTP handlers the service serves, keyed by the
+	// path they are mounted at.
+	HTTPHandlers() map[string]http.Handler
+}
@@ -20,6 +20,7 @@
 package node
 
 import (
+	"net/http"
 	"reflect"
 
 	"github.com/tomochain/tomochain/p2p"
@@ -131,3 +132,12 @@ func (api *OneMethodApi) TheOneMethod() {
 		api.fun()
 	}
 }
+
+// HTTPHandlerService is an implementation of HTTPService serving the given HTTP
+// handlers next to the HTTP RPC API.
+type HTTPHandlerService struct {
+	NoopService
+	handlers map[string]http.Handler
+}
+
+func (s *HTTPHandlerService) HTTPHandlers() map[string]http.Handler { return s.handlers }
@@ -253,6 +253,32 @@ func (c *ChainConfig) IsTIPTomoX(num *big.Int) bool {
 	}
 }
 
+func (c *ChainConfig) IsTIPAttestation(num *big.Int) bool {
+	return isForked(common.TIPAttestation, num)
+}
+
+func (c *ChainConfig) IsTIPSlashing(num *big.Int) bool {
+	return isForked(common.TIPSlashing, num)
+}
+
+func (c *ChainConfig) IsTIPCommitReveal(num *big.Int) bool {
+	return isForked(common.TIPCommitReveal, num)
+}
+
+func (c *ChainConfig) IsTIPCreate2(num *big.Int) bool {
+	return isForked(common.TIPCreate2, num)
+}
+
+func (c *ChainConfig) IsTIPIstanbul(num *big.Int) bool {
+	return isForked(common.TIPIstanbul, num)
+}
+
+// IsTIPTomoXPrecompiles returns whether num enables the TomoX precompiled
+// contracts, which read the order books so need TomoX to be enabled too.
+func (c *ChainConfig) IsTIPTomoXPrecompiles(num *big.Int) bool {
+	return isForked(common.TIPTomoXPrecompiles, num) && c.IsTIPTomoX(num)
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
@@ -382,7 +408,7 @@ func (err *ConfigCompatError) Error() string {
 type Rules struct {
 	ChainId                                   *big.Int
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158 bool
-	IsByzantium                               bool
+	IsByzantium, IsTIPCreate2, IsTIPIstanbul  bool
 }
 
 func (c *ChainConfig) Rules(num *big.Int) Rules {
@@ -390,5 +416,5 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 	if chainId == nil {
 		chainId = new(big.Int)
 	}
-	return Rules{ChainId: new(big.Int).Set(chainId), IsHomestead: c.IsHomestead(num), IsEIP150: c.IsEIP150(num), IsEIP155: c.IsEIP155(num), IsEIP158: c.IsEIP158(num), IsByzantium: c.IsByzantium(num)}
+	return Rules{ChainId: new(big.Int).Set(chainId), IsHomestead: c.IsHomestead(num), IsEIP150: c.IsEIP150(num), IsEIP155: c.IsEIP155(num), IsEIP158: c.IsEIP158(num), IsByzantium: c.IsByzantium(num), IsTIPCreate2: c.IsTIPCreate2(num), IsTIPIstanbul: c.IsTIPIstanbul(num)}
 }
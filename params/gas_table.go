@@ -19,6 +19,7 @@ package params
 type GasTable struct {
 	ExtcodeSize uint64
 	ExtcodeCopy uint64
+	ExtcodeHash uint64 // Set in every table, TIPCreate2 enables EXTCODEHASH whatever the table
 	Balance     uint64
 	SLoad       uint64
 	Calls       uint64
@@ -40,6 +41,7 @@ var (
 	GasTableHomestead = GasTable{
 		ExtcodeSize: 20,
 		ExtcodeCopy: 20,
+		ExtcodeHash: 20,
 		Balance:     20,
 		SLoad:       50,
 		Calls:       40,
@@ -52,6 +54,7 @@ var (
 	GasTableEIP150 = GasTable{
 		ExtcodeSize: 700,
 		ExtcodeCopy: 700,
+		ExtcodeHash: 400,
 		Balance:     400,
 		SLoad:       200,
 		Calls:       700,
@@ -64,6 +67,7 @@ var (
 	GasTableEIP158 = GasTable{
 		ExtcodeSize: 700,
 		ExtcodeCopy: 700,
+		ExtcodeHash: 400,
 		Balance:     400,
 		SLoad:       200,
 		Calls:       700,
@@ -64,12 +64,17 @@ type Decoder interface {
 // error if there are too few or too many elements.
 //
 // The decoding of struct fields honours certain struct tags, "tail",
-// "nil" and "-".
+// "optional", "nil" and "-".
 //
 // The "-" tag ignores fields.
 //
 // For an explanation of "tail", see the example.
 //
+// The "optional" tag allows the input list to end before the field. Missing
+// optional fields are set to their zero value. All fields following an
+// optional field must be optional as well. This can be used to append new
+// fields to a struct while still accepting the original encoding.
+//
 // The "nil" tag applies to pointer-typed fields and changes the decoding
 // rules for the field such that input values of size zero decode as a nil
 // pointer. This tag can be useful when decoding recursive types.
@@ -422,9 +427,17 @@ func makeStructDecoder(typ reflect.Type) (decoder, error) {
 		if _, err := s.List(); err != nil {
 			return wrapStreamError(err, typ)
 		}
-		for _, f := range fields {
+		for i, f := range fields {
 			err := f.info.decoder(s, val.Field(f.index))
-			if err == EOL {
+			if err == EOL && f.optional {
+				// The input list ended before the optional fields,
+				// reset the remaining ones to their zero value.
+				for _, f := range fields[i:] {
+					fv := val.Field(f.index)
+					fv.Set(reflect.Zero(fv.Type()))
+				}
+				break
+			} else if err == EOL {
 				return &decodeError{msg: "too few elements", typ: typ}
 			} else if err != nil {
 				return addErrorContext(err, "."+typ.Field(f.index).Name)
@@ -347,6 +347,17 @@ type tailUint struct {
 	Tail []uint `rlp:"tail"`
 }
 
+type optionalFields struct {
+	A uint
+	B uint   `rlp:"optional"`
+	C []uint `rlp:"optional"`
+}
+
+type invalidOptional struct {
+	A uint `rlp:"optional"`
+	B uint
+}
+
 var (
 	veryBigInt = big.NewInt(0).Add(
 		big.NewInt(0).Lsh(big.NewInt(0xFFFFFFFFFFFFFF), 16),
@@ -488,6 +499,11 @@ var decodeTests = []decodeTest{
 		ptr:   new(invalidTail2),
 		error: "rlp: invalid struct tag \"tail\" for rlp.invalidTail2.B (field type is not slice)",
 	},
+	{
+		input: "C0",
+		ptr:   new(invalidOptional),
+		error: "rlp: struct field rlp.invalidOptional.B needs \"optional\" tag (previous field A is optional)",
+	},
 	{
 		input: "C50102C20102",
 		ptr:   new(tailUint),
@@ -511,6 +527,28 @@ var decodeTests = []decodeTest{
 		value: tailRaw{A: 1, Tail: []RawValue{}},
 	},
 
+	// struct tag "optional"
+	{
+		input: "C101",
+		ptr:   new(optionalFields),
+		value: optionalFields{A: 1},
+	},
+	{
+		input: "C20102",
+		ptr:   new(optionalFields),
+		value: optionalFields{A: 1, B: 2},
+	},
+	{
+		input: "C40102C103",
+		ptr:   new(optionalFields),
+		value: optionalFields{A: 1, B: 2, C: []uint{3}},
+	},
+	{
+		input: "C0",
+		ptr:   new(optionalFields),
+		error: "rlp: too few elements for rlp.optionalFields",
+	},
+
 	// struct tag "-"
 	{
 		input: "C20102",
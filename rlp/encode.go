@@ -62,7 +62,8 @@ type Encoder interface {
 // if the array has element type byte).
 //
 // Struct values are encoded as an RLP list of all their encoded
-// public fields. Recursive struct types are supported.
+// public fields. Recursive struct types are supported. Trailing fields
+// with the "optional" tag are omitted if they are empty.
 //
 // To encode slices and arrays, the elements are encoded as an RLP
 // list of the value's elements. Note that arrays and slices with
@@ -531,9 +532,17 @@ func makeStructWriter(typ reflect.Type) (writer, error) {
 	if err != nil {
 		return nil, err
 	}
+	firstOptional := firstOptionalField(fields)
 	writer := func(val reflect.Value, w *encbuf) error {
+		// Trailing optional fields which are empty are left out.
+		lastField := len(fields) - 1
+		for ; lastField >= firstOptional; lastField-- {
+			if !isEmptyOptional(val.Field(fields[lastField].index)) {
+				break
+			}
+		}
 		lh := w.list()
-		for _, f := range fields {
+		for _, f := range fields[:lastField+1] {
 			if err := f.info.writer(val.Field(f.index), w); err != nil {
 				return err
 			}
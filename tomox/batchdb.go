@@ -11,6 +11,7 @@ import (
 	"github.com/tomochain/tomochain/ethdb"
 	"github.com/tomochain/tomochain/log"
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
 )
 
 const (
@@ -112,6 +113,26 @@ func (db *BatchDatabase) NewBatch() ethdb.Batch {
 	return db.db.NewBatch()
 }
 
+func (db *BatchDatabase) NewIterator() iterator.Iterator {
+	return db.db.NewIterator()
+}
+
+func (db *BatchDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	return db.db.NewIteratorWithPrefix(prefix)
+}
+
+func (db *BatchDatabase) Stat(property string) (string, error) {
+	return db.db.Stat(property)
+}
+
+func (db *BatchDatabase) Compact(start []byte, limit []byte) error {
+	return db.db.Compact(start, limit)
+}
+
+func (db *BatchDatabase) DeleteRange(start []byte, limit []byte) error {
+	return db.db.DeleteRange(start, limit)
+}
+
 func (db *BatchDatabase) DeleteTradeByTxHash(txhash common.Hash) {
 }
 
@@ -29,4 +29,8 @@ type OrderDao interface {
 	Has(key []byte) (bool, error)
 	Delete(key []byte) error
 	NewBatch() ethdb.Batch
+	ethdb.Iteratee
+	ethdb.Stater
+	ethdb.Compacter
+	ethdb.RangeDeleter
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"strings"
 )
 
@@ -311,6 +312,31 @@ func (db *MongoDatabase) NewBatch() ethdb.Batch {
 	return nil
 }
 
+func (db *MongoDatabase) NewIterator() iterator.Iterator {
+	// for levelDB only
+	return iterator.NewEmptyIterator(nil)
+}
+
+func (db *MongoDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	// for levelDB only
+	return iterator.NewEmptyIterator(nil)
+}
+
+func (db *MongoDatabase) Stat(property string) (string, error) {
+	// for levelDB only
+	return "", nil
+}
+
+func (db *MongoDatabase) Compact(start []byte, limit []byte) error {
+	// for levelDB only
+	return nil
+}
+
+func (db *MongoDatabase) DeleteRange(start []byte, limit []byte) error {
+	// for levelDB only
+	return nil
+}
+
 type keyvalue struct {
 	key   []byte
 	value []byte
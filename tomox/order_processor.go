@@ -29,6 +29,12 @@ func (tomox *TomoX) CommitOrder(coinbase common.Address, chain consensus.ChainCo
 }
 
 func (tomox *TomoX) ApplyOrder(coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tomoXstatedb *tomox_state.TomoXStateDB, orderBook common.Hash, order *tomox_state.OrderItem) ([]map[string]string, []*tomox_state.OrderItem, error) {
+	return tomox.ApplyOrderWithTracer(coinbase, chain, statedb, tomoXstatedb, orderBook, order, nil)
+}
+
+// ApplyOrderWithTracer applies the order like ApplyOrder, reporting the steps of
+// the matching to the given tracer, if any.
+func (tomox *TomoX) ApplyOrderWithTracer(coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tomoXstatedb *tomox_state.TomoXStateDB, orderBook common.Hash, order *tomox_state.OrderItem, tracer OrderTracer) ([]map[string]string, []*tomox_state.OrderItem, error) {
 	var (
 		rejects []*tomox_state.OrderItem
 		trades  []map[string]string
@@ -43,12 +49,18 @@ func (tomox *TomoX) ApplyOrder(coinbase common.Address, chain consensus.ChainCon
 	}
 	if order.Price.Sign() == 0 || common.BigToHash(order.Price).Big().Cmp(order.Price) != 0 {
 		log.Debug("Reject order price invalid", "price", order.Price)
+		if tracer != nil {
+			tracer.CaptureReject(order, "invalid price")
+		}
 		rejects = append(rejects, order)
 		tomoXstatedb.SetNonce(order.UserAddress.Hash(), nonce+1)
 		return trades, rejects, nil
 	}
 	if order.Quantity.Sign() == 0 || common.BigToHash(order.Quantity).Big().Cmp(order.Quantity) != 0 {
 		log.Debug("Reject order quantity invalid", "quantity", order.Quantity)
+		if tracer != nil {
+			tracer.CaptureReject(order, "invalid quantity")
+		}
 		rejects = append(rejects, order)
 		tomoXstatedb.SetNonce(order.UserAddress.Hash(), nonce+1)
 		return trades, rejects, nil
@@ -68,13 +80,13 @@ func (tomox *TomoX) ApplyOrder(coinbase common.Address, chain consensus.ChainCon
 	// if we do not use auto-increment orderid, we must set price slot to avoid conflict
 	if orderType == tomox_state.Market {
 		log.Debug("Process maket order", "side", order.Side, "quantity", order.Quantity, "price", order.Price)
-		trades, rejects, err = tomox.processMarketOrder(coinbase, chain, statedb, tomoXstatedb, orderBook, order)
+		trades, rejects, err = tomox.processMarketOrder(coinbase, chain, statedb, tomoXstatedb, orderBook, order, tracer)
 		if err != nil {
 			return nil, nil, err
 		}
 	} else {
 		log.Debug("Process limit order", "side", order.Side, "quantity", order.Quantity, "price", order.Price)
-		trades, rejects, err = tomox.processLimitOrder(coinbase, chain, statedb, tomoXstatedb, orderBook, order)
+		trades, rejects, err = tomox.processLimitOrder(coinbase, chain, statedb, tomoXstatedb, orderBook, order, tracer)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -86,7 +98,7 @@ func (tomox *TomoX) ApplyOrder(coinbase common.Address, chain consensus.ChainCon
 }
 
 // processMarketOrder : process the market order
-func (tomox *TomoX) processMarketOrder(coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tomoXstatedb *tomox_state.TomoXStateDB, orderBook common.Hash, order *tomox_state.OrderItem) ([]map[string]string, []*tomox_state.OrderItem, error) {
+func (tomox *TomoX) processMarketOrder(coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tomoXstatedb *tomox_state.TomoXStateDB, orderBook common.Hash, order *tomox_state.OrderItem, tracer OrderTracer) ([]map[string]string, []*tomox_state.OrderItem, error) {
 	var (
 		trades     []map[string]string
 		newTrades  []map[string]string
@@ -101,8 +113,11 @@ func (tomox *TomoX) processMarketOrder(coinbase common.Address, chain consensus.
 	if side == tomox_state.Bid {
 		bestPrice, volume := tomoXstatedb.GetBestAskPrice(orderBook)
 		log.Debug("processMarketOrder ", "side", side, "bestPrice", bestPrice, "quantityToTrade", quantityToTrade, "volume", volume)
+		if tracer != nil {
+			tracer.CapturePriceLevel(tomox_state.Ask, bestPrice, volume, quantityToTrade)
+		}
 		for quantityToTrade.Cmp(zero) > 0 && bestPrice.Cmp(zero) > 0 {
-			quantityToTrade, newTrades, newRejects, err = tomox.processOrderList(coinbase, chain, statedb, tomoXstatedb, tomox_state.Ask, orderBook, bestPrice, quantityToTrade, order)
+			quantityToTrade, newTrades, newRejects, err = tomox.processOrderList(coinbase, chain, statedb, tomoXstatedb, tomox_state.Ask, orderBook, bestPrice, quantityToTrade, order, tracer)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -110,12 +125,18 @@ func (tomox *TomoX) processMarketOrder(coinbase common.Address, chain consensus.
 			rejects = append(rejects, newRejects...)
 			bestPrice, volume = tomoXstatedb.GetBestAskPrice(orderBook)
 			log.Debug("processMarketOrder ", "side", side, "bestPrice", bestPrice, "quantityToTrade", quantityToTrade, "volume", volume)
+			if tracer != nil {
+				tracer.CapturePriceLevel(tomox_state.Ask, bestPrice, volume, quantityToTrade)
+			}
 		}
 	} else {
 		bestPrice, volume := tomoXstatedb.GetBestBidPrice(orderBook)
 		log.Debug("processMarketOrder ", "side", side, "bestPrice", bestPrice, "quantityToTrade", quantityToTrade, "volume", volume)
+		if tracer != nil {
+			tracer.CapturePriceLevel(tomox_state.Bid, bestPrice, volume, quantityToTrade)
+		}
 		for quantityToTrade.Cmp(zero) > 0 && bestPrice.Cmp(zero) > 0 {
-			quantityToTrade, newTrades, newRejects, err = tomox.processOrderList(coinbase, chain, statedb, tomoXstatedb, tomox_state.Bid, orderBook, bestPrice, quantityToTrade, order)
+			quantityToTrade, newTrades, newRejects, err = tomox.processOrderList(coinbase, chain, statedb, tomoXstatedb, tomox_state.Bid, orderBook, bestPrice, quantityToTrade, order, tracer)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -123,6 +144,9 @@ func (tomox *TomoX) processMarketOrder(coinbase common.Address, chain consensus.
 			rejects = append(rejects, newRejects...)
 			bestPrice, volume = tomoXstatedb.GetBestBidPrice(orderBook)
 			log.Debug("processMarketOrder ", "side", side, "bestPrice", bestPrice, "quantityToTrade", quantityToTrade, "volume", volume)
+			if tracer != nil {
+				tracer.CapturePriceLevel(tomox_state.Bid, bestPrice, volume, quantityToTrade)
+			}
 		}
 	}
 	return trades, newRejects, nil
@@ -130,7 +154,7 @@ func (tomox *TomoX) processMarketOrder(coinbase common.Address, chain consensus.
 
 // processLimitOrder : process the limit order, can change the quote
 // If not care for performance, we should make a copy of quote to prevent further reference problem
-func (tomox *TomoX) processLimitOrder(coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tomoXstatedb *tomox_state.TomoXStateDB, orderBook common.Hash, order *tomox_state.OrderItem) ([]map[string]string, []*tomox_state.OrderItem, error) {
+func (tomox *TomoX) processLimitOrder(coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tomoXstatedb *tomox_state.TomoXStateDB, orderBook common.Hash, order *tomox_state.OrderItem, tracer OrderTracer) ([]map[string]string, []*tomox_state.OrderItem, error) {
 	var (
 		trades     []map[string]string
 		newTrades  []map[string]string
@@ -148,9 +172,12 @@ func (tomox *TomoX) processLimitOrder(coinbase common.Address, chain consensus.C
 	if side == tomox_state.Bid {
 		minPrice, volume := tomoXstatedb.GetBestAskPrice(orderBook)
 		log.Debug("processLimitOrder ", "side", side, "minPrice", minPrice, "orderPrice", price, "volume", volume)
+		if tracer != nil {
+			tracer.CapturePriceLevel(tomox_state.Ask, minPrice, volume, quantityToTrade)
+		}
 		for quantityToTrade.Cmp(zero) > 0 && price.Cmp(minPrice) >= 0 && minPrice.Cmp(zero) > 0 {
 			log.Debug("Min price in asks tree", "price", minPrice.String())
-			quantityToTrade, newTrades, newRejects, err = tomox.processOrderList(coinbase, chain, statedb, tomoXstatedb, tomox_state.Ask, orderBook, minPrice, quantityToTrade, order)
+			quantityToTrade, newTrades, newRejects, err = tomox.processOrderList(coinbase, chain, statedb, tomoXstatedb, tomox_state.Ask, orderBook, minPrice, quantityToTrade, order, tracer)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -159,13 +186,19 @@ func (tomox *TomoX) processLimitOrder(coinbase common.Address, chain consensus.C
 			log.Debug("New trade found", "newTrades", newTrades, "quantityToTrade", quantityToTrade)
 			minPrice, volume = tomoXstatedb.GetBestAskPrice(orderBook)
 			log.Debug("processLimitOrder ", "side", side, "minPrice", minPrice, "orderPrice", price, "volume", volume)
+			if tracer != nil {
+				tracer.CapturePriceLevel(tomox_state.Ask, minPrice, volume, quantityToTrade)
+			}
 		}
 	} else {
 		maxPrice, volume := tomoXstatedb.GetBestBidPrice(orderBook)
 		log.Debug("processLimitOrder ", "side", side, "maxPrice", maxPrice, "orderPrice", price, "volume", volume)
+		if tracer != nil {
+			tracer.CapturePriceLevel(tomox_state.Bid, maxPrice, volume, quantityToTrade)
+		}
 		for quantityToTrade.Cmp(zero) > 0 && price.Cmp(maxPrice) <= 0 && maxPrice.Cmp(zero) > 0 {
 			log.Debug("Max price in bids tree", "price", maxPrice.String())
-			quantityToTrade, newTrades, newRejects, err = tomox.processOrderList(coinbase, chain, statedb, tomoXstatedb, tomox_state.Bid, orderBook, maxPrice, quantityToTrade, order)
+			quantityToTrade, newTrades, newRejects, err = tomox.processOrderList(coinbase, chain, statedb, tomoXstatedb, tomox_state.Bid, orderBook, maxPrice, quantityToTrade, order, tracer)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -174,6 +207,9 @@ func (tomox *TomoX) processLimitOrder(coinbase common.Address, chain consensus.C
 			log.Debug("New trade found", "newTrades", newTrades, "quantityToTrade", quantityToTrade)
 			maxPrice, volume = tomoXstatedb.GetBestBidPrice(orderBook)
 			log.Debug("processLimitOrder ", "side", side, "maxPrice", maxPrice, "orderPrice", price, "volume", volume)
+			if tracer != nil {
+				tracer.CapturePriceLevel(tomox_state.Bid, maxPrice, volume, quantityToTrade)
+			}
 		}
 	}
 	if quantityToTrade.Cmp(zero) > 0 {
@@ -189,7 +225,7 @@ func (tomox *TomoX) processLimitOrder(coinbase common.Address, chain consensus.C
 }
 
 // processOrderList : process the order list
-func (tomox *TomoX) processOrderList(coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tomoXstatedb *tomox_state.TomoXStateDB, side string, orderBook common.Hash, price *big.Int, quantityStillToTrade *big.Int, order *tomox_state.OrderItem) (*big.Int, []map[string]string, []*tomox_state.OrderItem, error) {
+func (tomox *TomoX) processOrderList(coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tomoXstatedb *tomox_state.TomoXStateDB, side string, orderBook common.Hash, price *big.Int, quantityStillToTrade *big.Int, order *tomox_state.OrderItem, tracer OrderTracer) (*big.Int, []map[string]string, []*tomox_state.OrderItem, error) {
 	quantityToTrade := tomox_state.CloneBigInt(quantityStillToTrade)
 	log.Debug("Process matching between order and orderlist", "quantityToTrade", quantityToTrade)
 	var (
@@ -216,14 +252,21 @@ func (tomox *TomoX) processOrderList(coinbase common.Address, chain consensus.Ch
 		} else {
 			maxTradedQuantity = tomox_state.CloneBigInt(amount)
 		}
+		if tracer != nil {
+			tracer.CaptureMatch(&oldestOrder, amount, maxTradedQuantity)
+		}
 		var quotePrice *big.Int
 		if oldestOrder.QuoteToken.String() != common.TomoNativeAddress {
 			quotePrice = tomoXstatedb.GetPrice(tomox_state.GetOrderBookHash(oldestOrder.QuoteToken, common.HexToAddress(common.TomoNativeAddress)))
 		}
-		tradedQuantity, rejectMaker, err := tomox.getTradeQuantity(quotePrice, coinbase, chain, statedb, order, &oldestOrder, maxTradedQuantity)
+		tradedQuantity, rejectMaker, err := tomox.getTradeQuantity(quotePrice, coinbase, chain, statedb, order, &oldestOrder, maxTradedQuantity, tracer)
 		if err != nil && err == errQuantityTradeTooSmall {
 			if tradedQuantity.Cmp(maxTradedQuantity) == 0 {
 				if quantityToTrade.Cmp(amount) == 0 { // reject Taker & maker
+					if tracer != nil {
+						tracer.CaptureReject(order, err.Error())
+						tracer.CaptureReject(&oldestOrder, err.Error())
+					}
 					rejects = append(rejects, order)
 					quantityToTrade = tomox_state.Zero
 					rejects = append(rejects, &oldestOrder)
@@ -233,10 +276,16 @@ func (tomox *TomoX) processOrderList(coinbase common.Address, chain consensus.Ch
 					}
 					break
 				} else if quantityToTrade.Cmp(amount) < 0 { // reject Taker
+					if tracer != nil {
+						tracer.CaptureReject(order, err.Error())
+					}
 					rejects = append(rejects, order)
 					quantityToTrade = tomox_state.Zero
 					break
 				} else { // reject maker
+					if tracer != nil {
+						tracer.CaptureReject(&oldestOrder, err.Error())
+					}
 					rejects = append(rejects, &oldestOrder)
 					err = tomoXstatedb.CancelOrder(orderBook, &oldestOrder)
 					if err != nil {
@@ -246,6 +295,9 @@ func (tomox *TomoX) processOrderList(coinbase common.Address, chain consensus.Ch
 				}
 			} else {
 				if rejectMaker { // reject maker
+					if tracer != nil {
+						tracer.CaptureReject(&oldestOrder, err.Error())
+					}
 					rejects = append(rejects, &oldestOrder)
 					err = tomoXstatedb.CancelOrder(orderBook, &oldestOrder)
 					if err != nil {
@@ -253,6 +305,9 @@ func (tomox *TomoX) processOrderList(coinbase common.Address, chain consensus.Ch
 					}
 					continue
 				} else { // reject Taker
+					if tracer != nil {
+						tracer.CaptureReject(order, err.Error())
+					}
 					rejects = append(rejects, order)
 					quantityToTrade = tomox_state.Zero
 					break
@@ -263,6 +318,9 @@ func (tomox *TomoX) processOrderList(coinbase common.Address, chain consensus.Ch
 		}
 		if tradedQuantity.Sign() == 0 && !rejectMaker {
 			log.Debug("Reject order Taker ", "tradedQuantity", tradedQuantity, "rejectMaker", rejectMaker)
+			if tracer != nil {
+				tracer.CaptureReject(order, "taker order cannot be traded")
+			}
 			rejects = append(rejects, order)
 			quantityToTrade = tomox_state.Zero
 			break
@@ -293,6 +351,9 @@ func (tomox *TomoX) processOrderList(coinbase common.Address, chain consensus.Ch
 			trades = append(trades, transactionRecord)
 		}
 		if rejectMaker {
+			if tracer != nil {
+				tracer.CaptureReject(&oldestOrder, "maker order cannot be traded")
+			}
 			rejects = append(rejects, &oldestOrder)
 			err := tomoXstatedb.CancelOrder(orderBook, &oldestOrder)
 			if err != nil {
@@ -303,7 +364,7 @@ func (tomox *TomoX) processOrderList(coinbase common.Address, chain consensus.Ch
 	return quantityToTrade, trades, rejects, nil
 }
 
-func (tomox *TomoX) getTradeQuantity(quotePrice *big.Int, coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, takerOrder *tomox_state.OrderItem, makerOrder *tomox_state.OrderItem, quantityToTrade *big.Int) (*big.Int, bool, error) {
+func (tomox *TomoX) getTradeQuantity(quotePrice *big.Int, coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, takerOrder *tomox_state.OrderItem, makerOrder *tomox_state.OrderItem, quantityToTrade *big.Int, tracer OrderTracer) (*big.Int, bool, error) {
 	baseTokenDecimal, err := tomox.GetTokenDecimal(chain, statedb, coinbase, makerOrder.BaseToken)
 	if err != nil || baseTokenDecimal.Sign() == 0 {
 		return tomox_state.Zero, false, fmt.Errorf("Fail to get tokenDecimal. Token: %v . Err: %v", makerOrder.BaseToken.String(), err)
@@ -346,6 +407,9 @@ func (tomox *TomoX) getTradeQuantity(quotePrice *big.Int, coinbase common.Addres
 	}
 	quantity, rejectMaker := GetTradeQuantity(takerOrder.Side, takerFeeRate, takerBalance, makerOrder.Price, makerFeeRate, makerBalance, baseTokenDecimal, quantityToTrade)
 	log.Debug("GetTradeQuantity", "side", takerOrder.Side, "takerBalance", takerBalance, "makerBalance", makerBalance, "BaseToken", makerOrder.BaseToken, "QuoteToken", makerOrder.QuoteToken, "quantity", quantity, "rejectMaker", rejectMaker, "quotePrice", quotePrice)
+	if tracer != nil {
+		tracer.CaptureTradeQuantity(takerOrder.Side, takerFeeRate, takerBalance, makerOrder.Price, makerFeeRate, makerBalance, baseTokenDecimal, quantityToTrade, quantity, rejectMaker)
+	}
 	if quantity.Sign() > 0 {
 		// Apply Match Order
 		setteBalance, err := GetSettleBalance(quotePrice, takerOrder.Side, takerFeeRate, makerOrder.BaseToken, makerOrder.QuoteToken, makerOrder.Price, makerFeeRate, baseTokenDecimal, quoteTokenDecimal, quantity)
@@ -353,6 +417,9 @@ func (tomox *TomoX) getTradeQuantity(quotePrice *big.Int, coinbase common.Addres
 		if err == nil {
 			err = SetteBalance(coinbase, takerOrder, makerOrder, setteBalance, statedb)
 		}
+		if tracer != nil {
+			tracer.CaptureSettle(takerOrder, makerOrder, setteBalance, err)
+		}
 		return quantity, rejectMaker, err
 	}
 	return quantity, rejectMaker, nil
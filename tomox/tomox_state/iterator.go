@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package tomox_state
+
+import (
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/rlp"
+	"github.com/tomochain/tomochain/trie"
+)
+
+// IterateNodes walks every trie node reachable from a TomoX state root, which
+// includes the exchange trie, the ask, bid and order tries of each order book
+// and the order tries of each price level, calling fn with each node hash.
+func IterateNodes(db Database, root common.Hash, fn func(common.Hash)) error {
+	triedb := db.TrieDB()
+	return iterateTrie(triedb, root, fn, func(blob []byte) error {
+		var exchange exchangeObject
+		if err := rlp.DecodeBytes(blob, &exchange); err != nil {
+			return err
+		}
+		onOrderList := func(blob []byte) error {
+			var list orderList
+			if err := rlp.DecodeBytes(blob, &list); err != nil {
+				return err
+			}
+			return iterateTrie(triedb, list.Root, fn, nil)
+		}
+		if err := iterateTrie(triedb, exchange.AskRoot, fn, onOrderList); err != nil {
+			return err
+		}
+		if err := iterateTrie(triedb, exchange.BidRoot, fn, onOrderList); err != nil {
+			return err
+		}
+		return iterateTrie(triedb, exchange.OrderRoot, fn, nil)
+	})
+}
+
+// iterateTrie walks the nodes of a single trie, calling fn with the hash of
+// every node stored on its own and onLeaf with every leaf value.
+func iterateTrie(triedb *trie.Database, root common.Hash, fn func(common.Hash), onLeaf func([]byte) error) error {
+	if common.EmptyHash(root) || root == EmptyRoot {
+		return nil
+	}
+	tr, err := trie.New(root, triedb)
+	if err != nil {
+		return err
+	}
+	it := tr.NodeIterator(nil)
+	for it.Next(true) {
+		if hash := it.Hash(); !common.EmptyHash(hash) {
+			fn(hash)
+		}
+		if it.Leaf() && onLeaf != nil {
+			if err := onLeaf(it.LeafBlob()); err != nil {
+				return err
+			}
+		}
+	}
+	return it.Error()
+}
@@ -186,6 +186,40 @@ func (self *TomoXStateDB) GetOrder(orderBook common.Hash, orderId common.Hash) O
 	}
 	return stateOrderItem.data
 }
+
+// GetOrderRoot returns the root of the orders trie of the given order book.
+func (self *TomoXStateDB) GetOrderRoot(orderBook common.Hash) common.Hash {
+	stateObject := self.getStateExchangeObject(orderBook)
+	if stateObject == nil {
+		return EmptyRoot
+	}
+	return stateObject.data.OrderRoot
+}
+
+// GetOrderBookProof returns the merkle proof of the given order book in the
+// TomoX state trie, proving its absence if it doesn't exist.
+func (self *TomoXStateDB) GetOrderBookProof(orderBook common.Hash) ([][]byte, error) {
+	var proof trie.ProofList
+	err := self.trie.Prove(orderBook[:], 0, &proof)
+	return [][]byte(proof), err
+}
+
+// GetOrderProof returns the merkle proof of the given order in the orders trie
+// of the order book, as committed to the order root.
+func (self *TomoXStateDB) GetOrderProof(orderBook common.Hash, orderId common.Hash) ([][]byte, error) {
+	var proof trie.ProofList
+	stateObject := self.getStateExchangeObject(orderBook)
+	if stateObject == nil {
+		return proof, fmt.Errorf("order book not found: %s", orderBook.Hex())
+	}
+	tr, err := self.db.OpenStorageTrie(orderBook, stateObject.data.OrderRoot)
+	if err != nil {
+		return proof, err
+	}
+	err = tr.Prove(orderId[:], 0, &proof)
+	return [][]byte(proof), err
+}
+
 func (self *TomoXStateDB) SubAmountOrderItem(orderBook common.Hash, orderId common.Hash, price *big.Int, amount *big.Int, side string) error {
 	priceHash := common.BigToHash(price)
 	stateObject := self.GetOrNewStateExchangeObject(orderBook)
@@ -20,7 +20,10 @@ import (
 	"fmt"
 	"github.com/tomochain/tomochain/common"
 	"github.com/tomochain/tomochain/common/math"
+	"github.com/tomochain/tomochain/crypto"
 	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/rlp"
+	"github.com/tomochain/tomochain/trie"
 	"math/big"
 	"testing"
 )
@@ -228,3 +231,55 @@ func TestRevertStates(t *testing.T) {
 	}
 	db.Close()
 }
+
+func TestOrderProof(t *testing.T) {
+	orderBook := common.StringToHash("BTC/TOMO")
+	order := OrderItem{OrderID: 1, Quantity: big.NewInt(5), Price: big.NewInt(7), Side: Ask, Signature: &Signature{V: 1, R: common.HexToHash("111111"), S: common.HexToHash("222222222222")}}
+	orderId := common.BigToHash(new(big.Int).SetUint64(order.OrderID))
+
+	db, _ := ethdb.NewMemDatabase()
+	stateCache := NewDatabase(db)
+	statedb, _ := New(common.Hash{}, stateCache)
+	statedb.InsertOrderItem(orderBook, orderId, order)
+	root := statedb.IntermediateRoot()
+	statedb.Commit()
+	statedb, err := New(root, stateCache)
+	if err != nil {
+		t.Fatalf("Error when get trie in database: %s , err: %v", root.Hex(), err)
+	}
+	verify := func(root common.Hash, key []byte, proof [][]byte) []byte {
+		proofDb, _ := ethdb.NewMemDatabase()
+		for _, node := range proof {
+			proofDb.Put(crypto.Keccak256(node), node)
+		}
+		val, err, _ := trie.VerifyProof(root, key, proofDb)
+		if err != nil {
+			t.Fatalf("Error when verify proof of %x: %v", key, err)
+		}
+		return val
+	}
+	proof, err := statedb.GetOrderBookProof(orderBook)
+	if err != nil {
+		t.Fatalf("Error when get order book proof: %v", err)
+	}
+	var exchange exchangeObject
+	if err := rlp.DecodeBytes(verify(root, orderBook[:], proof), &exchange); err != nil {
+		t.Fatalf("Error when decode proven order book: %v", err)
+	}
+	if exchange.OrderRoot != statedb.GetOrderRoot(orderBook) {
+		t.Fatalf("Error when get order root: got : %x , wanted : %x ", statedb.GetOrderRoot(orderBook), exchange.OrderRoot)
+	}
+	if proof, err = statedb.GetOrderProof(orderBook, orderId); err != nil {
+		t.Fatalf("Error when get order proof: %v", err)
+	}
+	var proven OrderItem
+	if err := rlp.DecodeBytes(verify(exchange.OrderRoot, orderId[:], proof), &proven); err != nil {
+		t.Fatalf("Error when decode proven order: %v", err)
+	}
+	if proven.Quantity.Cmp(order.Quantity) != 0 || proven.Price.Cmp(order.Price) != 0 {
+		t.Fatalf("Error when prove order: got : %v , wanted : %v ", proven, order)
+	}
+	if _, err := statedb.GetOrderProof(common.StringToHash("ETH/TOMO"), orderId); err == nil {
+		t.Fatalf("Error when prove order of missing order book: no error")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package tomox_state
+
+import (
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/rlp"
+	"github.com/tomochain/tomochain/trie"
+)
+
+// TrieKind tells what the leaves of a trie of the TomoX state hold.
+type TrieKind uint8
+
+const (
+	ExchangeTrie  TrieKind = iota // Leaves are exchanges, referencing their order books and orders
+	OrderBookTrie                 // Leaves are the price levels of an order book, referencing their orders
+	OrderTrie                     // Leaves are orders or order amounts, referencing nothing
+)
+
+// TrieRef references a trie of the TomoX state.
+type TrieRef struct {
+	Root common.Hash
+	Kind TrieKind
+}
+
+// ChildTries decodes a leaf of a trie of the given kind and returns the
+// non-empty tries it references.
+func ChildTries(kind TrieKind, leaf []byte) ([]TrieRef, error) {
+	var refs []TrieRef
+	add := func(root common.Hash, kind TrieKind) {
+		if !common.EmptyHash(root) && root != EmptyRoot {
+			refs = append(refs, TrieRef{Root: root, Kind: kind})
+		}
+	}
+	switch kind {
+	case ExchangeTrie:
+		var exchange exchangeObject
+		if err := rlp.DecodeBytes(leaf, &exchange); err != nil {
+			return nil, err
+		}
+		add(exchange.AskRoot, OrderBookTrie)
+		add(exchange.BidRoot, OrderBookTrie)
+		add(exchange.OrderRoot, OrderTrie)
+	case OrderBookTrie:
+		var list orderList
+		if err := rlp.DecodeBytes(leaf, &list); err != nil {
+			return nil, err
+		}
+		add(list.Root, OrderTrie)
+	}
+	return refs, nil
+}
+
+// NewStateSync creates a download scheduler of the TomoX state with the given
+// root, following the order books and orders of every exchange.
+func NewStateSync(root common.Hash, database trie.DatabaseReader) *trie.TrieSync {
+	return NewTrieSync(root, ExchangeTrie, database)
+}
+
+// NewTrieSync creates a download scheduler of a trie of the TomoX state of the
+// given kind, following the tries its leaves reference.
+func NewTrieSync(root common.Hash, kind TrieKind, database trie.DatabaseReader) *trie.TrieSync {
+	var (
+		syncer   *trie.TrieSync
+		callback func(kind TrieKind) trie.LeafCallback
+	)
+	callback = func(kind TrieKind) trie.LeafCallback {
+		if kind == OrderTrie {
+			return nil
+		}
+		return func(leaf []byte, parent common.Hash) error {
+			refs, err := ChildTries(kind, leaf)
+			if err != nil {
+				return err
+			}
+			for _, ref := range refs {
+				syncer.AddSubTrie(ref.Root, 64, parent, callback(ref.Kind))
+			}
+			return nil
+		}
+	}
+	syncer = trie.NewTrieSync(root, database, callback(kind))
+	return syncer
+}
+
+// VerifyState checks that the TomoX state with the given root is fully present
+// in the database, along with the order books and orders of every exchange.
+func VerifyState(root common.Hash, database ethdb.Database) error {
+	var (
+		triedb = trie.NewDatabase(database)
+		verify func(root common.Hash, kind TrieKind) error
+	)
+	verify = func(root common.Hash, kind TrieKind) error {
+		tr, err := trie.New(root, triedb)
+		if err != nil {
+			return err
+		}
+		it := tr.NodeIterator(nil)
+		for it.Next(true) {
+			if !it.Leaf() || kind == OrderTrie {
+				continue
+			}
+			refs, err := ChildTries(kind, it.LeafBlob())
+			if err != nil {
+				return err
+			}
+			for _, ref := range refs {
+				if err := verify(ref.Root, ref.Kind); err != nil {
+					return err
+				}
+			}
+		}
+		return it.Error()
+	}
+	return verify(root, ExchangeTrie)
+}
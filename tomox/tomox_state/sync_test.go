@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package tomox_state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/trie"
+)
+
+// makeTestState creates a TomoX state with a couple of order books holding
+// asks and bids, and commits it to disk.
+func makeTestState(t *testing.T) (Database, common.Hash) {
+	db, _ := ethdb.NewMemDatabase()
+	stateCache := NewDatabase(db)
+	statedb, _ := New(common.Hash{}, stateCache)
+
+	for _, pair := range []string{"BTC/TOMO", "ETH/TOMO"} {
+		orderBook := common.StringToHash(pair)
+		for i := 0; i < 20; i++ {
+			side := Ask
+			if i%2 == 0 {
+				side = Bid
+			}
+			order := OrderItem{OrderID: uint64(i + 1), Quantity: big.NewInt(int64(i + 1)), Price: big.NewInt(int64(i/4 + 1)), Side: side, Signature: &Signature{V: 1, R: common.HexToHash("0x01"), S: common.HexToHash("0x02")}}
+			statedb.InsertOrderItem(orderBook, common.BigToHash(big.NewInt(int64(i+1))), order)
+		}
+		statedb.SetPrice(orderBook, big.NewInt(10000))
+	}
+	root, err := statedb.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit TomoX state: %v", err)
+	}
+	if err := stateCache.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to write TomoX state: %v", err)
+	}
+	return stateCache, root
+}
+
+// Tests that the TomoX state sync retrieves the order books and orders of
+// every exchange, and that the synced state verifies.
+func TestStateSync(t *testing.T) {
+	srcCache, root := makeTestState(t)
+
+	dstDb, _ := ethdb.NewMemDatabase()
+	if err := VerifyState(root, dstDb); err == nil {
+		t.Fatalf("empty database verified")
+	}
+	sched := NewStateSync(root, dstDb)
+	for queue := sched.Missing(0); len(queue) > 0; queue = sched.Missing(0) {
+		results := make([]trie.SyncResult, len(queue))
+		for i, hash := range queue {
+			data, err := srcCache.TrieDB().Node(hash)
+			if err != nil {
+				t.Fatalf("failed to retrieve node data for %x: %v", hash, err)
+			}
+			results[i] = trie.SyncResult{Hash: hash, Data: data}
+		}
+		if _, index, err := sched.Process(results); err != nil {
+			t.Fatalf("failed to process result #%d: %v", index, err)
+		}
+		batch := dstDb.NewBatch()
+		if _, err := sched.Commit(batch); err != nil {
+			t.Fatalf("failed to commit data: %v", err)
+		}
+		batch.Write()
+	}
+	if err := VerifyState(root, dstDb); err != nil {
+		t.Fatalf("synced TomoX state incomplete: %v", err)
+	}
+	statedb, err := New(root, NewDatabase(dstDb))
+	if err != nil {
+		t.Fatalf("failed to open synced TomoX state: %v", err)
+	}
+	orderBook := common.StringToHash("ETH/TOMO")
+	if price := statedb.GetPrice(orderBook); price.Cmp(big.NewInt(10000)) != 0 {
+		t.Fatalf("price mismatch: have %v, want 10000", price)
+	}
+	if order := statedb.GetOrder(orderBook, common.BigToHash(big.NewInt(7))); order.Quantity.Cmp(big.NewInt(7)) != 0 {
+		t.Fatalf("order quantity mismatch: have %v, want 7", order.Quantity)
+	}
+}
+
+// Tests that a TomoX state missing an order trie node fails to verify.
+func TestVerifyStateMissingNode(t *testing.T) {
+	srcCache, root := makeTestState(t)
+	db := srcCache.TrieDB().DiskDB().(*ethdb.MemDatabase)
+
+	// Find a node of an order trie and delete it
+	var orderRoot common.Hash
+	tr, _ := trie.New(root, srcCache.TrieDB())
+	it := trie.NewIterator(tr.NodeIterator(nil))
+	for it.Next() {
+		refs, err := ChildTries(ExchangeTrie, it.Value)
+		if err != nil {
+			t.Fatalf("failed to decode exchange: %v", err)
+		}
+		for _, ref := range refs {
+			if ref.Kind == OrderTrie {
+				orderRoot = ref.Root
+			}
+		}
+	}
+	if orderRoot == (common.Hash{}) {
+		t.Fatalf("no order trie found")
+	}
+	if err := VerifyState(root, db); err != nil {
+		t.Fatalf("complete TomoX state failed to verify: %v", err)
+	}
+	db.Delete(orderRoot[:])
+	if err := VerifyState(root, db); err == nil {
+		t.Fatalf("TomoX state missing an order trie verified")
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package tomox
+
+import (
+	"math/big"
+
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/tomox/tomox_state"
+)
+
+// OrderTracer is used to collect the steps the matching engine takes to apply
+// an order, the way vm.Tracer does for the EVM. The hooks are called with the
+// values used by the matching, they must not modify them.
+type OrderTracer interface {
+	// CapturePriceLevel is called for each best price level of the given side
+	// of the order book looked up while matching the order.
+	CapturePriceLevel(side string, price, volume, quantityToTrade *big.Int)
+	// CaptureMatch is called for each maker order the order is matched against,
+	// with the amount left in the price level and the most that can be traded.
+	CaptureMatch(maker *tomox_state.OrderItem, amount, maxTradedQuantity *big.Int)
+	// CaptureTradeQuantity is called with the inputs and the results of
+	// GetTradeQuantity.
+	CaptureTradeQuantity(takerSide string, takerFeeRate, takerBalance, makerPrice, makerFeeRate, makerBalance, baseTokenDecimal, quantityToTrade, quantity *big.Int, rejectMaker bool)
+	// CaptureSettle is called with the balances settled between the taker and
+	// the maker, or the error the settlement failed with.
+	CaptureSettle(taker, maker *tomox_state.OrderItem, settleBalance *SettleBalance, err error)
+	// CaptureReject is called for each order rejected, with the reason why.
+	CaptureReject(order *tomox_state.OrderItem, reason string)
+}
+
+// OrderStep is a step of the matching of an order, collected by OrderLogger.
+type OrderStep struct {
+	Op string `json:"op"` // priceLevel, match, tradeQuantity, settle or reject
+
+	Side            string       `json:"side,omitempty"`
+	Price           *big.Int     `json:"price,omitempty"`
+	Volume          *big.Int     `json:"volume,omitempty"`
+	Order           *common.Hash `json:"order,omitempty"` // Maker order matched, or order rejected
+	Amount          *big.Int     `json:"amount,omitempty"`
+	QuantityToTrade *big.Int     `json:"quantityToTrade,omitempty"`
+
+	TakerFeeRate     *big.Int `json:"takerFeeRate,omitempty"`
+	TakerBalance     *big.Int `json:"takerBalance,omitempty"`
+	MakerFeeRate     *big.Int `json:"makerFeeRate,omitempty"`
+	MakerBalance     *big.Int `json:"makerBalance,omitempty"`
+	BaseTokenDecimal *big.Int `json:"baseTokenDecimal,omitempty"`
+	Quantity         *big.Int `json:"quantity,omitempty"`
+	RejectMaker      bool     `json:"rejectMaker,omitempty"`
+
+	Settle *SettleBalance `json:"settle,omitempty"`
+	Reason string         `json:"reason,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// OrderTrace is the result of tracing the matching of an order.
+type OrderTrace struct {
+	Hash            common.Hash    `json:"hash"`
+	UserAddress     common.Address `json:"userAddress"`
+	ExchangeAddress common.Address `json:"exchangeAddress"`
+	BaseToken       common.Address `json:"baseToken"`
+	QuoteToken      common.Address `json:"quoteToken"`
+	Side            string         `json:"side"`
+	Type            string         `json:"type"`
+	Status          string         `json:"status"`
+	Price           *big.Int       `json:"price"`
+	Quantity        *big.Int       `json:"quantity"`
+	Nonce           *big.Int       `json:"nonce"`
+
+	Steps   []*OrderStep        `json:"steps"`
+	Trades  []map[string]string `json:"trades"`
+	Rejects []common.Hash       `json:"rejects"`
+	Error   string              `json:"error,omitempty"`
+}
+
+// OrderLogger is an OrderTracer collecting the steps of the matching of an
+// order, to be returned over the tracing API.
+type OrderLogger struct {
+	trace *OrderTrace
+}
+
+// NewOrderLogger returns an order logger for the given order, which is copied
+// before the matching updates it.
+func NewOrderLogger(order *tomox_state.OrderItem) *OrderLogger {
+	return &OrderLogger{
+		trace: &OrderTrace{
+			Hash:            order.Hash,
+			UserAddress:     order.UserAddress,
+			ExchangeAddress: order.ExchangeAddress,
+			BaseToken:       order.BaseToken,
+			QuoteToken:      order.QuoteToken,
+			Side:            order.Side,
+			Type:            order.Type,
+			Status:          order.Status,
+			Price:           tomox_state.CloneBigInt(order.Price),
+			Quantity:        tomox_state.CloneBigInt(order.Quantity),
+			Nonce:           tomox_state.CloneBigInt(order.Nonce),
+			Steps:           []*OrderStep{},
+			Trades:          []map[string]string{},
+			Rejects:         []common.Hash{},
+		},
+	}
+}
+
+// CapturePriceLevel implements OrderTracer.
+func (l *OrderLogger) CapturePriceLevel(side string, price, volume, quantityToTrade *big.Int) {
+	l.trace.Steps = append(l.trace.Steps, &OrderStep{
+		Op:              "priceLevel",
+		Side:            side,
+		Price:           tomox_state.CloneBigInt(price),
+		Volume:          tomox_state.CloneBigInt(volume),
+		QuantityToTrade: tomox_state.CloneBigInt(quantityToTrade),
+	})
+}
+
+// CaptureMatch implements OrderTracer.
+func (l *OrderLogger) CaptureMatch(maker *tomox_state.OrderItem, amount, maxTradedQuantity *big.Int) {
+	hash := maker.Hash
+	l.trace.Steps = append(l.trace.Steps, &OrderStep{
+		Op:              "match",
+		Side:            maker.Side,
+		Price:           tomox_state.CloneBigInt(maker.Price),
+		Order:           &hash,
+		Amount:          tomox_state.CloneBigInt(amount),
+		QuantityToTrade: tomox_state.CloneBigInt(maxTradedQuantity),
+	})
+}
+
+// CaptureTradeQuantity implements OrderTracer.
+func (l *OrderLogger) CaptureTradeQuantity(takerSide string, takerFeeRate, takerBalance, makerPrice, makerFeeRate, makerBalance, baseTokenDecimal, quantityToTrade, quantity *big.Int, rejectMaker bool) {
+	l.trace.Steps = append(l.trace.Steps, &OrderStep{
+		Op:               "tradeQuantity",
+		Side:             takerSide,
+		Price:            tomox_state.CloneBigInt(makerPrice),
+		QuantityToTrade:  tomox_state.CloneBigInt(quantityToTrade),
+		TakerFeeRate:     tomox_state.CloneBigInt(takerFeeRate),
+		TakerBalance:     tomox_state.CloneBigInt(takerBalance),
+		MakerFeeRate:     tomox_state.CloneBigInt(makerFeeRate),
+		MakerBalance:     tomox_state.CloneBigInt(makerBalance),
+		BaseTokenDecimal: tomox_state.CloneBigInt(baseTokenDecimal),
+		Quantity:         tomox_state.CloneBigInt(quantity),
+		RejectMaker:      rejectMaker,
+	})
+}
+
+// CaptureSettle implements OrderTracer.
+func (l *OrderLogger) CaptureSettle(taker, maker *tomox_state.OrderItem, settleBalance *SettleBalance, err error) {
+	hash := maker.Hash
+	step := &OrderStep{
+		Op:     "settle",
+		Order:  &hash,
+		Settle: settleBalance,
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	l.trace.Steps = append(l.trace.Steps, step)
+}
+
+// CaptureReject implements OrderTracer.
+func (l *OrderLogger) CaptureReject(order *tomox_state.OrderItem, reason string) {
+	hash := order.Hash
+	l.trace.Steps = append(l.trace.Steps, &OrderStep{
+		Op:     "reject",
+		Order:  &hash,
+		Reason: reason,
+	})
+}
+
+// Result returns the trace of the order, with the outcome of the matching.
+func (l *OrderLogger) Result(trades []map[string]string, rejects []*tomox_state.OrderItem, err error) *OrderTrace {
+	if trades != nil {
+		l.trace.Trades = trades
+	}
+	for _, reject := range rejects {
+		l.trace.Rejects = append(l.trace.Rejects, reject.Hash)
+	}
+	if err != nil {
+		l.trace.Error = err.Error()
+	}
+	return l.trace
+}
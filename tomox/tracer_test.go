@@ -0,0 +1,147 @@
+// Copyright (c) 2018 Tomochain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package tomox
+
+import (
+	"math/big"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/tomochain/tomochain/common"
+	"github.com/tomochain/tomochain/core/state"
+	"github.com/tomochain/tomochain/ethdb"
+	"github.com/tomochain/tomochain/tomox/tomox_state"
+)
+
+// setRelayer registers the relayer with the given owner, deposit and fee rate
+// in the relayer registration contract.
+func setRelayer(statedb *state.StateDB, relayer, owner common.Address, deposit, fee *big.Int) {
+	contract := common.HexToAddress(common.RelayerRegistrationSMC)
+	loc := tomox_state.GetLocMappingAtKey(relayer.Hash(), tomox_state.RelayerMappingSlot["RELAYER_LIST"])
+
+	statedb.SetState(contract, common.BigToHash(new(big.Int).Add(loc, tomox_state.RelayerStructMappingSlot["_deposit"])), common.BigToHash(deposit))
+	statedb.SetState(contract, common.BigToHash(new(big.Int).Add(loc, tomox_state.RelayerStructMappingSlot["_fee"])), common.BigToHash(fee))
+	statedb.SetState(contract, common.BigToHash(new(big.Int).Add(loc, tomox_state.RelayerStructMappingSlot["_owner"])), owner.Hash())
+	statedb.AddBalance(contract, deposit)
+}
+
+// Tests that the order logger reports the steps, the trades and the order book
+// changes of an order partially matched against the order book.
+func TestOrderLogger(t *testing.T) {
+	var (
+		ether      = big.NewInt(1000000000000000000)
+		relayer    = common.HexToAddress("0x0a")
+		owner      = common.HexToAddress("0x0b")
+		maker      = common.HexToAddress("0x0c")
+		taker      = common.HexToAddress("0x0d")
+		baseToken  = common.HexToAddress("0x0e")
+		quoteToken = common.HexToAddress(common.TomoNativeAddress)
+		orderBook  = tomox_state.GetOrderBookHash(baseToken, quoteToken)
+		price      = new(big.Int).Mul(big.NewInt(2), ether)
+	)
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	setRelayer(statedb, relayer, owner, ether, big.NewInt(1))
+
+	// The maker sells 1 base token at 2 TOMO, the taker buys 3 of them
+	statedb.SetNonce(baseToken, 1)
+	tomox_state.SetTokenBalance(maker, ether, baseToken, statedb)
+	tomox_state.SetTokenBalance(taker, new(big.Int).Mul(big.NewInt(10), ether), quoteToken, statedb)
+
+	tomoxState, _ := tomox_state.New(common.Hash{}, tomox_state.NewDatabase(db))
+	makerOrder := tomox_state.OrderItem{
+		OrderID:         1,
+		Hash:            common.HexToHash("0x01"),
+		UserAddress:     maker,
+		ExchangeAddress: relayer,
+		BaseToken:       baseToken,
+		QuoteToken:      quoteToken,
+		Side:            tomox_state.Ask,
+		Type:            tomox_state.Limit,
+		Status:          OrderStatusOpen,
+		Price:           price,
+		Quantity:        ether,
+		Nonce:           big.NewInt(0),
+	}
+	tomoxState.InsertOrderItem(orderBook, common.BigToHash(big.NewInt(1)), makerOrder)
+	tomoxState.SetNonce(orderBook, 1)
+
+	takerOrder := &tomox_state.OrderItem{
+		Hash:            common.HexToHash("0x02"),
+		UserAddress:     taker,
+		ExchangeAddress: relayer,
+		BaseToken:       baseToken,
+		QuoteToken:      quoteToken,
+		Side:            tomox_state.Bid,
+		Type:            tomox_state.Limit,
+		Status:          OrderStatusNew,
+		Price:           price,
+		Quantity:        new(big.Int).Mul(big.NewInt(3), ether),
+		Nonce:           big.NewInt(0),
+	}
+	tokenDecimalCache, _ := lru.New(defaultCacheLimit)
+	tokenDecimalCache.Add(baseToken, ether)
+	tomoX := &TomoX{tokenDecimalCache: tokenDecimalCache}
+
+	logger := NewOrderLogger(takerOrder)
+	trades, rejects, err := tomoX.ApplyOrderWithTracer(common.Address{}, nil, statedb, tomoxState, orderBook, takerOrder, logger)
+	result := logger.Result(trades, rejects, err)
+
+	// Check the reported order, steps and trades
+	if result.Error != "" {
+		t.Fatalf("order matching failed: %v", result.Error)
+	}
+	if result.Hash != takerOrder.Hash || result.Quantity.Cmp(new(big.Int).Mul(big.NewInt(3), ether)) != 0 {
+		t.Errorf("traced order mismatch: have %x with quantity %v, want %x with quantity 3 ether", result.Hash, result.Quantity, takerOrder.Hash)
+	}
+	ops := []string{"priceLevel", "match", "tradeQuantity", "settle", "priceLevel"}
+	if len(result.Steps) != len(ops) {
+		t.Fatalf("step count mismatch: have %d, want %d", len(result.Steps), len(ops))
+	}
+	for i, step := range result.Steps {
+		if step.Op != ops[i] {
+			t.Errorf("step %d op mismatch: have %s, want %s", i, step.Op, ops[i])
+		}
+	}
+	if step := result.Steps[1]; *step.Order != makerOrder.Hash || step.QuantityToTrade.Cmp(ether) != 0 {
+		t.Errorf("match step mismatch: have order %x quantity %v, want order %x quantity 1 ether", *step.Order, step.QuantityToTrade, makerOrder.Hash)
+	}
+	if step := result.Steps[3]; step.Error != "" || step.Settle == nil || step.Settle.Taker.InQuantity.Cmp(ether) != 0 || step.Settle.Taker.OutQuantity.Cmp(price) != 0 {
+		t.Errorf("settle step mismatch: %+v", step)
+	}
+	if len(result.Trades) != 1 {
+		t.Fatalf("trade count mismatch: have %d, want 1", len(result.Trades))
+	}
+	if trade := result.Trades[0]; trade[TradeMakerOrderHash] != makerOrder.Hash.Hex() || trade[TradeQuantity] != ether.String() || trade[TradePrice] != price.String() {
+		t.Errorf("trade mismatch: %v", trade)
+	}
+	if len(result.Rejects) != 0 {
+		t.Errorf("unexpected rejected orders: %v", result.Rejects)
+	}
+	// Check the order book: the ask is filled, the rest of the bid is open
+	if askPrice, volume := tomoxState.GetBestAskPrice(orderBook); askPrice.Sign() != 0 || volume.Sign() != 0 {
+		t.Errorf("best ask mismatch: have %v at %v, want none", volume, askPrice)
+	}
+	if bidPrice, volume := tomoxState.GetBestBidPrice(orderBook); bidPrice.Cmp(price) != 0 || volume.Cmp(new(big.Int).Mul(big.NewInt(2), ether)) != 0 {
+		t.Errorf("best bid mismatch: have %v at %v, want 2 ether at %v", volume, bidPrice, price)
+	}
+	if balance := tomox_state.GetTokenBalance(taker, baseToken, statedb); balance.Cmp(ether) != 0 {
+		t.Errorf("taker base token balance mismatch: have %v, want %v", balance, ether)
+	}
+	if nonce := tomoxState.GetNonce(taker.Hash()); nonce != 1 {
+		t.Errorf("taker nonce mismatch: have %d, want 1", nonce)
+	}
+}
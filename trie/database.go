@@ -57,6 +57,8 @@ type Database struct {
 	nodesSize     common.StorageSize // Storage size of the nodes cache
 	preimagesSize common.StorageSize // Storage size of the preimages cache
 
+	flushHook func(common.Hash) // Callback notified of every node before it's persisted
+
 	Lock sync.RWMutex
 }
 
@@ -85,6 +87,16 @@ func (db *Database) DiskDB() DatabaseReader {
 	return db.diskdb
 }
 
+// SetFlushHook installs a callback which is invoked with the hash of every
+// trie node right before it's written to disk, or removes it if hook is nil.
+// The callback must be safe for concurrent use.
+func (db *Database) SetFlushHook(hook func(common.Hash)) {
+	db.Lock.Lock()
+	defer db.Lock.Unlock()
+
+	db.flushHook = hook
+}
+
 // Insert writes a new trie node to the memory database if it's yet unknown. The
 // method will make a copy of the slice.
 func (db *Database) Insert(hash common.Hash, blob []byte) {
@@ -313,6 +325,9 @@ func (db *Database) commit(hash common.Hash, batch ethdb.Batch) error {
 			return err
 		}
 	}
+	if db.flushHook != nil {
+		db.flushHook(hash)
+	}
 	if err := batch.Put(hash[:], node.blob); err != nil {
 		return err
 	}
@@ -18,6 +18,7 @@ package trie
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 
 	"github.com/tomochain/tomochain/common"
@@ -99,6 +100,16 @@ func (t *SecureTrie) Prove(key []byte, fromLevel uint, proofDb ethdb.Putter) err
 	return t.trie.Prove(key, fromLevel, proofDb)
 }
 
+// ProofList collects the nodes of a merkle proof in the order they are given,
+// as the proof database of Prove.
+type ProofList [][]byte
+
+// Put implements ethdb.Putter, appending the node to the list.
+func (n *ProofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
 // VerifyProof checks merkle proofs. The given proof must contain the value for
 // key in a trie with the given root hash. VerifyProof returns an error if the
 // proof contains invalid trie nodes or the wrong value.
@@ -114,7 +125,7 @@ func VerifyProof(rootHash common.Hash, key []byte, proofDb DatabaseReader) (valu
 		if err != nil {
 			return nil, fmt.Errorf("bad proof node %d: %v", i, err), i
 		}
-		keyrest, cld := get(n, key)
+		keyrest, cld := get(n, key, true)
 		switch cld := cld.(type) {
 		case nil:
 			// The trie doesn't contain the key.
@@ -128,7 +139,10 @@ func VerifyProof(rootHash common.Hash, key []byte, proofDb DatabaseReader) (valu
 	}
 }
 
-func get(tn Node, key []byte) ([]byte, Node) {
+// get returns the child of tn on the path of key along with the rest of the
+// key. If skipResolved is set, it descends through the resolved nodes down to
+// the first hash or value node.
+func get(tn Node, key []byte, skipResolved bool) ([]byte, Node) {
 	for {
 		switch n := tn.(type) {
 		case *ShortNode:
@@ -137,9 +151,15 @@ func get(tn Node, key []byte) ([]byte, Node) {
 			}
 			tn = n.Val
 			key = key[len(n.Key):]
+			if !skipResolved {
+				return key, tn
+			}
 		case *FullNode:
 			tn = n.Children[key[0]]
 			key = key[1:]
+			if !skipResolved {
+				return key, tn
+			}
 		case HashNode:
 			return key, n
 		case nil:
@@ -151,3 +171,343 @@ func get(tn Node, key []byte) ([]byte, Node) {
 		}
 	}
 }
+
+// proofToPath resolves the path of key from the proof nodes, linking them into
+// the given root node which is decoded from the proof if nil. It returns the
+// root along with the value of key, nil if allowNonExistent is set and the
+// proof shows the key is absent.
+func proofToPath(rootHash common.Hash, root Node, key []byte, proofDb DatabaseReader, allowNonExistent bool) (Node, []byte, error) {
+	resolve := func(hash []byte) (Node, error) {
+		buf, _ := proofDb.Get(hash)
+		if buf == nil {
+			return nil, fmt.Errorf("proof node (hash %064x) missing", hash)
+		}
+		n, err := decodeNode(hash, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node %v", err)
+		}
+		return n, nil
+	}
+	if root == nil {
+		n, err := resolve(rootHash[:])
+		if err != nil {
+			return nil, nil, err
+		}
+		root = n
+	}
+	var (
+		child, parent Node
+		keyrest       []byte
+		value         []byte
+	)
+	key, parent = keybytesToHex(key), root
+	for {
+		keyrest, child = get(parent, key, false)
+		switch cld := child.(type) {
+		case nil:
+			// The trie doesn't contain the key
+			if allowNonExistent {
+				return root, nil, nil
+			}
+			return nil, nil, errors.New("the node is not contained in trie")
+		case *ShortNode, *FullNode:
+			key, parent = keyrest, child
+			continue
+		case HashNode:
+			n, err := resolve(cld)
+			if err != nil {
+				return nil, nil, err
+			}
+			child = n
+		case ValueNode:
+			value = cld
+		}
+		// Link the resolved child into its parent
+		switch n := parent.(type) {
+		case *ShortNode:
+			n.Val = child
+		case *FullNode:
+			n.Children[key[0]] = child
+		default:
+			return nil, nil, fmt.Errorf("%T: invalid node: %v", parent, parent)
+		}
+		if len(value) > 0 {
+			return root, value, nil
+		}
+		key, parent = keyrest, child
+	}
+}
+
+// unsetInternal removes the nodes strictly between the paths of the left and
+// right keys, which the leaves of the range will rebuild. It reports whether
+// the whole trie is within the range.
+func unsetInternal(n Node, left []byte, right []byte) (bool, error) {
+	left, right = keybytesToHex(left), keybytesToHex(right)
+
+	// Step down to the fork point, a short node whose key doesn't match one
+	// of the paths or a full node where the paths split
+	var (
+		pos    = 0
+		parent Node
+
+		// Position of each path relative to the key of the short node
+		shortForkLeft, shortForkRight int
+	)
+findFork:
+	for {
+		switch rn := n.(type) {
+		case *ShortNode:
+			rn.flags = nodeFlag{dirty: true}
+
+			if len(left)-pos < len(rn.Key) {
+				shortForkLeft = bytes.Compare(left[pos:], rn.Key)
+			} else {
+				shortForkLeft = bytes.Compare(left[pos:pos+len(rn.Key)], rn.Key)
+			}
+			if len(right)-pos < len(rn.Key) {
+				shortForkRight = bytes.Compare(right[pos:], rn.Key)
+			} else {
+				shortForkRight = bytes.Compare(right[pos:pos+len(rn.Key)], rn.Key)
+			}
+			if shortForkLeft != 0 || shortForkRight != 0 {
+				break findFork
+			}
+			parent = n
+			n, pos = rn.Val, pos+len(rn.Key)
+		case *FullNode:
+			rn.flags = nodeFlag{dirty: true}
+
+			leftnode, rightnode := rn.Children[left[pos]], rn.Children[right[pos]]
+			if leftnode == nil || rightnode == nil || leftnode != rightnode {
+				break findFork
+			}
+			parent = n
+			n, pos = rn.Children[left[pos]], pos+1
+		default:
+			return false, fmt.Errorf("%T: invalid node: %v", n, n)
+		}
+	}
+	switch rn := n.(type) {
+	case *ShortNode:
+		// Both paths on the same side of the node leave no valid range
+		if shortForkLeft == -1 && shortForkRight == -1 {
+			return false, errors.New("empty range")
+		}
+		if shortForkLeft == 1 && shortForkRight == 1 {
+			return false, errors.New("empty range")
+		}
+		// The node is entirely within the range
+		if shortForkLeft != 0 && shortForkRight != 0 {
+			if parent == nil {
+				return true, nil
+			}
+			return false, removeChild(parent, left[pos-1])
+		}
+		// Only one path runs through the node
+		if shortForkRight != 0 {
+			if _, ok := rn.Val.(ValueNode); ok {
+				if parent == nil {
+					return true, nil
+				}
+				return false, removeChild(parent, left[pos-1])
+			}
+			return false, unset(rn, rn.Val, left[pos:], len(rn.Key), false)
+		}
+		if shortForkLeft != 0 {
+			if _, ok := rn.Val.(ValueNode); ok {
+				if parent == nil {
+					return true, nil
+				}
+				return false, removeChild(parent, right[pos-1])
+			}
+			return false, unset(rn, rn.Val, right[pos:], len(rn.Key), true)
+		}
+		return false, nil
+	case *FullNode:
+		// Remove the children between the paths and what's beyond them
+		for i := left[pos] + 1; i < right[pos]; i++ {
+			rn.Children[i] = nil
+		}
+		if err := unset(rn, rn.Children[left[pos]], left[pos:], 1, false); err != nil {
+			return false, err
+		}
+		if err := unset(rn, rn.Children[right[pos]], right[pos:], 1, true); err != nil {
+			return false, err
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("%T: invalid node: %v", n, n)
+	}
+}
+
+// unset removes the nodes on one side of the path of key below the fork
+// point, the right side of the left path or the left side of the right path.
+func unset(parent Node, child Node, key []byte, pos int, removeLeft bool) error {
+	switch cld := child.(type) {
+	case *FullNode:
+		if removeLeft {
+			for i := 0; i < int(key[pos]); i++ {
+				cld.Children[i] = nil
+			}
+		} else {
+			for i := key[pos] + 1; i < 16; i++ {
+				cld.Children[i] = nil
+			}
+		}
+		cld.flags = nodeFlag{dirty: true}
+		return unset(cld, cld.Children[key[pos]], key, pos+1, removeLeft)
+	case *ShortNode:
+		if len(key[pos:]) < len(cld.Key) || !bytes.Equal(cld.Key, key[pos:pos+len(cld.Key)]) {
+			// The path forks off the node, remove it if it's within the range
+			if removeLeft {
+				if bytes.Compare(cld.Key, key[pos:]) < 0 {
+					return removeChild(parent, key[pos-1])
+				}
+			} else {
+				if bytes.Compare(cld.Key, key[pos:]) > 0 {
+					return removeChild(parent, key[pos-1])
+				}
+			}
+			return nil
+		}
+		if _, ok := cld.Val.(ValueNode); ok {
+			return removeChild(parent, key[pos-1])
+		}
+		cld.flags = nodeFlag{dirty: true}
+		return unset(cld, cld.Val, key, pos+len(cld.Key), removeLeft)
+	case nil:
+		// The path ends in a missing child of the fork point
+		return nil
+	default:
+		return fmt.Errorf("%T: invalid node: %v", child, child)
+	}
+}
+
+// removeChild removes the child of the parent full node at the given nibble.
+// A short node holding the child only reaches here from a malformed proof.
+func removeChild(parent Node, nibble byte) error {
+	fn, ok := parent.(*FullNode)
+	if !ok {
+		return fmt.Errorf("%T: invalid parent node: %v", parent, parent)
+	}
+	fn.Children[nibble] = nil
+	return nil
+}
+
+// hasRightElement reports whether the trie holds any leaf right of key.
+func hasRightElement(node Node, key []byte) bool {
+	pos, key := 0, keybytesToHex(key)
+	for node != nil {
+		switch rn := node.(type) {
+		case *FullNode:
+			for i := key[pos] + 1; i < 16; i++ {
+				if rn.Children[i] != nil {
+					return true
+				}
+			}
+			node, pos = rn.Children[key[pos]], pos+1
+		case *ShortNode:
+			if len(key)-pos < len(rn.Key) || !bytes.Equal(rn.Key, key[pos:pos+len(rn.Key)]) {
+				return bytes.Compare(rn.Key, key[pos:]) > 0
+			}
+			node, pos = rn.Val, pos+len(rn.Key)
+		case ValueNode:
+			return false
+		default:
+			panic(fmt.Sprintf("%T: invalid node: %v", node, node))
+		}
+	}
+	return false
+}
+
+// VerifyRangeProof checks that the given leaves are all the leaves of the trie
+// with the given root from firstKey up to the last key, proven by the paths of
+// firstKey and the last key. A nil proof requires the leaves to be the whole
+// trie. It reports whether the trie holds more leaves right of the range.
+func VerifyRangeProof(rootHash common.Hash, firstKey []byte, keys [][]byte, values [][]byte, proofDb DatabaseReader) (bool, error) {
+	if len(keys) != len(values) {
+		return false, fmt.Errorf("inconsistent proof data, keys: %d, values: %d", len(keys), len(values))
+	}
+	if len(keys) > 0 && bytes.Compare(keys[0], firstKey) < 0 {
+		return false, errors.New("range starts before the first key")
+	}
+	for i := 0; i < len(keys)-1; i++ {
+		if bytes.Compare(keys[i], keys[i+1]) >= 0 {
+			return false, errors.New("range is not monotonically increasing")
+		}
+	}
+	for _, value := range values {
+		if len(value) == 0 {
+			return false, errors.New("range contains deletion")
+		}
+	}
+	// Without proof, the range must be the whole trie
+	if proofDb == nil {
+		tr := new(Trie)
+		for i, key := range keys {
+			tr.Update(key, values[i])
+		}
+		if have := tr.Hash(); have != rootHash {
+			return false, fmt.Errorf("invalid proof, want hash %x, got %x", rootHash, have)
+		}
+		return false, nil
+	}
+	// Without leaves, the proof must show nothing is right of firstKey
+	if len(keys) == 0 {
+		root, value, err := proofToPath(rootHash, nil, firstKey, proofDb, true)
+		if err != nil {
+			return false, err
+		}
+		if value != nil || hasRightElement(root, firstKey) {
+			return false, errors.New("more entries available")
+		}
+		return false, nil
+	}
+	lastKey := keys[len(keys)-1]
+
+	// A single leaf at firstKey is proven by its own path
+	if len(keys) == 1 && bytes.Equal(firstKey, lastKey) {
+		root, value, err := proofToPath(rootHash, nil, firstKey, proofDb, false)
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(value, values[0]) {
+			return false, errors.New("correct proof but invalid data")
+		}
+		return hasRightElement(root, firstKey), nil
+	}
+	if bytes.Compare(firstKey, lastKey) >= 0 {
+		return false, errors.New("invalid edge keys")
+	}
+	if len(firstKey) != len(lastKey) {
+		return false, errors.New("inconsistent edge keys")
+	}
+	// Resolve both edge paths, drop everything between them and rebuild it
+	// from the leaves, which must yield the same root
+	root, _, err := proofToPath(rootHash, nil, firstKey, proofDb, true)
+	if err != nil {
+		return false, err
+	}
+	root, _, err = proofToPath(rootHash, root, lastKey, proofDb, true)
+	if err != nil {
+		return false, err
+	}
+	empty, err := unsetInternal(root, firstKey, lastKey)
+	if err != nil {
+		return false, err
+	}
+	memdb, _ := ethdb.NewMemDatabase()
+	tr := &Trie{Db: NewDatabase(memdb), root: root}
+	if empty {
+		tr.root = nil
+	}
+	for i, key := range keys {
+		if err := tr.TryUpdate(key, values[i]); err != nil {
+			return false, err
+		}
+	}
+	if have := tr.Hash(); have != rootHash {
+		return false, fmt.Errorf("invalid proof, want hash %x, got %x", rootHash, have)
+	}
+	return hasRightElement(tr.root, lastKey), nil
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	crand "crypto/rand"
 	mrand "math/rand"
+	"sort"
 	"testing"
 	"time"
 
@@ -88,6 +89,117 @@ func TestVerifyBadProof(t *testing.T) {
 	}
 }
 
+// sortedEntries returns the entries of a random trie ordered by key.
+func sortedEntries(vals map[string]*kv) []*kv {
+	entries := make([]*kv, 0, len(vals))
+	for _, kv := range vals {
+		entries = append(entries, kv)
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].k, entries[j].k) < 0 })
+	return entries
+}
+
+// Tests that ranges of leaves proven by their edge paths verify, and that
+// tampered ranges don't.
+func TestRangeProof(t *testing.T) {
+	trie, vals := randomTrie(500)
+	root := trie.Hash()
+	entries := sortedEntries(vals)
+
+	for i := 0; i < 200; i++ {
+		start := mrand.Intn(len(entries))
+		end := start + 1 + mrand.Intn(len(entries)-start)
+
+		proof, _ := ethdb.NewMemDatabase()
+		if err := trie.Prove(entries[start].k, 0, proof); err != nil {
+			t.Fatalf("failed to prove the first node: %v", err)
+		}
+		if err := trie.Prove(entries[end-1].k, 0, proof); err != nil {
+			t.Fatalf("failed to prove the last node: %v", err)
+		}
+		var keys, values [][]byte
+		for _, kv := range entries[start:end] {
+			keys = append(keys, kv.k)
+			values = append(values, kv.v)
+		}
+		more, err := VerifyRangeProof(root, entries[start].k, keys, values, proof)
+		if err != nil {
+			t.Fatalf("range [%d, %d): verification failed: %v", start, end, err)
+		}
+		if more != (end < len(entries)) {
+			t.Fatalf("range [%d, %d): continuation mismatch: have %v", start, end, more)
+		}
+		// Dropping a leaf from within the range must fail
+		if end-start > 2 {
+			drop := start + 1 + mrand.Intn(end-start-2)
+			keys = append(keys[:drop-start:drop-start], keys[drop-start+1:]...)
+			values = append(values[:drop-start:drop-start], values[drop-start+1:]...)
+			if _, err := VerifyRangeProof(root, entries[start].k, keys, values, proof); err == nil {
+				t.Fatalf("range [%d, %d): missing leaf %d accepted", start, end, drop)
+			}
+		}
+	}
+}
+
+// Tests ranges starting at a key absent from the trie, as well as the whole
+// trie without proof.
+func TestRangeProofEdges(t *testing.T) {
+	trie, vals := randomTrie(500)
+	root := trie.Hash()
+	entries := sortedEntries(vals)
+
+	// The range from the zero key proves the absence of leaves before it
+	origin := make([]byte, 32)
+	proof, _ := ethdb.NewMemDatabase()
+	trie.Prove(origin, 0, proof)
+	trie.Prove(entries[9].k, 0, proof)
+
+	var keys, values [][]byte
+	for _, kv := range entries[:10] {
+		keys = append(keys, kv.k)
+		values = append(values, kv.v)
+	}
+	if more, err := VerifyRangeProof(root, origin, keys, values, proof); err != nil || !more {
+		t.Fatalf("range from absent origin: have %v, %v, want true, nil", more, err)
+	}
+	// Skipping the first leaf must fail
+	if _, err := VerifyRangeProof(root, origin, keys[1:], values[1:], proof); err == nil {
+		t.Fatalf("range skipping the first leaf accepted")
+	}
+	// The whole trie verifies without proof
+	keys, values = nil, nil
+	for _, kv := range entries {
+		keys = append(keys, kv.k)
+		values = append(values, kv.v)
+	}
+	if more, err := VerifyRangeProof(root, nil, keys, values, nil); err != nil || more {
+		t.Fatalf("whole trie: have %v, %v, want false, nil", more, err)
+	}
+	if _, err := VerifyRangeProof(root, nil, keys[1:], values[1:], nil); err == nil {
+		t.Fatalf("partial trie accepted as whole")
+	}
+	// An empty range past the last leaf proves the end of the trie
+	last := bytes.Repeat([]byte{0xff}, 32)
+	proof, _ = ethdb.NewMemDatabase()
+	trie.Prove(last, 0, proof)
+	if more, err := VerifyRangeProof(root, last, nil, nil, proof); err != nil || more {
+		t.Fatalf("range past the end: have %v, %v, want false, nil", more, err)
+	}
+}
+
+func TestUnsetInvalidParent(t *testing.T) {
+	// A leaf below a short node can't be removed from it, which only a
+	// malformed proof leads to
+	leaf := &ShortNode{Key: []byte{2, 16}, Val: ValueNode("v")}
+	parent := &ShortNode{Key: []byte{1}, Val: leaf}
+	if err := unset(parent, leaf, []byte{1, 2, 16}, 1, false); err == nil {
+		t.Fatalf("leaf removed from short node parent")
+	}
+	if err := unset(parent, HashNode(common.Hash{}.Bytes()), []byte{1, 2, 16}, 1, false); err == nil {
+		t.Fatalf("unresolved child accepted")
+	}
+}
+
 // mutateByte changes one byte in b.
 func mutateByte(b []byte) {
 	for r := mrand.Intn(len(b)); ; {